@@ -10,7 +10,14 @@ CREATE TABLE IF NOT EXISTS analytics.events
     currency String,
     customer_id String,
     created_by String,
-    created_at DateTime
+    created_at DateTime,
+    company_code String,
+    ingested_at DateTime64(3) DEFAULT now64(3)
 )
-ENGINE = MergeTree
-ORDER BY (occurred_at, deal_id);
+-- One row per deal event: redeliveries and replays insert a newer version that replaces
+-- the stored one on merge; reports read the table with FINAL.
+ENGINE = ReplacingMergeTree(ingested_at)
+ORDER BY (event_type, deal_id);
+
+-- Events written before multi-company support carry an empty company until replayed.
+ALTER TABLE analytics.events ADD COLUMN IF NOT EXISTS company_code String DEFAULT '';
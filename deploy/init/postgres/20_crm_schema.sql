@@ -1,29 +0,0 @@
-﻿CREATE SCHEMA IF NOT EXISTS crm;
-
-CREATE TABLE IF NOT EXISTS crm.customers (
-    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    name TEXT NOT NULL,
-    inn TEXT,
-    kpp TEXT,
-    created_at TIMESTAMPTZ DEFAULT NOW()
-);
-
-CREATE TABLE IF NOT EXISTS crm.deals (
-    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    title TEXT NOT NULL,
-    customer_id UUID REFERENCES crm.customers(id),
-    stage TEXT NOT NULL,
-    amount NUMERIC(18,2) NOT NULL,
-    currency TEXT NOT NULL DEFAULT 'RUB',
-    created_by TEXT,
-    org_unit_code TEXT NOT NULL REFERENCES core.org_units(code),
-    created_at TIMESTAMPTZ DEFAULT NOW()
-);
-
-CREATE TABLE IF NOT EXISTS crm.deal_events (
-    id BIGSERIAL PRIMARY KEY,
-    deal_id UUID REFERENCES crm.deals(id) ON DELETE CASCADE,
-    event_type TEXT NOT NULL,
-    payload JSONB NOT NULL,
-    created_at TIMESTAMPTZ DEFAULT NOW()
-);
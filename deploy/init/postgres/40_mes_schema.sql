@@ -1,51 +0,0 @@
-CREATE SCHEMA IF NOT EXISTS mes;
-
-CREATE TABLE IF NOT EXISTS mes.work_center (
-    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    code TEXT NOT NULL UNIQUE,
-    name TEXT NOT NULL,
-    description TEXT DEFAULT '' NOT NULL,
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-);
-
-CREATE TABLE IF NOT EXISTS mes.operation (
-    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    code TEXT NOT NULL UNIQUE,
-    name TEXT NOT NULL,
-    description TEXT DEFAULT '' NOT NULL,
-    default_duration_minutes INTEGER NOT NULL DEFAULT 0,
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-);
-
-CREATE TABLE IF NOT EXISTS mes.route (
-    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    code TEXT NOT NULL UNIQUE,
-    name TEXT NOT NULL,
-    description TEXT DEFAULT '' NOT NULL,
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-);
-
-CREATE TABLE IF NOT EXISTS mes.route_operation (
-    route_id UUID NOT NULL REFERENCES mes.route(id) ON DELETE CASCADE,
-    operation_id UUID NOT NULL REFERENCES mes.operation(id),
-    position SMALLINT NOT NULL,
-    PRIMARY KEY (route_id, operation_id)
-);
-
-CREATE TABLE IF NOT EXISTS mes.work_order (
-    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    code TEXT NOT NULL UNIQUE,
-    customer_id UUID,
-    route_id UUID REFERENCES mes.route(id),
-    status TEXT NOT NULL DEFAULT 'planned',
-    planned_start TIMESTAMPTZ,
-    planned_end TIMESTAMPTZ,
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-);
-
-CREATE INDEX IF NOT EXISTS idx_mes_work_order_route ON mes.work_order(route_id);
-CREATE INDEX IF NOT EXISTS idx_mes_work_order_status ON mes.work_order(status);
@@ -1,36 +0,0 @@
-CREATE SCHEMA IF NOT EXISTS montage;
-
-CREATE TABLE IF NOT EXISTS montage.crew (
-    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    code TEXT NOT NULL UNIQUE,
-    name TEXT NOT NULL,
-    specialization TEXT NOT NULL DEFAULT '',
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-);
-
-CREATE TABLE IF NOT EXISTS montage.vehicle (
-    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    code TEXT NOT NULL UNIQUE,
-    name TEXT NOT NULL,
-    plate TEXT NOT NULL DEFAULT '',
-    capacity TEXT NOT NULL DEFAULT '',
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-);
-
-CREATE TABLE IF NOT EXISTS montage.task (
-    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    code TEXT NOT NULL UNIQUE,
-    title TEXT NOT NULL,
-    status TEXT NOT NULL DEFAULT 'planned',
-    crew_id UUID REFERENCES montage.crew(id),
-    vehicle_id UUID REFERENCES montage.vehicle(id),
-    scheduled_at TIMESTAMPTZ,
-    location TEXT NOT NULL DEFAULT '',
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-);
-
-CREATE INDEX IF NOT EXISTS idx_montage_task_status ON montage.task(status);
-CREATE INDEX IF NOT EXISTS idx_montage_task_crew ON montage.task(crew_id);
@@ -1,65 +0,0 @@
-CREATE SCHEMA IF NOT EXISTS docs;
-
-CREATE TABLE IF NOT EXISTS docs.template (
-    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    code TEXT NOT NULL UNIQUE,
-    name TEXT NOT NULL,
-    description TEXT,
-    version INT NOT NULL DEFAULT 1,
-    body JSONB NOT NULL DEFAULT '{}'::jsonb,
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-);
-
-CREATE TABLE IF NOT EXISTS docs.number_sequence (
-    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    code TEXT NOT NULL UNIQUE,
-    prefix TEXT NOT NULL DEFAULT '',
-    padding SMALLINT NOT NULL DEFAULT 4,
-    current_value BIGINT NOT NULL DEFAULT 0,
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-);
-
-CREATE TABLE IF NOT EXISTS docs.signer (
-    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    code TEXT NOT NULL UNIQUE,
-    full_name TEXT NOT NULL,
-    position TEXT,
-    email TEXT,
-    phone TEXT,
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-);
-
-CREATE TABLE IF NOT EXISTS docs.document (
-    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    template_id UUID NOT NULL REFERENCES docs.template(id),
-    sequence_id UUID NOT NULL REFERENCES docs.number_sequence(id),
-    number TEXT NOT NULL UNIQUE,
-    title TEXT NOT NULL,
-    status TEXT NOT NULL,
-    payload JSONB NOT NULL DEFAULT '{}'::jsonb,
-    issued_at TIMESTAMPTZ,
-    signed_at TIMESTAMPTZ,
-    archived_at TIMESTAMPTZ,
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-);
-
-CREATE INDEX IF NOT EXISTS idx_docs_document_status ON docs.document(status);
-CREATE INDEX IF NOT EXISTS idx_docs_document_template ON docs.document(template_id);
-
-CREATE TABLE IF NOT EXISTS docs.document_signer (
-    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    document_id UUID NOT NULL REFERENCES docs.document(id) ON DELETE CASCADE,
-    signer_id UUID NOT NULL REFERENCES docs.signer(id),
-    status TEXT NOT NULL DEFAULT 'pending',
-    signed_at TIMESTAMPTZ,
-    order_no SMALLINT NOT NULL DEFAULT 1,
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    UNIQUE (document_id, signer_id)
-);
-
-CREATE INDEX IF NOT EXISTS idx_docs_document_signer_document ON docs.document_signer(document_id);
@@ -1,69 +0,0 @@
-CREATE SCHEMA IF NOT EXISTS bpm;
-
-CREATE TABLE IF NOT EXISTS bpm.process_definition (
-    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    code TEXT NOT NULL UNIQUE,
-    name TEXT NOT NULL,
-    description TEXT,
-    version INT NOT NULL DEFAULT 1,
-    status TEXT NOT NULL DEFAULT 'draft',
-    definition JSONB NOT NULL DEFAULT '{}'::jsonb,
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-);
-
-CREATE TABLE IF NOT EXISTS bpm.form (
-    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    process_id UUID NOT NULL REFERENCES bpm.process_definition(id) ON DELETE CASCADE,
-    code TEXT NOT NULL UNIQUE,
-    name TEXT NOT NULL,
-    version INT NOT NULL DEFAULT 1,
-    schema JSONB NOT NULL DEFAULT '{}'::jsonb,
-    ui_schema JSONB NOT NULL DEFAULT '{}'::jsonb,
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-);
-
-CREATE TABLE IF NOT EXISTS bpm.task (
-    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    process_id UUID NOT NULL REFERENCES bpm.process_definition(id) ON DELETE CASCADE,
-    code TEXT NOT NULL UNIQUE,
-    title TEXT NOT NULL,
-    status TEXT NOT NULL DEFAULT 'pending',
-    assignee TEXT,
-    due_at TIMESTAMPTZ,
-    payload JSONB NOT NULL DEFAULT '{}'::jsonb,
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-);
-
-CREATE INDEX IF NOT EXISTS idx_bpm_task_process ON bpm.task(process_id);
-CREATE INDEX IF NOT EXISTS idx_bpm_task_status ON bpm.task(status);
-
-CREATE TABLE IF NOT EXISTS bpm.assignment_rule (
-    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    process_id UUID NOT NULL REFERENCES bpm.process_definition(id) ON DELETE CASCADE,
-    task_code TEXT NOT NULL,
-    priority INT NOT NULL DEFAULT 1,
-    rule_type TEXT NOT NULL,
-    rule_value TEXT NOT NULL,
-    conditions JSONB NOT NULL DEFAULT '{}'::jsonb,
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    CONSTRAINT uq_bpm_assignment_rule_process_task UNIQUE (process_id, task_code, priority)
-);
-
-CREATE INDEX IF NOT EXISTS idx_bpm_assignment_rule_process ON bpm.assignment_rule(process_id, task_code);
-
-CREATE TABLE IF NOT EXISTS bpm.escalation (
-    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    task_id UUID NOT NULL REFERENCES bpm.task(id) ON DELETE CASCADE,
-    threshold_minutes INT NOT NULL,
-    escalate_to TEXT NOT NULL,
-    policy TEXT NOT NULL,
-    metadata JSONB NOT NULL DEFAULT '{}'::jsonb,
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-);
-
-CREATE INDEX IF NOT EXISTS idx_bpm_escalation_task ON bpm.escalation(task_id);
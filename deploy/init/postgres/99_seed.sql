@@ -1,4 +0,0 @@
--- Seed demo data for core, CRM and WMS modules.
-\ir seed/10_core.sql
-\ir seed/20_crm.sql
-\ir seed/30_wms.sql
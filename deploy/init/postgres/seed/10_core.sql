@@ -16,6 +16,16 @@ SET
     full_name = EXCLUDED.full_name,
     password_hash = EXCLUDED.password_hash;
 
+INSERT INTO core.companies (code, name)
+VALUES ('MAIN', 'Основное юрлицо')
+ON CONFLICT (code) DO UPDATE SET name = EXCLUDED.name;
+
+INSERT INTO core.user_companies (user_id, company_code, is_default)
+SELECT u.id, 'MAIN', TRUE
+FROM core.users u
+WHERE u.email = 'admin@example.com'
+ON CONFLICT DO NOTHING;
+
 INSERT INTO core.user_roles (user_id, role_code, warehouse_scope)
 SELECT u.id, 'director', '*'
 FROM core.users u
@@ -1,7 +1,7 @@
-INSERT INTO crm.customers (id, name, inn, kpp)
+INSERT INTO crm.customers (id, name, inn, kpp, company_code)
 VALUES
-    ('30000000-0000-0000-0000-000000000001', 'ООО «Афиша»', '7701234567', '770101001'),
-    ('30000000-0000-0000-0000-000000000002', 'ООО «Сфера Принт»', '7812345678', '781201001')
+    ('30000000-0000-0000-0000-000000000001', 'ООО «Афиша»', '7701234567', '770101001', 'MAIN'),
+    ('30000000-0000-0000-0000-000000000002', 'ООО «Сфера Принт»', '7812345678', '781201001', 'MAIN')
 ON CONFLICT (id) DO UPDATE
 SET
     name = EXCLUDED.name,
@@ -12,10 +12,10 @@ DO
 $$
 BEGIN
     IF to_regclass('crm.deals') IS NOT NULL THEN
-        INSERT INTO crm.deals (id, title, customer_id, stage, amount, currency, created_by, org_unit_code)
+        INSERT INTO crm.deals (id, title, customer_id, stage, amount, currency, created_by, org_unit_code, company_code)
         VALUES
-            ('31000000-0000-0000-0000-000000000001', 'Демо договор на вывеску', '30000000-0000-0000-0000-000000000001', 'new', 350000, 'RUB', 'admin@example.com', 'HQ-SALES'),
-            ('31000000-0000-0000-0000-000000000002', 'Обслуживание digital-вывесок', '30000000-0000-0000-0000-000000000002', 'qualification', 120000, 'RUB', 'admin@example.com', 'HQ-SALES')
+            ('31000000-0000-0000-0000-000000000001', 'Демо договор на вывеску', '30000000-0000-0000-0000-000000000001', 'new', 350000, 'RUB', 'admin@example.com', 'HQ-SALES', 'MAIN'),
+            ('31000000-0000-0000-0000-000000000002', 'Обслуживание digital-вывесок', '30000000-0000-0000-0000-000000000002', 'qualification', 120000, 'RUB', 'admin@example.com', 'HQ-SALES', 'MAIN')
         ON CONFLICT (id) DO UPDATE
         SET
             title = EXCLUDED.title,
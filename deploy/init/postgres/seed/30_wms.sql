@@ -169,6 +169,6 @@ ON CONFLICT (warehouse_id, code) DO UPDATE SET
     metadata = EXCLUDED.metadata,
     updated_at = NOW();
 
-INSERT INTO wms.stock (sku, warehouse, quantity, uom)
-VALUES ('banner-001', 'msk-main', 120, 'pcs')
-ON CONFLICT (sku, warehouse) DO UPDATE SET quantity = EXCLUDED.quantity;
+INSERT INTO wms.stock (company_code, sku, warehouse, quantity, uom)
+VALUES ('MAIN', 'banner-001', 'msk-main', 120, 'pcs')
+ON CONFLICT (company_code, sku, warehouse) DO UPDATE SET quantity = EXCLUDED.quantity;
@@ -18,10 +18,10 @@ BEGIN
     IF to_regclass('docs.number_sequence') IS NOT NULL
        AND EXISTS (SELECT 1 FROM information_schema.columns WHERE table_schema = 'docs' AND table_name = 'number_sequence' AND column_name = 'code')
        AND EXISTS (SELECT 1 FROM information_schema.columns WHERE table_schema = 'docs' AND table_name = 'number_sequence' AND column_name = 'id') THEN
-        INSERT INTO docs.number_sequence (id, code)
+        INSERT INTO docs.number_sequence (id, company_code, code)
         VALUES
-            ('60000000-0000-0000-0000-000000000101', 'DOC-OFFER'),
-            ('60000000-0000-0000-0000-000000000102', 'DOC-ACT')
+            ('60000000-0000-0000-0000-000000000101', 'MAIN', 'DOC-OFFER'),
+            ('60000000-0000-0000-0000-000000000102', 'MAIN', 'DOC-ACT')
         ON CONFLICT (id) DO UPDATE SET code = EXCLUDED.code;
     ELSE
         RAISE NOTICE 'docs.number_sequence missing expected columns, skipping seed';
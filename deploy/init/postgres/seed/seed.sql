@@ -0,0 +1,5 @@
+-- Seed demo data for core, CRM and WMS modules. Run after the migrations (make seed);
+-- PostgreSQL init only creates extensions, the schema comes from the embedded migrations.
+\ir 10_core.sql
+\ir 20_crm.sql
+\ir 30_wms.sql
@@ -23,9 +23,17 @@ if not ok then
 end
 
 if not queue.tube.events_queue then
-    queue.create_tube("events_queue", "fifo", {
+    -- fifottl supports delayed release, which consumers use for retries.
+    queue.create_tube("events_queue", "fifottl", {
         if_not_exists = true,
         temporary = false,
         opts = {ttl = 3600, max_len = 5000}
     })
 end
+
+if not queue.tube.events_queue_dead then
+    queue.create_tube("events_queue_dead", "fifo", {
+        if_not_exists = true,
+        temporary = false
+    })
+end
@@ -4,54 +4,179 @@ package main
 import (
 	"context"
 	stdlog "log"
+	"os"
 
-	ch "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/rs/zerolog"
 
 	"asfppro/gateway/internal/auth"
+	"asfppro/gateway/internal/files"
 	"asfppro/gateway/internal/http"
 	"asfppro/pkg/audit"
 	"asfppro/pkg/config"
 	"asfppro/pkg/db"
+	coremigrations "asfppro/pkg/db/migrations/core"
+	"asfppro/pkg/diagnostics"
+	"asfppro/pkg/lifecycle"
 	logpkg "asfppro/pkg/log"
+	"asfppro/pkg/migrate"
+	"asfppro/pkg/queue"
 	"asfppro/pkg/s3"
+	"asfppro/pkg/tracing"
 )
 
 func main() {
-	cfg, err := config.Load("GATEWAY")
+	if handled, err := config.Command("GATEWAY", os.Args[1:], os.Stdout); handled {
+		if err != nil {
+			stdlog.Fatalf("config: %v", err)
+		}
+		return
+	}
+
+	src := migrate.Source{Table: coremigrations.Table, Files: coremigrations.Files}
+	if handled, err := migrate.Command(context.Background(), "GATEWAY", src, os.Args[1:], os.Stdout); handled {
+		if err != nil {
+			stdlog.Fatalf("migrate: %v", err)
+		}
+		return
+	}
+
+	cfg, err := config.LoadArgs("GATEWAY", os.Args[1:])
 	if err != nil {
 		stdlog.Fatalf("config load: %v", err)
 	}
 
-	logger := logpkg.Init(cfg.Env)
+	logger, err := logpkg.New(logpkg.Options{Env: cfg.Env, Service: cfg.AppName, Level: cfg.LogLevel, Format: cfg.LogFormat})
+	if err != nil {
+		stdlog.Fatalf("init logger: %v", err)
+	}
+
+	lc := lifecycle.New(logger, cfg.ShutdownTimeout)
+
+	if cfg.EnablePprof || cfg.MetricsEnable {
+		diag, err := diagnostics.Start(diagnostics.Config{
+			Addr:    cfg.PprofAddr,
+			Token:   cfg.PprofToken,
+			Pprof:   cfg.EnablePprof,
+			Metrics: cfg.MetricsEnable,
+		}, logger)
+		if err != nil {
+			lc.Abort(err, "start admin listener")
+		}
+		lc.Append(lifecycle.Hook{Name: "admin", Stop: diag.Shutdown})
+	}
+
+	flushTraces, err := tracing.Init(context.Background(), tracing.Config{
+		ServiceName: cfg.AppName,
+		Environment: cfg.Env,
+		Endpoint:    cfg.OTLPEndpoint,
+		SampleRatio: cfg.TraceSample,
+	}, logger)
+	if err != nil {
+		lc.Abort(err, "init tracing")
+	}
+	lc.Close("tracing", flushTraces)
+
+	poolCfg := db.PoolConfig{
+		MaxConns:        int32(cfg.DBMaxConns),
+		MinConns:        int32(cfg.DBMinConns),
+		MaxConnIdleTime: cfg.DBMaxConnIdle,
+		MaxConnLifetime: cfg.DBMaxConnLife,
+	}
+	pool, err := db.NewPostgresPoolWithConfig(context.Background(), cfg.DatabaseURL, poolCfg)
+	if err != nil {
+		lc.Abort(err, "init postgres")
+	}
+	lc.Close("postgres", pool.Close)
+
+	if err := migrate.OnStart(context.Background(), cfg, pool, src, logger); err != nil {
+		lc.Abort(err, "migrate")
+	}
 
-	pool, err := db.NewPostgresPool(context.Background(), cfg.DatabaseURL)
+	// List endpoints read from replicas when DATABASE_REPLICAS is set; writes stay on the primary.
+	router, err := db.NewRouter(context.Background(), pool, cfg.Replicas(), poolCfg, cfg.DBReplicaMaxLag, logger)
 	if err != nil {
-		logger.Fatal().Err(err).Msg("init postgres")
+		lc.Abort(err, "postgres replicas")
 	}
-	defer pool.Close()
+	lc.Close("postgres replicas", router.Close)
+	lc.Go("replica monitor", router.Run)
 
-	storage, err := s3.New(cfg.S3Endpoint, cfg.S3Region, cfg.S3AccessKey, cfg.S3SecretKey, cfg.S3Bucket, cfg.S3UseSSL)
+	storage, err := s3.NewStorage(s3.Config{
+		Backend:        cfg.S3Backend,
+		Endpoint:       cfg.S3Endpoint,
+		Region:         cfg.S3Region,
+		AccessKey:      cfg.S3AccessKey,
+		SecretKey:      cfg.S3SecretKey,
+		Bucket:         cfg.S3Bucket,
+		UseSSL:         cfg.S3UseSSL,
+		PublicEndpoint: cfg.S3PublicURL,
+		Root:           cfg.S3FSRoot,
+	})
 	if err != nil {
-		logger.Fatal().Err(err).Msg("init s3")
+		lc.Abort(err, "init s3")
 	}
 
 	chConn, err := db.NewClickHouse(context.Background(), cfg.ClickHouseDSN)
 	if err != nil {
-		logger.Fatal().Err(err).Msg("init clickhouse")
+		lc.Abort(err, "init clickhouse")
 	}
-	defer func(conn ch.Conn) {
-		_ = conn.Close()
-	}(chConn)
+	lc.Append(lifecycle.Hook{Name: "clickhouse", Stop: func(context.Context) error { return chConn.Close() }})
 
 	authService := auth.NewService(pool)
 	auditRecorder := audit.NewRecorder(pool, logger)
 
-	server, err := http.NewServer(cfg, logger, pool, storage, chConn, authService, auditRecorder)
+	// Queue administration is optional: the gateway keeps serving when Tarantool is down.
+	var queueAdmin queue.Admin
+	if admin, err := queue.NewAdmin(queue.AdminConfig{Backend: cfg.QueueBackend, Addr: cfg.TarantoolAddr}); err != nil {
+		logger.Warn().Err(err).Msg("queue admin disabled")
+	} else {
+		queueAdmin = admin
+		lc.Close("queue admin", admin.Close)
+	}
+
+	// Previews are optional as well: without the queue uploads are registered without them.
+	var previews http.Previews
+	if cfg.PreviewEnable {
+		previews = connectPreviews(cfg, lc, logger)
+	}
+
+	server, err := http.NewServer(cfg, logger, pool, router, storage, chConn, authService, auditRecorder, queueAdmin, previews)
 	if err != nil {
-		logger.Fatal().Err(err).Msg("init server")
+		lc.Abort(err, "init server")
+	}
+
+	server.Register(lc)
+	if err := lc.Run(context.Background()); err != nil {
+		os.Exit(1)
 	}
+}
 
-	if err := server.Run(); err != nil {
-		logger.Fatal().Err(err).Msg("server stopped")
+// connectPreviews opens the queue publisher announcing uploads and the consumer of the
+// preview worker; on failure previews are disabled.
+func connectPreviews(cfg config.AppConfig, lc *lifecycle.Manager, logger zerolog.Logger) http.Previews {
+	publisher, err := queue.NewPublisher(queue.PublisherConfig{
+		Backend:  cfg.QueueBackend,
+		Addr:     cfg.TarantoolAddr,
+		Tube:     cfg.TarantoolQueue,
+		Producer: "gateway",
+	})
+	if err != nil {
+		logger.Warn().Err(err).Msg("file previews disabled")
+		return http.Previews{}
+	}
+	consumer, err := queue.NewConsumer(queue.ConsumerConfig{
+		Backend:     cfg.QueueBackend,
+		Addr:        cfg.TarantoolAddr,
+		Tube:        cfg.TarantoolQueue,
+		Group:       "previews",
+		Topics:      []string{files.FileUploadedEvent},
+		MaxAttempts: cfg.QueueMaxRetries,
+	})
+	if err != nil {
+		publisher.Close()
+		logger.Warn().Err(err).Msg("file previews disabled")
+		return http.Previews{}
 	}
+	lc.Close("queue publisher", publisher.Close)
+	lc.Close("preview consumer", consumer.Close)
+	return http.Previews{Publisher: publisher, Consumer: consumer}
 }
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	ch "github.com/ClickHouse/clickhouse-go/v2"
+
+	"asfppro/pkg/tenant"
 )
 
 // Repository provides access to analytics data stored in ClickHouse.
@@ -18,8 +20,12 @@ func NewRepository(conn ch.Conn) *Repository {
 	return &Repository{conn: conn}
 }
 
-// ConversionReport returns aggregated conversion metrics between bounds.
+// ConversionReport returns aggregated conversion metrics of the active company between bounds.
 func (r *Repository) ConversionReport(ctx context.Context, from, to time.Time) ([]ConversionRow, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
 	query := `
 SELECT
     toStartOfMonth(created_at) AS period,
@@ -27,12 +33,12 @@ SELECT
     countIf(stage = 'won') AS won_count,
     sum(amount) AS total_amount,
     sumIf(amount, stage = 'won') AS won_amount
-FROM analytics.events
-WHERE event_type = 'deal.created' AND created_at BETWEEN ? AND ?
+FROM analytics.events FINAL
+WHERE event_type = 'deal.created' AND company_code = ? AND created_at BETWEEN ? AND ?
 GROUP BY period
 ORDER BY period`
 
-	rows, err := r.conn.Query(ctx, query, from, to)
+	rows, err := r.conn.Query(ctx, query, company, from, to)
 	if err != nil {
 		return nil, fmt.Errorf("query conversion report: %w", err)
 	}
@@ -50,19 +56,23 @@ ORDER BY period`
 	return result, rows.Err()
 }
 
-// ManagerLoad aggregates deals by manager.
+// ManagerLoad aggregates deals of the active company by manager.
 func (r *Repository) ManagerLoad(ctx context.Context, from, to time.Time) ([]ManagerLoadRow, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
 	query := `
 SELECT
     if(length(created_by) = 0, 'unknown', created_by) AS manager,
     count() AS total_count,
     sum(amount) AS total_amount
-FROM analytics.events
-WHERE event_type = 'deal.created' AND created_at BETWEEN ? AND ?
+FROM analytics.events FINAL
+WHERE event_type = 'deal.created' AND company_code = ? AND created_at BETWEEN ? AND ?
 GROUP BY manager
 ORDER BY total_count DESC`
 
-	rows, err := r.conn.Query(ctx, query, from, to)
+	rows, err := r.conn.Query(ctx, query, company, from, to)
 	if err != nil {
 		return nil, fmt.Errorf("query manager load: %w", err)
 	}
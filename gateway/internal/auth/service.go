@@ -12,6 +12,8 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"golang.org/x/crypto/bcrypt"
+
+	"asfppro/pkg/tenant"
 )
 
 var (
@@ -29,11 +31,12 @@ type Role struct {
 
 // User represents authenticated principal.
 type User struct {
-	ID       uuid.UUID
-	Email    string
-	FullName string
-	Roles    []Role
-	OrgUnits []string
+	ID        uuid.UUID
+	Email     string
+	FullName  string
+	Roles     []Role
+	OrgUnits  []string
+	Companies []tenant.Grant
 }
 
 // Service provides authentication helpers backed by Postgres.
@@ -128,15 +131,47 @@ GROUP BY u.id;
 		return User{}, err
 	}
 
+	companies, err := s.fetchUserCompanies(ctx, id)
+	if err != nil {
+		return User{}, err
+	}
+
 	return User{
-		ID:       id,
-		Email:    dbEmail,
-		FullName: fullName,
-		Roles:    roles,
-		OrgUnits: orgUnits,
+		ID:        id,
+		Email:     dbEmail,
+		FullName:  fullName,
+		Roles:     roles,
+		OrgUnits:  orgUnits,
+		Companies: companies,
 	}, nil
 }
 
+func (s *Service) fetchUserCompanies(ctx context.Context, userID uuid.UUID) ([]tenant.Grant, error) {
+	const query = `SELECT c.code, c.name, uc.is_default
+FROM core.user_companies uc
+JOIN core.companies c ON c.code = uc.company_code
+WHERE uc.user_id = $1 AND c.is_active
+ORDER BY uc.is_default DESC, c.code`
+	rows, err := s.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query user companies: %w", err)
+	}
+	defer rows.Close()
+
+	grants := make([]tenant.Grant, 0)
+	for rows.Next() {
+		var g tenant.Grant
+		if err := rows.Scan(&g.Code, &g.Name, &g.Default); err != nil {
+			return nil, fmt.Errorf("scan company: %w", err)
+		}
+		grants = append(grants, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("company rows: %w", err)
+	}
+	return grants, nil
+}
+
 func (s *Service) fetchUserOrgUnits(ctx context.Context, userID uuid.UUID) ([]string, error) {
 	const query = `SELECT org_unit_code FROM core.user_org_units WHERE user_id = $1`
 	rows, err := s.pool.Query(ctx, query, userID)
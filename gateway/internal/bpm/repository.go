@@ -13,6 +13,8 @@ import (
     "github.com/jackc/pgx/v5/pgconn"
     "github.com/jackc/pgx/v5/pgtype"
     "github.com/jackc/pgx/v5/pgxpool"
+
+    "asfppro/pkg/db"
 )
 
 var (
@@ -27,11 +29,18 @@ var (
 // Repository предоставляет доступ к таблицам BPM для gateway.
 type Repository struct {
     pool *pgxpool.Pool
+    read db.Querier
 }
 
 // NewRepository создает репозиторий.
 func NewRepository(pool *pgxpool.Pool) *Repository {
-    return &Repository{pool: pool}
+    return &Repository{pool: pool, read: pool}
+}
+
+// WithReader направляет запросы списков через read — обычно db.Router, читающий с реплик.
+func (r *Repository) WithReader(read db.Querier) *Repository {
+    r.read = read
+    return r
 }
 
 // ListProcesses возвращает процессы.
@@ -51,7 +60,7 @@ func (r *Repository) ListProcesses(ctx context.Context, limit int, status string
         args = append(args, limit)
     }
 
-    rows, err := r.pool.Query(ctx, builder.String(), args...)
+    rows, err := r.read.Query(ctx, builder.String(), args...)
     if err != nil {
         return nil, fmt.Errorf("list processes: %w", err)
     }
@@ -165,7 +174,7 @@ func (r *Repository) getProcess(ctx context.Context, id uuid.UUID) (ProcessDefin
 // ListForms возвращает формы.
 func (r *Repository) ListForms(ctx context.Context, limit int) ([]Form, error) {
     const query = `SELECT id, process_id, code, name, version, schema, ui_schema, created_at, updated_at FROM bpm.form ORDER BY created_at DESC LIMIT $1`
-    rows, err := r.pool.Query(ctx, query, limit)
+    rows, err := r.read.Query(ctx, query, limit)
     if err != nil {
         return nil, fmt.Errorf("list forms: %w", err)
     }
@@ -284,7 +293,7 @@ func (r *Repository) ListTasks(ctx context.Context, filter TaskListFilter) ([]Ta
     builder.WriteString(fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d", idx))
     args = append(args, filter.Limit)
 
-    rows, err := r.pool.Query(ctx, builder.String(), args...)
+    rows, err := r.read.Query(ctx, builder.String(), args...)
     if err != nil {
         return nil, fmt.Errorf("list tasks: %w", err)
     }
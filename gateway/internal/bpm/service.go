@@ -9,6 +9,8 @@ import (
 
     "github.com/google/uuid"
     "github.com/rs/zerolog"
+
+    logpkg "asfppro/pkg/log"
 )
 
 var (
@@ -72,7 +74,7 @@ func (s *Service) CreateProcess(ctx context.Context, input ProcessCreateInput) (
     if err != nil {
         return ProcessDefinition{}, err
     }
-    s.logger.Info().Str("processId", proc.ID.String()).Msg("bpm process created via gateway")
+    logpkg.Ctx(ctx, s.logger).Info().Str("processId", proc.ID.String()).Msg("bpm process created via gateway")
     return proc, nil
 }
 
@@ -117,7 +119,7 @@ func (s *Service) UpdateProcess(ctx context.Context, id uuid.UUID, input Process
     if err != nil {
         return ProcessDefinition{}, err
     }
-    s.logger.Info().Str("processId", proc.ID.String()).Msg("bpm process updated via gateway")
+    logpkg.Ctx(ctx, s.logger).Info().Str("processId", proc.ID.String()).Msg("bpm process updated via gateway")
     return proc, nil
 }
 
@@ -159,7 +161,7 @@ func (s *Service) CreateForm(ctx context.Context, input FormCreateInput) (Form,
     if err != nil {
         return Form{}, err
     }
-    s.logger.Info().Str("formId", form.ID.String()).Msg("bpm form created via gateway")
+    logpkg.Ctx(ctx, s.logger).Info().Str("formId", form.ID.String()).Msg("bpm form created via gateway")
     return form, nil
 }
 
@@ -198,7 +200,7 @@ func (s *Service) UpdateForm(ctx context.Context, id uuid.UUID, input FormUpdate
     if err != nil {
         return Form{}, err
     }
-    s.logger.Info().Str("formId", form.ID.String()).Msg("bpm form updated via gateway")
+    logpkg.Ctx(ctx, s.logger).Info().Str("formId", form.ID.String()).Msg("bpm form updated via gateway")
     return form, nil
 }
 
@@ -246,7 +248,7 @@ func (s *Service) CreateTask(ctx context.Context, input TaskCreateInput) (Task,
     if err != nil {
         return Task{}, err
     }
-    s.logger.Info().Str("taskId", task.ID.String()).Msg("bpm task created via gateway")
+    logpkg.Ctx(ctx, s.logger).Info().Str("taskId", task.ID.String()).Msg("bpm task created via gateway")
     return task, nil
 }
 
@@ -295,6 +297,6 @@ func (s *Service) UpdateTask(ctx context.Context, id uuid.UUID, input TaskUpdate
     if err != nil {
         return Task{}, err
     }
-    s.logger.Info().Str("taskId", task.ID.String()).Msg("bpm task updated via gateway")
+    logpkg.Ctx(ctx, s.logger).Info().Str("taskId", task.ID.String()).Msg("bpm task updated via gateway")
     return task, nil
 }
@@ -13,6 +13,7 @@ import (
 	"golang.org/x/crypto/bcrypt"
 
 	"asfppro/pkg/audit"
+	logpkg "asfppro/pkg/log"
 )
 
 // Service contains business logic for core domain operations.
@@ -159,7 +160,7 @@ func (s *Service) recordAudit(ctx context.Context, actor uuid.UUID, action, enti
 		Payload:  payload,
 	}
 	if err := s.auditor.Record(ctx, entry); err != nil {
-		s.logger.Error().Err(err).Msg("audit record")
+		logpkg.Ctx(ctx, s.logger).Error().Err(err).Msg("audit record")
 	}
 }
 
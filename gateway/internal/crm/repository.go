@@ -9,24 +9,39 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"asfppro/pkg/db"
+	"asfppro/pkg/tenant"
 )
 
-// Repository provides access to crm tables.
+// Repository provides access to crm tables. Every query is limited to the active company in ctx;
+// deal queries also run under the row-level security session in ctx.
 type Repository struct {
 	pool *pgxpool.Pool
+	read db.SessionReader
 }
 
 // NewRepository constructs repository.
 func NewRepository(pool *pgxpool.Pool) *Repository {
-	return &Repository{pool: pool}
+	return &Repository{pool: pool, read: pool}
+}
+
+// WithReader routes list queries through read, typically a db.Router sending them to replicas.
+func (r *Repository) WithReader(read db.SessionReader) *Repository {
+	r.read = read
+	return r
 }
 
 // ListCustomers returns customers ordered by creation.
 func (r *Repository) ListCustomers(ctx context.Context) ([]Customer, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
 	const query = `SELECT id, name, COALESCE(inn, ''), COALESCE(kpp, ''), created_at
-FROM crm.customers ORDER BY created_at DESC`
+FROM crm.customers WHERE company_code = $1 ORDER BY created_at DESC`
 
-	rows, err := r.pool.Query(ctx, query)
+	rows, err := r.read.Query(ctx, query, company)
 	if err != nil {
 		return nil, fmt.Errorf("list customers: %w", err)
 	}
@@ -46,13 +61,17 @@ FROM crm.customers ORDER BY created_at DESC`
 
 // CreateCustomer inserts customer.
 func (r *Repository) CreateCustomer(ctx context.Context, input CreateCustomerInput) (Customer, error) {
-	const query = `INSERT INTO crm.customers (id, name, inn, kpp)
-VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''))
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return Customer{}, err
+	}
+	const query = `INSERT INTO crm.customers (id, name, inn, kpp, company_code)
+VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5)
 RETURNING id, name, COALESCE(inn, ''), COALESCE(kpp, ''), created_at`
 
 	var c Customer
 	id := uuid.New()
-	if err := r.pool.QueryRow(ctx, query, id, input.Name, input.INN, input.KPP).
+	if err := r.pool.QueryRow(ctx, query, id, input.Name, input.INN, input.KPP, company).
 		Scan(&c.ID, &c.Name, &c.INN, &c.KPP, &c.CreatedAt); err != nil {
 		return Customer{}, fmt.Errorf("insert customer: %w", err)
 	}
@@ -62,6 +81,10 @@ RETURNING id, name, COALESCE(inn, ''), COALESCE(kpp, ''), created_at`
 
 // UpdateCustomer updates fields for existing customer.
 func (r *Repository) UpdateCustomer(ctx context.Context, id uuid.UUID, input UpdateCustomerInput) (Customer, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return Customer{}, err
+	}
 	setParts := make([]string, 0, 3)
 	args := make([]any, 0, 4)
 	idx := 1
@@ -86,8 +109,8 @@ func (r *Repository) UpdateCustomer(ctx context.Context, id uuid.UUID, input Upd
 		return r.findCustomer(ctx, id)
 	}
 
-	query := fmt.Sprintf("UPDATE crm.customers SET %s WHERE id = $%d RETURNING id, name, COALESCE(inn, ''), COALESCE(kpp, ''), created_at", strings.Join(setParts, ", "), idx)
-	args = append(args, id)
+	query := fmt.Sprintf("UPDATE crm.customers SET %s WHERE id = $%d AND company_code = $%d RETURNING id, name, COALESCE(inn, ''), COALESCE(kpp, ''), created_at", strings.Join(setParts, ", "), idx, idx+1)
+	args = append(args, id, company)
 
 	var c Customer
 	if err := r.pool.QueryRow(ctx, query, args...).Scan(&c.ID, &c.Name, &c.INN, &c.KPP, &c.CreatedAt); err != nil {
@@ -102,6 +125,10 @@ func (r *Repository) UpdateCustomer(ctx context.Context, id uuid.UUID, input Upd
 
 // ListDeals returns deals with optional stage filter.
 func (r *Repository) ListDeals(ctx context.Context, scopes []string, allowAll bool, filter ListDealsFilter) ([]Deal, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
 	if filter.Limit <= 0 {
 		filter.Limit = 20
 	}
@@ -111,44 +138,53 @@ func (r *Repository) ListDeals(ctx context.Context, scopes []string, allowAll bo
 
 	query := `SELECT id, title, customer_id, stage, amount, currency, COALESCE(created_by, ''), created_at, org_unit_code
 FROM crm.deals
-WHERE ($1 = '' OR stage = $1)`
-	args := []any{filter.Stage}
+WHERE company_code = $1 AND ($2 = '' OR stage = $2)`
+	args := []any{company, filter.Stage}
 	if !allowAll {
-		query += " AND org_unit_code = ANY($2)"
+		query += " AND org_unit_code = ANY($3)"
 		args = append(args, scopes)
 	}
 	limitPlaceholder := fmt.Sprintf("$%d", len(args)+1)
 	query += " ORDER BY created_at DESC LIMIT " + limitPlaceholder
 	args = append(args, filter.Limit)
 
-	rows, err := r.pool.Query(ctx, query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("list deals: %w", err)
-	}
-	defer rows.Close()
-
 	var deals []Deal
-	for rows.Next() {
-		var d Deal
-		if err := rows.Scan(&d.ID, &d.Title, &d.CustomerID, &d.Stage, &d.Amount, &d.Currency, &d.CreatedBy, &d.CreatedAt, &d.OrgUnitCode); err != nil {
-			return nil, fmt.Errorf("scan deal: %w", err)
+	err = db.InSession(ctx, r.read, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("list deals: %w", err)
 		}
-		d.CreatedAt = d.CreatedAt.UTC()
-		deals = append(deals, d)
-	}
-	return deals, rows.Err()
+		defer rows.Close()
+
+		for rows.Next() {
+			var d Deal
+			if err := rows.Scan(&d.ID, &d.Title, &d.CustomerID, &d.Stage, &d.Amount, &d.Currency, &d.CreatedBy, &d.CreatedAt, &d.OrgUnitCode); err != nil {
+				return fmt.Errorf("scan deal: %w", err)
+			}
+			d.CreatedAt = d.CreatedAt.UTC()
+			deals = append(deals, d)
+		}
+		return rows.Err()
+	})
+	return deals, err
 }
 
 // CreateDeal inserts deal row.
 func (r *Repository) CreateDeal(ctx context.Context, input CreateDealInput) (Deal, error) {
-	const query = `INSERT INTO crm.deals (id, title, customer_id, stage, amount, currency, created_by, org_unit_code)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return Deal{}, err
+	}
+	const query = `INSERT INTO crm.deals (id, title, customer_id, stage, amount, currency, created_by, org_unit_code, company_code)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 RETURNING id, title, customer_id, stage, amount, currency, COALESCE(created_by, ''), created_at, org_unit_code`
 
 	var d Deal
 	id := uuid.New()
-	if err := r.pool.QueryRow(ctx, query, id, input.Title, input.CustomerID, input.Stage, input.Amount, input.Currency, input.CreatedBy, input.OrgUnitCode).
-		Scan(&d.ID, &d.Title, &d.CustomerID, &d.Stage, &d.Amount, &d.Currency, &d.CreatedBy, &d.CreatedAt, &d.OrgUnitCode); err != nil {
+	if err := db.InSession(ctx, r.pool, func(tx pgx.Tx) error {
+		return tx.QueryRow(ctx, query, id, input.Title, input.CustomerID, input.Stage, input.Amount, input.Currency, input.CreatedBy, input.OrgUnitCode, company).
+			Scan(&d.ID, &d.Title, &d.CustomerID, &d.Stage, &d.Amount, &d.Currency, &d.CreatedBy, &d.CreatedAt, &d.OrgUnitCode)
+	}); err != nil {
 		return Deal{}, fmt.Errorf("insert deal: %w", err)
 	}
 	d.CreatedAt = d.CreatedAt.UTC()
@@ -157,6 +193,10 @@ RETURNING id, title, customer_id, stage, amount, currency, COALESCE(created_by,
 
 // UpdateDeal updates deal fields.
 func (r *Repository) UpdateDeal(ctx context.Context, id uuid.UUID, input UpdateDealInput) (Deal, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return Deal{}, err
+	}
 	setParts := make([]string, 0, 5)
 	args := make([]any, 0, 6)
 	idx := 1
@@ -191,11 +231,13 @@ func (r *Repository) UpdateDeal(ctx context.Context, id uuid.UUID, input UpdateD
 		return r.findDeal(ctx, id)
 	}
 
-	query := fmt.Sprintf("UPDATE crm.deals SET %s WHERE id = $%d RETURNING id, title, customer_id, stage, amount, currency, COALESCE(created_by, ''), created_at, org_unit_code", strings.Join(setParts, ", "), idx)
-	args = append(args, id)
+	query := fmt.Sprintf("UPDATE crm.deals SET %s WHERE id = $%d AND company_code = $%d RETURNING id, title, customer_id, stage, amount, currency, COALESCE(created_by, ''), created_at, org_unit_code", strings.Join(setParts, ", "), idx, idx+1)
+	args = append(args, id, company)
 
 	var d Deal
-	if err := r.pool.QueryRow(ctx, query, args...).Scan(&d.ID, &d.Title, &d.CustomerID, &d.Stage, &d.Amount, &d.Currency, &d.CreatedBy, &d.CreatedAt, &d.OrgUnitCode); err != nil {
+	if err := db.InSession(ctx, r.pool, func(tx pgx.Tx) error {
+		return tx.QueryRow(ctx, query, args...).Scan(&d.ID, &d.Title, &d.CustomerID, &d.Stage, &d.Amount, &d.Currency, &d.CreatedBy, &d.CreatedAt, &d.OrgUnitCode)
+	}); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return Deal{}, ErrDealNotFound
 		}
@@ -207,10 +249,14 @@ func (r *Repository) UpdateDeal(ctx context.Context, id uuid.UUID, input UpdateD
 
 // findCustomer fetches a customer by id or returns ErrCustomerNotFound.
 func (r *Repository) findCustomer(ctx context.Context, id uuid.UUID) (Customer, error) {
-	const query = `SELECT id, name, COALESCE(inn, ''), COALESCE(kpp, ''), created_at FROM crm.customers WHERE id = $1`
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return Customer{}, err
+	}
+	const query = `SELECT id, name, COALESCE(inn, ''), COALESCE(kpp, ''), created_at FROM crm.customers WHERE id = $1 AND company_code = $2`
 
 	var c Customer
-	if err := r.pool.QueryRow(ctx, query, id).Scan(&c.ID, &c.Name, &c.INN, &c.KPP, &c.CreatedAt); err != nil {
+	if err := r.pool.QueryRow(ctx, query, id, company).Scan(&c.ID, &c.Name, &c.INN, &c.KPP, &c.CreatedAt); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return Customer{}, ErrCustomerNotFound
 		}
@@ -222,10 +268,16 @@ func (r *Repository) findCustomer(ctx context.Context, id uuid.UUID) (Customer,
 
 // findDeal fetches a deal by id or returns ErrDealNotFound.
 func (r *Repository) findDeal(ctx context.Context, id uuid.UUID) (Deal, error) {
-	const query = `SELECT id, title, customer_id, stage, amount, currency, COALESCE(created_by, ''), created_at, org_unit_code FROM crm.deals WHERE id = $1`
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return Deal{}, err
+	}
+	const query = `SELECT id, title, customer_id, stage, amount, currency, COALESCE(created_by, ''), created_at, org_unit_code FROM crm.deals WHERE id = $1 AND company_code = $2`
 
 	var d Deal
-	if err := r.pool.QueryRow(ctx, query, id).Scan(&d.ID, &d.Title, &d.CustomerID, &d.Stage, &d.Amount, &d.Currency, &d.CreatedBy, &d.CreatedAt, &d.OrgUnitCode); err != nil {
+	if err := db.InSession(ctx, r.pool, func(tx pgx.Tx) error {
+		return tx.QueryRow(ctx, query, id, company).Scan(&d.ID, &d.Title, &d.CustomerID, &d.Stage, &d.Amount, &d.Currency, &d.CreatedBy, &d.CreatedAt, &d.OrgUnitCode)
+	}); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return Deal{}, ErrDealNotFound
 		}
@@ -238,7 +290,10 @@ func (r *Repository) findDeal(ctx context.Context, id uuid.UUID) (Deal, error) {
 // AppendDealEvent stores event entry.
 func (r *Repository) AppendDealEvent(ctx context.Context, dealID uuid.UUID, eventType string, payload any) error {
 	const query = `INSERT INTO crm.deal_events (deal_id, event_type, payload) VALUES ($1, $2, $3)`
-	if _, err := r.pool.Exec(ctx, query, dealID, eventType, payload); err != nil {
+	if err := db.InSession(ctx, r.pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query, dealID, eventType, payload)
+		return err
+	}); err != nil {
 		return fmt.Errorf("insert deal event: %w", err)
 	}
 	return nil
@@ -249,35 +304,47 @@ func (r *Repository) ListDealEvents(ctx context.Context, dealID uuid.UUID, limit
 	if limit <= 0 {
 		limit = 20
 	}
-	const query = `SELECT id, deal_id, event_type, payload, created_at
-FROM crm.deal_events WHERE deal_id = $1 ORDER BY created_at DESC LIMIT $2`
-
-	rows, err := r.pool.Query(ctx, query, dealID, limit)
+	company, err := tenant.Require(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("list deal events: %w", err)
+		return nil, err
 	}
-	defer rows.Close()
+	const query = `SELECT e.id, e.deal_id, e.event_type, e.payload, e.created_at
+FROM crm.deal_events e JOIN crm.deals d ON d.id = e.deal_id
+WHERE e.deal_id = $1 AND d.company_code = $2 ORDER BY e.created_at DESC LIMIT $3`
 
 	var events []DealEvent
-	for rows.Next() {
-		var (
-			e       DealEvent
-			payload map[string]any
-		)
-		if err := rows.Scan(&e.ID, &e.DealID, &e.EventType, &payload, &e.CreatedAt); err != nil {
-			return nil, fmt.Errorf("scan deal event: %w", err)
+	err = db.InSession(ctx, r.read, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, dealID, company, limit)
+		if err != nil {
+			return fmt.Errorf("list deal events: %w", err)
 		}
-		e.Payload = payload
-		e.CreatedAt = e.CreatedAt.UTC()
-		events = append(events, e)
-	}
-	return events, rows.Err()
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				e       DealEvent
+				payload map[string]any
+			)
+			if err := rows.Scan(&e.ID, &e.DealID, &e.EventType, &payload, &e.CreatedAt); err != nil {
+				return fmt.Errorf("scan deal event: %w", err)
+			}
+			e.Payload = payload
+			e.CreatedAt = e.CreatedAt.UTC()
+			events = append(events, e)
+		}
+		return rows.Err()
+	})
+	return events, err
 }
 
-// CustomerExists checks presence of customer.
+// CustomerExists checks presence of customer in the active company.
 func (r *Repository) CustomerExists(ctx context.Context, id uuid.UUID) (bool, error) {
-	const query = `SELECT 1 FROM crm.customers WHERE id = $1`
-	row := r.pool.QueryRow(ctx, query, id)
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return false, err
+	}
+	const query = `SELECT 1 FROM crm.customers WHERE id = $1 AND company_code = $2`
+	row := r.pool.QueryRow(ctx, query, id, company)
 	var dummy int
 	if err := row.Scan(&dummy); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -7,12 +7,21 @@ import (
 	"strings"
 
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 
 	corepkg "asfppro/gateway/internal/core"
 	"asfppro/pkg/audit"
+	"asfppro/pkg/db"
+	logpkg "asfppro/pkg/log"
+	"asfppro/pkg/metrics"
 )
 
+var dealsCreated = metrics.Register(prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "crm_deals_created_total",
+	Help: "Deals created.",
+}))
+
 // Service handles CRM operations.
 type Service struct {
 	repo    *Repository
@@ -78,6 +87,7 @@ func (s *Service) UpdateCustomer(ctx context.Context, actor uuid.UUID, id uuid.U
 
 // ListDeals returns deals with filter.
 func (s *Service) ListDeals(ctx context.Context, subject corepkg.Subject, filter ListDealsFilter) ([]Deal, error) {
+	ctx = withSession(ctx, subject)
 	filter.Stage = strings.TrimSpace(strings.ToLower(filter.Stage))
 	allowAll, scopes := extractScopes(subject)
 	return s.repo.ListDeals(ctx, scopes, allowAll, filter)
@@ -85,6 +95,7 @@ func (s *Service) ListDeals(ctx context.Context, subject corepkg.Subject, filter
 
 // CreateDeal inserts deal.
 func (s *Service) CreateDeal(ctx context.Context, actor uuid.UUID, subject corepkg.Subject, input CreateDealInput) (Deal, error) {
+	ctx = withSession(ctx, subject)
 	input.Title = strings.TrimSpace(input.Title)
 	input.Stage = strings.TrimSpace(strings.ToLower(input.Stage))
 	input.Currency = strings.TrimSpace(strings.ToUpper(input.Currency))
@@ -127,6 +138,7 @@ func (s *Service) CreateDeal(ctx context.Context, actor uuid.UUID, subject corep
 		return Deal{}, err
 	}
 
+	dealsCreated.Inc()
 	s.recordAudit(ctx, actor, "crm.deal.create", deal.ID.String(), deal)
 	_ = s.repo.AppendDealEvent(ctx, deal.ID, "deal.created", map[string]any{"stage": deal.Stage, "title": deal.Title})
 	return deal, nil
@@ -134,6 +146,7 @@ func (s *Service) CreateDeal(ctx context.Context, actor uuid.UUID, subject corep
 
 // UpdateDeal updates deal fields.
 func (s *Service) UpdateDeal(ctx context.Context, actor uuid.UUID, subject corepkg.Subject, id uuid.UUID, input UpdateDealInput) (Deal, error) {
+	ctx = withSession(ctx, subject)
 	deal, err := s.repo.findDeal(ctx, id)
 	if err != nil {
 		return Deal{}, err
@@ -188,6 +201,7 @@ func (s *Service) UpdateDeal(ctx context.Context, actor uuid.UUID, subject corep
 
 // ListDealEvents returns history.
 func (s *Service) ListDealEvents(ctx context.Context, subject corepkg.Subject, dealID uuid.UUID, limit int) ([]DealEvent, error) {
+	ctx = withSession(ctx, subject)
 	deal, err := s.repo.findDeal(ctx, dealID)
 	if err != nil {
 		return nil, err
@@ -214,10 +228,16 @@ func (s *Service) recordAudit(ctx context.Context, actor uuid.UUID, action, enti
 		entry.Entity = "crm.customer"
 	}
 	if err := s.auditor.Record(ctx, entry); err != nil {
-		s.logger.Error().Err(err).Msg("crm audit record")
+		logpkg.Ctx(ctx, s.logger).Error().Err(err).Msg("crm audit record")
 	}
 }
 
+// withSession attaches the subject's org unit scopes to ctx for row-level security policies.
+func withSession(ctx context.Context, subject corepkg.Subject) context.Context {
+	allowAll, scopes := extractScopes(subject)
+	return db.WithSession(ctx, db.Session{UserID: subject.ID.String(), Scopes: scopes, AllowAll: allowAll})
+}
+
 func extractScopes(subject corepkg.Subject) (bool, []string) {
 	scopeSet := make(map[string]struct{})
 	allowAll := false
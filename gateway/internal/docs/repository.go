@@ -13,6 +13,9 @@ import (
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"asfppro/pkg/db"
+	"asfppro/pkg/tenant"
 )
 
 var (
@@ -26,20 +29,28 @@ var (
 	ErrSequenceNotFound = errors.New("number sequence not found")
 )
 
-// Repository предоставляет доступ к таблицам Docs.
+// Repository предоставляет доступ к таблицам Docs. Подписанты, документы и нумерация
+// ограничены активной компанией из контекста; шаблоны общие.
 type Repository struct {
 	pool *pgxpool.Pool
+	read db.Querier
 }
 
 // NewRepository создаёт репозиторий.
 func NewRepository(pool *pgxpool.Pool) *Repository {
-	return &Repository{pool: pool}
+	return &Repository{pool: pool, read: pool}
+}
+
+// WithReader направляет запросы списков через read — обычно db.Router, читающий с реплик.
+func (r *Repository) WithReader(read db.Querier) *Repository {
+	r.read = read
+	return r
 }
 
 // ListTemplates возвращает шаблоны документов.
 func (r *Repository) ListTemplates(ctx context.Context, limit int) ([]Template, error) {
 	const query = `SELECT id, code, name, COALESCE(description, ''), version, body, created_at, updated_at FROM docs.template ORDER BY created_at DESC LIMIT $1`
-	rows, err := r.pool.Query(ctx, query, limit)
+	rows, err := r.read.Query(ctx, query, limit)
 	if err != nil {
 		return nil, fmt.Errorf("list templates: %w", err)
 	}
@@ -143,8 +154,12 @@ func (r *Repository) getTemplate(ctx context.Context, id uuid.UUID) (Template, e
 
 // ListSigners возвращает подписантов.
 func (r *Repository) ListSigners(ctx context.Context, limit int) ([]Signer, error) {
-	const query = `SELECT id, code, full_name, COALESCE(position, ''), COALESCE(email, ''), COALESCE(phone, ''), created_at, updated_at FROM docs.signer ORDER BY created_at DESC LIMIT $1`
-	rows, err := r.pool.Query(ctx, query, limit)
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	const query = `SELECT id, code, full_name, COALESCE(position, ''), COALESCE(email, ''), COALESCE(phone, ''), created_at, updated_at FROM docs.signer WHERE company_code = $1 ORDER BY created_at DESC LIMIT $2`
+	rows, err := r.read.Query(ctx, query, company, limit)
 	if err != nil {
 		return nil, fmt.Errorf("list signers: %w", err)
 	}
@@ -163,13 +178,17 @@ func (r *Repository) ListSigners(ctx context.Context, limit int) ([]Signer, erro
 
 // CreateSigner вставляет подписанта.
 func (r *Repository) CreateSigner(ctx context.Context, input CreateSignerInput) (Signer, error) {
-	const query = `INSERT INTO docs.signer (id, code, full_name, position, email, phone)
-VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''))
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return Signer{}, err
+	}
+	const query = `INSERT INTO docs.signer (id, code, full_name, position, email, phone, company_code)
+VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), $7)
 RETURNING id, code, full_name, COALESCE(position, ''), COALESCE(email, ''), COALESCE(phone, ''), created_at, updated_at`
 
 	id := uuid.New()
 	var signer Signer
-	if err := r.pool.QueryRow(ctx, query, id, input.Code, input.FullName, strings.TrimSpace(input.Position), strings.TrimSpace(input.Email), strings.TrimSpace(input.Phone)).
+	if err := r.pool.QueryRow(ctx, query, id, input.Code, input.FullName, strings.TrimSpace(input.Position), strings.TrimSpace(input.Email), strings.TrimSpace(input.Phone), company).
 		Scan(&signer.ID, &signer.Code, &signer.FullName, &signer.Position, &signer.Email, &signer.Phone, &signer.CreatedAt, &signer.UpdatedAt); err != nil {
 		return Signer{}, fmt.Errorf("insert signer: %w", err)
 	}
@@ -178,6 +197,10 @@ RETURNING id, code, full_name, COALESCE(position, ''), COALESCE(email, ''), COAL
 
 // UpdateSigner обновляет подписанта.
 func (r *Repository) UpdateSigner(ctx context.Context, id uuid.UUID, input UpdateSignerInput) (Signer, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return Signer{}, err
+	}
 	parts := make([]string, 0, 4)
 	args := make([]any, 0, 4)
 	idx := 1
@@ -223,8 +246,8 @@ func (r *Repository) UpdateSigner(ctx context.Context, id uuid.UUID, input Updat
 	}
 
 	parts = append(parts, "updated_at = NOW()")
-	query := fmt.Sprintf("UPDATE docs.signer SET %s WHERE id = $%d RETURNING id, code, full_name, COALESCE(position, ''), COALESCE(email, ''), COALESCE(phone, ''), created_at, updated_at", strings.Join(parts, ", "), idx)
-	args = append(args, id)
+	query := fmt.Sprintf("UPDATE docs.signer SET %s WHERE id = $%d AND company_code = $%d RETURNING id, code, full_name, COALESCE(position, ''), COALESCE(email, ''), COALESCE(phone, ''), created_at, updated_at", strings.Join(parts, ", "), idx, idx+1)
+	args = append(args, id, company)
 
 	var signer Signer
 	if err := r.pool.QueryRow(ctx, query, args...).Scan(&signer.ID, &signer.Code, &signer.FullName, &signer.Position, &signer.Email, &signer.Phone, &signer.CreatedAt, &signer.UpdatedAt); err != nil {
@@ -237,9 +260,13 @@ func (r *Repository) UpdateSigner(ctx context.Context, id uuid.UUID, input Updat
 }
 
 func (r *Repository) getSigner(ctx context.Context, id uuid.UUID) (Signer, error) {
-	const query = `SELECT id, code, full_name, COALESCE(position, ''), COALESCE(email, ''), COALESCE(phone, ''), created_at, updated_at FROM docs.signer WHERE id = $1`
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return Signer{}, err
+	}
+	const query = `SELECT id, code, full_name, COALESCE(position, ''), COALESCE(email, ''), COALESCE(phone, ''), created_at, updated_at FROM docs.signer WHERE id = $1 AND company_code = $2`
 	var signer Signer
-	if err := r.pool.QueryRow(ctx, query, id).Scan(&signer.ID, &signer.Code, &signer.FullName, &signer.Position, &signer.Email, &signer.Phone, &signer.CreatedAt, &signer.UpdatedAt); err != nil {
+	if err := r.pool.QueryRow(ctx, query, id, company).Scan(&signer.ID, &signer.Code, &signer.FullName, &signer.Position, &signer.Email, &signer.Phone, &signer.CreatedAt, &signer.UpdatedAt); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return Signer{}, ErrSignerNotFound
 		}
@@ -250,19 +277,23 @@ func (r *Repository) getSigner(ctx context.Context, id uuid.UUID) (Signer, error
 
 // ListDocuments возвращает документы с подписантами.
 func (r *Repository) ListDocuments(ctx context.Context, filter DocumentListFilter) ([]Document, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
 	builder := strings.Builder{}
-	builder.WriteString("SELECT id, template_id, sequence_id, number, title, status, payload, issued_at, signed_at, archived_at, created_at, updated_at FROM docs.document")
-	args := make([]any, 0, 2)
-	idx := 1
+	builder.WriteString("SELECT id, template_id, sequence_id, number, title, status, payload, issued_at, signed_at, archived_at, created_at, updated_at FROM docs.document WHERE company_code = $1")
+	args := []any{company}
+	idx := 2
 	if filter.Status != "" {
-		builder.WriteString(fmt.Sprintf(" WHERE status = $%d", idx))
+		builder.WriteString(fmt.Sprintf(" AND status = $%d", idx))
 		args = append(args, strings.TrimSpace(filter.Status))
 		idx++
 	}
 	builder.WriteString(fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d", idx))
 	args = append(args, filter.Limit)
 
-	rows, err := r.pool.Query(ctx, builder.String(), args...)
+	rows, err := r.read.Query(ctx, builder.String(), args...)
 	if err != nil {
 		return nil, fmt.Errorf("list documents: %w", err)
 	}
@@ -280,13 +311,17 @@ func (r *Repository) ListDocuments(ctx context.Context, filter DocumentListFilte
 
 // CreateDocument создаёт документ и связывает подписантов.
 func (r *Repository) CreateDocument(ctx context.Context, input CreateDocumentInput) (Document, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return Document{}, err
+	}
 	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
 		return Document{}, fmt.Errorf("begin tx: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
-	sequenceID, number, err := r.nextNumber(ctx, tx, strings.TrimSpace(strings.ToUpper(input.SequenceCode)))
+	sequenceID, number, err := r.nextNumber(ctx, tx, company, strings.TrimSpace(strings.ToUpper(input.SequenceCode)))
 	if err != nil {
 		return Document{}, err
 	}
@@ -296,15 +331,15 @@ func (r *Repository) CreateDocument(ctx context.Context, input CreateDocumentInp
 		status = "issued"
 	}
 
-	const insertDoc = `INSERT INTO docs.document (id, template_id, sequence_id, number, title, status, payload, issued_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+	const insertDoc = `INSERT INTO docs.document (id, template_id, sequence_id, number, title, status, payload, issued_at, company_code)
+VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), $8)
 RETURNING id, template_id, sequence_id, number, title, status, payload, issued_at, signed_at, archived_at, created_at, updated_at`
 
 	docID := uuid.New()
 	var doc Document
 	var payload []byte
 	var issuedAt, signedAt, archivedAt pgtype.Timestamptz
-	if err := tx.QueryRow(ctx, insertDoc, docID, input.TemplateID, sequenceID, number, input.Title, status, ensureJSON(input.Payload)).
+	if err := tx.QueryRow(ctx, insertDoc, docID, input.TemplateID, sequenceID, number, input.Title, status, ensureJSON(input.Payload), company).
 		Scan(&doc.ID, &doc.TemplateID, &doc.SequenceID, &doc.Number, &doc.Title, &doc.Status, &payload, &issuedAt, &signedAt, &archivedAt, &doc.CreatedAt, &doc.UpdatedAt); err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
@@ -318,7 +353,7 @@ RETURNING id, template_id, sequence_id, number, title, status, payload, issued_a
 	doc.ArchivedAt = timestamptzPtr(archivedAt)
 
 	if len(input.SignerIDs) > 0 {
-		if err := r.insertDocumentSigners(ctx, tx, doc.ID, input.SignerIDs); err != nil {
+		if err := r.insertDocumentSigners(ctx, tx, company, doc.ID, input.SignerIDs); err != nil {
 			return Document{}, err
 		}
 	}
@@ -337,23 +372,30 @@ RETURNING id, template_id, sequence_id, number, title, status, payload, issued_a
 
 // UpdateDocument обновляет документ и статусы подписантов.
 func (r *Repository) UpdateDocument(ctx context.Context, id uuid.UUID, input UpdateDocumentInput) (Document, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return Document{}, err
+	}
 	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
 		return Document{}, fmt.Errorf("begin tx: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
-	if err := r.updateDocumentFields(ctx, tx, id, input); err != nil {
+	if err := r.updateDocumentFields(ctx, tx, company, id, input); err != nil {
 		return Document{}, err
 	}
 
 	if len(input.SignerStatuses) > 0 {
+		if _, err := r.getDocumentTx(ctx, tx, company, id); err != nil {
+			return Document{}, err
+		}
 		if err := r.updateSignerStatuses(ctx, tx, id, input.SignerStatuses); err != nil {
 			return Document{}, err
 		}
 	}
 
-	doc, err := r.getDocumentTx(ctx, tx, id)
+	doc, err := r.getDocumentTx(ctx, tx, company, id)
 	if err != nil {
 		return Document{}, err
 	}
@@ -364,7 +406,7 @@ func (r *Repository) UpdateDocument(ctx context.Context, id uuid.UUID, input Upd
 	return doc, nil
 }
 
-func (r *Repository) updateDocumentFields(ctx context.Context, tx pgx.Tx, id uuid.UUID, input UpdateDocumentInput) error {
+func (r *Repository) updateDocumentFields(ctx context.Context, tx pgx.Tx, company string, id uuid.UUID, input UpdateDocumentInput) error {
 	parts := make([]string, 0, 4)
 	args := make([]any, 0, 4)
 	idx := 1
@@ -406,8 +448,8 @@ func (r *Repository) updateDocumentFields(ctx context.Context, tx pgx.Tx, id uui
 	if statusClause != "" {
 		parts = append(parts, statusClause)
 	}
-	query := fmt.Sprintf("UPDATE docs.document SET %s WHERE id = $%d", strings.Join(parts, ", "), idx)
-	args = append(args, id)
+	query := fmt.Sprintf("UPDATE docs.document SET %s WHERE id = $%d AND company_code = $%d", strings.Join(parts, ", "), idx, idx+1)
+	args = append(args, id, company)
 
 	cmd, err := tx.Exec(ctx, query, args...)
 	if err != nil {
@@ -433,9 +475,9 @@ func (r *Repository) updateSignerStatuses(ctx context.Context, tx pgx.Tx, docID
 	return nil
 }
 
-func (r *Repository) getDocumentTx(ctx context.Context, q queryer, id uuid.UUID) (Document, error) {
-	const query = `SELECT id, template_id, sequence_id, number, title, status, payload, issued_at, signed_at, archived_at, created_at, updated_at FROM docs.document WHERE id = $1`
-	rows, err := q.Query(ctx, query, id)
+func (r *Repository) getDocumentTx(ctx context.Context, q queryer, company string, id uuid.UUID) (Document, error) {
+	const query = `SELECT id, template_id, sequence_id, number, title, status, payload, issued_at, signed_at, archived_at, created_at, updated_at FROM docs.document WHERE id = $1 AND company_code = $2`
+	rows, err := q.Query(ctx, query, id, company)
 	if err != nil {
 		return Document{}, fmt.Errorf("get document: %w", err)
 	}
@@ -494,13 +536,19 @@ ORDER BY ds.order_no`
 	return rows.Err()
 }
 
-func (r *Repository) insertDocumentSigners(ctx context.Context, tx pgx.Tx, docID uuid.UUID, signerIDs []uuid.UUID) error {
+// insertDocumentSigners links signers of company only; a signer of another company is
+// reported as not found.
+func (r *Repository) insertDocumentSigners(ctx context.Context, tx pgx.Tx, company string, docID uuid.UUID, signerIDs []uuid.UUID) error {
 	const query = `INSERT INTO docs.document_signer (id, document_id, signer_id, status, order_no)
-VALUES ($1, $2, $3, 'pending', $4)`
+SELECT $1, $2, s.id, 'pending', $4 FROM docs.signer s WHERE s.id = $3 AND s.company_code = $5`
 	for idx, signer := range signerIDs {
-		if _, err := tx.Exec(ctx, query, uuid.New(), docID, signer, idx+1); err != nil {
+		cmd, err := tx.Exec(ctx, query, uuid.New(), docID, signer, idx+1, company)
+		if err != nil {
 			return fmt.Errorf("insert document signer: %w", err)
 		}
+		if cmd.RowsAffected() == 0 {
+			return fmt.Errorf("%w: %s", ErrSignerNotFound, signer)
+		}
 	}
 	return nil
 }
@@ -531,15 +579,17 @@ ORDER BY ds.order_no`
 	return items, rows.Err()
 }
 
-func (r *Repository) nextNumber(ctx context.Context, tx pgx.Tx, code string) (uuid.UUID, string, error) {
-	const query = `SELECT id, prefix, padding, current_value FROM docs.number_sequence WHERE code = $1 FOR UPDATE`
+// nextNumber takes the next value of the company's sequence; each company numbers its
+// documents independently.
+func (r *Repository) nextNumber(ctx context.Context, tx pgx.Tx, company, code string) (uuid.UUID, string, error) {
+	const query = `SELECT id, prefix, padding, current_value FROM docs.number_sequence WHERE company_code = $1 AND code = $2 FOR UPDATE`
 	var (
 		seqID   uuid.UUID
 		prefix  string
 		padding int16
 		current int64
 	)
-	if err := tx.QueryRow(ctx, query, code).Scan(&seqID, &prefix, &padding, &current); err != nil {
+	if err := tx.QueryRow(ctx, query, company, code).Scan(&seqID, &prefix, &padding, &current); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return uuid.Nil, "", ErrSequenceNotFound
 		}
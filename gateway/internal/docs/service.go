@@ -8,6 +8,8 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
+
+	logpkg "asfppro/pkg/log"
 )
 
 var (
@@ -66,7 +68,7 @@ func (s *Service) CreateTemplate(ctx context.Context, input CreateTemplateInput)
 	if err != nil {
 		return Template{}, err
 	}
-	s.logger.Info().Str("templateId", tpl.ID.String()).Msg("docs template created via gateway")
+	logpkg.Ctx(ctx, s.logger).Info().Str("templateId", tpl.ID.String()).Msg("docs template created via gateway")
 	return tpl, nil
 }
 
@@ -100,7 +102,7 @@ func (s *Service) UpdateTemplate(ctx context.Context, id uuid.UUID, input Update
 	if err != nil {
 		return Template{}, err
 	}
-	s.logger.Info().Str("templateId", tpl.ID.String()).Msg("docs template updated via gateway")
+	logpkg.Ctx(ctx, s.logger).Info().Str("templateId", tpl.ID.String()).Msg("docs template updated via gateway")
 	return tpl, nil
 }
 
@@ -131,7 +133,7 @@ func (s *Service) CreateSigner(ctx context.Context, input CreateSignerInput) (Si
 	if err != nil {
 		return Signer{}, err
 	}
-	s.logger.Info().Str("signerId", signer.ID.String()).Msg("docs signer created via gateway")
+	logpkg.Ctx(ctx, s.logger).Info().Str("signerId", signer.ID.String()).Msg("docs signer created via gateway")
 	return signer, nil
 }
 
@@ -161,7 +163,7 @@ func (s *Service) UpdateSigner(ctx context.Context, id uuid.UUID, input UpdateSi
 	if err != nil {
 		return Signer{}, err
 	}
-	s.logger.Info().Str("signerId", signer.ID.String()).Msg("docs signer updated via gateway")
+	logpkg.Ctx(ctx, s.logger).Info().Str("signerId", signer.ID.String()).Msg("docs signer updated via gateway")
 	return signer, nil
 }
 
@@ -221,7 +223,7 @@ func (s *Service) CreateDocument(ctx context.Context, input CreateDocumentInput)
 	if err != nil {
 		return Document{}, err
 	}
-	s.logger.Info().Str("documentId", doc.ID.String()).Msg("docs document created via gateway")
+	logpkg.Ctx(ctx, s.logger).Info().Str("documentId", doc.ID.String()).Msg("docs document created via gateway")
 	return doc, nil
 }
 
@@ -267,6 +269,6 @@ func (s *Service) UpdateDocument(ctx context.Context, id uuid.UUID, input Update
 	if err != nil {
 		return Document{}, err
 	}
-	s.logger.Info().Str("documentId", doc.ID.String()).Msg("docs document updated via gateway")
+	logpkg.Ctx(ctx, s.logger).Info().Str("documentId", doc.ID.String()).Msg("docs document updated via gateway")
 	return doc, nil
 }
@@ -0,0 +1,21 @@
+package files
+
+import "errors"
+
+var (
+	ErrFileNotFound       = errors.New("file not found")
+	ErrVersionNotFound    = errors.New("file version not found")
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	// ErrVersionUnavailable is returned when storage kept no separate copy of a version,
+	// e.g. because bucket versioning is off.
+	ErrVersionUnavailable = errors.New("file version is not kept by storage")
+	ErrUnknownEntity      = errors.New("files cannot be attached to this entity")
+	ErrEntityIDRequired   = errors.New("entityId is required")
+	ErrPreviewNotFound    = errors.New("preview not found")
+	// ErrPresignNotFound is returned when a confirmed upload was never presigned or its
+	// confirmation window is over.
+	ErrPresignNotFound = errors.New("upload not found or expired")
+	// ErrRetained and ErrLegalHold are returned when a protected file is deleted.
+	ErrRetained  = errors.New("file is under retention")
+	ErrLegalHold = errors.New("file is under legal hold")
+)
@@ -0,0 +1,114 @@
+package files
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// File is a registered object together with its current version.
+type File struct {
+	ID             uuid.UUID `json:"id"`
+	Key            string    `json:"key"`
+	Filename       string    `json:"filename"`
+	CurrentVersion int       `json:"currentVersion"`
+	Size           int64     `json:"size"`
+	Checksum       string    `json:"checksum"`
+	ContentType    string    `json:"contentType"`
+	PreviewStatus  string    `json:"previewStatus,omitempty"`
+	CreatedBy      string    `json:"createdBy,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+	// DocumentType selects retention rules in addition to the folder, e.g. contract or act.
+	DocumentType string     `json:"documentType,omitempty"`
+	RetainUntil  *time.Time `json:"retainUntil,omitempty"`
+	LegalHold    bool       `json:"legalHold"`
+	// DeletedAt is set while the file is in the trash; it is purged after PurgeAfter.
+	DeletedAt  *time.Time `json:"deletedAt,omitempty"`
+	DeletedBy  string     `json:"deletedBy,omitempty"`
+	PurgeAfter *time.Time `json:"purgeAfter,omitempty"`
+}
+
+// Retained reports whether retention keeps the file from being deleted at now.
+func (f File) Retained(now time.Time) bool {
+	return f.RetainUntil != nil && now.Before(*f.RetainUntil)
+}
+
+// Version is one stored revision of a file.
+type Version struct {
+	Number         int       `json:"version"`
+	StorageVersion string    `json:"storageVersion,omitempty"`
+	Size           int64     `json:"size"`
+	Checksum       string    `json:"checksum"`
+	ContentType    string    `json:"contentType"`
+	UploadedBy     string    `json:"uploadedBy,omitempty"`
+	RestoredFrom   *int      `json:"restoredFrom,omitempty"`
+	ScanStatus     string    `json:"scanStatus,omitempty"`
+	PreviewStatus  string    `json:"previewStatus,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// Scan statuses of a version; empty means scanning is disabled.
+const (
+	ScanClean   = "clean"
+	ScanSkipped = "skipped"
+)
+
+// Preview statuses of a version; empty means the content type has no previews.
+const (
+	PreviewPending = "pending"
+	PreviewReady   = "ready"
+	PreviewFailed  = "failed"
+	PreviewSkipped = "skipped"
+)
+
+// Preview kinds: a list thumbnail and a larger rendering for the viewer.
+const (
+	PreviewThumb = "thumb"
+	PreviewLarge = "large"
+)
+
+// Preview is a rendered JPEG of a file version.
+type Preview struct {
+	Kind           string `json:"kind"`
+	Key            string `json:"-"`
+	StorageVersion string `json:"-"`
+	Width          int    `json:"width"`
+	Height         int    `json:"height"`
+	Size           int64  `json:"size"`
+}
+
+// Attachment links a file to a record of another module.
+type Attachment struct {
+	Entity     string    `json:"entity"`
+	EntityID   string    `json:"entityId"`
+	AttachedBy string    `json:"attachedBy,omitempty"`
+	AttachedAt time.Time `json:"attachedAt"`
+	File       File      `json:"file"`
+}
+
+// UploadInput describes an object that has just been written to storage.
+type UploadInput struct {
+	Key            string
+	Filename       string
+	StorageVersion string
+	Size           int64
+	Checksum       string
+	ContentType    string
+	UploadedBy     uuid.UUID
+	ScanStatus     string
+	PreviewStatus  string
+}
+
+// PresignedUpload is an upload issued as a presigned PUT. It has to be confirmed before
+// ExpiresAt; afterwards an unregistered object is removed from storage.
+type PresignedUpload struct {
+	ID          uuid.UUID
+	Company     string
+	Key         string
+	Size        int64
+	MD5         string
+	CreatedBy   uuid.UUID
+	ExpiresAt   time.Time
+	ConfirmedAt *time.Time
+}
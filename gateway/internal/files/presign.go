@@ -0,0 +1,99 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"asfppro/pkg/s3"
+	"asfppro/pkg/tenant"
+)
+
+const (
+	// presignConfirmWindow is how long after its URL expired a presigned upload can still be
+	// confirmed.
+	presignConfirmWindow = time.Hour
+	presignReapBatch     = 100
+)
+
+// TrackPresigned records a presigned upload of key so it can be confirmed until the URL
+// expires plus the confirmation window.
+func (s *Service) TrackPresigned(ctx context.Context, actor uuid.UUID, key string, size int64, md5 string, urlExpires time.Time) error {
+	return s.repo.AddPresigned(ctx, PresignedUpload{
+		ID:        uuid.New(),
+		Key:       key,
+		Size:      size,
+		MD5:       strings.ToLower(md5),
+		CreatedBy: actor,
+		ExpiresAt: urlExpires.Add(presignConfirmWindow).UTC(),
+	})
+}
+
+// Presigned returns the pending presigned upload of key with the given MD5 or
+// ErrPresignNotFound once its confirmation window is over.
+func (s *Service) Presigned(ctx context.Context, key, md5 string) (PresignedUpload, error) {
+	return s.repo.FindPresigned(ctx, key, strings.ToLower(md5))
+}
+
+// ConfirmPresigned marks a presigned upload as registered, so expiry keeps its object.
+func (s *Service) ConfirmPresigned(ctx context.Context, id uuid.UUID) error {
+	return s.repo.ConfirmPresigned(ctx, id)
+}
+
+// Registered reports whether a storage version of key is a registered file version.
+func (s *Service) Registered(ctx context.Context, key, storageVersion string) (bool, error) {
+	return s.repo.VersionRegistered(ctx, key, storageVersion)
+}
+
+// ReapPresigned forgets expired presigned uploads and removes the objects of those never
+// confirmed. Objects that were registered anyway, or overwritten since, are kept.
+func (p *Purger) ReapPresigned(ctx context.Context) (int, error) {
+	expired, err := p.repo.ListExpiredPresigned(ctx, presignReapBatch)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, u := range expired {
+		uploadCtx := tenant.WithCompany(ctx, u.Company)
+		ok, err := p.reapPresigned(uploadCtx, u)
+		if err != nil {
+			p.logger.Error().Err(err).Str("uploadId", u.ID.String()).Str("key", u.Key).Msg("reap presigned upload")
+			continue
+		}
+		if ok {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+func (p *Purger) reapPresigned(ctx context.Context, u PresignedUpload) (bool, error) {
+	removed := false
+	if u.ConfirmedAt == nil {
+		info, err := p.storage.Stat(ctx, u.Key, "")
+		switch {
+		case errors.Is(err, s3.ErrNotFound):
+		case err != nil:
+			return false, fmt.Errorf("stat upload: %w", err)
+		case info.Size == u.Size && strings.EqualFold(info.ETag, u.MD5):
+			registered, err := p.repo.VersionRegistered(ctx, u.Key, info.VersionID)
+			if err != nil {
+				return false, err
+			}
+			if !registered {
+				if err := p.storage.Remove(ctx, u.Key, info.VersionID); err != nil && !errors.Is(err, s3.ErrNotFound) {
+					return false, fmt.Errorf("remove upload: %w", err)
+				}
+				removed = true
+			}
+		}
+	}
+	if err := p.repo.DeletePresigned(ctx, u.ID); err != nil {
+		return false, err
+	}
+	return removed, nil
+}
@@ -0,0 +1,261 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	logpkg "asfppro/pkg/log"
+	"asfppro/pkg/preview"
+	"asfppro/pkg/queue"
+	"asfppro/pkg/s3"
+	"asfppro/pkg/tenant"
+)
+
+// FileUploadedEvent announces a new file version; the preview worker subscribes to it.
+const FileUploadedEvent = "FileUploaded"
+
+// PreviewDir is the folder next to an original that holds its previews, e.g.
+// acme/layouts/.previews/poster.pdf/3/thumb.jpg. Uploads cannot use it as a folder name.
+const PreviewDir = ".previews"
+
+// previewSizes lists the rendered kinds, largest first, by their longer edge in pixels.
+var previewSizes = []struct {
+	kind string
+	side int
+}{
+	{PreviewLarge, 1600},
+	{PreviewThumb, 320},
+}
+
+// errRender marks failures of the file itself, which retries cannot fix.
+var errRender = errors.New("render preview")
+
+type fileUploadedPayload struct {
+	FileID         uuid.UUID `json:"fileId"`
+	Version        int       `json:"version"`
+	Key            string    `json:"key"`
+	StorageVersion string    `json:"storageVersion,omitempty"`
+	ContentType    string    `json:"contentType"`
+	Size           int64     `json:"size"`
+	Company        string    `json:"companyCode"`
+	UploadedBy     string    `json:"uploadedBy,omitempty"`
+}
+
+// previewStatus is the initial preview status of a new version.
+func (s *Service) previewStatus(contentType string) string {
+	if s.publisher == nil || !preview.Supported(contentType) {
+		return ""
+	}
+	return PreviewPending
+}
+
+// requestPreview publishes a pending version for the preview worker. Previews are best
+// effort: when publishing fails the version is left without one.
+func (s *Service) requestPreview(ctx context.Context, actor uuid.UUID, file File) {
+	if s.publisher == nil || file.PreviewStatus != PreviewPending {
+		return
+	}
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return
+	}
+	payload := fileUploadedPayload{
+		FileID:      file.ID,
+		Version:     file.CurrentVersion,
+		Key:         file.Key,
+		ContentType: file.ContentType,
+		Size:        file.Size,
+		Company:     company,
+	}
+	if actor != uuid.Nil {
+		payload.UploadedBy = actor.String()
+	}
+	if err := s.publisher.Publish(ctx, FileUploadedEvent, payload); err != nil {
+		logpkg.Ctx(ctx, s.logger).Error().Err(err).Str("key", file.Key).Msg("publish file upload")
+		if err := s.repo.SetPreviewStatus(context.WithoutCancel(ctx), file.ID, file.CurrentVersion, ""); err != nil {
+			logpkg.Ctx(ctx, s.logger).Error().Err(err).Str("key", file.Key).Msg("reset preview status")
+		}
+	}
+}
+
+// previewKey places a preview of a version next to its original.
+func previewKey(key string, version int, kind string) string {
+	dir, name := path.Split(key)
+	return path.Join(dir, PreviewDir, name, strconv.Itoa(version), kind+".jpg")
+}
+
+// PreviewWorker renders previews of the versions announced by FileUploadedEvent.
+type PreviewWorker struct {
+	consumer queue.Consumer
+	repo     Store
+	storage  Storage
+	renderer *preview.Renderer
+	maxSize  int64
+	logger   zerolog.Logger
+}
+
+// NewPreviewWorker builds the worker. Files larger than maxSize are skipped.
+func NewPreviewWorker(consumer queue.Consumer, repo Store, storage Storage, renderer *preview.Renderer, maxSize int64, logger zerolog.Logger) *PreviewWorker {
+	return &PreviewWorker{
+		consumer: consumer,
+		repo:     repo,
+		storage:  storage,
+		renderer: renderer,
+		maxSize:  maxSize,
+		logger:   logger.With().Str("component", "files.previews").Logger(),
+	}
+}
+
+// Run takes jobs until ctx is cancelled. A job taken before cancellation is still finished.
+func (w *PreviewWorker) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		job, err := w.consumer.Next(ctx)
+		if errors.Is(err, queue.ErrInvalidEnvelope) {
+			w.logger.Warn().Err(err).Msg("invalid event moved to dead-letter tube")
+			continue
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				w.logger.Error().Err(err).Msg("fetch preview job")
+			}
+			sleep(ctx, time.Second)
+			continue
+		}
+		if job == nil {
+			continue
+		}
+		w.handle(context.WithoutCancel(ctx), job)
+	}
+}
+
+// handle renders one job: ack when done or when the file cannot be rendered, release on
+// storage and database failures.
+func (w *PreviewWorker) handle(ctx context.Context, job *queue.Job) {
+	ctx = job.Context(ctx)
+	logger := w.logger.With().Str("jobId", job.ID()).Str("event", job.EventType()).Int("attempts", job.Attempts()).Logger()
+
+	if job.EventType() != FileUploadedEvent {
+		logger.Warn().Msg("skip unknown event")
+		w.ack(ctx, job, logger)
+		return
+	}
+	var payload fileUploadedPayload
+	if err := job.Decode(&payload); err != nil {
+		if err := job.Bury(ctx, "decode payload: "+err.Error()); err != nil {
+			logger.Error().Err(err).Msg("bury preview job")
+		}
+		return
+	}
+	ctx = tenant.WithCompany(ctx, payload.Company)
+	logger = logger.With().Str("fileId", payload.FileID.String()).Int("version", payload.Version).Logger()
+
+	if err := w.process(ctx, payload, logger); err != nil {
+		logger.Error().Err(err).Msg("render preview")
+		delay := time.Duration(job.Attempts()+1) * 10 * time.Second
+		dead, err := job.Release(ctx, delay)
+		if err != nil {
+			logger.Error().Err(err).Msg("release preview job")
+			return
+		}
+		if dead {
+			w.setStatus(ctx, payload, PreviewFailed, logger)
+		}
+		return
+	}
+	w.ack(ctx, job, logger)
+}
+
+func (w *PreviewWorker) process(ctx context.Context, p fileUploadedPayload, logger zerolog.Logger) error {
+	version, err := w.repo.GetVersion(ctx, p.FileID, p.Version)
+	if errors.Is(err, ErrVersionNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if version.Size > w.maxSize {
+		return w.repo.SetPreviewStatus(ctx, p.FileID, p.Version, PreviewSkipped)
+	}
+
+	previews, err := w.render(ctx, p.Key, version)
+	if errors.Is(err, errRender) || errors.Is(err, s3.ErrNotFound) {
+		logger.Warn().Err(err).Str("contentType", version.ContentType).Msg("file has no preview")
+		return w.repo.SetPreviewStatus(ctx, p.FileID, p.Version, PreviewFailed)
+	}
+	if err != nil {
+		return err
+	}
+	return w.repo.SavePreviews(ctx, p.FileID, p.Version, previews)
+}
+
+// render decodes the version once at the largest size and stores every preview kind.
+func (w *PreviewWorker) render(ctx context.Context, key string, version Version) ([]Preview, error) {
+	rc, err := w.storage.Open(ctx, key, version.StorageVersion, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+
+	img, err := w.renderer.Render(ctx, rc, version.ContentType, previewSizes[0].side)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errRender, err)
+	}
+
+	previews := make([]Preview, 0, len(previewSizes))
+	for _, size := range previewSizes {
+		scaled := preview.Fit(img, size.side)
+		data, err := preview.EncodeJPEG(scaled)
+		if err != nil {
+			return nil, err
+		}
+		target := previewKey(key, version.Number, size.kind)
+		_, storageVersion, err := w.storage.Upload(ctx, path.Dir(target), path.Base(target), bytes.NewReader(data), int64(len(data)), "image/jpeg")
+		if err != nil {
+			return nil, err
+		}
+		previews = append(previews, Preview{
+			Kind:           size.kind,
+			Key:            target,
+			StorageVersion: storageVersion,
+			Width:          scaled.Bounds().Dx(),
+			Height:         scaled.Bounds().Dy(),
+			Size:           int64(len(data)),
+		})
+	}
+	return previews, nil
+}
+
+func (w *PreviewWorker) setStatus(ctx context.Context, p fileUploadedPayload, status string, logger zerolog.Logger) {
+	if err := w.repo.SetPreviewStatus(ctx, p.FileID, p.Version, status); err != nil {
+		logger.Error().Err(err).Msg("set preview status")
+	}
+}
+
+func (w *PreviewWorker) ack(ctx context.Context, job *queue.Job, logger zerolog.Logger) {
+	if err := job.Ack(ctx); err != nil {
+		logger.Error().Err(err).Msg("ack preview job")
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
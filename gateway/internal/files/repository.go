@@ -0,0 +1,793 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"asfppro/pkg/tenant"
+)
+
+// fileColumns selects a file joined with its current version as f and v.
+const fileColumns = `f.id, f.object_key, f.filename, f.current_version, v.size_bytes, v.checksum, v.content_type,
+v.preview_status, COALESCE(f.created_by::text, ''), f.created_at, f.updated_at, f.document_type, f.retain_until,
+f.legal_hold, f.deleted_at, COALESCE(f.deleted_by::text, ''), f.purge_after`
+
+// Repository provides access to the core file registry. Every query is limited to the active
+// company in ctx.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository creates repository.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// AddVersion registers the file at input.Key if needed and appends a version to it.
+// restoredFrom is set when the version re-publishes an earlier one.
+func (r *Repository) AddVersion(ctx context.Context, input UploadInput, restoredFrom *int) (File, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return File{}, err
+	}
+	// The upsert locks the file row, so concurrent uploads of one key get consecutive numbers.
+	const upsertFile = `INSERT INTO core.files (id, company_code, object_key, filename, current_version, created_by)
+VALUES ($1, $2, $3, $4, 1, $5)
+ON CONFLICT (company_code, object_key) DO UPDATE
+SET current_version = core.files.current_version + 1, filename = EXCLUDED.filename, updated_at = NOW(),
+    deleted_at = NULL, deleted_by = NULL, purge_after = NULL
+RETURNING id, current_version`
+	const insertVersion = `INSERT INTO core.file_versions (file_id, version_no, storage_version, size_bytes, checksum, content_type, uploaded_by, restored_from, scan_status, preview_status)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return File{}, fmt.Errorf("begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// A retried confirmation reports a storage version that is already registered.
+	const findVersion = `SELECT f.id FROM core.files f
+JOIN core.file_versions v ON v.file_id = f.id
+WHERE f.company_code = $1 AND f.object_key = $2 AND v.storage_version = $3 AND v.storage_version <> ''`
+
+	var (
+		id      uuid.UUID
+		version int
+	)
+	if restoredFrom == nil {
+		err := tx.QueryRow(ctx, findVersion, company, input.Key, input.StorageVersion).Scan(&id)
+		if err == nil {
+			return getFile(ctx, tx, company, id)
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return File{}, fmt.Errorf("find file version: %w", err)
+		}
+	}
+	if err := tx.QueryRow(ctx, upsertFile, uuid.New(), company, input.Key, input.Filename, nullableUser(input.UploadedBy)).Scan(&id, &version); err != nil {
+		return File{}, fmt.Errorf("upsert file: %w", err)
+	}
+	if _, err := tx.Exec(ctx, insertVersion, id, version, input.StorageVersion, input.Size, input.Checksum, input.ContentType, nullableUser(input.UploadedBy), restoredFrom, input.ScanStatus, input.PreviewStatus); err != nil {
+		return File{}, fmt.Errorf("insert file version: %w", err)
+	}
+	file, err := getFile(ctx, tx, company, id)
+	if err != nil {
+		return File{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return File{}, fmt.Errorf("commit: %w", err)
+	}
+	return file, nil
+}
+
+// GetFile returns a file by id or ErrFileNotFound.
+func (r *Repository) GetFile(ctx context.Context, id uuid.UUID) (File, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return File{}, err
+	}
+	return getFile(ctx, r.pool, company, id)
+}
+
+// ListVersions returns the versions of a file, newest first.
+func (r *Repository) ListVersions(ctx context.Context, id uuid.UUID) ([]Version, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	const query = `SELECT v.version_no, v.storage_version, v.size_bytes, v.checksum, v.content_type,
+COALESCE(v.uploaded_by::text, ''), v.restored_from, v.scan_status, v.preview_status, v.created_at
+FROM core.file_versions v
+JOIN core.files f ON f.id = v.file_id
+WHERE v.file_id = $1 AND f.company_code = $2
+ORDER BY v.version_no DESC`
+
+	rows, err := r.pool.Query(ctx, query, id, company)
+	if err != nil {
+		return nil, fmt.Errorf("list file versions: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]Version, 0)
+	for rows.Next() {
+		v, err := scanVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+	}
+	return items, rows.Err()
+}
+
+// GetVersion returns one version of a file or ErrVersionNotFound.
+func (r *Repository) GetVersion(ctx context.Context, id uuid.UUID, number int) (Version, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return Version{}, err
+	}
+	const query = `SELECT v.version_no, v.storage_version, v.size_bytes, v.checksum, v.content_type,
+COALESCE(v.uploaded_by::text, ''), v.restored_from, v.scan_status, v.preview_status, v.created_at
+FROM core.file_versions v
+JOIN core.files f ON f.id = v.file_id
+WHERE v.file_id = $1 AND v.version_no = $2 AND f.company_code = $3`
+
+	v, err := scanVersion(r.pool.QueryRow(ctx, query, id, number, company))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Version{}, ErrVersionNotFound
+	}
+	return v, err
+}
+
+// Attach links a file to an entity; linking twice is a no-op.
+func (r *Repository) Attach(ctx context.Context, id uuid.UUID, entity, entityID string, actor uuid.UUID) error {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+	const query = `INSERT INTO core.file_attachments (file_id, entity, entity_id, attached_by)
+SELECT id, $3, $4, $5 FROM core.files WHERE id = $1 AND company_code = $2
+ON CONFLICT DO NOTHING`
+
+	if _, err := r.pool.Exec(ctx, query, id, company, entity, entityID, nullableUser(actor)); err != nil {
+		return fmt.Errorf("attach file: %w", err)
+	}
+	return nil
+}
+
+// Detach removes a link between a file and an entity or returns ErrAttachmentNotFound.
+func (r *Repository) Detach(ctx context.Context, id uuid.UUID, entity, entityID string) error {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+	const query = `DELETE FROM core.file_attachments a USING core.files f
+WHERE a.file_id = f.id AND a.file_id = $1 AND a.entity = $2 AND a.entity_id = $3 AND f.company_code = $4`
+
+	tag, err := r.pool.Exec(ctx, query, id, entity, entityID, company)
+	if err != nil {
+		return fmt.Errorf("detach file: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrAttachmentNotFound
+	}
+	return nil
+}
+
+// ListAttachments returns the files attached to an entity, newest link first.
+func (r *Repository) ListAttachments(ctx context.Context, entity, entityID string) ([]Attachment, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT a.entity, a.entity_id, COALESCE(a.attached_by::text, ''), a.attached_at, ` + fileColumns + `
+FROM core.file_attachments a
+JOIN core.files f ON f.id = a.file_id
+JOIN core.file_versions v ON v.file_id = f.id AND v.version_no = f.current_version
+WHERE a.entity = $1 AND a.entity_id = $2 AND f.company_code = $3 AND f.deleted_at IS NULL
+ORDER BY a.attached_at DESC`
+
+	rows, err := r.pool.Query(ctx, query, entity, entityID, company)
+	if err != nil {
+		return nil, fmt.Errorf("list attachments: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]Attachment, 0)
+	for rows.Next() {
+		var (
+			a Attachment
+			f = &a.File
+		)
+		dest := append([]any{&a.Entity, &a.EntityID, &a.AttachedBy, &a.AttachedAt}, fileFields(f)...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("scan attachment: %w", err)
+		}
+		a.AttachedAt = a.AttachedAt.UTC()
+		normalizeFile(f)
+		items = append(items, a)
+	}
+	return items, rows.Err()
+}
+
+// SetPreviewStatus records the preview state of a version.
+func (r *Repository) SetPreviewStatus(ctx context.Context, id uuid.UUID, number int, status string) error {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+	const query = `UPDATE core.file_versions v SET preview_status = $3
+FROM core.files f
+WHERE f.id = v.file_id AND v.file_id = $1 AND v.version_no = $2 AND f.company_code = $4`
+
+	if _, err := r.pool.Exec(ctx, query, id, number, status, company); err != nil {
+		return fmt.Errorf("set preview status: %w", err)
+	}
+	return nil
+}
+
+// SavePreviews stores the previews of a version and marks it ready.
+func (r *Repository) SavePreviews(ctx context.Context, id uuid.UUID, number int, previews []Preview) error {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+	const upsert = `INSERT INTO core.file_previews (file_id, version_no, kind, object_key, width, height, size_bytes, storage_version)
+SELECT v.file_id, v.version_no, $3, $4, $5, $6, $7, $9
+FROM core.file_versions v
+JOIN core.files f ON f.id = v.file_id
+WHERE v.file_id = $1 AND v.version_no = $2 AND f.company_code = $8
+ON CONFLICT (file_id, version_no, kind) DO UPDATE
+SET object_key = EXCLUDED.object_key, width = EXCLUDED.width, height = EXCLUDED.height,
+    size_bytes = EXCLUDED.size_bytes, storage_version = EXCLUDED.storage_version, created_at = NOW()`
+	const ready = `UPDATE core.file_versions SET preview_status = $3 WHERE file_id = $1 AND version_no = $2`
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for _, p := range previews {
+		if _, err := tx.Exec(ctx, upsert, id, number, p.Kind, p.Key, p.Width, p.Height, p.Size, company, p.StorageVersion); err != nil {
+			return fmt.Errorf("save file preview: %w", err)
+		}
+	}
+	if _, err := tx.Exec(ctx, ready, id, number, PreviewReady); err != nil {
+		return fmt.Errorf("set preview status: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// GetPreview returns a preview of a version or ErrPreviewNotFound.
+func (r *Repository) GetPreview(ctx context.Context, id uuid.UUID, number int, kind string) (Preview, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return Preview{}, err
+	}
+	const query = `SELECT p.kind, p.object_key, p.width, p.height, p.size_bytes, p.storage_version
+FROM core.file_previews p
+JOIN core.files f ON f.id = p.file_id
+WHERE p.file_id = $1 AND p.version_no = $2 AND p.kind = $3 AND f.company_code = $4`
+
+	var p Preview
+	err = r.pool.QueryRow(ctx, query, id, number, kind, company).Scan(&p.Kind, &p.Key, &p.Width, &p.Height, &p.Size, &p.StorageVersion)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Preview{}, ErrPreviewNotFound
+	}
+	if err != nil {
+		return Preview{}, fmt.Errorf("get file preview: %w", err)
+	}
+	return p, nil
+}
+
+// StoredObject is an object version belonging to a file: a version of the file itself or
+// one of its previews.
+type StoredObject struct {
+	Key     string
+	Version string
+	Preview bool
+}
+
+// PurgeCandidate is a trashed file whose trash period is over.
+type PurgeCandidate struct {
+	ID      uuid.UUID
+	Company string
+	Key     string
+}
+
+// Removal is a stored object of a purged file queued for removal from storage.
+type Removal struct {
+	ID      int64
+	Company string
+	Key     string
+	Version string
+}
+
+// ExtendRetention keeps a file at least until the given time. Retention is never shortened.
+func (r *Repository) ExtendRetention(ctx context.Context, id uuid.UUID, until time.Time) (File, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return File{}, err
+	}
+	const query = `UPDATE core.files SET retain_until = GREATEST(retain_until, $3)
+WHERE id = $1 AND company_code = $2 AND deleted_at IS NULL`
+
+	if _, err := r.pool.Exec(ctx, query, id, company, until); err != nil {
+		return File{}, fmt.Errorf("extend file retention: %w", err)
+	}
+	return getFile(ctx, r.pool, company, id)
+}
+
+// SetDocumentType classifies a file and extends its retention to until unless it is zero.
+func (r *Repository) SetDocumentType(ctx context.Context, id uuid.UUID, documentType string, until time.Time) (File, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return File{}, err
+	}
+	const query = `UPDATE core.files SET document_type = $3, retain_until = GREATEST(retain_until, $4)
+WHERE id = $1 AND company_code = $2 AND deleted_at IS NULL`
+
+	tag, err := r.pool.Exec(ctx, query, id, company, documentType, nullableTime(until))
+	if err != nil {
+		return File{}, fmt.Errorf("set document type: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return File{}, ErrFileNotFound
+	}
+	return getFile(ctx, r.pool, company, id)
+}
+
+// SetLegalHold places or lifts the legal hold of a file.
+func (r *Repository) SetLegalHold(ctx context.Context, id uuid.UUID, on bool) (File, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return File{}, err
+	}
+	const query = `UPDATE core.files SET legal_hold = $3
+WHERE id = $1 AND company_code = $2 AND deleted_at IS NULL`
+
+	tag, err := r.pool.Exec(ctx, query, id, company, on)
+	if err != nil {
+		return File{}, fmt.Errorf("set legal hold: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return File{}, ErrFileNotFound
+	}
+	return getFile(ctx, r.pool, company, id)
+}
+
+// Trash moves a file to the trash until purgeAfter. The database refuses files under
+// retention or legal hold with ErrRetained.
+func (r *Repository) Trash(ctx context.Context, id uuid.UUID, actor uuid.UUID, purgeAfter time.Time) error {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+	const query = `UPDATE core.files SET deleted_at = NOW(), deleted_by = $3, purge_after = $4
+WHERE id = $1 AND company_code = $2 AND deleted_at IS NULL`
+
+	tag, err := r.pool.Exec(ctx, query, id, company, nullableUser(actor), purgeAfter)
+	if err != nil {
+		return fmt.Errorf("trash file: %w", protectionError(err))
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrFileNotFound
+	}
+	return nil
+}
+
+// Untrash takes a file out of the trash or returns ErrFileNotFound.
+func (r *Repository) Untrash(ctx context.Context, id uuid.UUID) (File, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return File{}, err
+	}
+	const query = `UPDATE core.files SET deleted_at = NULL, deleted_by = NULL, purge_after = NULL
+WHERE id = $1 AND company_code = $2 AND deleted_at IS NOT NULL`
+
+	tag, err := r.pool.Exec(ctx, query, id, company)
+	if err != nil {
+		return File{}, fmt.Errorf("untrash file: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return File{}, ErrFileNotFound
+	}
+	return getFile(ctx, r.pool, company, id)
+}
+
+// ListTrash returns the trashed files of the company, most recently deleted first.
+func (r *Repository) ListTrash(ctx context.Context) ([]File, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT ` + fileColumns + `
+FROM core.files f
+JOIN core.file_versions v ON v.file_id = f.id AND v.version_no = f.current_version
+WHERE f.company_code = $1 AND f.deleted_at IS NOT NULL
+ORDER BY f.deleted_at DESC`
+
+	rows, err := r.pool.Query(ctx, query, company)
+	if err != nil {
+		return nil, fmt.Errorf("list trash: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]File, 0)
+	for rows.Next() {
+		var f File
+		if err := rows.Scan(fileFields(&f)...); err != nil {
+			return nil, fmt.Errorf("scan file: %w", err)
+		}
+		normalizeFile(&f)
+		items = append(items, f)
+	}
+	return items, rows.Err()
+}
+
+// Objects returns the stored object versions of a file and its previews, trashed or not.
+func (r *Repository) Objects(ctx context.Context, id uuid.UUID) ([]StoredObject, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return listObjects(ctx, r.pool, company, id)
+}
+
+// ListPurgeable returns trashed files of every company whose trash period is over and
+// which no retention or legal hold protects.
+func (r *Repository) ListPurgeable(ctx context.Context, limit int) ([]PurgeCandidate, error) {
+	const query = `SELECT id, company_code, object_key FROM core.files
+WHERE deleted_at IS NOT NULL AND purge_after < NOW() AND NOT legal_hold
+  AND (retain_until IS NULL OR retain_until <= NOW())
+ORDER BY purge_after
+LIMIT $1`
+
+	rows, err := r.pool.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list purgeable files: %w", err)
+	}
+	defer rows.Close()
+
+	var items []PurgeCandidate
+	for rows.Next() {
+		var c PurgeCandidate
+		if err := rows.Scan(&c.ID, &c.Company, &c.Key); err != nil {
+			return nil, fmt.Errorf("scan purgeable file: %w", err)
+		}
+		items = append(items, c)
+	}
+	return items, rows.Err()
+}
+
+// Purge deletes a trashed file with its versions, previews and attachments if it may still
+// be purged, and queues its stored objects for removal in the same transaction. Once purged
+// the file cannot be restored or revived by an upload; the trash purger removes the queued
+// objects afterwards, see ListRemovals.
+// Returns the number of queued objects or ErrFileNotFound when the file is not purgeable.
+func (r *Repository) Purge(ctx context.Context, id uuid.UUID) (int, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return 0, err
+	}
+	// The row lock keeps previews and versions from being added between listing and deleting.
+	const claim = `SELECT id FROM core.files
+WHERE id = $1 AND company_code = $2 AND deleted_at IS NOT NULL AND purge_after < NOW() AND NOT legal_hold
+  AND (retain_until IS NULL OR retain_until <= NOW())
+FOR UPDATE`
+	const queue = `INSERT INTO core.file_removals (company_code, file_id, object_key, storage_version)
+VALUES ($1, $2, $3, $4)`
+	const purge = `DELETE FROM core.files WHERE id = $1`
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := tx.QueryRow(ctx, claim, id, company).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrFileNotFound
+		}
+		return 0, fmt.Errorf("claim file: %w", err)
+	}
+	objects, err := listObjects(ctx, tx, company, id)
+	if err != nil {
+		return 0, err
+	}
+	for _, o := range objects {
+		if _, err := tx.Exec(ctx, queue, company, id, o.Key, o.Version); err != nil {
+			return 0, fmt.Errorf("queue file object: %w", err)
+		}
+	}
+	if _, err := tx.Exec(ctx, purge, id); err != nil {
+		return 0, fmt.Errorf("purge file: %w", protectionError(err))
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit: %w", err)
+	}
+	return len(objects), nil
+}
+
+// ListRemovals returns stored objects of purged files of every company that still have to be
+// removed from storage, in queue order after the given removal ID.
+func (r *Repository) ListRemovals(ctx context.Context, after int64, limit int) ([]Removal, error) {
+	const query = `SELECT id, company_code, object_key, storage_version FROM core.file_removals
+WHERE id > $1
+ORDER BY id
+LIMIT $2`
+
+	rows, err := r.pool.Query(ctx, query, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list file removals: %w", err)
+	}
+	defer rows.Close()
+
+	var items []Removal
+	for rows.Next() {
+		var item Removal
+		if err := rows.Scan(&item.ID, &item.Company, &item.Key, &item.Version); err != nil {
+			return nil, fmt.Errorf("scan file removal: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// DeleteRemoval drops a queued removal once its object is gone from storage.
+func (r *Repository) DeleteRemoval(ctx context.Context, id int64) error {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+	const query = `DELETE FROM core.file_removals WHERE id = $1 AND company_code = $2`
+
+	if _, err := r.pool.Exec(ctx, query, id, company); err != nil {
+		return fmt.Errorf("delete file removal: %w", err)
+	}
+	return nil
+}
+
+// AddPresigned records a presigned upload of the active company.
+func (r *Repository) AddPresigned(ctx context.Context, p PresignedUpload) error {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+	const query = `INSERT INTO core.presigned_uploads (id, company_code, object_key, size_bytes, md5, created_by, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	if _, err := r.pool.Exec(ctx, query, p.ID, company, p.Key, p.Size, p.MD5, nullableUser(p.CreatedBy), p.ExpiresAt); err != nil {
+		return fmt.Errorf("add presigned upload: %w", err)
+	}
+	return nil
+}
+
+// FindPresigned returns the newest unexpired presigned upload of key with the given MD5 or
+// ErrPresignNotFound.
+func (r *Repository) FindPresigned(ctx context.Context, key, md5 string) (PresignedUpload, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return PresignedUpload{}, err
+	}
+	const query = `SELECT ` + presignedColumns + ` FROM core.presigned_uploads
+WHERE company_code = $1 AND object_key = $2 AND md5 = $3 AND expires_at > NOW()
+ORDER BY created_at DESC
+LIMIT 1`
+
+	p, err := scanPresigned(r.pool.QueryRow(ctx, query, company, key, md5))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return PresignedUpload{}, ErrPresignNotFound
+	}
+	return p, err
+}
+
+// ConfirmPresigned marks a presigned upload as registered.
+func (r *Repository) ConfirmPresigned(ctx context.Context, id uuid.UUID) error {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+	const query = `UPDATE core.presigned_uploads SET confirmed_at = COALESCE(confirmed_at, NOW())
+WHERE id = $1 AND company_code = $2`
+
+	if _, err := r.pool.Exec(ctx, query, id, company); err != nil {
+		return fmt.Errorf("confirm presigned upload: %w", err)
+	}
+	return nil
+}
+
+// ListExpiredPresigned returns presigned uploads of every company whose confirmation
+// window is over.
+func (r *Repository) ListExpiredPresigned(ctx context.Context, limit int) ([]PresignedUpload, error) {
+	const query = `SELECT ` + presignedColumns + ` FROM core.presigned_uploads
+WHERE expires_at < NOW()
+ORDER BY expires_at
+LIMIT $1`
+
+	rows, err := r.pool.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list expired presigned uploads: %w", err)
+	}
+	defer rows.Close()
+
+	var items []PresignedUpload
+	for rows.Next() {
+		p, err := scanPresigned(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, p)
+	}
+	return items, rows.Err()
+}
+
+// DeletePresigned forgets a presigned upload.
+func (r *Repository) DeletePresigned(ctx context.Context, id uuid.UUID) error {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+	const query = `DELETE FROM core.presigned_uploads WHERE id = $1 AND company_code = $2`
+
+	if _, err := r.pool.Exec(ctx, query, id, company); err != nil {
+		return fmt.Errorf("delete presigned upload: %w", err)
+	}
+	return nil
+}
+
+// VersionRegistered reports whether the storage version of key belongs to a registered
+// file, trashed or not.
+func (r *Repository) VersionRegistered(ctx context.Context, key, storageVersion string) (bool, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return false, err
+	}
+	const query = `SELECT EXISTS (
+    SELECT 1 FROM core.files f
+    JOIN core.file_versions v ON v.file_id = f.id
+    WHERE f.company_code = $1 AND f.object_key = $2 AND v.storage_version = $3
+)`
+
+	var registered bool
+	if err := r.pool.QueryRow(ctx, query, company, key, storageVersion).Scan(&registered); err != nil {
+		return false, fmt.Errorf("find file version: %w", err)
+	}
+	return registered, nil
+}
+
+type queryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+func listObjects(ctx context.Context, q querier, company string, id uuid.UUID) ([]StoredObject, error) {
+	const query = `SELECT f.object_key, v.storage_version, FALSE
+FROM core.file_versions v
+JOIN core.files f ON f.id = v.file_id
+WHERE v.file_id = $1 AND f.company_code = $2
+UNION
+SELECT p.object_key, p.storage_version, TRUE
+FROM core.file_previews p
+JOIN core.files f ON f.id = p.file_id
+WHERE p.file_id = $1 AND f.company_code = $2`
+
+	rows, err := q.Query(ctx, query, id, company)
+	if err != nil {
+		return nil, fmt.Errorf("list file objects: %w", err)
+	}
+	defer rows.Close()
+
+	var items []StoredObject
+	for rows.Next() {
+		var o StoredObject
+		if err := rows.Scan(&o.Key, &o.Version, &o.Preview); err != nil {
+			return nil, fmt.Errorf("scan file object: %w", err)
+		}
+		items = append(items, o)
+	}
+	return items, rows.Err()
+}
+
+func getFile(ctx context.Context, q queryRower, company string, id uuid.UUID) (File, error) {
+	query := `SELECT ` + fileColumns + `
+FROM core.files f
+JOIN core.file_versions v ON v.file_id = f.id AND v.version_no = f.current_version
+WHERE f.id = $1 AND f.company_code = $2 AND f.deleted_at IS NULL`
+
+	var f File
+	if err := q.QueryRow(ctx, query, id, company).Scan(fileFields(&f)...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return File{}, ErrFileNotFound
+		}
+		return File{}, fmt.Errorf("get file: %w", err)
+	}
+	normalizeFile(&f)
+	return f, nil
+}
+
+// fileFields returns the scan destinations of fileColumns.
+func fileFields(f *File) []any {
+	return []any{&f.ID, &f.Key, &f.Filename, &f.CurrentVersion, &f.Size, &f.Checksum, &f.ContentType, &f.PreviewStatus,
+		&f.CreatedBy, &f.CreatedAt, &f.UpdatedAt, &f.DocumentType, &f.RetainUntil, &f.LegalHold, &f.DeletedAt, &f.DeletedBy, &f.PurgeAfter}
+}
+
+func normalizeFile(f *File) {
+	f.CreatedAt, f.UpdatedAt = f.CreatedAt.UTC(), f.UpdatedAt.UTC()
+	for _, t := range []*time.Time{f.RetainUntil, f.DeletedAt, f.PurgeAfter} {
+		if t != nil {
+			*t = t.UTC()
+		}
+	}
+}
+
+// presignedColumns selects a presigned upload for scanPresigned.
+const presignedColumns = `id, company_code, object_key, size_bytes, md5, created_by, expires_at, confirmed_at`
+
+func scanPresigned(row pgx.Row) (PresignedUpload, error) {
+	var (
+		p         PresignedUpload
+		createdBy *uuid.UUID
+	)
+	if err := row.Scan(&p.ID, &p.Company, &p.Key, &p.Size, &p.MD5, &createdBy, &p.ExpiresAt, &p.ConfirmedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return PresignedUpload{}, err
+		}
+		return PresignedUpload{}, fmt.Errorf("scan presigned upload: %w", err)
+	}
+	if createdBy != nil {
+		p.CreatedBy = *createdBy
+	}
+	p.ExpiresAt = p.ExpiresAt.UTC()
+	return p, nil
+}
+
+func scanVersion(row pgx.Row) (Version, error) {
+	var v Version
+	if err := row.Scan(&v.Number, &v.StorageVersion, &v.Size, &v.Checksum, &v.ContentType, &v.UploadedBy, &v.RestoredFrom, &v.ScanStatus, &v.PreviewStatus, &v.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Version{}, err
+		}
+		return Version{}, fmt.Errorf("scan file version: %w", err)
+	}
+	v.CreatedAt = v.CreatedAt.UTC()
+	return v, nil
+}
+
+// protectionError maps refusals of the retention trigger of core.files to ErrRetained.
+func protectionError(err error) error {
+	var pgErr *pgconn.PgError
+	// 23000 is integrity_constraint_violation, raised by core.files_enforce_retention.
+	if errors.As(err, &pgErr) && pgErr.Code == "23000" {
+		return fmt.Errorf("%w: %s", ErrRetained, pgErr.Message)
+	}
+	return err
+}
+
+func nullableTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// nullableUser stores the zero UUID as NULL, e.g. for uploads made by API tokens.
+func nullableUser(id uuid.UUID) any {
+	if id == uuid.Nil {
+		return nil
+	}
+	return id
+}
@@ -0,0 +1,301 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"asfppro/pkg/audit"
+	logpkg "asfppro/pkg/log"
+	"asfppro/pkg/retention"
+	"asfppro/pkg/s3"
+	"asfppro/pkg/tenant"
+)
+
+// DefaultTrashPeriod is how long deleted files stay restorable unless configured otherwise.
+const DefaultTrashPeriod = 30 * 24 * time.Hour
+
+const (
+	// purgeInterval is how often the trash is emptied.
+	purgeInterval = time.Hour
+	purgeBatch    = 100
+)
+
+// protect extends the retention of a file for its new version and locks that version in
+// storage. The version is already registered, so failures are logged: the database keeps
+// enforcing the retention even when storage could not lock the object.
+func (s *Service) protect(ctx context.Context, file File, storageVersion string) File {
+	if until := s.retention.Until(folderOf(file.Key), file.DocumentType, time.Now()); !until.IsZero() {
+		extended, err := s.repo.ExtendRetention(ctx, file.ID, until)
+		if err != nil {
+			logpkg.Ctx(ctx, s.logger).Error().Err(err).Str("key", file.Key).Msg("extend file retention")
+			return file
+		}
+		file = extended
+	}
+	if err := s.lock(ctx, file, []string{storageVersion}); err != nil {
+		logpkg.Ctx(ctx, s.logger).Error().Err(err).Str("key", file.Key).Msg("lock file version")
+	}
+	return file
+}
+
+// lock applies the retention and legal hold of file to the given storage versions. Buckets
+// without object lock are left to the database.
+func (s *Service) lock(ctx context.Context, file File, versions []string) error {
+	for _, version := range versions {
+		if version == "" {
+			continue
+		}
+		if file.RetainUntil != nil {
+			if err := s.storage.SetRetention(ctx, file.Key, version, *file.RetainUntil); err != nil && !errors.Is(err, s3.ErrLockUnsupported) {
+				return err
+			}
+		}
+		if file.LegalHold {
+			if err := s.storage.SetLegalHold(ctx, file.Key, version, true); err != nil && !errors.Is(err, s3.ErrLockUnsupported) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// storageVersions returns the storage versions of a file, without its previews.
+func (s *Service) storageVersions(ctx context.Context, id uuid.UUID) ([]string, error) {
+	objects, err := s.repo.Objects(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]string, 0, len(objects))
+	for _, o := range objects {
+		if !o.Preview {
+			versions = append(versions, o.Version)
+		}
+	}
+	return versions, nil
+}
+
+// SetDocumentType classifies a file. The retention rule of the type is counted from the
+// upload of the current version and can only extend the retention.
+func (s *Service) SetDocumentType(ctx context.Context, actor uuid.UUID, id uuid.UUID, documentType string) (File, error) {
+	file, err := s.repo.GetFile(ctx, id)
+	if err != nil {
+		return File{}, err
+	}
+	documentType = retention.NormalizeType(documentType)
+	until := s.retention.Until(folderOf(file.Key), documentType, file.UpdatedAt)
+	updated, err := s.repo.SetDocumentType(ctx, id, documentType, until)
+	if err != nil {
+		return File{}, err
+	}
+
+	if updated.RetainUntil != nil && (file.RetainUntil == nil || updated.RetainUntil.After(*file.RetainUntil)) {
+		versions, err := s.storageVersions(ctx, id)
+		if err != nil {
+			return File{}, err
+		}
+		if err := s.lock(ctx, File{Key: updated.Key, RetainUntil: updated.RetainUntil}, versions); err != nil {
+			return File{}, fmt.Errorf("lock file versions: %w", err)
+		}
+	}
+	s.recordAudit(ctx, actor, "core.file.classify", id.String(), map[string]any{
+		"documentType": documentType,
+		"retainUntil":  updated.RetainUntil,
+	})
+	return updated, nil
+}
+
+// SetLegalHold places or lifts the legal hold of a file and of all its stored versions.
+func (s *Service) SetLegalHold(ctx context.Context, actor uuid.UUID, id uuid.UUID, on bool) (File, error) {
+	file, err := s.repo.SetLegalHold(ctx, id, on)
+	if err != nil {
+		return File{}, err
+	}
+	versions, err := s.storageVersions(ctx, id)
+	if err != nil {
+		return File{}, err
+	}
+	for _, version := range versions {
+		if version == "" {
+			continue
+		}
+		if err := s.storage.SetLegalHold(ctx, file.Key, version, on); err != nil && !errors.Is(err, s3.ErrLockUnsupported) {
+			return File{}, fmt.Errorf("set legal hold of %s: %w", version, err)
+		}
+	}
+	s.recordAudit(ctx, actor, "core.file.legal_hold", id.String(), map[string]any{"key": file.Key, "legalHold": on})
+	return file, nil
+}
+
+// Delete moves a file to the trash, from which it is purged after the trash period. Files
+// under retention or legal hold cannot be deleted.
+func (s *Service) Delete(ctx context.Context, actor uuid.UUID, id uuid.UUID) error {
+	file, err := s.repo.GetFile(ctx, id)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	switch {
+	case file.LegalHold:
+		return ErrLegalHold
+	case file.Retained(now):
+		return fmt.Errorf("%w until %s", ErrRetained, file.RetainUntil.Format(time.RFC3339))
+	}
+
+	purgeAfter := now.Add(s.trash).UTC()
+	if err := s.repo.Trash(ctx, id, actor, purgeAfter); err != nil {
+		return err
+	}
+	s.recordAudit(ctx, actor, "core.file.delete", id.String(), map[string]any{"key": file.Key, "purgeAfter": purgeAfter})
+	return nil
+}
+
+// Undelete takes a file out of the trash.
+func (s *Service) Undelete(ctx context.Context, actor uuid.UUID, id uuid.UUID) (File, error) {
+	file, err := s.repo.Untrash(ctx, id)
+	if err != nil {
+		return File{}, err
+	}
+	s.recordAudit(ctx, actor, "core.file.undelete", id.String(), map[string]any{"key": file.Key})
+	return file, nil
+}
+
+// Trash returns the deleted files that have not been purged yet.
+func (s *Service) Trash(ctx context.Context) ([]File, error) {
+	return s.repo.ListTrash(ctx)
+}
+
+// Purger permanently deletes trashed files once their trash period is over, skipping files
+// under retention or legal hold. It also removes presigned uploads that were never confirmed.
+type Purger struct {
+	repo    Store
+	storage Storage
+	auditor *audit.Recorder
+	logger  zerolog.Logger
+}
+
+// NewPurger builds the trash purger.
+func NewPurger(repo Store, storage Storage, auditor *audit.Recorder, logger zerolog.Logger) *Purger {
+	return &Purger{repo: repo, storage: storage, auditor: auditor, logger: logger.With().Str("component", "files.purger").Logger()}
+}
+
+// Run purges the trash and expired presigned uploads until ctx is cancelled.
+func (p *Purger) Run(ctx context.Context) error {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if n, err := p.Purge(ctx); err != nil {
+				p.logger.Error().Err(err).Msg("purge trash")
+			} else if n > 0 {
+				p.logger.Info().Int("count", n).Msg("trashed files purged")
+			}
+			if n, err := p.ReapPresigned(ctx); err != nil {
+				p.logger.Error().Err(err).Msg("reap presigned uploads")
+			} else if n > 0 {
+				p.logger.Info().Int("count", n).Msg("unconfirmed presigned uploads removed")
+			}
+		}
+	}
+}
+
+// Purge deletes purgeable files from the registry, queueing their stored objects, and then
+// removes the queued objects from storage. Objects storage still locks stay queued for a
+// later run; the files themselves are gone either way.
+func (p *Purger) Purge(ctx context.Context) (int, error) {
+	candidates, err := p.repo.ListPurgeable(ctx, purgeBatch)
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, c := range candidates {
+		fileCtx := tenant.WithCompany(ctx, c.Company)
+		queued, err := p.repo.Purge(fileCtx, c.ID)
+		if errors.Is(err, ErrFileNotFound) {
+			// Restored, re-uploaded or protected since it was listed.
+			continue
+		}
+		if err != nil {
+			p.logger.Error().Err(err).Str("fileId", c.ID.String()).Str("key", c.Key).Msg("purge file")
+			continue
+		}
+		purged++
+		p.recordPurge(fileCtx, c, queued)
+	}
+
+	if n, err := p.removeQueued(ctx); err != nil {
+		return purged, err
+	} else if n > 0 {
+		p.logger.Info().Int("count", n).Msg("purged file objects removed")
+	}
+	return purged, nil
+}
+
+// removeQueued removes the queued objects of purged files from storage and returns how many
+// were removed. Removal is idempotent: objects already gone count as removed.
+func (p *Purger) removeQueued(ctx context.Context) (int, error) {
+	removed := 0
+	var after int64
+	for {
+		batch, err := p.repo.ListRemovals(ctx, after, purgeBatch)
+		if err != nil {
+			return removed, err
+		}
+		for _, item := range batch {
+			after = item.ID
+			itemCtx := tenant.WithCompany(ctx, item.Company)
+			err := p.remove(itemCtx, item)
+			switch {
+			case errors.Is(err, s3.ErrObjectLocked):
+				p.logger.Debug().Str("key", item.Key).Str("version", item.Version).Msg("file object still locked")
+				continue
+			case err != nil:
+				p.logger.Error().Err(err).Str("key", item.Key).Str("version", item.Version).Msg("remove file object")
+				continue
+			}
+			if err := p.repo.DeleteRemoval(itemCtx, item.ID); err != nil {
+				p.logger.Error().Err(err).Int64("removalId", item.ID).Msg("delete file removal")
+				continue
+			}
+			removed++
+		}
+		if len(batch) < purgeBatch {
+			return removed, nil
+		}
+	}
+}
+
+// remove deletes a queued object unless the same storage version is registered again, which
+// happens in buckets without versioning when the key is uploaded anew.
+func (p *Purger) remove(ctx context.Context, item Removal) error {
+	registered, err := p.repo.VersionRegistered(ctx, item.Key, item.Version)
+	if err != nil || registered {
+		return err
+	}
+	if err := p.storage.Remove(ctx, item.Key, item.Version); err != nil && !errors.Is(err, s3.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (p *Purger) recordPurge(ctx context.Context, c PurgeCandidate, objects int) {
+	if p.auditor == nil {
+		return
+	}
+	entry := audit.Entry{
+		Action:   "core.file.purge",
+		Entity:   "core.file",
+		EntityID: c.ID.String(),
+		Payload:  map[string]any{"key": c.Key, "objects": objects},
+	}
+	if err := p.auditor.Record(ctx, entry); err != nil {
+		p.logger.Error().Err(err).Msg("files audit record")
+	}
+}
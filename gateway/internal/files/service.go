@@ -0,0 +1,382 @@
+package files
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"asfppro/pkg/audit"
+	"asfppro/pkg/filecheck"
+	logpkg "asfppro/pkg/log"
+	"asfppro/pkg/queue"
+	"asfppro/pkg/retention"
+	"asfppro/pkg/s3"
+)
+
+// attachable lists the entities files can be attached to. The entity name doubles as the RBAC
+// resource guarding its attachments.
+var attachable = map[string]bool{
+	"crm.customer":  true,
+	"crm.deal":      true,
+	"docs.document": true,
+	"montage.task":  true,
+	"bpm.task":      true,
+	"wms.warehouse": true,
+}
+
+// Attachable reports whether files can be attached to entity.
+func Attachable(entity string) bool {
+	return attachable[entity]
+}
+
+// Storage is the object store holding file contents; s3.Storage implements it.
+type Storage interface {
+	PresignGet(ctx context.Context, key, versionID, filename string, expires time.Duration) (string, time.Time, error)
+	RestoreVersion(ctx context.Context, key, versionID string) (string, error)
+	Open(ctx context.Context, key, versionID string, offset, length int64) (io.ReadCloser, error)
+	Quarantine(ctx context.Context, key, versionID string) (string, error)
+	Remove(ctx context.Context, key, versionID string) error
+	Stat(ctx context.Context, key, versionID string) (s3.ObjectInfo, error)
+	Upload(ctx context.Context, folder, filename string, r io.Reader, size int64, contentType string) (string, string, error)
+	SetRetention(ctx context.Context, key, versionID string, until time.Time) error
+	SetLegalHold(ctx context.Context, key, versionID string, on bool) error
+}
+
+// Store persists the file registry, scoped to the active company in ctx except for the
+// cross-company listings of the purger; Repository implements it.
+type Store interface {
+	AddVersion(ctx context.Context, input UploadInput, restoredFrom *int) (File, error)
+	GetFile(ctx context.Context, id uuid.UUID) (File, error)
+	ListVersions(ctx context.Context, id uuid.UUID) ([]Version, error)
+	GetVersion(ctx context.Context, id uuid.UUID, number int) (Version, error)
+	VersionRegistered(ctx context.Context, key, storageVersion string) (bool, error)
+
+	Attach(ctx context.Context, id uuid.UUID, entity, entityID string, actor uuid.UUID) error
+	Detach(ctx context.Context, id uuid.UUID, entity, entityID string) error
+	ListAttachments(ctx context.Context, entity, entityID string) ([]Attachment, error)
+
+	SetPreviewStatus(ctx context.Context, id uuid.UUID, number int, status string) error
+	SavePreviews(ctx context.Context, id uuid.UUID, number int, previews []Preview) error
+	GetPreview(ctx context.Context, id uuid.UUID, number int, kind string) (Preview, error)
+
+	ExtendRetention(ctx context.Context, id uuid.UUID, until time.Time) (File, error)
+	SetDocumentType(ctx context.Context, id uuid.UUID, documentType string, until time.Time) (File, error)
+	SetLegalHold(ctx context.Context, id uuid.UUID, on bool) (File, error)
+	Trash(ctx context.Context, id uuid.UUID, actor uuid.UUID, purgeAfter time.Time) error
+	Untrash(ctx context.Context, id uuid.UUID) (File, error)
+	ListTrash(ctx context.Context) ([]File, error)
+	Objects(ctx context.Context, id uuid.UUID) ([]StoredObject, error)
+	ListPurgeable(ctx context.Context, limit int) ([]PurgeCandidate, error)
+	Purge(ctx context.Context, id uuid.UUID) (int, error)
+	ListRemovals(ctx context.Context, after int64, limit int) ([]Removal, error)
+	DeleteRemoval(ctx context.Context, id int64) error
+
+	AddPresigned(ctx context.Context, p PresignedUpload) error
+	FindPresigned(ctx context.Context, key, md5 string) (PresignedUpload, error)
+	ConfirmPresigned(ctx context.Context, id uuid.UUID) error
+	ListExpiredPresigned(ctx context.Context, limit int) ([]PresignedUpload, error)
+	DeletePresigned(ctx context.Context, id uuid.UUID) error
+}
+
+// Checks configures validation of uploaded files.
+type Checks struct {
+	Policy filecheck.Policy
+	// Scanner is nil when virus scanning is disabled.
+	Scanner filecheck.Scanner
+	// ScanLimit is the largest file scanned; bigger files are stored as not scanned.
+	ScanLimit int64
+}
+
+// Service manages the file registry.
+type Service struct {
+	repo      Store
+	storage   Storage
+	checks    Checks
+	publisher queue.Publisher
+	retention retention.Policy
+	trash     time.Duration
+	auditor   *audit.Recorder
+	logger    zerolog.Logger
+}
+
+// NewService builds files service.
+func NewService(repo Store, storage Storage, checks Checks, auditor *audit.Recorder, logger zerolog.Logger) *Service {
+	return &Service{
+		repo:    repo,
+		storage: storage,
+		checks:  checks,
+		trash:   DefaultTrashPeriod,
+		auditor: auditor,
+		logger:  logger.With().Str("component", "files.service").Logger(),
+	}
+}
+
+// WithPublisher announces new versions on publisher so the preview worker renders them.
+func (s *Service) WithPublisher(publisher queue.Publisher) *Service {
+	s.publisher = publisher
+	return s
+}
+
+// WithRetention keeps files for the periods of policy and deleted files in the trash for
+// trash before they are purged.
+func (s *Service) WithRetention(policy retention.Policy, trash time.Duration) *Service {
+	s.retention = policy
+	s.trash = trash
+	return s
+}
+
+// Admit checks an announced upload against the folder rules before any data is stored.
+func (s *Service) Admit(key string, size int64, contentType string) error {
+	return s.checks.Policy.Check(folderOf(key), size, contentType)
+}
+
+// RecordUpload validates a stored object and registers it as the newest version of its
+// file. Objects of a type the folder does not allow are deleted and infected ones are
+// quarantined; both return the filecheck error. Upload handlers audit the upload themselves.
+func (s *Service) RecordUpload(ctx context.Context, input UploadInput) (File, error) {
+	if input.Filename == "" {
+		input.Filename = path.Base(input.Key)
+	}
+	input.Checksum = strings.ToLower(input.Checksum)
+
+	if err := s.inspect(ctx, &input); err != nil {
+		return File{}, err
+	}
+	input.PreviewStatus = s.previewStatus(input.ContentType)
+	file, err := s.repo.AddVersion(ctx, input, nil)
+	if err != nil {
+		return File{}, err
+	}
+	file = s.protect(ctx, file, input.StorageVersion)
+	s.requestPreview(ctx, input.UploadedBy, file)
+	return file, nil
+}
+
+// inspect sniffs the stored content type, applies the folder rules and scans the object.
+func (s *Service) inspect(ctx context.Context, input *UploadInput) error {
+	if input.Size > 0 {
+		head, err := s.readHead(ctx, input.Key, input.StorageVersion)
+		if err != nil {
+			return err
+		}
+		input.ContentType = filecheck.Detect(head, input.ContentType)
+	}
+	if err := s.checks.Policy.Check(folderOf(input.Key), input.Size, input.ContentType); err != nil {
+		s.discard(ctx, input.Key, input.StorageVersion)
+		return err
+	}
+
+	switch {
+	case s.checks.Scanner == nil:
+		return nil
+	case input.Size > s.checks.ScanLimit:
+		input.ScanStatus = ScanSkipped
+		return nil
+	}
+	verdict, err := s.scan(ctx, input.Key, input.StorageVersion)
+	if err != nil {
+		s.discard(ctx, input.Key, input.StorageVersion)
+		return fmt.Errorf("scan file: %w", err)
+	}
+	if !verdict.Infected {
+		input.ScanStatus = ScanClean
+		return nil
+	}
+
+	quarantined, err := s.storage.Quarantine(ctx, input.Key, input.StorageVersion)
+	if err != nil {
+		logpkg.Ctx(ctx, s.logger).Error().Err(err).Str("key", input.Key).Msg("quarantine infected file")
+		s.discard(ctx, input.Key, input.StorageVersion)
+	}
+	s.recordAudit(ctx, input.UploadedBy, "core.file.quarantine", input.Key, map[string]any{
+		"key":        input.Key,
+		"version":    input.StorageVersion,
+		"signature":  verdict.Signature,
+		"quarantine": quarantined,
+	})
+	return fmt.Errorf("%w: %s", filecheck.ErrInfected, verdict.Signature)
+}
+
+func (s *Service) readHead(ctx context.Context, key, versionID string) ([]byte, error) {
+	rc, err := s.storage.Open(ctx, key, versionID, 0, filecheck.SniffLen)
+	if err != nil {
+		return nil, fmt.Errorf("read file head: %w", err)
+	}
+	defer func() { _ = rc.Close() }()
+	head, err := io.ReadAll(io.LimitReader(rc, filecheck.SniffLen))
+	if err != nil {
+		return nil, fmt.Errorf("read file head: %w", err)
+	}
+	return head, nil
+}
+
+func (s *Service) scan(ctx context.Context, key, versionID string) (filecheck.Verdict, error) {
+	rc, err := s.storage.Open(ctx, key, versionID, 0, 0)
+	if err != nil {
+		return filecheck.Verdict{}, err
+	}
+	defer func() { _ = rc.Close() }()
+	return s.checks.Scanner.Scan(ctx, rc)
+}
+
+// discard deletes a rejected object version.
+func (s *Service) discard(ctx context.Context, key, versionID string) {
+	if err := s.storage.Remove(context.WithoutCancel(ctx), key, versionID); err != nil {
+		logpkg.Ctx(ctx, s.logger).Error().Err(err).Str("key", key).Msg("remove rejected file")
+	}
+}
+
+// Get returns a file with its current version.
+func (s *Service) Get(ctx context.Context, id uuid.UUID) (File, error) {
+	return s.repo.GetFile(ctx, id)
+}
+
+// Versions returns the version history of a file.
+func (s *Service) Versions(ctx context.Context, id uuid.UUID) ([]Version, error) {
+	if _, err := s.repo.GetFile(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListVersions(ctx, id)
+}
+
+// DownloadURL presigns a download of the given file version.
+func (s *Service) DownloadURL(ctx context.Context, id uuid.UUID, number int, ttl time.Duration) (string, time.Time, error) {
+	file, err := s.repo.GetFile(ctx, id)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	version, err := s.repo.GetVersion(ctx, id, number)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if version.StorageVersion == "" && number != file.CurrentVersion {
+		return "", time.Time{}, ErrVersionUnavailable
+	}
+	return s.storage.PresignGet(ctx, file.Key, version.StorageVersion, file.Filename, ttl)
+}
+
+// Restore copies an earlier version over the file, making it the newest version.
+func (s *Service) Restore(ctx context.Context, actor uuid.UUID, id uuid.UUID, number int) (File, error) {
+	file, err := s.repo.GetFile(ctx, id)
+	if err != nil {
+		return File{}, err
+	}
+	version, err := s.repo.GetVersion(ctx, id, number)
+	if err != nil {
+		return File{}, err
+	}
+	if version.StorageVersion == "" {
+		return File{}, ErrVersionUnavailable
+	}
+
+	storageVersion, err := s.storage.RestoreVersion(ctx, file.Key, version.StorageVersion)
+	if err != nil {
+		return File{}, fmt.Errorf("restore file version: %w", err)
+	}
+	restored, err := s.repo.AddVersion(ctx, UploadInput{
+		Key:            file.Key,
+		Filename:       file.Filename,
+		StorageVersion: storageVersion,
+		Size:           version.Size,
+		Checksum:       version.Checksum,
+		ContentType:    version.ContentType,
+		UploadedBy:     actor,
+		ScanStatus:     version.ScanStatus,
+		PreviewStatus:  s.previewStatus(version.ContentType),
+	}, &number)
+	if err != nil {
+		return File{}, err
+	}
+	restored = s.protect(ctx, restored, storageVersion)
+	s.requestPreview(ctx, actor, restored)
+	s.recordAudit(ctx, actor, "core.file.restore", id.String(), map[string]any{
+		"key":          restored.Key,
+		"version":      restored.CurrentVersion,
+		"restoredFrom": number,
+	})
+	return restored, nil
+}
+
+// PreviewURL presigns a download of a rendered preview of the given file version.
+func (s *Service) PreviewURL(ctx context.Context, id uuid.UUID, number int, kind string, ttl time.Duration) (Preview, string, time.Time, error) {
+	if _, err := s.repo.GetFile(ctx, id); err != nil {
+		return Preview{}, "", time.Time{}, err
+	}
+	p, err := s.repo.GetPreview(ctx, id, number, kind)
+	if err != nil {
+		return Preview{}, "", time.Time{}, err
+	}
+	url, expires, err := s.storage.PresignGet(ctx, p.Key, "", "", ttl)
+	if err != nil {
+		return Preview{}, "", time.Time{}, err
+	}
+	return p, url, expires, nil
+}
+
+// Attach links a file to an entity.
+func (s *Service) Attach(ctx context.Context, actor uuid.UUID, id uuid.UUID, entity, entityID string) error {
+	entityID = strings.TrimSpace(entityID)
+	if !Attachable(entity) {
+		return ErrUnknownEntity
+	}
+	if entityID == "" {
+		return ErrEntityIDRequired
+	}
+	if _, err := s.repo.GetFile(ctx, id); err != nil {
+		return err
+	}
+	if err := s.repo.Attach(ctx, id, entity, entityID, actor); err != nil {
+		return err
+	}
+	s.recordAudit(ctx, actor, "core.file.attach", id.String(), map[string]any{"entity": entity, "entityId": entityID})
+	return nil
+}
+
+// Detach removes a link between a file and an entity.
+func (s *Service) Detach(ctx context.Context, actor uuid.UUID, id uuid.UUID, entity, entityID string) error {
+	if !Attachable(entity) {
+		return ErrUnknownEntity
+	}
+	if err := s.repo.Detach(ctx, id, entity, strings.TrimSpace(entityID)); err != nil {
+		return err
+	}
+	s.recordAudit(ctx, actor, "core.file.detach", id.String(), map[string]any{"entity": entity, "entityId": entityID})
+	return nil
+}
+
+// Attachments returns the files attached to an entity.
+func (s *Service) Attachments(ctx context.Context, entity, entityID string) ([]Attachment, error) {
+	if !Attachable(entity) {
+		return nil, ErrUnknownEntity
+	}
+	return s.repo.ListAttachments(ctx, entity, strings.TrimSpace(entityID))
+}
+
+// folderOf returns the folder of a company object key, without the company prefix.
+func folderOf(key string) string {
+	dir := path.Dir(key)
+	_, folder, _ := strings.Cut(dir, "/")
+	return folder
+}
+
+func (s *Service) recordAudit(ctx context.Context, actor uuid.UUID, action, entityID string, payload any) {
+	if s.auditor == nil {
+		return
+	}
+	entry := audit.Entry{
+		ActorID:  actor,
+		Action:   action,
+		Entity:   "core.file",
+		EntityID: entityID,
+		Payload:  payload,
+	}
+	if err := s.auditor.Record(ctx, entry); err != nil {
+		logpkg.Ctx(ctx, s.logger).Error().Err(err).Msg("files audit record")
+	}
+}
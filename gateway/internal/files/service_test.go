@@ -0,0 +1,486 @@
+package files_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"asfppro/gateway/internal/files"
+	"asfppro/pkg/filecheck"
+	"asfppro/pkg/retention"
+	"asfppro/pkg/s3"
+	"asfppro/pkg/tenant"
+)
+
+// pdf is the head of a PDF document; the content sniffer recognises it.
+var pdf = []byte("%PDF-1.7\n1 0 obj\n<<>>\nendobj\n%%EOF\n")
+
+// memoryStore keeps the file registry in memory and enforces retention like the database
+// trigger does. Methods the tests do not reach fall through to the nil Store and panic.
+type memoryStore struct {
+	files.Store
+
+	mu        sync.Mutex
+	entries   map[uuid.UUID]*memoryFile
+	presigned map[uuid.UUID]files.PresignedUpload
+	removals  []files.Removal
+	// skew moves the clock of purge queries forward, e.g. past the trash period.
+	skew time.Duration
+}
+
+type memoryFile struct {
+	company  string
+	file     files.File
+	versions []files.Version
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{entries: map[uuid.UUID]*memoryFile{}, presigned: map[uuid.UUID]files.PresignedUpload{}}
+}
+
+func (m *memoryStore) now() time.Time {
+	return time.Now().Add(m.skew)
+}
+
+func (m *memoryStore) AddVersion(ctx context.Context, input files.UploadInput, restoredFrom *int) (files.File, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return files.File{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var entry *memoryFile
+	for _, e := range m.entries {
+		if e.company == company && e.file.Key == input.Key {
+			entry = e
+		}
+	}
+	if entry == nil {
+		entry = &memoryFile{company: company, file: files.File{ID: uuid.New(), Key: input.Key, CreatedAt: time.Now()}}
+		m.entries[entry.file.ID] = entry
+	}
+	if restoredFrom == nil && input.StorageVersion != "" {
+		for _, v := range entry.versions {
+			if v.StorageVersion == input.StorageVersion {
+				return entry.file, nil
+			}
+		}
+	}
+	v := files.Version{
+		Number:         len(entry.versions) + 1,
+		StorageVersion: input.StorageVersion,
+		Size:           input.Size,
+		Checksum:       input.Checksum,
+		ContentType:    input.ContentType,
+		RestoredFrom:   restoredFrom,
+		ScanStatus:     input.ScanStatus,
+		PreviewStatus:  input.PreviewStatus,
+		CreatedAt:      time.Now(),
+	}
+	entry.versions = append(entry.versions, v)
+	f := &entry.file
+	f.Filename, f.CurrentVersion, f.Size, f.Checksum, f.ContentType = input.Filename, v.Number, v.Size, v.Checksum, v.ContentType
+	f.UpdatedAt, f.DeletedAt, f.PurgeAfter = time.Now(), nil, nil
+	return *f, nil
+}
+
+func (m *memoryStore) GetFile(ctx context.Context, id uuid.UUID) (files.File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, err := m.entry(ctx, id)
+	if err != nil || entry.file.DeletedAt != nil {
+		return files.File{}, files.ErrFileNotFound
+	}
+	return entry.file, nil
+}
+
+func (m *memoryStore) VersionRegistered(ctx context.Context, key, storageVersion string) (bool, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return false, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.entries {
+		if e.company != company || e.file.Key != key {
+			continue
+		}
+		for _, v := range e.versions {
+			if v.StorageVersion == storageVersion {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryStore) ExtendRetention(ctx context.Context, id uuid.UUID, until time.Time) (files.File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, err := m.entry(ctx, id)
+	if err != nil {
+		return files.File{}, err
+	}
+	if entry.file.RetainUntil == nil || until.After(*entry.file.RetainUntil) {
+		entry.file.RetainUntil = &until
+	}
+	return entry.file, nil
+}
+
+func (m *memoryStore) SetLegalHold(ctx context.Context, id uuid.UUID, on bool) (files.File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, err := m.entry(ctx, id)
+	if err != nil {
+		return files.File{}, err
+	}
+	entry.file.LegalHold = on
+	return entry.file, nil
+}
+
+func (m *memoryStore) Trash(ctx context.Context, id uuid.UUID, _ uuid.UUID, purgeAfter time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, err := m.entry(ctx, id)
+	if err != nil {
+		return err
+	}
+	if entry.file.LegalHold || entry.file.Retained(time.Now()) {
+		return files.ErrRetained
+	}
+	deletedAt := time.Now()
+	entry.file.DeletedAt, entry.file.PurgeAfter = &deletedAt, &purgeAfter
+	return nil
+}
+
+func (m *memoryStore) Untrash(ctx context.Context, id uuid.UUID) (files.File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, err := m.entry(ctx, id)
+	if err != nil {
+		return files.File{}, err
+	}
+	if entry.file.DeletedAt == nil {
+		return files.File{}, files.ErrFileNotFound
+	}
+	entry.file.DeletedAt, entry.file.PurgeAfter = nil, nil
+	return entry.file, nil
+}
+
+func (m *memoryStore) Objects(ctx context.Context, id uuid.UUID) ([]files.StoredObject, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, err := m.entry(ctx, id)
+	if err != nil {
+		return nil, nil
+	}
+	var objects []files.StoredObject
+	for _, v := range entry.versions {
+		objects = append(objects, files.StoredObject{Key: entry.file.Key, Version: v.StorageVersion})
+	}
+	return objects, nil
+}
+
+func (m *memoryStore) ListPurgeable(_ context.Context, limit int) ([]files.PurgeCandidate, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var items []files.PurgeCandidate
+	for _, e := range m.entries {
+		if m.purgeable(e) && len(items) < limit {
+			items = append(items, files.PurgeCandidate{ID: e.file.ID, Company: e.company, Key: e.file.Key})
+		}
+	}
+	return items, nil
+}
+
+func (m *memoryStore) Purge(ctx context.Context, id uuid.UUID) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, err := m.entry(ctx, id)
+	if err != nil || !m.purgeable(entry) {
+		return 0, files.ErrFileNotFound
+	}
+	for _, v := range entry.versions {
+		m.removals = append(m.removals, files.Removal{ID: int64(len(m.removals) + 1), Company: entry.company, Key: entry.file.Key, Version: v.StorageVersion})
+	}
+	delete(m.entries, id)
+	return len(entry.versions), nil
+}
+
+func (m *memoryStore) ListRemovals(_ context.Context, after int64, limit int) ([]files.Removal, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var items []files.Removal
+	for _, r := range m.removals {
+		if r.ID > after && len(items) < limit {
+			items = append(items, r)
+		}
+	}
+	return items, nil
+}
+
+func (m *memoryStore) DeleteRemoval(_ context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, r := range m.removals {
+		if r.ID == id {
+			m.removals = append(m.removals[:i], m.removals[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (m *memoryStore) AddPresigned(ctx context.Context, p files.PresignedUpload) error {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p.Company = company
+	m.presigned[p.ID] = p
+	return nil
+}
+
+func (m *memoryStore) ListExpiredPresigned(_ context.Context, limit int) ([]files.PresignedUpload, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var items []files.PresignedUpload
+	for _, p := range m.presigned {
+		if p.ExpiresAt.Before(time.Now()) && len(items) < limit {
+			items = append(items, p)
+		}
+	}
+	return items, nil
+}
+
+func (m *memoryStore) DeletePresigned(_ context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.presigned, id)
+	return nil
+}
+
+func (m *memoryStore) entry(ctx context.Context, id uuid.UUID) (*memoryFile, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entry, ok := m.entries[id]
+	if !ok || entry.company != company {
+		return nil, files.ErrFileNotFound
+	}
+	return entry, nil
+}
+
+func (m *memoryStore) purgeable(e *memoryFile) bool {
+	now := m.now()
+	return e.file.DeletedAt != nil && e.file.PurgeAfter.Before(now) && !e.file.LegalHold && !e.file.Retained(now)
+}
+
+type infectedScanner struct{}
+
+func (infectedScanner) Scan(_ context.Context, r io.Reader) (filecheck.Verdict, error) {
+	_, _ = io.Copy(io.Discard, r)
+	return filecheck.Verdict{Infected: true, Signature: "Eicar-Test-Signature"}, nil
+}
+
+func newStorage(t *testing.T) *s3.FS {
+	t.Helper()
+	storage, err := s3.NewFS(t.TempDir(), "secret")
+	require.NoError(t, err)
+	return storage
+}
+
+// store writes data at key and returns the upload input describing it.
+func store(t *testing.T, storage *s3.FS, key string, data []byte, contentType string) files.UploadInput {
+	t.Helper()
+	_, version, err := storage.UploadBytes(context.Background(), path.Dir(key), path.Base(key), data, contentType)
+	require.NoError(t, err)
+	sum := md5.Sum(data)
+	return files.UploadInput{
+		Key:            key,
+		StorageVersion: version,
+		Size:           int64(len(data)),
+		Checksum:       hex.EncodeToString(sum[:]),
+		ContentType:    contentType,
+	}
+}
+
+func TestRecordUploadRegistersAndDeduplicatesVersions(t *testing.T) {
+	ctx := tenant.WithCompany(context.Background(), "MAIN")
+	storage := newStorage(t)
+	svc := files.NewService(newMemoryStore(), storage, files.Checks{}, nil, zerolog.Nop())
+
+	input := store(t, storage, "MAIN/uploads/layout.pdf", pdf, "application/octet-stream")
+	file, err := svc.RecordUpload(ctx, input)
+	require.NoError(t, err)
+	require.Equal(t, 1, file.CurrentVersion)
+	require.Equal(t, "application/pdf", file.ContentType)
+	require.Equal(t, "layout.pdf", file.Filename)
+
+	// A retried confirmation of the same storage version adds nothing.
+	again, err := svc.RecordUpload(ctx, input)
+	require.NoError(t, err)
+	require.Equal(t, file.ID, again.ID)
+	require.Equal(t, 1, again.CurrentVersion)
+}
+
+func TestRecordUploadRejectsDisallowedTypes(t *testing.T) {
+	ctx := tenant.WithCompany(context.Background(), "MAIN")
+	storage := newStorage(t)
+	policy, err := filecheck.ParsePolicy("*:10M:image/*")
+	require.NoError(t, err)
+	svc := files.NewService(newMemoryStore(), storage, files.Checks{Policy: policy}, nil, zerolog.Nop())
+
+	// The claimed type is allowed, the sniffed one is not.
+	input := store(t, storage, "MAIN/uploads/photo.png", pdf, "image/png")
+	_, err = svc.RecordUpload(ctx, input)
+	require.ErrorIs(t, err, filecheck.ErrTypeNotAllowed)
+
+	_, err = storage.Stat(ctx, input.Key, input.StorageVersion)
+	require.ErrorIs(t, err, s3.ErrNotFound, "rejected object must be removed")
+	registered, err := svc.Registered(ctx, input.Key, input.StorageVersion)
+	require.NoError(t, err)
+	require.False(t, registered)
+}
+
+func TestRecordUploadQuarantinesInfectedFiles(t *testing.T) {
+	ctx := tenant.WithCompany(context.Background(), "MAIN")
+	storage := newStorage(t)
+	checks := files.Checks{Scanner: infectedScanner{}, ScanLimit: 1 << 20}
+	svc := files.NewService(newMemoryStore(), storage, checks, nil, zerolog.Nop())
+
+	input := store(t, storage, "MAIN/uploads/invoice.pdf", pdf, "application/pdf")
+	_, err := svc.RecordUpload(ctx, input)
+	require.ErrorIs(t, err, filecheck.ErrInfected)
+
+	_, err = storage.Stat(ctx, input.Key, "")
+	require.ErrorIs(t, err, s3.ErrNotFound, "infected object must leave its key")
+	quarantined, err := storage.Stat(ctx, s3.QuarantinePrefix+input.Key, "")
+	require.NoError(t, err)
+	require.Equal(t, input.Checksum, quarantined.ETag)
+}
+
+func TestRetentionAndPurge(t *testing.T) {
+	ctx := tenant.WithCompany(context.Background(), "MAIN")
+	storage := newStorage(t)
+	repo := newMemoryStore()
+	policy, err := retention.ParsePolicy("contracts:5y")
+	require.NoError(t, err)
+	svc := files.NewService(repo, storage, files.Checks{}, nil, zerolog.Nop()).WithRetention(policy, 24*time.Hour)
+	purger := files.NewPurger(repo, storage, nil, zerolog.Nop())
+
+	contract, err := svc.RecordUpload(ctx, store(t, storage, "MAIN/contracts/supply.pdf", pdf, "application/pdf"))
+	require.NoError(t, err)
+	require.NotNil(t, contract.RetainUntil)
+	require.ErrorIs(t, svc.Delete(ctx, uuid.Nil, contract.ID), files.ErrRetained)
+
+	held, err := svc.RecordUpload(ctx, store(t, storage, "MAIN/uploads/claim.pdf", pdf, "application/pdf"))
+	require.NoError(t, err)
+	_, err = svc.SetLegalHold(ctx, uuid.Nil, held.ID, true)
+	require.NoError(t, err)
+	require.ErrorIs(t, svc.Delete(ctx, uuid.Nil, held.ID), files.ErrLegalHold)
+
+	draft := store(t, storage, "MAIN/uploads/draft.pdf", pdf, "application/pdf")
+	file, err := svc.RecordUpload(ctx, draft)
+	require.NoError(t, err)
+	require.NoError(t, svc.Delete(ctx, uuid.Nil, file.ID))
+
+	// Nothing is purged during the trash period.
+	n, err := purger.Purge(ctx)
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	repo.skew = 48 * time.Hour
+	n, err = purger.Purge(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	_, err = storage.Stat(ctx, draft.Key, draft.StorageVersion)
+	require.ErrorIs(t, err, s3.ErrNotFound)
+	objects, err := repo.Objects(ctx, file.ID)
+	require.NoError(t, err)
+	require.Empty(t, objects)
+
+	// Protected files and their objects are kept.
+	_, err = svc.Get(ctx, contract.ID)
+	require.NoError(t, err)
+	_, err = svc.Get(ctx, held.ID)
+	require.NoError(t, err)
+}
+
+func TestPurgeRetriesLockedObjectsWithoutRestoringTheFile(t *testing.T) {
+	ctx := tenant.WithCompany(context.Background(), "MAIN")
+	storage := newStorage(t)
+	repo := newMemoryStore()
+	svc := files.NewService(repo, storage, files.Checks{}, nil, zerolog.Nop()).WithRetention(retention.Policy{}, time.Hour)
+	purger := files.NewPurger(repo, storage, nil, zerolog.Nop())
+
+	input := store(t, storage, "MAIN/uploads/locked.pdf", pdf, "application/pdf")
+	file, err := svc.RecordUpload(ctx, input)
+	require.NoError(t, err)
+	require.NoError(t, svc.Delete(ctx, uuid.Nil, file.ID))
+	// Storage holds the object although the registry does not, e.g. after a manual hold.
+	require.NoError(t, storage.SetLegalHold(ctx, input.Key, input.StorageVersion, true))
+
+	repo.skew = 2 * time.Hour
+	n, err := purger.Purge(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	_, err = svc.Undelete(ctx, uuid.Nil, file.ID)
+	require.ErrorIs(t, err, files.ErrFileNotFound, "a purged file must not come back")
+	_, err = storage.Stat(ctx, input.Key, input.StorageVersion)
+	require.NoError(t, err, "locked object stays queued")
+	require.Len(t, repo.removals, 1)
+
+	require.NoError(t, storage.SetLegalHold(ctx, input.Key, input.StorageVersion, false))
+	n, err = purger.Purge(ctx)
+	require.NoError(t, err)
+	require.Zero(t, n)
+	_, err = storage.Stat(ctx, input.Key, input.StorageVersion)
+	require.ErrorIs(t, err, s3.ErrNotFound)
+	require.Empty(t, repo.removals)
+}
+
+func TestReapPresignedRemovesOnlyUnregisteredUploads(t *testing.T) {
+	ctx := tenant.WithCompany(context.Background(), "MAIN")
+	storage := newStorage(t)
+	repo := newMemoryStore()
+	svc := files.NewService(repo, storage, files.Checks{}, nil, zerolog.Nop())
+	purger := files.NewPurger(repo, storage, nil, zerolog.Nop())
+	urlExpired := time.Now().Add(-2 * time.Hour)
+
+	abandoned := store(t, storage, "MAIN/layouts/abandoned.pdf", pdf, "application/pdf")
+	require.NoError(t, svc.TrackPresigned(ctx, uuid.Nil, abandoned.Key, abandoned.Size, abandoned.Checksum, urlExpired))
+
+	// Registered by another path although its confirmation never arrived.
+	registered := store(t, storage, "MAIN/layouts/registered.pdf", bytes.Repeat(pdf, 2), "application/pdf")
+	_, err := svc.RecordUpload(ctx, registered)
+	require.NoError(t, err)
+	require.NoError(t, svc.TrackPresigned(ctx, uuid.Nil, registered.Key, registered.Size, registered.Checksum, urlExpired))
+
+	n, err := purger.ReapPresigned(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	_, err = storage.Stat(ctx, abandoned.Key, abandoned.StorageVersion)
+	require.ErrorIs(t, err, s3.ErrNotFound, "abandoned upload must be removed")
+	_, err = storage.Stat(ctx, registered.Key, registered.StorageVersion)
+	require.NoError(t, err)
+
+	expired, err := repo.ListExpiredPresigned(ctx, 10)
+	require.NoError(t, err)
+	require.Empty(t, expired)
+}
@@ -33,7 +33,7 @@ func analyticsConversion(svc *analyticspkg.Service) fiber.Handler {
 			return fiber.NewError(fiber.StatusBadRequest, "invalid date range")
 		}
 
-		rows, err := svc.ConversionReport(c.Context(), bounds)
+		rows, err := svc.ConversionReport(c.UserContext(), bounds)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -61,7 +61,7 @@ func analyticsManagerLoad(svc *analyticspkg.Service) fiber.Handler {
 			return fiber.NewError(fiber.StatusBadRequest, "invalid date range")
 		}
 
-		rows, err := svc.ManagerLoadReport(c.Context(), bounds)
+		rows, err := svc.ManagerLoadReport(c.UserContext(), bounds)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -86,7 +86,7 @@ func analyticsConversionExport(svc *analyticspkg.Service) fiber.Handler {
 			return fiber.NewError(fiber.StatusBadRequest, "invalid date range")
 		}
 
-		file, err := svc.ConversionExport(c.Context(), bounds)
+		file, err := svc.ConversionExport(c.UserContext(), bounds)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -102,7 +102,7 @@ func analyticsManagerLoadExport(svc *analyticspkg.Service) fiber.Handler {
 			return fiber.NewError(fiber.StatusBadRequest, "invalid date range")
 		}
 
-		file, err := svc.ManagerLoadExport(c.Context(), bounds)
+		file, err := svc.ManagerLoadExport(c.UserContext(), bounds)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -13,6 +13,7 @@ import (
 
 	"asfppro/gateway/internal/auth"
 	"asfppro/pkg/audit"
+	logpkg "asfppro/pkg/log"
 )
 
 // AuditListHandler exposes aggregated audit log entries for authorized users.
@@ -36,12 +37,12 @@ func AuditListHandler(recorder *audit.Recorder, logger zerolog.Logger) fiber.Han
 			return err
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 		defer cancel()
 
 		records, err := recorder.List(ctx, filter)
 		if err != nil {
-			logger.Error().Err(err).Msg("list audit records")
+			logpkg.Ctx(c.UserContext(), logger).Error().Err(err).Msg("list audit records")
 			return fiber.NewError(fiber.StatusInternalServerError, "cannot load audit log")
 		}
 
@@ -64,6 +65,7 @@ func buildFilter(c *fiber.Ctx) (audit.Filter, error) {
 	}
 	filter.Entity = c.Query("entity")
 	filter.EntityID = c.Query("entityId")
+	filter.Company = c.Query("company")
 	filter.Limit = c.QueryInt("limit", 50)
 
 	if after := strings.TrimSpace(c.Query("afterId")); after != "" {
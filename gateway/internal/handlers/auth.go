@@ -3,14 +3,24 @@ package handlers
 import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+
+	"asfppro/pkg/tenant"
 )
 
 type currentUserResponse struct {
-	ID       uuid.UUID     `json:"id"`
-	Email    string        `json:"email"`
-	FullName string        `json:"fullName"`
-	Roles    []userRoleDTO `json:"roles"`
-	OrgUnits []string      `json:"orgUnits"`
+	ID        uuid.UUID        `json:"id"`
+	Email     string           `json:"email"`
+	FullName  string           `json:"fullName"`
+	Roles     []userRoleDTO    `json:"roles"`
+	OrgUnits  []string         `json:"orgUnits"`
+	Company   string           `json:"company"`
+	Companies []userCompanyDTO `json:"companies"`
+}
+
+type userCompanyDTO struct {
+	Code    string `json:"code"`
+	Name    string `json:"name"`
+	Default bool   `json:"default"`
 }
 
 type userRoleDTO struct {
@@ -18,7 +28,8 @@ type userRoleDTO struct {
 	Scope string `json:"scope"`
 }
 
-// CurrentUserHandler returns authenticated user profile along with assigned roles and org units.
+// CurrentUserHandler returns authenticated user profile along with assigned roles, org units
+// and companies, including the company the request acts for.
 func CurrentUserHandler() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		user, ok := currentUser(c)
@@ -27,16 +38,21 @@ func CurrentUserHandler() fiber.Handler {
 		}
 
 		response := currentUserResponse{
-			ID:       user.ID,
-			Email:    user.Email,
-			FullName: user.FullName,
-			Roles:    make([]userRoleDTO, 0, len(user.Roles)),
-			OrgUnits: user.OrgUnits,
+			ID:        user.ID,
+			Email:     user.Email,
+			FullName:  user.FullName,
+			Roles:     make([]userRoleDTO, 0, len(user.Roles)),
+			OrgUnits:  user.OrgUnits,
+			Companies: make([]userCompanyDTO, 0, len(user.Companies)),
 		}
+		response.Company, _ = tenant.Company(c.UserContext())
 
 		for _, role := range user.Roles {
 			response.Roles = append(response.Roles, userRoleDTO{Code: role.Code, Scope: role.Scope})
 		}
+		for _, company := range user.Companies {
+			response.Companies = append(response.Companies, userCompanyDTO{Code: company.Code, Name: company.Name, Default: company.Default})
+		}
 
 		return c.JSON(response)
 	}
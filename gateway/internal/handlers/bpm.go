@@ -10,6 +10,7 @@ import (
 	"github.com/rs/zerolog"
 
 	bpmpkg "asfppro/gateway/internal/bpm"
+	logpkg "asfppro/pkg/log"
 )
 
 // RegisterBPMRoutes подключает BPM minimal API.
@@ -43,7 +44,7 @@ func listBPMProcesses(svc *bpmpkg.Service) fiber.Handler {
 			Limit:  parseQueryLimit(c.Query("limit"), 50),
 			Status: c.Query("status"),
 		}
-		processes, err := svc.ListProcesses(c.Context(), filter)
+		processes, err := svc.ListProcesses(c.UserContext(), filter)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
@@ -58,11 +59,11 @@ func createBPMProcess(svc *bpmpkg.Service, logger zerolog.Logger) fiber.Handler
 			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 		}
 
-		proc, err := svc.CreateProcess(c.Context(), req)
+		proc, err := svc.CreateProcess(c.UserContext(), req)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
-		logger.Info().Str("processId", proc.ID.String()).Msg("gateway bpm process created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("processId", proc.ID.String()).Msg("gateway bpm process created")
 		return c.Status(fiber.StatusCreated).JSON(proc)
 	}
 }
@@ -114,7 +115,7 @@ func updateBPMProcess(svc *bpmpkg.Service, logger zerolog.Logger) fiber.Handler
 			input.Version = &version
 		}
 
-		proc, err := svc.UpdateProcess(c.Context(), id, input)
+		proc, err := svc.UpdateProcess(c.UserContext(), id, input)
 		if err != nil {
 			status := fiber.StatusBadRequest
 			if errors.Is(err, bpmpkg.ErrProcessNotFound) {
@@ -122,14 +123,14 @@ func updateBPMProcess(svc *bpmpkg.Service, logger zerolog.Logger) fiber.Handler
 			}
 			return fiber.NewError(status, err.Error())
 		}
-		logger.Info().Str("processId", proc.ID.String()).Msg("gateway bpm process updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("processId", proc.ID.String()).Msg("gateway bpm process updated")
 		return c.JSON(proc)
 	}
 }
 
 func listBPMForms(svc *bpmpkg.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		forms, err := svc.ListForms(c.Context(), parseQueryLimit(c.Query("limit"), 50))
+		forms, err := svc.ListForms(c.UserContext(), parseQueryLimit(c.Query("limit"), 50))
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -161,11 +162,11 @@ func createBPMForm(svc *bpmpkg.Service, logger zerolog.Logger) fiber.Handler {
 			Schema:    req.Schema,
 			UISchema:  req.UISchema,
 		}
-		form, err := svc.CreateForm(c.Context(), input)
+		form, err := svc.CreateForm(c.UserContext(), input)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
-		logger.Info().Str("formId", form.ID.String()).Msg("gateway bpm form created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("formId", form.ID.String()).Msg("gateway bpm form created")
 		return c.Status(fiber.StatusCreated).JSON(form)
 	}
 }
@@ -208,7 +209,7 @@ func updateBPMForm(svc *bpmpkg.Service, logger zerolog.Logger) fiber.Handler {
 			input.UISchema = &clone
 		}
 
-		form, err := svc.UpdateForm(c.Context(), id, input)
+		form, err := svc.UpdateForm(c.UserContext(), id, input)
 		if err != nil {
 			status := fiber.StatusBadRequest
 			if errors.Is(err, bpmpkg.ErrFormNotFound) {
@@ -216,7 +217,7 @@ func updateBPMForm(svc *bpmpkg.Service, logger zerolog.Logger) fiber.Handler {
 			}
 			return fiber.NewError(status, err.Error())
 		}
-		logger.Info().Str("formId", form.ID.String()).Msg("gateway bpm form updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("formId", form.ID.String()).Msg("gateway bpm form updated")
 		return c.JSON(form)
 	}
 }
@@ -227,7 +228,7 @@ func listBPMTasks(svc *bpmpkg.Service) fiber.Handler {
 			Limit:  parseQueryLimit(c.Query("limit"), 50),
 			Status: c.Query("status"),
 		}
-		tasks, err := svc.ListTasks(c.Context(), filter)
+		tasks, err := svc.ListTasks(c.UserContext(), filter)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
@@ -263,11 +264,11 @@ func createBPMTask(svc *bpmpkg.Service, logger zerolog.Logger) fiber.Handler {
 			Payload:   req.Payload,
 		}
 
-		task, err := svc.CreateTask(c.Context(), input)
+		task, err := svc.CreateTask(c.UserContext(), input)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
-		logger.Info().Str("taskId", task.ID.String()).Msg("gateway bpm task created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("taskId", task.ID.String()).Msg("gateway bpm task created")
 		return c.Status(fiber.StatusCreated).JSON(task)
 	}
 }
@@ -298,7 +299,7 @@ func updateBPMTask(svc *bpmpkg.Service, logger zerolog.Logger) fiber.Handler {
 			Payload:  payload.Payload,
 		}
 
-		task, err := svc.UpdateTask(c.Context(), id, input)
+		task, err := svc.UpdateTask(c.UserContext(), id, input)
 		if err != nil {
 			status := fiber.StatusBadRequest
 			if errors.Is(err, bpmpkg.ErrTaskNotFound) {
@@ -306,7 +307,7 @@ func updateBPMTask(svc *bpmpkg.Service, logger zerolog.Logger) fiber.Handler {
 			}
 			return fiber.NewError(status, err.Error())
 		}
-		logger.Info().Str("taskId", task.ID.String()).Msg("gateway bpm task updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("taskId", task.ID.String()).Msg("gateway bpm task updated")
 		return c.JSON(task)
 	}
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/rs/zerolog"
 
 	"asfppro/gateway/internal/core"
+	logpkg "asfppro/pkg/log"
 )
 
 // RegisterCoreRoutes wires core management endpoints under authenticated router.
@@ -94,7 +95,7 @@ type createAPITokenRequest struct {
 func listUsersHandler(svc *core.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		filter := core.ListUsersFilter{Role: c.Query("role")}
-		users, err := svc.ListUsers(c.Context(), filter)
+		users, err := svc.ListUsers(c.UserContext(), filter)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -143,12 +144,12 @@ func createUserHandler(svc *core.Service, logger zerolog.Logger) fiber.Handler {
 		}
 
 		actorID := extractActorID(c)
-		user, err := svc.CreateUser(c.Context(), actorID, input)
+		user, err := svc.CreateUser(c.UserContext(), actorID, input)
 		if err != nil {
 			return mapCoreError(err)
 		}
 
-		logger.Info().Str("userId", user.ID.String()).Msg("core user created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("userId", user.ID.String()).Msg("core user created")
 		return c.Status(fiber.StatusCreated).JSON(user)
 	}
 }
@@ -189,19 +190,19 @@ func updateUserHandler(svc *core.Service, logger zerolog.Logger) fiber.Handler {
 		}
 
 		actorID := extractActorID(c)
-		user, err := svc.UpdateUser(c.Context(), actorID, userID, input)
+		user, err := svc.UpdateUser(c.UserContext(), actorID, userID, input)
 		if err != nil {
 			return mapCoreError(err)
 		}
 
-		logger.Info().Str("userId", user.ID.String()).Msg("core user updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("userId", user.ID.String()).Msg("core user updated")
 		return c.JSON(user)
 	}
 }
 
 func listRolesHandler(svc *core.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		roles, err := svc.ListRoles(c.Context())
+		roles, err := svc.ListRoles(c.UserContext())
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -220,7 +221,7 @@ func createRoleHandler(svc *core.Service, logger zerolog.Logger) fiber.Handler {
 		}
 
 		actorID := extractActorID(c)
-		role, err := svc.CreateRole(c.Context(), actorID, core.Role{
+		role, err := svc.CreateRole(c.UserContext(), actorID, core.Role{
 			Code:        req.Code,
 			Description: req.Description,
 		})
@@ -228,14 +229,14 @@ func createRoleHandler(svc *core.Service, logger zerolog.Logger) fiber.Handler {
 			return mapCoreError(err)
 		}
 
-		logger.Info().Str("role", role.Code).Msg("core role created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("role", role.Code).Msg("core role created")
 		return c.Status(fiber.StatusCreated).JSON(role)
 	}
 }
 
 func listOrgUnitsHandler(svc *core.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		units, err := svc.ListOrgUnits(c.Context())
+		units, err := svc.ListOrgUnits(c.UserContext())
 		if err != nil {
 			return mapCoreError(err)
 		}
@@ -250,7 +251,7 @@ func createOrgUnitHandler(svc *core.Service, logger zerolog.Logger) fiber.Handle
 			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 		}
 		actor := extractActorID(c)
-		unit, err := svc.CreateOrgUnit(c.Context(), actor, core.CreateOrgUnitInput{
+		unit, err := svc.CreateOrgUnit(c.UserContext(), actor, core.CreateOrgUnitInput{
 			Code:        req.Code,
 			Name:        req.Name,
 			Description: req.Description,
@@ -260,7 +261,7 @@ func createOrgUnitHandler(svc *core.Service, logger zerolog.Logger) fiber.Handle
 		if err != nil {
 			return mapCoreError(err)
 		}
-		logger.Info().Str("orgUnit", unit.Code).Msg("core org unit created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("orgUnit", unit.Code).Msg("core org unit created")
 		return c.Status(fiber.StatusCreated).JSON(unit)
 	}
 }
@@ -272,7 +273,7 @@ func updateOrgUnitHandler(svc *core.Service, logger zerolog.Logger) fiber.Handle
 			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 		}
 		actor := extractActorID(c)
-		unit, err := svc.UpdateOrgUnit(c.Context(), actor, c.Params("code"), core.UpdateOrgUnitInput{
+		unit, err := svc.UpdateOrgUnit(c.UserContext(), actor, c.Params("code"), core.UpdateOrgUnitInput{
 			Name:        req.Name,
 			Description: req.Description,
 			IsActive:    req.IsActive,
@@ -281,7 +282,7 @@ func updateOrgUnitHandler(svc *core.Service, logger zerolog.Logger) fiber.Handle
 		if err != nil {
 			return mapCoreError(err)
 		}
-		logger.Info().Str("orgUnit", unit.Code).Msg("core org unit updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("orgUnit", unit.Code).Msg("core org unit updated")
 		return c.JSON(unit)
 	}
 }
@@ -289,17 +290,17 @@ func updateOrgUnitHandler(svc *core.Service, logger zerolog.Logger) fiber.Handle
 func deleteOrgUnitHandler(svc *core.Service, logger zerolog.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		actor := extractActorID(c)
-		if err := svc.DeleteOrgUnit(c.Context(), actor, c.Params("code")); err != nil {
+		if err := svc.DeleteOrgUnit(c.UserContext(), actor, c.Params("code")); err != nil {
 			return mapCoreError(err)
 		}
-		logger.Info().Str("orgUnit", c.Params("code")).Msg("core org unit deleted")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("orgUnit", c.Params("code")).Msg("core org unit deleted")
 		return c.SendStatus(fiber.StatusNoContent)
 	}
 }
 
 func listRolePermissionsHandler(svc *core.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		perms, err := svc.ListRolePermissions(c.Context(), c.Params("code"))
+		perms, err := svc.ListRolePermissions(c.UserContext(), c.Params("code"))
 		if err != nil {
 			return mapCoreError(err)
 		}
@@ -324,18 +325,18 @@ func updateRolePermissionsHandler(svc *core.Service, logger zerolog.Logger) fibe
 			})
 		}
 		actor := extractActorID(c)
-		permissions, err := svc.UpdateRolePermissions(c.Context(), actor, c.Params("code"), items)
+		permissions, err := svc.UpdateRolePermissions(c.UserContext(), actor, c.Params("code"), items)
 		if err != nil {
 			return mapCoreError(err)
 		}
-		logger.Info().Str("role", c.Params("code")).Int("count", len(permissions)).Msg("core permissions updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("role", c.Params("code")).Int("count", len(permissions)).Msg("core permissions updated")
 		return c.JSON(fiber.Map{"items": permissions})
 	}
 }
 
 func listAPITokensHandler(svc *core.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		tokens, err := svc.ListAPITokens(c.Context())
+		tokens, err := svc.ListAPITokens(c.UserContext())
 		if err != nil {
 			return mapCoreError(err)
 		}
@@ -350,7 +351,7 @@ func createAPITokenHandler(svc *core.Service, logger zerolog.Logger) fiber.Handl
 			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 		}
 		actor := extractActorID(c)
-		token, err := svc.CreateAPIToken(c.Context(), actor, core.CreateAPITokenInput{
+		token, err := svc.CreateAPIToken(c.UserContext(), actor, core.CreateAPITokenInput{
 			Name:     req.Name,
 			RoleCode: req.RoleCode,
 			Scope:    req.Scope,
@@ -358,7 +359,7 @@ func createAPITokenHandler(svc *core.Service, logger zerolog.Logger) fiber.Handl
 		if err != nil {
 			return mapCoreError(err)
 		}
-		logger.Info().Str("tokenId", token.ID.String()).Msg("core api token created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("tokenId", token.ID.String()).Msg("core api token created")
 		return c.Status(fiber.StatusCreated).JSON(token)
 	}
 }
@@ -370,11 +371,11 @@ func revokeAPITokenHandler(svc *core.Service, logger zerolog.Logger) fiber.Handl
 			return fiber.NewError(fiber.StatusBadRequest, "invalid token id")
 		}
 		actor := extractActorID(c)
-		token, err := svc.RevokeAPIToken(c.Context(), actor, id)
+		token, err := svc.RevokeAPIToken(c.UserContext(), actor, id)
 		if err != nil {
 			return mapCoreError(err)
 		}
-		logger.Info().Str("tokenId", token.ID.String()).Msg("core api token revoked")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("tokenId", token.ID.String()).Msg("core api token revoked")
 		return c.JSON(token)
 	}
 }
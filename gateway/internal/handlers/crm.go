@@ -11,6 +11,7 @@ import (
 	"github.com/rs/zerolog"
 
 	"asfppro/gateway/internal/crm"
+	logpkg "asfppro/pkg/log"
 )
 
 // RegisterCRMRoutes wires minimal CRM endpoints.
@@ -52,7 +53,7 @@ type dealRequest struct {
 
 func listCustomersHandler(svc *crm.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		customers, err := svc.ListCustomers(c.Context())
+		customers, err := svc.ListCustomers(c.UserContext())
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -73,12 +74,12 @@ func createCustomerHandler(svc *crm.Service, logger zerolog.Logger) fiber.Handle
 			KPP:  req.KPP,
 		}
 
-		customer, err := svc.CreateCustomer(c.Context(), extractActorID(c), input)
+		customer, err := svc.CreateCustomer(c.UserContext(), extractActorID(c), input)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
 
-		logger.Info().Str("customerId", customer.ID.String()).Msg("crm customer created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("customerId", customer.ID.String()).Msg("crm customer created")
 		return c.Status(fiber.StatusCreated).JSON(customer)
 	}
 }
@@ -109,12 +110,12 @@ func updateCustomerHandler(svc *crm.Service, logger zerolog.Logger) fiber.Handle
 			input.KPP = &kpp
 		}
 
-		customer, err := svc.UpdateCustomer(c.Context(), extractActorID(c), id, input)
+		customer, err := svc.UpdateCustomer(c.UserContext(), extractActorID(c), id, input)
 		if err != nil {
 			return mapCRMError(err)
 		}
 
-		logger.Info().Str("customerId", customer.ID.String()).Msg("crm customer updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("customerId", customer.ID.String()).Msg("crm customer updated")
 		return c.JSON(customer)
 	}
 }
@@ -127,7 +128,7 @@ func listDealsHandler(svc *crm.Service) fiber.Handler {
 			Limit: limit,
 		}
 		subject, _ := currentSubject(c)
-		deals, err := svc.ListDeals(c.Context(), subject, filter)
+		deals, err := svc.ListDeals(c.UserContext(), subject, filter)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -158,12 +159,12 @@ func createDealHandler(svc *crm.Service, logger zerolog.Logger) fiber.Handler {
 		}
 
 		subject, _ := currentSubject(c)
-		deal, err := svc.CreateDeal(c.Context(), extractActorID(c), subject, input)
+		deal, err := svc.CreateDeal(c.UserContext(), extractActorID(c), subject, input)
 		if err != nil {
 			return mapCRMError(err)
 		}
 
-		logger.Info().Str("dealId", deal.ID.String()).Msg("crm deal created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("dealId", deal.ID.String()).Msg("crm deal created")
 		return c.Status(fiber.StatusCreated).JSON(deal)
 	}
 }
@@ -213,12 +214,12 @@ func updateDealHandler(svc *crm.Service, logger zerolog.Logger) fiber.Handler {
 		}
 
 		subject, _ := currentSubject(c)
-		deal, err := svc.UpdateDeal(c.Context(), extractActorID(c), subject, id, input)
+		deal, err := svc.UpdateDeal(c.UserContext(), extractActorID(c), subject, id, input)
 		if err != nil {
 			return mapCRMError(err)
 		}
 
-		logger.Info().Str("dealId", deal.ID.String()).Msg("crm deal updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("dealId", deal.ID.String()).Msg("crm deal updated")
 		return c.JSON(deal)
 	}
 }
@@ -232,7 +233,7 @@ func dealHistoryHandler(svc *crm.Service) fiber.Handler {
 		limit, _ := strconv.Atoi(c.Query("limit", "20"))
 
 		subject, _ := currentSubject(c)
-		events, err := svc.ListDealEvents(c.Context(), subject, dealID, limit)
+		events, err := svc.ListDealEvents(c.UserContext(), subject, dealID, limit)
 		if err != nil {
 			if errors.Is(err, crm.ErrForbidden) {
 				return fiber.ErrForbidden
@@ -10,6 +10,7 @@ import (
 	"github.com/rs/zerolog"
 
 	docspkg "asfppro/gateway/internal/docs"
+	logpkg "asfppro/pkg/log"
 )
 
 // RegisterDocsRoutes подключает Docs минимальный API на gateway.
@@ -40,7 +41,7 @@ func RegisterDocsRoutes(router fiber.Router, svc *docspkg.Service, guard func(re
 func listDocsTemplates(svc *docspkg.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		limit := parseQueryLimit(c.Query("limit"), 50)
-		templates, err := svc.ListTemplates(c.Context(), limit)
+		templates, err := svc.ListTemplates(c.UserContext(), limit)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -55,11 +56,11 @@ func createDocsTemplate(svc *docspkg.Service, logger zerolog.Logger) fiber.Handl
 			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 		}
 
-		tpl, err := svc.CreateTemplate(c.Context(), req)
+		tpl, err := svc.CreateTemplate(c.UserContext(), req)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
-		logger.Info().Str("templateId", tpl.ID.String()).Msg("gateway docs template created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("templateId", tpl.ID.String()).Msg("gateway docs template created")
 		return c.Status(fiber.StatusCreated).JSON(tpl)
 	}
 }
@@ -104,7 +105,7 @@ func updateDocsTemplate(svc *docspkg.Service, logger zerolog.Logger) fiber.Handl
 			input.Version = &val
 		}
 
-		tpl, err := svc.UpdateTemplate(c.Context(), id, input)
+		tpl, err := svc.UpdateTemplate(c.UserContext(), id, input)
 		if err != nil {
 			status := fiber.StatusBadRequest
 			if errors.Is(err, docspkg.ErrTemplateNotFound) {
@@ -112,7 +113,7 @@ func updateDocsTemplate(svc *docspkg.Service, logger zerolog.Logger) fiber.Handl
 			}
 			return fiber.NewError(status, err.Error())
 		}
-		logger.Info().Str("templateId", tpl.ID.String()).Msg("gateway docs template updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("templateId", tpl.ID.String()).Msg("gateway docs template updated")
 		return c.JSON(tpl)
 	}
 }
@@ -120,7 +121,7 @@ func updateDocsTemplate(svc *docspkg.Service, logger zerolog.Logger) fiber.Handl
 func listDocsSigners(svc *docspkg.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		limit := parseQueryLimit(c.Query("limit"), 50)
-		signers, err := svc.ListSigners(c.Context(), limit)
+		signers, err := svc.ListSigners(c.UserContext(), limit)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -135,11 +136,11 @@ func createDocsSigner(svc *docspkg.Service, logger zerolog.Logger) fiber.Handler
 			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 		}
 
-		signer, err := svc.CreateSigner(c.Context(), req)
+		signer, err := svc.CreateSigner(c.UserContext(), req)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
-		logger.Info().Str("signerId", signer.ID.String()).Msg("gateway docs signer created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("signerId", signer.ID.String()).Msg("gateway docs signer created")
 		return c.Status(fiber.StatusCreated).JSON(signer)
 	}
 }
@@ -174,7 +175,7 @@ func updateDocsSigner(svc *docspkg.Service, logger zerolog.Logger) fiber.Handler
 			input.Phone = &trimmed
 		}
 
-		signer, err := svc.UpdateSigner(c.Context(), id, input)
+		signer, err := svc.UpdateSigner(c.UserContext(), id, input)
 		if err != nil {
 			status := fiber.StatusBadRequest
 			if errors.Is(err, docspkg.ErrSignerNotFound) {
@@ -182,7 +183,7 @@ func updateDocsSigner(svc *docspkg.Service, logger zerolog.Logger) fiber.Handler
 			}
 			return fiber.NewError(status, err.Error())
 		}
-		logger.Info().Str("signerId", signer.ID.String()).Msg("gateway docs signer updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("signerId", signer.ID.String()).Msg("gateway docs signer updated")
 		return c.JSON(signer)
 	}
 }
@@ -191,7 +192,7 @@ func listDocsDocuments(svc *docspkg.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		limit := parseQueryLimit(c.Query("limit"), 50)
 		status := strings.TrimSpace(c.Query("status"))
-		docs, err := svc.ListDocuments(c.Context(), docspkg.DocumentListFilter{Limit: limit, Status: status})
+		docs, err := svc.ListDocuments(c.UserContext(), docspkg.DocumentListFilter{Limit: limit, Status: status})
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
@@ -206,11 +207,11 @@ func createDocsDocument(svc *docspkg.Service, logger zerolog.Logger) fiber.Handl
 			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 		}
 
-		doc, err := svc.CreateDocument(c.Context(), req)
+		doc, err := svc.CreateDocument(c.UserContext(), req)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
-		logger.Info().Str("documentId", doc.ID.String()).Msg("gateway docs document created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("documentId", doc.ID.String()).Msg("gateway docs document created")
 		return c.Status(fiber.StatusCreated).JSON(doc)
 	}
 }
@@ -239,7 +240,7 @@ func updateDocsDocument(svc *docspkg.Service, logger zerolog.Logger) fiber.Handl
 			SignerStatuses: payload.Signers,
 		}
 
-		doc, err := svc.UpdateDocument(c.Context(), id, input)
+		doc, err := svc.UpdateDocument(c.UserContext(), id, input)
 		if err != nil {
 			status := fiber.StatusBadRequest
 			if errors.Is(err, docspkg.ErrDocumentNotFound) {
@@ -247,7 +248,7 @@ func updateDocsDocument(svc *docspkg.Service, logger zerolog.Logger) fiber.Handl
 			}
 			return fiber.NewError(status, err.Error())
 		}
-		logger.Info().Str("documentId", doc.ID.String()).Msg("gateway docs document updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("documentId", doc.ID.String()).Msg("gateway docs document updated")
 		return c.JSON(doc)
 	}
 }
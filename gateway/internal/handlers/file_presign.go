@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+
+	"asfppro/gateway/internal/files"
+	"asfppro/pkg/audit"
+	"asfppro/pkg/filecheck"
+	logpkg "asfppro/pkg/log"
+	"asfppro/pkg/s3"
+	"asfppro/pkg/tenant"
+)
+
+// maxPresignedUploadSize is the largest body S3 accepts in a single PUT.
+const maxPresignedUploadSize = 5 << 30
+
+type presignUploadRequest struct {
+	Folder      string `json:"folder"`
+	Filename    string `json:"filename"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"contentType"`
+	MD5         string `json:"md5"`
+}
+
+type confirmUploadRequest struct {
+	Key  string `json:"key"`
+	Size int64  `json:"size"`
+	MD5  string `json:"md5"`
+}
+
+// FilePresignUploadHandler issues a short-lived presigned PUT so large files go straight to
+// storage. The announced MD5 is part of the signature; the upload has to be confirmed
+// separately before the confirmation window ends or its object is removed.
+func FilePresignUploadHandler(client s3.Storage, registry *files.Service, ttl time.Duration, logger zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req presignUploadRequest
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+		}
+		if req.Size <= 0 || req.Size > maxPresignedUploadSize {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("size must be between 1 and %d bytes", int64(maxPresignedUploadSize)))
+		}
+		req.MD5 = strings.ToLower(strings.TrimSpace(req.MD5))
+
+		company, err := tenant.Require(c.UserContext())
+		if err != nil {
+			return fiber.ErrForbidden
+		}
+		key, err := companyObjectKey(company, req.Folder, req.Filename)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		if err := registry.Admit(key, req.Size, req.ContentType); err != nil {
+			return rejection(err)
+		}
+
+		put, err := client.PresignPut(c.UserContext(), key, req.ContentType, req.MD5, ttl)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		user, _ := currentUser(c)
+		if err := registry.TrackPresigned(c.UserContext(), user.ID, key, req.Size, req.MD5, put.Expires); err != nil {
+			logpkg.Ctx(c.UserContext(), logger).Error().Err(err).Str("key", key).Msg("track presigned upload")
+			return fiber.ErrInternalServerError
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"key":       key,
+			"method":    fiber.MethodPut,
+			"url":       put.URL,
+			"headers":   put.Headers,
+			"expiresAt": put.Expires,
+		})
+	}
+}
+
+// FileConfirmUploadHandler checks a presigned upload against the announced size and MD5 and
+// registers it as a new version of its file. Only uploads presigned within the confirmation
+// window are accepted; a mismatching object version is deleted unless it is registered.
+func FileConfirmUploadHandler(client s3.Storage, registry *files.Service, recorder *audit.Recorder, logger zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req confirmUploadRequest
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+		}
+		req.MD5 = strings.ToLower(strings.TrimSpace(req.MD5))
+
+		company, err := tenant.Require(c.UserContext())
+		if err != nil {
+			return fiber.ErrForbidden
+		}
+		if !companyOwnsKey(company, req.Key) {
+			return fiber.ErrNotFound
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+		defer cancel()
+
+		pending, err := registry.Presigned(ctx, req.Key, req.MD5)
+		if errors.Is(err, files.ErrPresignNotFound) {
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
+		if err != nil {
+			logpkg.Ctx(c.UserContext(), logger).Error().Err(err).Str("key", req.Key).Msg("find presigned upload")
+			return fiber.ErrInternalServerError
+		}
+
+		info, err := client.Stat(ctx, req.Key, "")
+		if errors.Is(err, s3.ErrNotFound) {
+			return fiber.NewError(fiber.StatusNotFound, "upload not found")
+		}
+		if err != nil {
+			logpkg.Ctx(c.UserContext(), logger).Error().Err(err).Str("key", req.Key).Msg("stat uploaded file")
+			return fiber.NewError(fiber.StatusBadGateway, "cannot check upload")
+		}
+
+		if problem := uploadMismatch(info, req); problem != "" {
+			// The latest version may be another upload that is already registered.
+			registered, err := registry.Registered(ctx, info.Key, info.VersionID)
+			if err != nil {
+				logpkg.Ctx(c.UserContext(), logger).Error().Err(err).Str("key", info.Key).Msg("check rejected upload")
+			} else if !registered {
+				if err := client.Remove(ctx, info.Key, info.VersionID); err != nil {
+					logpkg.Ctx(c.UserContext(), logger).Error().Err(err).Str("key", info.Key).Msg("remove rejected upload")
+				}
+			}
+			return fiber.NewError(fiber.StatusUnprocessableEntity, problem)
+		}
+
+		user, _ := currentUser(c)
+		registered, err := registry.RecordUpload(ctx, files.UploadInput{
+			Key:            info.Key,
+			StorageVersion: info.VersionID,
+			Size:           info.Size,
+			Checksum:       info.ETag,
+			ContentType:    info.ContentType,
+			UploadedBy:     user.ID,
+		})
+		if err != nil {
+			if rejected := rejection(err); rejected != nil {
+				return rejected
+			}
+			logpkg.Ctx(c.UserContext(), logger).Error().Err(err).Str("key", info.Key).Msg("register uploaded file")
+			return fiber.ErrInternalServerError
+		}
+		if err := registry.ConfirmPresigned(ctx, pending.ID); err != nil {
+			logpkg.Ctx(c.UserContext(), logger).Error().Err(err).Str("key", info.Key).Msg("confirm presigned upload")
+		}
+
+		if recorder != nil {
+			if user, ok := currentUser(c); ok {
+				payload := map[string]any{
+					"key":         info.Key,
+					"size":        info.Size,
+					"md5":         info.ETag,
+					"contentType": info.ContentType,
+					"version":     info.VersionID,
+					"presigned":   true,
+				}
+				if err := recorder.Record(ctx, audit.Entry{
+					ActorID:  user.ID,
+					Action:   "gateway.file.upload",
+					Entity:   "gateway.file",
+					EntityID: info.Key,
+					Payload:  payload,
+				}); err != nil {
+					logpkg.Ctx(c.UserContext(), logger).Error().Err(err).Msg("audit file upload")
+				}
+			}
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"id":          registered.ID,
+			"key":         info.Key,
+			"version":     info.VersionID,
+			"fileVersion": registered.CurrentVersion,
+			"size":        info.Size,
+			"contentType": info.ContentType,
+		})
+	}
+}
+
+// uploadMismatch explains how the stored object differs from the announced upload.
+func uploadMismatch(info s3.ObjectInfo, req confirmUploadRequest) string {
+	if info.Size != req.Size {
+		return fmt.Sprintf("size mismatch: announced %d, stored %d", req.Size, info.Size)
+	}
+	if !strings.EqualFold(info.ETag, req.MD5) {
+		return "checksum mismatch"
+	}
+	return ""
+}
+
+// companyObjectKey places the sanitized filename under folder in the company's prefix and
+// rejects folders that would leave it or clash with stored previews.
+func companyObjectKey(company, folder, filename string) (string, error) {
+	filename, err := filecheck.SanitizeFilename(filename)
+	if err != nil {
+		return "", err
+	}
+	folder = strings.Trim(strings.TrimSpace(folder), "/")
+	if folder == "" {
+		folder = "uploads"
+	}
+	for _, part := range strings.Split(folder, "/") {
+		if part == "" || part == "." || part == ".." || part == files.PreviewDir || strings.Contains(part, `\`) {
+			return "", fmt.Errorf("invalid folder")
+		}
+	}
+	return path.Join(company, folder, filename), nil
+}
+
+// rejection maps a failed upload check to an HTTP error; other errors yield nil.
+func rejection(err error) *fiber.Error {
+	switch {
+	case errors.Is(err, filecheck.ErrInvalidFilename):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, filecheck.ErrFolderNotAllowed):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case errors.Is(err, filecheck.ErrTooLarge):
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, filecheck.ErrTypeNotAllowed):
+		return fiber.NewError(fiber.StatusUnsupportedMediaType, err.Error())
+	case errors.Is(err, filecheck.ErrInfected):
+		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	}
+	return nil
+}
+
+// companyOwnsKey reports whether key is a clean path inside the company's prefix.
+func companyOwnsKey(company, key string) bool {
+	return key != "" && path.Clean(key) == key && strings.HasPrefix(key, company+"/")
+}
@@ -3,19 +3,27 @@ package handlers
 
 import (
 	"context"
+	"crypto/md5"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"path"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/rs/zerolog"
 
+	"asfppro/gateway/internal/files"
 	"asfppro/pkg/audit"
+	logpkg "asfppro/pkg/log"
 	"asfppro/pkg/s3"
+	"asfppro/pkg/tenant"
 )
 
-// FileUploadHandler handles file uploads to Ceph RGW.
-func FileUploadHandler(client *s3.Client, recorder *audit.Recorder, logger zerolog.Logger) fiber.Handler {
+// FileUploadHandler handles file uploads to Ceph RGW through the gateway. Files are checked
+// against the folder rules, stored under the active company's prefix and registered as a new
+// version of their file; large files should use presigned uploads instead.
+func FileUploadHandler(client s3.Storage, registry *files.Service, recorder *audit.Recorder, logger zerolog.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		fileHeader, err := c.FormFile("file")
 		if err != nil {
@@ -28,24 +36,53 @@ func FileUploadHandler(client *s3.Client, recorder *audit.Recorder, logger zerol
 		}
 		defer func() { _ = file.Close() }()
 
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
-
+		company, err := tenant.Require(c.UserContext())
+		if err != nil {
+			return fiber.ErrForbidden
+		}
 		folder := c.FormValue("folder", "uploads")
-		objectKey := path.Join(folder, fileHeader.Filename)
+		objectKey, err := companyObjectKey(company, folder, fileHeader.Filename)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		contentType := fileHeader.Header.Get("Content-Type")
+		if err := registry.Admit(objectKey, fileHeader.Size, contentType); err != nil {
+			return rejection(err)
+		}
 
-		url, version, err := client.Upload(ctx, folder, fileHeader.Filename, file, fileHeader.Size, fileHeader.Header.Get("Content-Type"))
+		ctx, cancel := context.WithTimeout(c.UserContext(), 30*time.Second)
+		defer cancel()
+
+		hash := md5.New()
+		url, version, err := client.Upload(ctx, path.Dir(objectKey), path.Base(objectKey), io.TeeReader(file, hash), fileHeader.Size, contentType)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("upload failed: %v", err))
 		}
 
+		user, _ := currentUser(c)
+		registered, err := registry.RecordUpload(ctx, files.UploadInput{
+			Key:            objectKey,
+			StorageVersion: version,
+			Size:           fileHeader.Size,
+			Checksum:       hex.EncodeToString(hash.Sum(nil)),
+			ContentType:    contentType,
+			UploadedBy:     user.ID,
+		})
+		if err != nil {
+			if rejected := rejection(err); rejected != nil {
+				return rejected
+			}
+			logpkg.Ctx(c.UserContext(), logger).Error().Err(err).Str("key", objectKey).Msg("register uploaded file")
+			return fiber.ErrInternalServerError
+		}
+
 		if recorder != nil {
 			if user, ok := currentUser(c); ok {
 				payload := map[string]any{
 					"filename":    fileHeader.Filename,
 					"folder":      folder,
 					"size":        fileHeader.Size,
-					"contentType": fileHeader.Header.Get("Content-Type"),
+					"contentType": contentType,
 					"url":         url,
 					"version":     version,
 				}
@@ -56,14 +93,17 @@ func FileUploadHandler(client *s3.Client, recorder *audit.Recorder, logger zerol
 					EntityID: objectKey,
 					Payload:  payload,
 				}); err != nil {
-					logger.Error().Err(err).Msg("audit file upload")
+					logpkg.Ctx(c.UserContext(), logger).Error().Err(err).Msg("audit file upload")
 				}
 			}
 		}
 
 		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-			"url":     url,
-			"version": version,
+			"id":          registered.ID,
+			"key":         objectKey,
+			"url":         url,
+			"version":     version,
+			"fileVersion": registered.CurrentVersion,
 		})
 	}
 }
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"asfppro/gateway/internal/files"
+	"asfppro/pkg/audit"
+	logpkg "asfppro/pkg/log"
+	"asfppro/pkg/s3"
+)
+
+// RegisterFileRoutes wires uploads, the file registry with its trash and retention controls,
+// and entity attachments. Attachments are guarded by the permissions of the entity they
+// belong to.
+func RegisterFileRoutes(router fiber.Router, svc *files.Service, storage s3.Storage, recorder *audit.Recorder, presignTTL time.Duration, guard func(resource, action string) fiber.Handler, logger zerolog.Logger) {
+	if router == nil || svc == nil {
+		return
+	}
+	if guard == nil {
+		guard = func(_, _ string) fiber.Handler {
+			return func(c *fiber.Ctx) error { return c.Next() }
+		}
+	}
+
+	router.Post("/api/v1/files", guard("core.file", "write"), FileUploadHandler(storage, svc, recorder, logger))
+	router.Post("/api/v1/files/presign", guard("core.file", "write"), FilePresignUploadHandler(storage, svc, presignTTL, logger))
+	router.Post("/api/v1/files/confirm", guard("core.file", "write"), FileConfirmUploadHandler(storage, svc, recorder, logger))
+	router.Get("/api/v1/files/trash", guard("core.file", "read"), listTrashHandler(svc, logger))
+	router.Get("/api/v1/files/:id", guard("core.file", "read"), getFileHandler(svc, logger))
+	router.Delete("/api/v1/files/:id", guard("core.file", "delete"), deleteFileHandler(svc, logger))
+	router.Post("/api/v1/files/:id/undelete", guard("core.file", "delete"), undeleteFileHandler(svc, logger))
+	router.Put("/api/v1/files/:id/document-type", guard("core.file", "write"), setDocumentTypeHandler(svc, logger))
+	router.Put("/api/v1/files/:id/legal-hold", guard("core.file", "legal_hold"), legalHoldHandler(svc, true, logger))
+	router.Delete("/api/v1/files/:id/legal-hold", guard("core.file", "legal_hold"), legalHoldHandler(svc, false, logger))
+	router.Get("/api/v1/files/:id/versions/:version/download", guard("core.file", "read"), downloadFileVersionHandler(svc, presignTTL, logger))
+	router.Get("/api/v1/files/:id/versions/:version/previews/:kind", guard("core.file", "read"), filePreviewHandler(svc, presignTTL, logger))
+	router.Post("/api/v1/files/:id/versions/:version/restore", guard("core.file", "write"), restoreFileVersionHandler(svc, logger))
+
+	router.Get("/api/v1/attachments/:entity/:entityId", entityGuard(guard, "read"), listAttachmentsHandler(svc, logger))
+	router.Put("/api/v1/attachments/:entity/:entityId/:fileId", entityGuard(guard, "write"), attachFileHandler(svc, logger))
+	router.Delete("/api/v1/attachments/:entity/:entityId/:fileId", entityGuard(guard, "write"), detachFileHandler(svc, logger))
+}
+
+// entityGuard checks action on the resource named by the :entity parameter.
+func entityGuard(guard func(resource, action string) fiber.Handler, action string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		entity := c.Params("entity")
+		if !files.Attachable(entity) {
+			return fiber.NewError(fiber.StatusNotFound, files.ErrUnknownEntity.Error())
+		}
+		return guard(entity, action)(c)
+	}
+}
+
+func getFileHandler(svc *files.Service, logger zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+		}
+		file, err := svc.Get(c.UserContext(), id)
+		if err != nil {
+			return fileError(c, logger, err)
+		}
+		versions, err := svc.Versions(c.UserContext(), id)
+		if err != nil {
+			return fileError(c, logger, err)
+		}
+		return c.JSON(fiber.Map{"file": file, "versions": versions})
+	}
+}
+
+func listTrashHandler(svc *files.Service, logger zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		items, err := svc.Trash(c.UserContext())
+		if err != nil {
+			return fileError(c, logger, err)
+		}
+		return c.JSON(fiber.Map{"items": items})
+	}
+}
+
+func deleteFileHandler(svc *files.Service, logger zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+		}
+		user, _ := currentUser(c)
+		if err := svc.Delete(c.UserContext(), user.ID, id); err != nil {
+			return fileError(c, logger, err)
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+func undeleteFileHandler(svc *files.Service, logger zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+		}
+		user, _ := currentUser(c)
+		file, err := svc.Undelete(c.UserContext(), user.ID, id)
+		if err != nil {
+			return fileError(c, logger, err)
+		}
+		return c.JSON(file)
+	}
+}
+
+func setDocumentTypeHandler(svc *files.Service, logger zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+		}
+		var req struct {
+			DocumentType string `json:"documentType"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+		}
+		user, _ := currentUser(c)
+		file, err := svc.SetDocumentType(c.UserContext(), user.ID, id, req.DocumentType)
+		if err != nil {
+			return fileError(c, logger, err)
+		}
+		return c.JSON(file)
+	}
+}
+
+func legalHoldHandler(svc *files.Service, on bool, logger zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+		}
+		user, _ := currentUser(c)
+		file, err := svc.SetLegalHold(c.UserContext(), user.ID, id, on)
+		if err != nil {
+			return fileError(c, logger, err)
+		}
+		return c.JSON(file)
+	}
+}
+
+func downloadFileVersionHandler(svc *files.Service, ttl time.Duration, logger zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, version, err := fileVersionParams(c)
+		if err != nil {
+			return err
+		}
+		url, expires, err := svc.DownloadURL(c.UserContext(), id, version, ttl)
+		if err != nil {
+			return fileError(c, logger, err)
+		}
+		return c.JSON(fiber.Map{"url": url, "expiresAt": expires})
+	}
+}
+
+func filePreviewHandler(svc *files.Service, ttl time.Duration, logger zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, version, err := fileVersionParams(c)
+		if err != nil {
+			return err
+		}
+		preview, url, expires, err := svc.PreviewURL(c.UserContext(), id, version, c.Params("kind"), ttl)
+		if err != nil {
+			return fileError(c, logger, err)
+		}
+		return c.JSON(fiber.Map{"url": url, "expiresAt": expires, "width": preview.Width, "height": preview.Height})
+	}
+}
+
+func restoreFileVersionHandler(svc *files.Service, logger zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, version, err := fileVersionParams(c)
+		if err != nil {
+			return err
+		}
+		user, _ := currentUser(c)
+		file, err := svc.Restore(c.UserContext(), user.ID, id, version)
+		if err != nil {
+			return fileError(c, logger, err)
+		}
+		return c.Status(fiber.StatusCreated).JSON(file)
+	}
+}
+
+func listAttachmentsHandler(svc *files.Service, logger zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		items, err := svc.Attachments(c.UserContext(), c.Params("entity"), c.Params("entityId"))
+		if err != nil {
+			return fileError(c, logger, err)
+		}
+		return c.JSON(fiber.Map{"items": items})
+	}
+}
+
+func attachFileHandler(svc *files.Service, logger zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("fileId"))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid file id")
+		}
+		user, _ := currentUser(c)
+		if err := svc.Attach(c.UserContext(), user.ID, id, c.Params("entity"), c.Params("entityId")); err != nil {
+			return fileError(c, logger, err)
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+func detachFileHandler(svc *files.Service, logger zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("fileId"))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid file id")
+		}
+		user, _ := currentUser(c)
+		if err := svc.Detach(c.UserContext(), user.ID, id, c.Params("entity"), c.Params("entityId")); err != nil {
+			return fileError(c, logger, err)
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+func fileVersionParams(c *fiber.Ctx) (uuid.UUID, int, error) {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, 0, fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	version, err := c.ParamsInt("version")
+	if err != nil || version <= 0 {
+		return uuid.Nil, 0, fiber.NewError(fiber.StatusBadRequest, "invalid version")
+	}
+	return id, version, nil
+}
+
+// fileError maps registry errors to HTTP statuses.
+func fileError(c *fiber.Ctx, logger zerolog.Logger, err error) error {
+	switch {
+	case errors.Is(err, files.ErrFileNotFound), errors.Is(err, files.ErrVersionNotFound),
+		errors.Is(err, files.ErrAttachmentNotFound), errors.Is(err, files.ErrUnknownEntity),
+		errors.Is(err, files.ErrPreviewNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, files.ErrVersionUnavailable), errors.Is(err, files.ErrRetained),
+		errors.Is(err, files.ErrLegalHold), errors.Is(err, s3.ErrObjectLocked):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, s3.ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, "file content not found")
+	case errors.Is(err, files.ErrEntityIDRequired):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	logpkg.Ctx(c.UserContext(), logger).Error().Err(err).Msg("file registry")
+	return fiber.ErrInternalServerError
+}
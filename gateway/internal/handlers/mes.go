@@ -11,6 +11,7 @@ import (
 	"github.com/rs/zerolog"
 
 	"asfppro/gateway/internal/mes"
+	logpkg "asfppro/pkg/log"
 )
 
 // RegisterMESRoutes wires minimal MES endpoints via gateway.
@@ -40,7 +41,7 @@ func RegisterMESRoutes(router fiber.Router, svc *mes.Service, guard func(resourc
 func listMesWorkCenters(svc *mes.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		limit := parseQueryLimit(c.Query("limit"), 50)
-		centers, err := svc.ListWorkCenters(c.Context(), limit)
+		centers, err := svc.ListWorkCenters(c.UserContext(), limit)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -59,7 +60,7 @@ func createMesWorkCenter(svc *mes.Service, logger zerolog.Logger) fiber.Handler
 			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 		}
 
-		wc, err := svc.CreateWorkCenter(c.Context(), mes.CreateWorkCenterInput{
+		wc, err := svc.CreateWorkCenter(c.UserContext(), mes.CreateWorkCenterInput{
 			Code:        req.Code,
 			Name:        req.Name,
 			Description: req.Description,
@@ -68,7 +69,7 @@ func createMesWorkCenter(svc *mes.Service, logger zerolog.Logger) fiber.Handler
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
 
-		logger.Info().Str("workCenterId", wc.ID.String()).Msg("gateway mes work center created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("workCenterId", wc.ID.String()).Msg("gateway mes work center created")
 		return c.Status(fiber.StatusCreated).JSON(wc)
 	}
 }
@@ -95,7 +96,7 @@ func updateMesWorkCenter(svc *mes.Service, logger zerolog.Logger) fiber.Handler
 			input.Description = &value
 		}
 
-		wc, err := svc.UpdateWorkCenter(c.Context(), id, input)
+		wc, err := svc.UpdateWorkCenter(c.UserContext(), id, input)
 		if err != nil {
 			status := fiber.StatusBadRequest
 			if errors.Is(err, mes.ErrWorkCenterNotFound) {
@@ -104,7 +105,7 @@ func updateMesWorkCenter(svc *mes.Service, logger zerolog.Logger) fiber.Handler
 			return fiber.NewError(status, err.Error())
 		}
 
-		logger.Info().Str("workCenterId", wc.ID.String()).Msg("gateway mes work center updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("workCenterId", wc.ID.String()).Msg("gateway mes work center updated")
 		return c.JSON(wc)
 	}
 }
@@ -112,7 +113,7 @@ func updateMesWorkCenter(svc *mes.Service, logger zerolog.Logger) fiber.Handler
 func listMesOperations(svc *mes.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		limit := parseQueryLimit(c.Query("limit"), 50)
-		ops, err := svc.ListOperations(c.Context(), limit)
+		ops, err := svc.ListOperations(c.UserContext(), limit)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -132,7 +133,7 @@ func createMesOperation(svc *mes.Service, logger zerolog.Logger) fiber.Handler {
 			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 		}
 
-		op, err := svc.CreateOperation(c.Context(), mes.CreateOperationInput{
+		op, err := svc.CreateOperation(c.UserContext(), mes.CreateOperationInput{
 			Code:        req.Code,
 			Name:        req.Name,
 			Description: req.Description,
@@ -142,7 +143,7 @@ func createMesOperation(svc *mes.Service, logger zerolog.Logger) fiber.Handler {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
 
-		logger.Info().Str("operationId", op.ID.String()).Msg("gateway mes operation created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("operationId", op.ID.String()).Msg("gateway mes operation created")
 		return c.Status(fiber.StatusCreated).JSON(op)
 	}
 }
@@ -187,7 +188,7 @@ func updateMesOperation(svc *mes.Service, logger zerolog.Logger) fiber.Handler {
 			}
 		}
 
-		op, err := svc.UpdateOperation(c.Context(), id, input)
+		op, err := svc.UpdateOperation(c.UserContext(), id, input)
 		if err != nil {
 			status := fiber.StatusBadRequest
 			if errors.Is(err, mes.ErrOperationNotFound) {
@@ -196,7 +197,7 @@ func updateMesOperation(svc *mes.Service, logger zerolog.Logger) fiber.Handler {
 			return fiber.NewError(status, err.Error())
 		}
 
-		logger.Info().Str("operationId", op.ID.String()).Msg("gateway mes operation updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("operationId", op.ID.String()).Msg("gateway mes operation updated")
 		return c.JSON(op)
 	}
 }
@@ -204,7 +205,7 @@ func updateMesOperation(svc *mes.Service, logger zerolog.Logger) fiber.Handler {
 func listMesRoutes(svc *mes.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		limit := parseQueryLimit(c.Query("limit"), 50)
-		routes, err := svc.ListRoutes(c.Context(), limit)
+		routes, err := svc.ListRoutes(c.UserContext(), limit)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -223,7 +224,7 @@ func createMesRoute(svc *mes.Service, logger zerolog.Logger) fiber.Handler {
 			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 		}
 
-		route, err := svc.CreateRoute(c.Context(), mes.CreateRouteInput{
+		route, err := svc.CreateRoute(c.UserContext(), mes.CreateRouteInput{
 			Code:        req.Code,
 			Name:        req.Name,
 			Description: req.Description,
@@ -232,7 +233,7 @@ func createMesRoute(svc *mes.Service, logger zerolog.Logger) fiber.Handler {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
 
-		logger.Info().Str("routeId", route.ID.String()).Msg("gateway mes route created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("routeId", route.ID.String()).Msg("gateway mes route created")
 		return c.Status(fiber.StatusCreated).JSON(route)
 	}
 }
@@ -259,7 +260,7 @@ func updateMesRoute(svc *mes.Service, logger zerolog.Logger) fiber.Handler {
 			input.Description = &value
 		}
 
-		route, err := svc.UpdateRoute(c.Context(), id, input)
+		route, err := svc.UpdateRoute(c.UserContext(), id, input)
 		if err != nil {
 			status := fiber.StatusBadRequest
 			if errors.Is(err, mes.ErrRouteNotFound) {
@@ -268,7 +269,7 @@ func updateMesRoute(svc *mes.Service, logger zerolog.Logger) fiber.Handler {
 			return fiber.NewError(status, err.Error())
 		}
 
-		logger.Info().Str("routeId", route.ID.String()).Msg("gateway mes route updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("routeId", route.ID.String()).Msg("gateway mes route updated")
 		return c.JSON(route)
 	}
 }
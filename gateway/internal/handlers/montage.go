@@ -10,6 +10,7 @@ import (
 	"github.com/rs/zerolog"
 
 	"asfppro/gateway/internal/montage"
+	logpkg "asfppro/pkg/log"
 )
 
 // RegisterMontageRoutes регистрирует минимальные маршруты монтажа.
@@ -39,7 +40,7 @@ func RegisterMontageRoutes(router fiber.Router, svc *montage.Service, guard func
 func listMontageCrews(svc *montage.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		limit := parseQueryLimit(c.Query("limit"), 50)
-		crews, err := svc.ListCrews(c.Context(), limit)
+		crews, err := svc.ListCrews(c.UserContext(), limit)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -58,7 +59,7 @@ func createMontageCrew(svc *montage.Service, logger zerolog.Logger) fiber.Handle
 			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 		}
 
-		crew, err := svc.CreateCrew(c.Context(), montage.CreateCrewInput{
+		crew, err := svc.CreateCrew(c.UserContext(), montage.CreateCrewInput{
 			Code:           req.Code,
 			Name:           req.Name,
 			Specialization: req.Specialization,
@@ -67,7 +68,7 @@ func createMontageCrew(svc *montage.Service, logger zerolog.Logger) fiber.Handle
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
 
-		logger.Info().Str("crewId", crew.ID.String()).Msg("gateway montage crew created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("crewId", crew.ID.String()).Msg("gateway montage crew created")
 		return c.Status(fiber.StatusCreated).JSON(crew)
 	}
 }
@@ -94,7 +95,7 @@ func updateMontageCrew(svc *montage.Service, logger zerolog.Logger) fiber.Handle
 			input.Specialization = &trim
 		}
 
-		crew, err := svc.UpdateCrew(c.Context(), id, input)
+		crew, err := svc.UpdateCrew(c.UserContext(), id, input)
 		if err != nil {
 			status := fiber.StatusBadRequest
 			if errors.Is(err, montage.ErrCrewNotFound) {
@@ -103,7 +104,7 @@ func updateMontageCrew(svc *montage.Service, logger zerolog.Logger) fiber.Handle
 			return fiber.NewError(status, err.Error())
 		}
 
-		logger.Info().Str("crewId", crew.ID.String()).Msg("gateway montage crew updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("crewId", crew.ID.String()).Msg("gateway montage crew updated")
 		return c.JSON(crew)
 	}
 }
@@ -111,7 +112,7 @@ func updateMontageCrew(svc *montage.Service, logger zerolog.Logger) fiber.Handle
 func listMontageVehicles(svc *montage.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		limit := parseQueryLimit(c.Query("limit"), 50)
-		vehicles, err := svc.ListVehicles(c.Context(), limit)
+		vehicles, err := svc.ListVehicles(c.UserContext(), limit)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -131,7 +132,7 @@ func createMontageVehicle(svc *montage.Service, logger zerolog.Logger) fiber.Han
 			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 		}
 
-		vehicle, err := svc.CreateVehicle(c.Context(), montage.CreateVehicleInput{
+		vehicle, err := svc.CreateVehicle(c.UserContext(), montage.CreateVehicleInput{
 			Code:     req.Code,
 			Name:     req.Name,
 			Plate:    req.Plate,
@@ -141,7 +142,7 @@ func createMontageVehicle(svc *montage.Service, logger zerolog.Logger) fiber.Han
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
 
-		logger.Info().Str("vehicleId", vehicle.ID.String()).Msg("gateway montage vehicle created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("vehicleId", vehicle.ID.String()).Msg("gateway montage vehicle created")
 		return c.Status(fiber.StatusCreated).JSON(vehicle)
 	}
 }
@@ -172,7 +173,7 @@ func updateMontageVehicle(svc *montage.Service, logger zerolog.Logger) fiber.Han
 			input.Capacity = &trim
 		}
 
-		vehicle, err := svc.UpdateVehicle(c.Context(), id, input)
+		vehicle, err := svc.UpdateVehicle(c.UserContext(), id, input)
 		if err != nil {
 			status := fiber.StatusBadRequest
 			if errors.Is(err, montage.ErrVehicleNotFound) {
@@ -181,7 +182,7 @@ func updateMontageVehicle(svc *montage.Service, logger zerolog.Logger) fiber.Han
 			return fiber.NewError(status, err.Error())
 		}
 
-		logger.Info().Str("vehicleId", vehicle.ID.String()).Msg("gateway montage vehicle updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("vehicleId", vehicle.ID.String()).Msg("gateway montage vehicle updated")
 		return c.JSON(vehicle)
 	}
 }
@@ -189,7 +190,7 @@ func updateMontageVehicle(svc *montage.Service, logger zerolog.Logger) fiber.Han
 func listMontageTasks(svc *montage.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		limit := parseQueryLimit(c.Query("limit"), 50)
-		tasks, err := svc.ListTasks(c.Context(), limit)
+		tasks, err := svc.ListTasks(c.UserContext(), limit)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -204,12 +205,12 @@ func createMontageTask(svc *montage.Service, logger zerolog.Logger) fiber.Handle
 			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 		}
 
-		task, err := svc.CreateTask(c.Context(), req)
+		task, err := svc.CreateTask(c.UserContext(), req)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
 
-		logger.Info().Str("taskId", task.ID.String()).Msg("gateway montage task created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("taskId", task.ID.String()).Msg("gateway montage task created")
 		return c.Status(fiber.StatusCreated).JSON(task)
 	}
 }
@@ -252,7 +253,7 @@ func updateMontageTask(svc *montage.Service, logger zerolog.Logger) fiber.Handle
 			input.Location = &trim
 		}
 
-		task, err := svc.UpdateTask(c.Context(), id, input)
+		task, err := svc.UpdateTask(c.UserContext(), id, input)
 		if err != nil {
 			status := fiber.StatusBadRequest
 			if errors.Is(err, montage.ErrTaskNotFound) {
@@ -261,7 +262,7 @@ func updateMontageTask(svc *montage.Service, logger zerolog.Logger) fiber.Handle
 			return fiber.NewError(status, err.Error())
 		}
 
-		logger.Info().Str("taskId", task.ID.String()).Msg("gateway montage task updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("taskId", task.ID.String()).Msg("gateway montage task updated")
 		return c.JSON(task)
 	}
 }
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+
+	"asfppro/pkg/audit"
+	logpkg "asfppro/pkg/log"
+	"asfppro/pkg/queue"
+)
+
+// RegisterQueueRoutes wires queue administration endpoints under authenticated router.
+func RegisterQueueRoutes(router fiber.Router, admin queue.Admin, recorder *audit.Recorder, guard func(resource, action string) fiber.Handler, logger zerolog.Logger) {
+	if router == nil || admin == nil {
+		return
+	}
+	if guard == nil {
+		guard = func(_, _ string) fiber.Handler {
+			return func(c *fiber.Ctx) error { return c.Next() }
+		}
+	}
+
+	group := router.Group("/api/v1/queue")
+	group.Get("/tubes", guard("core.queue", "read"), queueStatsHandler(admin, logger))
+	group.Get("/tubes/:tube/buried", guard("core.queue", "read"), queueBuriedHandler(admin, logger))
+	group.Post("/tubes/:tube/buried/:id/requeue", guard("core.queue", "write"), queueRequeueHandler(admin, recorder, logger))
+	group.Delete("/tubes/:tube/buried/:id", guard("core.queue", "write"), queueDeleteHandler(admin, recorder, logger))
+	group.Post("/tubes/:tube/pause", guard("core.queue", "write"), queuePauseHandler(admin, recorder, logger, true))
+	group.Post("/tubes/:tube/resume", guard("core.queue", "write"), queuePauseHandler(admin, recorder, logger, false))
+}
+
+func queueStatsHandler(admin queue.Admin, logger zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+		defer cancel()
+
+		stats, err := admin.Stats(ctx)
+		if err != nil {
+			return queueError(c, err, logger, "queue stats")
+		}
+		return c.JSON(fiber.Map{"items": stats})
+	}
+}
+
+func queueBuriedHandler(admin queue.Admin, logger zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var afterID uint64
+		if raw := c.Query("afterId"); raw != "" {
+			value, err := strconv.ParseUint(raw, 10, 64)
+			if err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "invalid afterId")
+			}
+			afterID = value
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+		defer cancel()
+
+		items, err := admin.DeadLetters(ctx, c.Params("tube"), afterID, c.QueryInt("limit", 20))
+		if err != nil {
+			return queueError(c, err, logger, "peek buried messages")
+		}
+		return c.JSON(fiber.Map{"items": items})
+	}
+}
+
+func queueRequeueHandler(admin queue.Admin, recorder *audit.Recorder, logger zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid message id")
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+		defer cancel()
+
+		tube := c.Params("tube")
+		if err := admin.Requeue(ctx, tube, id); err != nil {
+			return queueError(c, err, logger, "requeue message")
+		}
+		recordQueueAction(ctx, c, recorder, logger, "gateway.queue.requeue", tube, map[string]any{"messageId": id})
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+func queueDeleteHandler(admin queue.Admin, recorder *audit.Recorder, logger zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid message id")
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+		defer cancel()
+
+		tube := c.Params("tube")
+		if err := admin.Delete(ctx, tube, id); err != nil {
+			return queueError(c, err, logger, "delete message")
+		}
+		recordQueueAction(ctx, c, recorder, logger, "gateway.queue.delete", tube, map[string]any{"messageId": id})
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+func queuePauseHandler(admin queue.Admin, recorder *audit.Recorder, logger zerolog.Logger, pause bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+		defer cancel()
+
+		tube := c.Params("tube")
+		action := "gateway.queue.resume"
+		op := admin.Resume
+		if pause {
+			action = "gateway.queue.pause"
+			op = admin.Pause
+		}
+
+		if err := op(ctx, tube); err != nil {
+			return queueError(c, err, logger, action)
+		}
+		recordQueueAction(ctx, c, recorder, logger, action, tube, nil)
+		return c.JSON(fiber.Map{"tube": tube, "paused": pause})
+	}
+}
+
+func queueError(c *fiber.Ctx, err error, logger zerolog.Logger, msg string) error {
+	switch {
+	case errors.Is(err, queue.ErrTubeNotFound), errors.Is(err, queue.ErrMessageNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	default:
+		logpkg.Ctx(c.UserContext(), logger).Error().Err(err).Msg(msg)
+		return fiber.NewError(fiber.StatusBadGateway, "queue unavailable")
+	}
+}
+
+func recordQueueAction(ctx context.Context, c *fiber.Ctx, recorder *audit.Recorder, logger zerolog.Logger, action, tube string, payload map[string]any) {
+	if recorder == nil {
+		return
+	}
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	if err := recorder.Record(ctx, audit.Entry{
+		ActorID:  user.ID,
+		Action:   action,
+		Entity:   "gateway.queue",
+		EntityID: tube,
+		Payload:  payload,
+	}); err != nil {
+		logpkg.Ctx(c.UserContext(), logger).Error().Err(err).Str("action", action).Msg("audit queue action")
+	}
+}
@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+
+	"asfppro/pkg/s3"
+)
+
+// LocalStorage serves the presigned URLs of the filesystem storage backend mounted at
+// s3.FSRoute. The URLs carry their own signature, so the route sits outside authentication.
+func LocalStorage(storage *s3.FS) fiber.Handler {
+	return adaptor.HTTPHandler(http.StripPrefix(s3.FSRoute, storage))
+}
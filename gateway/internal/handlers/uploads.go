@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"asfppro/gateway/internal/files"
+	"asfppro/gateway/internal/uploads"
+	logpkg "asfppro/pkg/log"
+	"asfppro/pkg/tenant"
+)
+
+// UploadsPath is the tus endpoint; its requests stream chunk bodies.
+const UploadsPath = "/api/v1/uploads"
+
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,creation-with-upload,expiration,termination"
+	tusBodyType   = "application/offset+octet-stream"
+)
+
+// RegisterUploadRoutes wires the tus 1.0 resumable upload protocol under /api/v1/uploads.
+// Finished uploads are registered in the file registry like any other upload.
+func RegisterUploadRoutes(router fiber.Router, svc *uploads.Service, registry *files.Service, maxSize int64, guard func(resource, action string) fiber.Handler, logger zerolog.Logger) {
+	if router == nil || svc == nil || registry == nil {
+		return
+	}
+	if guard == nil {
+		guard = func(_, _ string) fiber.Handler {
+			return func(c *fiber.Ctx) error { return c.Next() }
+		}
+	}
+	if maxSize <= 0 || maxSize > uploads.MaxLength {
+		maxSize = uploads.MaxLength
+	}
+
+	group := router.Group(UploadsPath, tusHeaders())
+	group.Options("", tusOptionsHandler(maxSize))
+	group.Post("", guard("core.file", "write"), tusCreateHandler(svc, registry, maxSize, logger))
+	group.Head("/:id", guard("core.file", "write"), tusHeadHandler(svc, logger))
+	group.Patch("/:id", guard("core.file", "write"), tusPatchHandler(svc, logger))
+	group.Delete("/:id", guard("core.file", "write"), tusDeleteHandler(svc, logger))
+}
+
+// tusHeaders checks the protocol version of every request except OPTIONS and marks responses.
+func tusHeaders() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set("Tus-Resumable", tusVersion)
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		if c.Method() != fiber.MethodOptions && c.Get("Tus-Resumable") != tusVersion {
+			c.Set("Tus-Version", tusVersion)
+			return fiber.NewError(fiber.StatusPreconditionFailed, "unsupported tus version")
+		}
+		return c.Next()
+	}
+}
+
+func tusOptionsHandler(maxSize int64) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set("Tus-Version", tusVersion)
+		c.Set("Tus-Extension", tusExtensions)
+		c.Set("Tus-Max-Size", strconv.FormatInt(maxSize, 10))
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+func tusCreateHandler(svc *uploads.Service, registry *files.Service, maxSize int64, logger zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		length, err := strconv.ParseInt(c.Get("Upload-Length"), 10, 64)
+		if err != nil || length < 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "Upload-Length is required")
+		}
+		if length > maxSize {
+			return fiber.NewError(fiber.StatusRequestEntityTooLarge, fmt.Sprintf("upload exceeds %d bytes", maxSize))
+		}
+		raw := c.Get("Upload-Metadata")
+		meta, err := parseUploadMetadata(raw)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+
+		company, err := tenant.Require(c.UserContext())
+		if err != nil {
+			return fiber.ErrForbidden
+		}
+		key, err := companyObjectKey(company, meta["folder"], meta["filename"])
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		contentType := meta["filetype"]
+		if contentType == "" {
+			contentType = meta["contentType"]
+		}
+		if err := registry.Admit(key, length, contentType); err != nil {
+			return rejection(err)
+		}
+
+		user, _ := currentUser(c)
+		u, err := svc.Create(c.UserContext(), uploads.CreateInput{
+			Key:         key,
+			Filename:    path.Base(key),
+			ContentType: contentType,
+			Metadata:    raw,
+			Length:      length,
+			CreatedBy:   user.ID,
+		})
+		if err != nil {
+			return uploadError(c, logger, err)
+		}
+		c.Location(c.BaseURL() + UploadsPath + "/" + u.ID.String())
+		setUploadHeaders(c, u)
+
+		// creation-with-upload: the request may already carry the first chunk.
+		if c.Get(fiber.HeaderContentType) == tusBodyType {
+			if u, err = svc.Write(c.UserContext(), user.ID, u.ID, 0, requestBody(c)); err != nil {
+				return uploadError(c, logger, err)
+			}
+			setUploadHeaders(c, u)
+		}
+		return c.SendStatus(fiber.StatusCreated)
+	}
+}
+
+func tusHeadHandler(svc *uploads.Service, logger zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return fiber.ErrNotFound
+		}
+		u, err := svc.Get(c.UserContext(), id)
+		if err != nil {
+			return uploadError(c, logger, err)
+		}
+		setUploadHeaders(c, u)
+		if u.Metadata != "" {
+			c.Set("Upload-Metadata", u.Metadata)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	}
+}
+
+func tusPatchHandler(svc *uploads.Service, logger zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Get(fiber.HeaderContentType) != tusBodyType {
+			return fiber.NewError(fiber.StatusUnsupportedMediaType, "Content-Type must be "+tusBodyType)
+		}
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return fiber.ErrNotFound
+		}
+		offset, err := strconv.ParseInt(c.Get("Upload-Offset"), 10, 64)
+		if err != nil || offset < 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "Upload-Offset is required")
+		}
+		if size := c.Request().Header.ContentLength(); size > 0 {
+			u, err := svc.Get(c.UserContext(), id)
+			if err != nil {
+				return uploadError(c, logger, err)
+			}
+			if offset+int64(size) > u.Length {
+				return fiber.NewError(fiber.StatusRequestEntityTooLarge, uploads.ErrTooLarge.Error())
+			}
+		}
+
+		user, _ := currentUser(c)
+		u, err := svc.Write(c.UserContext(), user.ID, id, offset, requestBody(c))
+		if err != nil {
+			return uploadError(c, logger, err)
+		}
+		setUploadHeaders(c, u)
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+func tusDeleteHandler(svc *uploads.Service, logger zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return fiber.ErrNotFound
+		}
+		if err := svc.Terminate(c.UserContext(), id); err != nil {
+			return uploadError(c, logger, err)
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+// setUploadHeaders reports the upload state; X-File-ID appears once the file is registered.
+func setUploadHeaders(c *fiber.Ctx, u uploads.Upload) {
+	c.Set("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	c.Set("Upload-Length", strconv.FormatInt(u.Length, 10))
+	if u.Completed() {
+		if u.FileID != nil {
+			c.Set("X-File-ID", u.FileID.String())
+		}
+		return
+	}
+	c.Set("Upload-Expires", u.ExpiresAt.Format(http.TimeFormat))
+}
+
+// requestBody returns the request body as a stream, so large chunks are not buffered.
+func requestBody(c *fiber.Ctx) io.Reader {
+	if stream := c.Context().RequestBodyStream(); stream != nil {
+		return stream
+	}
+	return bytes.NewReader(c.Body())
+}
+
+// parseUploadMetadata decodes the tus Upload-Metadata header: comma-separated pairs of a key
+// and an optional base64 value.
+func parseUploadMetadata(header string) (map[string]string, error) {
+	meta := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, encoded, _ := strings.Cut(pair, " ")
+		value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("invalid Upload-Metadata value for %q", key)
+		}
+		meta[key] = string(value)
+	}
+	return meta, nil
+}
+
+// uploadError maps upload session errors to tus status codes.
+func uploadError(c *fiber.Ctx, logger zerolog.Logger, err error) error {
+	if rejected := rejection(err); rejected != nil {
+		return rejected
+	}
+	switch {
+	case errors.Is(err, uploads.ErrUploadNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, uploads.ErrUploadExpired):
+		return fiber.NewError(fiber.StatusGone, err.Error())
+	case errors.Is(err, uploads.ErrOffsetMismatch):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, uploads.ErrUploadLocked):
+		return fiber.NewError(fiber.StatusLocked, err.Error())
+	case errors.Is(err, uploads.ErrTooLarge):
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge, err.Error())
+	}
+	logpkg.Ctx(c.UserContext(), logger).Error().Err(err).Msg("resumable upload")
+	return fiber.ErrInternalServerError
+}
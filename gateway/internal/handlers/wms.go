@@ -8,6 +8,7 @@ import (
 	"github.com/rs/zerolog"
 
 	"asfppro/gateway/internal/wms"
+	logpkg "asfppro/pkg/log"
 )
 
 // RegisterWMSRoutes wires minimal WMS endpoints.
@@ -60,7 +61,7 @@ type stockRequest struct {
 func listCatalogHandler(svc *wms.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		typeParam := c.Params("type")
-		nodes, err := svc.ListCatalogNodes(c.Context(), typeParam)
+		nodes, err := svc.ListCatalogNodes(c.UserContext(), typeParam)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
@@ -83,12 +84,12 @@ func createCatalogHandler(svc *wms.Service, logger zerolog.Logger) fiber.Handler
 			Description: req.Description,
 		}
 
-		node, err := svc.CreateCatalogNode(c.Context(), extractActorID(c), input)
+		node, err := svc.CreateCatalogNode(c.UserContext(), extractActorID(c), input)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
 
-		logger.Info().Str("catalogType", node.Type).Str("code", node.Code).Msg("wms catalog created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("catalogType", node.Type).Str("code", node.Code).Msg("wms catalog created")
 		return c.Status(fiber.StatusCreated).JSON(node)
 	}
 }
@@ -111,12 +112,12 @@ func updateCatalogHandler(svc *wms.Service, logger zerolog.Logger) fiber.Handler
 			IsActive:    req.IsActive,
 		}
 
-		node, err := svc.UpdateCatalogNode(c.Context(), extractActorID(c), id, input)
+		node, err := svc.UpdateCatalogNode(c.UserContext(), extractActorID(c), id, input)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
 
-		logger.Info().Str("catalogId", id.String()).Msg("wms catalog updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("catalogId", id.String()).Msg("wms catalog updated")
 		return c.JSON(node)
 	}
 }
@@ -128,11 +129,11 @@ func deleteCatalogHandler(svc *wms.Service, logger zerolog.Logger) fiber.Handler
 			return fiber.NewError(fiber.StatusBadRequest, "invalid catalog id")
 		}
 
-		if err := svc.DeleteCatalogNode(c.Context(), extractActorID(c), id); err != nil {
+		if err := svc.DeleteCatalogNode(c.UserContext(), extractActorID(c), id); err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
 
-		logger.Info().Str("catalogId", id.String()).Msg("wms catalog deleted")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("catalogId", id.String()).Msg("wms catalog deleted")
 		return c.SendStatus(fiber.StatusNoContent)
 	}
 }
@@ -140,7 +141,7 @@ func deleteCatalogHandler(svc *wms.Service, logger zerolog.Logger) fiber.Handler
 func listWarehousesHandler(svc *wms.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		subject, _ := currentSubject(c)
-		warehouses, err := svc.ListWarehouses(c.Context(), subject)
+		warehouses, err := svc.ListWarehouses(c.UserContext(), subject)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -164,7 +165,7 @@ func createWarehouseHandler(svc *wms.Service, logger zerolog.Logger) fiber.Handl
 		}
 
 		subject, _ := currentSubject(c)
-		wh, err := svc.CreateWarehouse(c.Context(), extractActorID(c), subject, input)
+		wh, err := svc.CreateWarehouse(c.UserContext(), extractActorID(c), subject, input)
 		if err != nil {
 			if errors.Is(err, wms.ErrForbidden) {
 				return fiber.ErrForbidden
@@ -172,7 +173,7 @@ func createWarehouseHandler(svc *wms.Service, logger zerolog.Logger) fiber.Handl
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
 
-		logger.Info().Str("warehouseId", wh.ID.String()).Msg("wms warehouse created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("warehouseId", wh.ID.String()).Msg("wms warehouse created")
 		return c.Status(fiber.StatusCreated).JSON(wh)
 	}
 }
@@ -204,7 +205,7 @@ func updateWarehouseHandler(svc *wms.Service, logger zerolog.Logger) fiber.Handl
 		}
 
 		subject, _ := currentSubject(c)
-		wh, err := svc.UpdateWarehouse(c.Context(), extractActorID(c), subject, id, input)
+		wh, err := svc.UpdateWarehouse(c.UserContext(), extractActorID(c), subject, id, input)
 		if err != nil {
 			if errors.Is(err, wms.ErrForbidden) {
 				return fiber.ErrForbidden
@@ -212,7 +213,7 @@ func updateWarehouseHandler(svc *wms.Service, logger zerolog.Logger) fiber.Handl
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
 
-		logger.Info().Str("warehouseId", id.String()).Msg("wms warehouse updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("warehouseId", id.String()).Msg("wms warehouse updated")
 		return c.JSON(wh)
 	}
 }
@@ -225,14 +226,14 @@ func deleteWarehouseHandler(svc *wms.Service, logger zerolog.Logger) fiber.Handl
 		}
 
 		subject, _ := currentSubject(c)
-		if err := svc.DeleteWarehouse(c.Context(), extractActorID(c), subject, id); err != nil {
+		if err := svc.DeleteWarehouse(c.UserContext(), extractActorID(c), subject, id); err != nil {
 			if errors.Is(err, wms.ErrForbidden) {
 				return fiber.ErrForbidden
 			}
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
 
-		logger.Info().Str("warehouseId", id.String()).Msg("wms warehouse deleted")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("warehouseId", id.String()).Msg("wms warehouse deleted")
 		return c.SendStatus(fiber.StatusNoContent)
 	}
 }
@@ -243,7 +244,7 @@ func listStockHandler(svc *wms.Service) fiber.Handler {
 		warehouse := c.Query("warehouse")
 
 		subject, _ := currentSubject(c)
-		records, err := svc.ListStock(c.Context(), subject, sku, warehouse)
+		records, err := svc.ListStock(c.UserContext(), subject, sku, warehouse)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
@@ -267,7 +268,7 @@ func upsertStockHandler(svc *wms.Service, logger zerolog.Logger) fiber.Handler {
 		}
 
 		subject, _ := currentSubject(c)
-		stock, err := svc.UpsertStock(c.Context(), extractActorID(c), subject, input)
+		stock, err := svc.UpsertStock(c.UserContext(), extractActorID(c), subject, input)
 		if err != nil {
 			if errors.Is(err, wms.ErrForbidden) {
 				return fiber.ErrForbidden
@@ -275,7 +276,7 @@ func upsertStockHandler(svc *wms.Service, logger zerolog.Logger) fiber.Handler {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
 
-		logger.Info().Str("sku", stock.SKU).Str("warehouse", stock.Warehouse).
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("sku", stock.SKU).Str("warehouse", stock.Warehouse).
 			Float64("quantity", stock.Quantity).Msg("wms stock upserted")
 		return c.Status(fiber.StatusCreated).JSON(stock)
 	}
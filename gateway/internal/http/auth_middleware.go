@@ -10,6 +10,7 @@ import (
 	"github.com/rs/zerolog"
 
 	"asfppro/gateway/internal/auth"
+	logpkg "asfppro/pkg/log"
 )
 
 const userContextKey = "auth.user"
@@ -24,18 +25,19 @@ func authMiddleware(authSvc *auth.Service, logger zerolog.Logger) fiber.Handler
 			return fiber.ErrUnauthorized
 		}
 
-		user, err := authSvc.Authenticate(c.Context(), username, password)
+		user, err := authSvc.Authenticate(c.UserContext(), username, password)
 		if err != nil {
 			c.Response().Header.Set("WWW-Authenticate", realmHeader)
 			if errors.Is(err, auth.ErrInactive) {
-				logger.Warn().Str("email", username).Msg("inactive user attempted to authenticate")
+				logpkg.Ctx(c.UserContext(), logger).Warn().Str("email", username).Msg("inactive user attempted to authenticate")
 				return fiber.ErrForbidden
 			}
-			logger.Warn().Str("email", username).Msg("authentication failed")
+			logpkg.Ctx(c.UserContext(), logger).Warn().Str("email", username).Msg("authentication failed")
 			return fiber.ErrUnauthorized
 		}
 
 		c.Locals(userContextKey, user)
+		logpkg.SetUser(c, user.ID.String())
 		return c.Next()
 	}
 }
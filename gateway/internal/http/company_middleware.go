@@ -0,0 +1,35 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+
+	logpkg "asfppro/pkg/log"
+	"asfppro/pkg/tenant"
+)
+
+// companyMiddleware resolves the company the request acts for from the X-Company header and
+// the user's grants, and stores it in the request context for company-scoped repositories.
+func companyMiddleware(logger zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, ok := CurrentUser(c)
+		if !ok {
+			return fiber.ErrUnauthorized
+		}
+
+		company, err := tenant.Resolve(c.Get(tenant.Header), user.Companies)
+		if err != nil {
+			logpkg.Ctx(c.UserContext(), logger).Warn().Err(err).Str("requested", c.Get(tenant.Header)).Msg("company not resolved")
+			if errors.Is(err, tenant.ErrNotGranted) {
+				return fiber.NewError(fiber.StatusForbidden, err.Error())
+			}
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+
+		c.SetUserContext(tenant.WithCompany(c.UserContext(), company))
+		c.Set(tenant.Header, company)
+		return c.Next()
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"asfppro/gateway/internal/auth"
 	corepkg "asfppro/gateway/internal/core"
+	logpkg "asfppro/pkg/log"
 )
 
 // PermissionGuard returns middleware that enforces resource/action access for authenticated users.
@@ -24,9 +25,9 @@ func permissionGuard(coreSvc *corepkg.Service, logger zerolog.Logger) Permission
 			}
 
 			subject := toSubject(user)
-			allowed, err := coreSvc.CheckPermission(c.Context(), subject, resource, action)
+			allowed, err := coreSvc.CheckPermission(c.UserContext(), subject, resource, action)
 			if err != nil {
-				logger.Error().Err(err).Str("resource", resource).Str("action", action).Msg("permission check failed")
+				logpkg.Ctx(c.UserContext(), logger).Error().Err(err).Str("resource", resource).Str("action", action).Msg("permission check failed")
 				return fiber.ErrInternalServerError
 			}
 			if !allowed {
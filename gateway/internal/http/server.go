@@ -1,19 +1,17 @@
 package http
 
 import (
-	"context"
+	"bytes"
 	"fmt"
 	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	ch "github.com/ClickHouse/clickhouse-go/v2"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/recover"
-	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog"
+	"github.com/valyala/fasthttp"
 
 	analyticspkg "asfppro/gateway/internal/analytics"
 	"asfppro/gateway/internal/auth"
@@ -21,13 +19,24 @@ import (
 	corepkg "asfppro/gateway/internal/core"
 	crmpkg "asfppro/gateway/internal/crm"
 	docpkg "asfppro/gateway/internal/docs"
+	filespkg "asfppro/gateway/internal/files"
 	"asfppro/gateway/internal/handlers"
 	mespkg "asfppro/gateway/internal/mes"
 	montagepkg "asfppro/gateway/internal/montage"
+	uploadspkg "asfppro/gateway/internal/uploads"
 	wmspkg "asfppro/gateway/internal/wms"
 	"asfppro/pkg/audit"
 	"asfppro/pkg/config"
+	"asfppro/pkg/db"
+	"asfppro/pkg/filecheck"
+	"asfppro/pkg/lifecycle"
+	logpkg "asfppro/pkg/log"
+	"asfppro/pkg/metrics"
+	"asfppro/pkg/preview"
+	"asfppro/pkg/queue"
+	"asfppro/pkg/retention"
 	"asfppro/pkg/s3"
+	"asfppro/pkg/tracing"
 )
 
 // Server wraps Fiber app with graceful shutdown.
@@ -35,11 +44,20 @@ type Server struct {
 	app      *fiber.App
 	cfg      config.AppConfig
 	logger   zerolog.Logger
-	shutdown chan os.Signal
+	uploads  *uploadspkg.Service
+	previews *filespkg.PreviewWorker
+	purger   *filespkg.Purger
+}
+
+// Previews connects the file registry to the preview worker; the zero value disables
+// previews.
+type Previews struct {
+	Publisher queue.Publisher
+	Consumer  queue.Consumer
 }
 
 // NewServer constructs HTTP server with base middlewares.
-func NewServer(cfg config.AppConfig, logger zerolog.Logger, pool *pgxpool.Pool, storage *s3.Client, clickhouse ch.Conn, authSvc *auth.Service, auditor *audit.Recorder) (*Server, error) {
+func NewServer(cfg config.AppConfig, logger zerolog.Logger, pool *pgxpool.Pool, router *db.Router, storage s3.Storage, clickhouse ch.Conn, authSvc *auth.Service, auditor *audit.Recorder, queueAdmin queue.Admin, previews Previews) (*Server, error) {
 	openapi, err := readOpenAPI("gateway/docs/openapi/openapi.json", "GATEWAY_OPENAPI_PATH")
 	if err != nil {
 		return nil, fmt.Errorf("load openapi: %w", err)
@@ -49,36 +67,67 @@ func NewServer(cfg config.AppConfig, logger zerolog.Logger, pool *pgxpool.Pool,
 		AppName:      cfg.AppName,
 		ReadTimeout:  cfg.RequestTimeout,
 		WriteTimeout: cfg.RequestTimeout,
+		// Resumable uploads read chunks as a stream instead of buffering them whole.
+		StreamRequestBody: true,
 	})
+	app.Server().HeaderReceived = chunkReadTimeout(cfg.ChunkTimeout)
 
 	app.Use(recover.New())
-	app.Use(loggerMiddleware(logger))
-	app.Use(requestIDMiddleware)
+	app.Use(tracing.Middleware())
+	if cfg.MetricsEnable {
+		app.Use(metrics.Middleware(metrics.Default()))
+	}
+	app.Use(logpkg.Middleware(logger, uint32(cfg.LogSampleBurst)))
 
 	app.Get("/", handlers.Home())
 	app.Get("/health", handlers.Health())
-	app.Get("/ready", handlers.Ready(pool, storage, clickhouse))
+	app.Get("/ready", handlers.Ready(router, storage, clickhouse))
 	app.Get("/openapi.json", handlers.OpenAPI(openapi))
+	if local, ok := storage.(*s3.FS); ok {
+		app.All(s3.FSRoute+"/*", handlers.LocalStorage(local))
+	}
 
-	protected := app.Group("", authMiddleware(authSvc, logger))
+	protected := app.Group("", authMiddleware(authSvc, logger), companyMiddleware(logger))
 	coreRepo := corepkg.NewRepository(pool)
 	coreSvc := corepkg.NewService(coreRepo, auditor, logger)
 	guardian := permissionGuard(coreSvc, logger)
 	protected.Get("/api/v1/auth/me", handlers.CurrentUserHandler())
-	wmsRepo := wmspkg.NewRepository(pool)
+	wmsRepo := wmspkg.NewRepository(pool).WithReader(router)
 	wmsSvc := wmspkg.NewService(wmsRepo, auditor, logger)
-	mesRepo := mespkg.NewRepository(pool)
+	mesRepo := mespkg.NewRepository(pool).WithReader(router)
 	mesSvc := mespkg.NewService(mesRepo, logger)
-	montageRepo := montagepkg.NewRepository(pool)
+	montageRepo := montagepkg.NewRepository(pool).WithReader(router)
 	montageSvc := montagepkg.NewService(montageRepo, logger)
-	docRepo := docpkg.NewRepository(pool)
+	docRepo := docpkg.NewRepository(pool).WithReader(router)
 	docSvc := docpkg.NewService(docRepo, logger)
-	bpmRepo := bpmpkg.NewRepository(pool)
+	bpmRepo := bpmpkg.NewRepository(pool).WithReader(router)
 	bpmSvc := bpmpkg.NewService(bpmRepo, logger)
-	crmRepo := crmpkg.NewRepository(pool)
+	crmRepo := crmpkg.NewRepository(pool).WithReader(router)
 	crmSvc := crmpkg.NewService(crmRepo, auditor, logger)
 	analyticsRepo := analyticspkg.NewRepository(clickhouse)
 	analyticsSvc := analyticspkg.NewService(analyticsRepo, logger)
+	filesRepo := filespkg.NewRepository(pool)
+	uploadRules, err := filecheck.ParsePolicy(cfg.UploadRules)
+	if err != nil {
+		return nil, fmt.Errorf("parse upload rules: %w", err)
+	}
+	checks := filespkg.Checks{Policy: uploadRules, ScanLimit: int64(cfg.ScanMaxSize)}
+	if cfg.ClamAVAddr != "" {
+		checks.Scanner = filecheck.NewClamAV(cfg.ClamAVAddr, cfg.ClamAVTimeout)
+	}
+	retentionRules, err := retention.ParsePolicy(cfg.FileRetention)
+	if err != nil {
+		return nil, fmt.Errorf("parse file retention: %w", err)
+	}
+	filesSvc := filespkg.NewService(filesRepo, storage, checks, auditor, logger).WithRetention(retentionRules, cfg.FileTrashPeriod)
+	var previewWorker *filespkg.PreviewWorker
+	if previews.Publisher != nil && previews.Consumer != nil {
+		filesSvc.WithPublisher(previews.Publisher)
+		renderer := preview.NewRenderer(cfg.PreviewCommand, 2*time.Minute)
+		previewWorker = filespkg.NewPreviewWorker(previews.Consumer, filesRepo, storage, renderer, int64(cfg.PreviewMaxSize), logger)
+	}
+	uploadsRepo := uploadspkg.NewRepository(pool)
+	uploadsSvc := uploadspkg.NewService(uploadsRepo, storage, filesSvc, auditor, cfg.UploadExpiry, logger)
 	handlers.RegisterCoreRoutes(protected, coreSvc, guardian, logger)
 	handlers.RegisterWMSRoutes(protected, wmsSvc, guardian, logger)
 	handlers.RegisterMESRoutes(protected, mesSvc, guardian, logger)
@@ -87,68 +136,43 @@ func NewServer(cfg config.AppConfig, logger zerolog.Logger, pool *pgxpool.Pool,
 	handlers.RegisterBPMRoutes(protected, bpmSvc, guardian, logger)
 	handlers.RegisterCRMRoutes(protected, crmSvc, guardian, logger)
 	handlers.RegisterAnalyticsRoutes(protected, analyticsSvc, guardian)
-	protected.Post("/api/v1/files", guardian("core.file", "write"), handlers.FileUploadHandler(storage, auditor, logger))
+	handlers.RegisterQueueRoutes(protected, queueAdmin, auditor, guardian, logger)
+	handlers.RegisterFileRoutes(protected, filesSvc, storage, auditor, cfg.S3PresignTTL, guardian, logger)
+	handlers.RegisterUploadRoutes(protected, uploadsSvc, filesSvc, int64(cfg.UploadMaxSize), guardian, logger)
 	protected.Get("/api/v1/audit", guardian("core.audit", "read"), handlers.AuditListHandler(auditor, logger))
 
 	return &Server{
 		app:      app,
 		cfg:      cfg,
 		logger:   logger,
-		shutdown: make(chan os.Signal, 1),
+		uploads:  uploadsSvc,
+		previews: previewWorker,
+		purger:   filespkg.NewPurger(filesRepo, storage, auditor, logger),
 	}, nil
 }
 
-// Run starts HTTP server and handles graceful shutdown.
-func (s *Server) Run() error {
-	addr := fmt.Sprintf(":%s", s.cfg.HTTPPort)
-	go func() {
-		signal.Notify(s.shutdown, syscall.SIGINT, syscall.SIGTERM)
-		sig := <-s.shutdown
-		s.logger.Info().Str("signal", sig.String()).Msg("shutdown signal received")
-		tx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
-		defer cancel()
-		if err := s.app.Shutdown(); err != nil {
-			s.logger.Error().Err(err).Msg("server shutdown error")
-		}
-		s.clean(tx)
-	}()
-
-	s.logger.Info().Str("addr", addr).Msg("gateway listening")
-	if err := s.app.Listen(addr); err != nil {
-		return fmt.Errorf("start fiber: %w", err)
+// Register adds the server, the expired upload reaper, the trash purger and the preview
+// worker to lc; on shutdown it drains in-flight requests.
+func (s *Server) Register(lc *lifecycle.Manager) {
+	lc.Go("upload reaper", s.uploads.Run)
+	lc.Go("trash purger", s.purger.Run)
+	if s.previews != nil {
+		lc.Go("preview worker", s.previews.Run)
 	}
-	return nil
-}
-
-func (s *Server) clean(ctx context.Context) {
-	// reserved for future cleanup tasks
-	<-ctx.Done()
+	lc.Fiber("http", s.app, fmt.Sprintf(":%s", s.cfg.HTTPPort))
 }
 
-func loggerMiddleware(logger zerolog.Logger) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		start := time.Now()
-		if err := c.Next(); err != nil {
-			return err
+// chunkReadTimeout replaces the read timeout of tus requests carrying chunks (PATCH and
+// creation-with-upload POST): the deadline also covers the streamed body, and a chunk may
+// take longer than REQUEST_TIMEOUT to arrive.
+func chunkReadTimeout(timeout time.Duration) func(*fasthttp.RequestHeader) fasthttp.RequestConfig {
+	prefix := []byte(handlers.UploadsPath)
+	return func(h *fasthttp.RequestHeader) fasthttp.RequestConfig {
+		if (h.IsPatch() || h.IsPost()) && bytes.HasPrefix(h.RequestURI(), prefix) {
+			return fasthttp.RequestConfig{ReadTimeout: timeout}
 		}
-		logger.Info().Fields(map[string]any{
-			"path":      c.Path(),
-			"method":    c.Method(),
-			"status":    c.Response().StatusCode(),
-			"duration":  time.Since(start).String(),
-			"requestId": c.GetRespHeader("X-Request-ID"),
-		}).Msg("http")
-		return nil
-	}
-}
-
-func requestIDMiddleware(c *fiber.Ctx) error {
-	id := c.Get("X-Request-ID")
-	if id == "" {
-		id = uuid.NewString()
+		return fasthttp.RequestConfig{}
 	}
-	c.Set("X-Request-ID", id)
-	return c.Next()
 }
 
 func readOpenAPI(defaultPath, envVar string) ([]byte, error) {
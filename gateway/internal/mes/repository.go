@@ -9,6 +9,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"asfppro/pkg/db"
 )
 
 var (
@@ -23,18 +25,25 @@ var (
 // Repository provides MES data access for gateway.
 type Repository struct {
 	pool *pgxpool.Pool
+	read db.Querier
 }
 
 // NewRepository constructs repository.
 func NewRepository(pool *pgxpool.Pool) *Repository {
-	return &Repository{pool: pool}
+	return &Repository{pool: pool, read: pool}
+}
+
+// WithReader routes list queries through read, typically a db.Router sending them to replicas.
+func (r *Repository) WithReader(read db.Querier) *Repository {
+	r.read = read
+	return r
 }
 
 // ListWorkCenters returns work center rows.
 func (r *Repository) ListWorkCenters(ctx context.Context, limit int) ([]WorkCenter, error) {
 	const query = `SELECT id, code, name, description, created_at, updated_at FROM mes.work_center ORDER BY created_at DESC LIMIT $1`
 
-	rows, err := r.pool.Query(ctx, query, limit)
+	rows, err := r.read.Query(ctx, query, limit)
 	if err != nil {
 		return nil, fmt.Errorf("list work centers: %w", err)
 	}
@@ -117,7 +126,7 @@ func (r *Repository) getWorkCenter(ctx context.Context, id uuid.UUID) (WorkCente
 func (r *Repository) ListOperations(ctx context.Context, limit int) ([]Operation, error) {
 	const query = `SELECT id, code, name, description, default_duration_minutes, created_at, updated_at FROM mes.operation ORDER BY created_at DESC LIMIT $1`
 
-	rows, err := r.pool.Query(ctx, query, limit)
+	rows, err := r.read.Query(ctx, query, limit)
 	if err != nil {
 		return nil, fmt.Errorf("list operations: %w", err)
 	}
@@ -205,7 +214,7 @@ func (r *Repository) getOperation(ctx context.Context, id uuid.UUID) (Operation,
 func (r *Repository) ListRoutes(ctx context.Context, limit int) ([]Route, error) {
 	const query = `SELECT id, code, name, description, created_at, updated_at FROM mes.route ORDER BY created_at DESC LIMIT $1`
 
-	rows, err := r.pool.Query(ctx, query, limit)
+	rows, err := r.read.Query(ctx, query, limit)
 	if err != nil {
 		return nil, fmt.Errorf("list routes: %w", err)
 	}
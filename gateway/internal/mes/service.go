@@ -7,6 +7,8 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
+
+	logpkg "asfppro/pkg/log"
 )
 
 // Service orchestrates MES operations for gateway.
@@ -49,7 +51,7 @@ func (s *Service) CreateWorkCenter(ctx context.Context, input CreateWorkCenterIn
 		return WorkCenter{}, err
 	}
 
-	s.logger.Info().Str("workCenterId", wc.ID.String()).Msg("mes work center created via gateway")
+	logpkg.Ctx(ctx, s.logger).Info().Str("workCenterId", wc.ID.String()).Msg("mes work center created via gateway")
 	return wc, nil
 }
 
@@ -72,7 +74,7 @@ func (s *Service) UpdateWorkCenter(ctx context.Context, id uuid.UUID, input Upda
 		return WorkCenter{}, err
 	}
 
-	s.logger.Info().Str("workCenterId", wc.ID.String()).Msg("mes work center updated via gateway")
+	logpkg.Ctx(ctx, s.logger).Info().Str("workCenterId", wc.ID.String()).Msg("mes work center updated via gateway")
 	return wc, nil
 }
 
@@ -108,7 +110,7 @@ func (s *Service) CreateOperation(ctx context.Context, input CreateOperationInpu
 		return Operation{}, err
 	}
 
-	s.logger.Info().Str("operationId", op.ID.String()).Msg("mes operation created via gateway")
+	logpkg.Ctx(ctx, s.logger).Info().Str("operationId", op.ID.String()).Msg("mes operation created via gateway")
 	return op, nil
 }
 
@@ -134,7 +136,7 @@ func (s *Service) UpdateOperation(ctx context.Context, id uuid.UUID, input Updat
 		return Operation{}, err
 	}
 
-	s.logger.Info().Str("operationId", op.ID.String()).Msg("mes operation updated via gateway")
+	logpkg.Ctx(ctx, s.logger).Info().Str("operationId", op.ID.String()).Msg("mes operation updated via gateway")
 	return op, nil
 }
 
@@ -167,7 +169,7 @@ func (s *Service) CreateRoute(ctx context.Context, input CreateRouteInput) (Rout
 		return Route{}, err
 	}
 
-	s.logger.Info().Str("routeId", rt.ID.String()).Msg("mes route created via gateway")
+	logpkg.Ctx(ctx, s.logger).Info().Str("routeId", rt.ID.String()).Msg("mes route created via gateway")
 	return rt, nil
 }
 
@@ -190,6 +192,6 @@ func (s *Service) UpdateRoute(ctx context.Context, id uuid.UUID, input UpdateRou
 		return Route{}, err
 	}
 
-	s.logger.Info().Str("routeId", rt.ID.String()).Msg("mes route updated via gateway")
+	logpkg.Ctx(ctx, s.logger).Info().Str("routeId", rt.ID.String()).Msg("mes route updated via gateway")
 	return rt, nil
 }
@@ -10,6 +10,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"asfppro/pkg/db"
 )
 
 var (
@@ -24,17 +26,24 @@ var (
 // Repository предоставляет доступ к таблицам Montage.
 type Repository struct {
 	pool *pgxpool.Pool
+	read db.Querier
 }
 
 // NewRepository создаёт репозиторий.
 func NewRepository(pool *pgxpool.Pool) *Repository {
-	return &Repository{pool: pool}
+	return &Repository{pool: pool, read: pool}
+}
+
+// WithReader направляет запросы списков через read — обычно db.Router, читающий с реплик.
+func (r *Repository) WithReader(read db.Querier) *Repository {
+	r.read = read
+	return r
 }
 
 // ListCrews возвращает список бригад.
 func (r *Repository) ListCrews(ctx context.Context, limit int) ([]Crew, error) {
 	const query = `SELECT id, code, name, specialization, created_at, updated_at FROM montage.crew ORDER BY created_at DESC LIMIT $1`
-	rows, err := r.pool.Query(ctx, query, limit)
+	rows, err := r.read.Query(ctx, query, limit)
 	if err != nil {
 		return nil, fmt.Errorf("list crews: %w", err)
 	}
@@ -115,7 +124,7 @@ func (r *Repository) getCrew(ctx context.Context, id uuid.UUID) (Crew, error) {
 // ListVehicles возвращает транспорт.
 func (r *Repository) ListVehicles(ctx context.Context, limit int) ([]Vehicle, error) {
 	const query = `SELECT id, code, name, plate, capacity, created_at, updated_at FROM montage.vehicle ORDER BY created_at DESC LIMIT $1`
-	rows, err := r.pool.Query(ctx, query, limit)
+	rows, err := r.read.Query(ctx, query, limit)
 	if err != nil {
 		return nil, fmt.Errorf("list vehicles: %w", err)
 	}
@@ -203,7 +212,7 @@ func (r *Repository) ListTasks(ctx context.Context, limit int) ([]Task, error) {
 	const query = `SELECT id, code, title, status, COALESCE(crew_id::text, ''), COALESCE(vehicle_id::text, ''), COALESCE(scheduled_at, '1970-01-01'), location, created_at, updated_at
 FROM montage.task ORDER BY created_at DESC LIMIT $1`
 
-	rows, err := r.pool.Query(ctx, query, limit)
+	rows, err := r.read.Query(ctx, query, limit)
 	if err != nil {
 		return nil, fmt.Errorf("list tasks: %w", err)
 	}
@@ -8,6 +8,8 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
+
+	logpkg "asfppro/pkg/log"
 )
 
 // Service инкапсулирует бизнес-логику Montage внутри gateway.
@@ -50,7 +52,7 @@ func (s *Service) CreateCrew(ctx context.Context, input CreateCrewInput) (Crew,
 		return Crew{}, err
 	}
 
-	s.logger.Info().Str("crewId", crew.ID.String()).Msg("montage crew created via gateway")
+	logpkg.Ctx(ctx, s.logger).Info().Str("crewId", crew.ID.String()).Msg("montage crew created via gateway")
 	return crew, nil
 }
 
@@ -73,7 +75,7 @@ func (s *Service) UpdateCrew(ctx context.Context, id uuid.UUID, input UpdateCrew
 		return Crew{}, err
 	}
 
-	s.logger.Info().Str("crewId", crew.ID.String()).Msg("montage crew updated via gateway")
+	logpkg.Ctx(ctx, s.logger).Info().Str("crewId", crew.ID.String()).Msg("montage crew updated via gateway")
 	return crew, nil
 }
 
@@ -107,7 +109,7 @@ func (s *Service) CreateVehicle(ctx context.Context, input CreateVehicleInput) (
 		return Vehicle{}, err
 	}
 
-	s.logger.Info().Str("vehicleId", vehicle.ID.String()).Msg("montage vehicle created via gateway")
+	logpkg.Ctx(ctx, s.logger).Info().Str("vehicleId", vehicle.ID.String()).Msg("montage vehicle created via gateway")
 	return vehicle, nil
 }
 
@@ -134,7 +136,7 @@ func (s *Service) UpdateVehicle(ctx context.Context, id uuid.UUID, input UpdateV
 		return Vehicle{}, err
 	}
 
-	s.logger.Info().Str("vehicleId", vehicle.ID.String()).Msg("montage vehicle updated via gateway")
+	logpkg.Ctx(ctx, s.logger).Info().Str("vehicleId", vehicle.ID.String()).Msg("montage vehicle updated via gateway")
 	return vehicle, nil
 }
 
@@ -176,7 +178,7 @@ func (s *Service) CreateTask(ctx context.Context, input CreateTaskInput) (Task,
 		return Task{}, err
 	}
 
-	s.logger.Info().Str("taskId", task.ID.String()).Msg("montage task created via gateway")
+	logpkg.Ctx(ctx, s.logger).Info().Str("taskId", task.ID.String()).Msg("montage task created via gateway")
 	return task, nil
 }
 
@@ -219,6 +221,6 @@ func (s *Service) UpdateTask(ctx context.Context, id uuid.UUID, input UpdateTask
 		return Task{}, err
 	}
 
-	s.logger.Info().Str("taskId", task.ID.String()).Msg("montage task updated via gateway")
+	logpkg.Ctx(ctx, s.logger).Info().Str("taskId", task.ID.String()).Msg("montage task updated via gateway")
 	return task, nil
 }
@@ -0,0 +1,12 @@
+package uploads
+
+import "errors"
+
+var (
+	ErrUploadNotFound = errors.New("upload not found")
+	ErrUploadExpired  = errors.New("upload expired")
+	// ErrUploadLocked is returned while another request writes to the upload.
+	ErrUploadLocked   = errors.New("upload is locked by another request")
+	ErrOffsetMismatch = errors.New("upload offset mismatch")
+	ErrTooLarge       = errors.New("upload exceeds its length")
+)
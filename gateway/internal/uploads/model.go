@@ -0,0 +1,53 @@
+package uploads
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"asfppro/pkg/s3"
+)
+
+// Upload is a resumable upload session.
+type Upload struct {
+	ID              uuid.UUID
+	Company         string
+	Key             string
+	Filename        string
+	ContentType     string
+	Metadata        string
+	Length          int64
+	Offset          int64
+	StorageUploadID string
+	Parts           []s3.Part
+	Pending         []byte
+	HashState       []byte
+	FileID          *uuid.UUID
+	CreatedBy       uuid.UUID
+	ExpiresAt       time.Time
+	CompletedAt     *time.Time
+	// StorageVersion is set once the multipart upload is assembled into an object.
+	StorageVersion *string
+	// LockToken identifies the request that acquired the session.
+	LockToken uuid.UUID
+}
+
+// Assembled reports whether the parts were already combined into the stored object.
+func (u Upload) Assembled() bool {
+	return u.StorageVersion != nil
+}
+
+// Completed reports whether all bytes arrived and the file was registered.
+func (u Upload) Completed() bool {
+	return u.CompletedAt != nil
+}
+
+// CreateInput describes a new upload session.
+type CreateInput struct {
+	Key         string
+	Filename    string
+	ContentType string
+	Metadata    string
+	Length      int64
+	CreatedBy   uuid.UUID
+}
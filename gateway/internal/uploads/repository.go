@@ -0,0 +1,224 @@
+package uploads
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"asfppro/pkg/tenant"
+)
+
+const uploadColumns = `id, company_code, object_key, filename, content_type, metadata, length, upload_offset, storage_upload_id,
+parts, pending, hash_state, file_id, COALESCE(created_by, '00000000-0000-0000-0000-000000000000'), expires_at, completed_at,
+storage_version`
+
+// Repository stores upload sessions. Queries are limited to the active company in ctx, except
+// the expiry ones used by the reaper.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository creates repository.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// Create inserts a session.
+func (r *Repository) Create(ctx context.Context, u Upload) (Upload, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return Upload{}, err
+	}
+	const query = `INSERT INTO core.uploads (id, company_code, object_key, filename, content_type, metadata, length, storage_upload_id, created_by, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING ` + uploadColumns
+
+	created, err := scanUpload(r.pool.QueryRow(ctx, query, u.ID, company, u.Key, u.Filename, u.ContentType, u.Metadata, u.Length,
+		u.StorageUploadID, nullableUser(u.CreatedBy), u.ExpiresAt))
+	if err != nil {
+		return Upload{}, fmt.Errorf("insert upload: %w", err)
+	}
+	return created, nil
+}
+
+// Get returns a session or ErrUploadNotFound.
+func (r *Repository) Get(ctx context.Context, id uuid.UUID) (Upload, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return Upload{}, err
+	}
+	const query = `SELECT ` + uploadColumns + ` FROM core.uploads WHERE id = $1 AND company_code = $2`
+
+	u, err := scanUpload(r.pool.QueryRow(ctx, query, id, company))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Upload{}, ErrUploadNotFound
+	}
+	if err != nil {
+		return Upload{}, fmt.Errorf("get upload: %w", err)
+	}
+	return u, nil
+}
+
+// Acquire locks a session for lease so only one request writes to it at a time. The
+// returned session carries the lock token that SaveProgress and Release require.
+func (r *Repository) Acquire(ctx context.Context, id uuid.UUID, lease time.Duration) (Upload, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return Upload{}, err
+	}
+	const query = `UPDATE core.uploads SET locked_until = NOW() + make_interval(secs => $3), lock_token = $4
+WHERE id = $1 AND company_code = $2 AND (locked_until IS NULL OR locked_until < NOW())
+RETURNING ` + uploadColumns
+
+	token := uuid.New()
+	u, err := scanUpload(r.pool.QueryRow(ctx, query, id, company, lease.Seconds(), token))
+	if errors.Is(err, pgx.ErrNoRows) {
+		if _, getErr := r.Get(ctx, id); getErr != nil {
+			return Upload{}, getErr
+		}
+		return Upload{}, ErrUploadLocked
+	}
+	if err != nil {
+		return Upload{}, fmt.Errorf("lock upload: %w", err)
+	}
+	u.LockToken = token
+	return u, nil
+}
+
+// Release unlocks a session unless another request took it over.
+func (r *Repository) Release(ctx context.Context, id, token uuid.UUID) error {
+	const query = `UPDATE core.uploads SET locked_until = NULL, lock_token = NULL WHERE id = $1 AND lock_token = $2`
+
+	if _, err := r.pool.Exec(ctx, query, id, token); err != nil {
+		return fmt.Errorf("unlock upload: %w", err)
+	}
+	return nil
+}
+
+// SaveProgress stores the offset, parts, pending tail and hash state of a session held with
+// u.LockToken and renews its lease. It returns ErrUploadLocked once another request took the
+// session over.
+func (r *Repository) SaveProgress(ctx context.Context, u Upload, lease time.Duration) error {
+	parts, err := json.Marshal(u.Parts)
+	if err != nil {
+		return fmt.Errorf("encode parts: %w", err)
+	}
+	const query = `UPDATE core.uploads
+SET upload_offset = $2, parts = $3, pending = $4, hash_state = $5, expires_at = $6, updated_at = NOW(),
+    locked_until = NOW() + make_interval(secs => $8)
+WHERE id = $1 AND lock_token = $7`
+
+	tag, err := r.pool.Exec(ctx, query, u.ID, u.Offset, parts, u.Pending, u.HashState, u.ExpiresAt, u.LockToken, lease.Seconds())
+	if err != nil {
+		return fmt.Errorf("save upload progress: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUploadLocked
+	}
+	return nil
+}
+
+// SaveAssembled records the storage version of the assembled object of a session held with
+// token.
+func (r *Repository) SaveAssembled(ctx context.Context, id, token uuid.UUID, storageVersion string) error {
+	const query = `UPDATE core.uploads SET storage_version = $3, pending = ''::bytea, updated_at = NOW()
+WHERE id = $1 AND lock_token = $2`
+
+	tag, err := r.pool.Exec(ctx, query, id, token, storageVersion)
+	if err != nil {
+		return fmt.Errorf("save assembled upload: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUploadLocked
+	}
+	return nil
+}
+
+// Complete marks a session finished and links the registered file.
+func (r *Repository) Complete(ctx context.Context, id, fileID uuid.UUID) (time.Time, error) {
+	const query = `UPDATE core.uploads
+SET completed_at = NOW(), file_id = $2, pending = ''::bytea, updated_at = NOW()
+WHERE id = $1
+RETURNING completed_at`
+
+	var completedAt time.Time
+	if err := r.pool.QueryRow(ctx, query, id, fileID).Scan(&completedAt); err != nil {
+		return time.Time{}, fmt.Errorf("complete upload: %w", err)
+	}
+	return completedAt.UTC(), nil
+}
+
+// Delete removes a session.
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM core.uploads WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("delete upload: %w", err)
+	}
+	return nil
+}
+
+// ListExpired returns unlocked sessions of every company whose expiry passed.
+func (r *Repository) ListExpired(ctx context.Context, limit int) ([]Upload, error) {
+	const query = `SELECT ` + uploadColumns + ` FROM core.uploads
+WHERE expires_at < NOW() AND (locked_until IS NULL OR locked_until < NOW())
+ORDER BY expires_at
+LIMIT $1`
+
+	rows, err := r.pool.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list expired uploads: %w", err)
+	}
+	defer rows.Close()
+
+	var items []Upload
+	for rows.Next() {
+		u, err := scanUpload(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan upload: %w", err)
+		}
+		items = append(items, u)
+	}
+	return items, rows.Err()
+}
+
+// DeleteExpired removes a session if it is still expired and unlocked.
+func (r *Repository) DeleteExpired(ctx context.Context, id uuid.UUID) (bool, error) {
+	const query = `DELETE FROM core.uploads
+WHERE id = $1 AND expires_at < NOW() AND (locked_until IS NULL OR locked_until < NOW())`
+
+	tag, err := r.pool.Exec(ctx, query, id)
+	if err != nil {
+		return false, fmt.Errorf("delete expired upload: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func scanUpload(row pgx.Row) (Upload, error) {
+	var (
+		u     Upload
+		parts []byte
+	)
+	if err := row.Scan(&u.ID, &u.Company, &u.Key, &u.Filename, &u.ContentType, &u.Metadata, &u.Length, &u.Offset, &u.StorageUploadID,
+		&parts, &u.Pending, &u.HashState, &u.FileID, &u.CreatedBy, &u.ExpiresAt, &u.CompletedAt,
+		&u.StorageVersion); err != nil {
+		return Upload{}, err
+	}
+	if err := json.Unmarshal(parts, &u.Parts); err != nil {
+		return Upload{}, fmt.Errorf("decode parts: %w", err)
+	}
+	u.ExpiresAt = u.ExpiresAt.UTC()
+	return u, nil
+}
+
+// nullableUser stores the zero UUID as NULL, e.g. for uploads made by API tokens.
+func nullableUser(id uuid.UUID) any {
+	if id == uuid.Nil {
+		return nil
+	}
+	return id
+}
@@ -0,0 +1,354 @@
+package uploads
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"asfppro/gateway/internal/files"
+	"asfppro/pkg/audit"
+	"asfppro/pkg/filecheck"
+	logpkg "asfppro/pkg/log"
+	"asfppro/pkg/s3"
+	"asfppro/pkg/tenant"
+)
+
+const (
+	// partSize is how much of a request body is buffered before it is sent as one S3 part.
+	partSize = 16 << 20
+	// lockLease bounds how long a crashed request keeps an upload locked.
+	lockLease = 5 * time.Minute
+	// reapInterval is how often abandoned uploads are cleaned up.
+	reapInterval = 10 * time.Minute
+	reapBatch    = 100
+)
+
+// MaxLength is the largest upload the part layout can hold.
+const MaxLength = int64(partSize) * s3.MaxParts
+
+// Storage receives upload data; s3.Storage implements it.
+type Storage interface {
+	CreateMultipart(ctx context.Context, key, contentType string) (string, error)
+	UploadPart(ctx context.Context, key, uploadID string, number int, r io.Reader, size int64) (s3.Part, error)
+	CompleteMultipart(ctx context.Context, key, uploadID string, parts []s3.Part) (string, error)
+	AbortMultipart(ctx context.Context, key, uploadID string) error
+	Remove(ctx context.Context, key, versionID string) error
+}
+
+// Store persists upload sessions; Repository implements it. Writes to a session require the
+// lock token returned by Acquire.
+type Store interface {
+	Create(ctx context.Context, u Upload) (Upload, error)
+	Get(ctx context.Context, id uuid.UUID) (Upload, error)
+	Acquire(ctx context.Context, id uuid.UUID, lease time.Duration) (Upload, error)
+	Release(ctx context.Context, id, token uuid.UUID) error
+	SaveProgress(ctx context.Context, u Upload, lease time.Duration) error
+	SaveAssembled(ctx context.Context, id, token uuid.UUID, storageVersion string) error
+	Complete(ctx context.Context, id, fileID uuid.UUID) (time.Time, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	ListExpired(ctx context.Context, limit int) ([]Upload, error)
+	DeleteExpired(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+// Service implements resumable uploads on top of S3 multipart uploads.
+type Service struct {
+	repo     Store
+	storage  Storage
+	registry *files.Service
+	auditor  *audit.Recorder
+	expiry   time.Duration
+	logger   zerolog.Logger
+}
+
+// NewService builds uploads service. Sessions without progress for expiry are discarded.
+func NewService(repo Store, storage Storage, registry *files.Service, auditor *audit.Recorder, expiry time.Duration, logger zerolog.Logger) *Service {
+	return &Service{
+		repo:     repo,
+		storage:  storage,
+		registry: registry,
+		auditor:  auditor,
+		expiry:   expiry,
+		logger:   logger.With().Str("component", "uploads.service").Logger(),
+	}
+}
+
+// Create starts an upload session and its multipart upload.
+func (s *Service) Create(ctx context.Context, input CreateInput) (Upload, error) {
+	if input.Length < 0 || input.Length > MaxLength {
+		return Upload{}, ErrTooLarge
+	}
+	storageID, err := s.storage.CreateMultipart(ctx, input.Key, input.ContentType)
+	if err != nil {
+		return Upload{}, err
+	}
+	u, err := s.repo.Create(ctx, Upload{
+		ID:              uuid.New(),
+		Key:             input.Key,
+		Filename:        input.Filename,
+		ContentType:     input.ContentType,
+		Metadata:        input.Metadata,
+		Length:          input.Length,
+		StorageUploadID: storageID,
+		CreatedBy:       input.CreatedBy,
+		ExpiresAt:       time.Now().Add(s.expiry).UTC(),
+	})
+	if err != nil {
+		if abortErr := s.storage.AbortMultipart(context.WithoutCancel(ctx), input.Key, storageID); abortErr != nil {
+			logpkg.Ctx(ctx, s.logger).Error().Err(abortErr).Str("key", input.Key).Msg("abort multipart upload")
+		}
+		return Upload{}, err
+	}
+	return u, nil
+}
+
+// Get returns an upload session; unfinished sessions past their expiry are reported as expired.
+func (s *Service) Get(ctx context.Context, id uuid.UUID) (Upload, error) {
+	u, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return Upload{}, err
+	}
+	if !u.Completed() && time.Now().After(u.ExpiresAt) {
+		return Upload{}, ErrUploadExpired
+	}
+	return u, nil
+}
+
+// Write appends body at offset. Whatever arrives before the body breaks off is kept, so the
+// client can resume from the returned offset. The last byte completes the upload and
+// registers the file.
+func (s *Service) Write(ctx context.Context, actor uuid.UUID, id uuid.UUID, offset int64, body io.Reader) (Upload, error) {
+	u, err := s.repo.Acquire(ctx, id, lockLease)
+	if err != nil {
+		return Upload{}, err
+	}
+	defer func() {
+		if err := s.repo.Release(context.WithoutCancel(ctx), id, u.LockToken); err != nil {
+			logpkg.Ctx(ctx, s.logger).Error().Err(err).Msg("unlock upload")
+		}
+	}()
+
+	if !u.Completed() && time.Now().After(u.ExpiresAt) {
+		return Upload{}, ErrUploadExpired
+	}
+	if offset != u.Offset {
+		return Upload{}, ErrOffsetMismatch
+	}
+	if u.Completed() {
+		return u, nil
+	}
+
+	digest := md5.New()
+	if len(u.HashState) > 0 {
+		if err := digest.(encoding.BinaryUnmarshaler).UnmarshalBinary(u.HashState); err != nil {
+			return Upload{}, fmt.Errorf("restore upload checksum: %w", err)
+		}
+	}
+
+	buf := make([]byte, partSize)
+	filled := copy(buf, u.Pending)
+	limited := io.LimitReader(body, u.Length-u.Offset)
+	var readErr error
+	for {
+		n, err := io.ReadFull(limited, buf[filled:])
+		digest.Write(buf[filled : filled+n])
+		filled += n
+		u.Offset += int64(n)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+				readErr = err
+			}
+			break
+		}
+		if err := s.flushPart(ctx, &u, buf[:filled], digest); err != nil {
+			return Upload{}, err
+		}
+		filled = 0
+	}
+
+	// The tail becomes a part when S3 accepts it; otherwise it waits for the next request.
+	done := u.Offset == u.Length
+	if filled >= s3.MinPartSize || (done && (filled > 0 || len(u.Parts) == 0)) {
+		if err := s.flushPart(ctx, &u, buf[:filled], digest); err != nil {
+			return Upload{}, err
+		}
+	} else {
+		u.Pending = append([]byte(nil), buf[:filled]...)
+		if err := s.saveProgress(ctx, &u, digest); err != nil {
+			return Upload{}, err
+		}
+	}
+	if readErr != nil {
+		logpkg.Ctx(ctx, s.logger).Warn().Err(readErr).Str("upload", id.String()).Int64("offset", u.Offset).Msg("upload body interrupted")
+	}
+	if !done {
+		return u, nil
+	}
+	return s.complete(ctx, actor, u, hex.EncodeToString(digest.Sum(nil)))
+}
+
+// Terminate aborts an upload and forgets it. Completed files stay registered.
+func (s *Service) Terminate(ctx context.Context, id uuid.UUID) error {
+	u, err := s.repo.Acquire(ctx, id, lockLease)
+	if err != nil {
+		return err
+	}
+	if !u.Completed() {
+		if err := s.storage.AbortMultipart(ctx, u.Key, u.StorageUploadID); err != nil {
+			_ = s.repo.Release(context.WithoutCancel(ctx), id, u.LockToken)
+			return err
+		}
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// Run removes expired sessions and their stored parts until ctx is cancelled.
+func (s *Service) Run(ctx context.Context) error {
+	ticker := time.NewTicker(reapInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if n, err := s.Reap(ctx); err != nil {
+				s.logger.Error().Err(err).Msg("reap expired uploads")
+			} else if n > 0 {
+				s.logger.Info().Int("count", n).Msg("expired uploads removed")
+			}
+		}
+	}
+}
+
+// Reap aborts the multipart uploads of expired sessions and deletes the sessions. Objects
+// assembled but never registered are removed.
+func (s *Service) Reap(ctx context.Context) (int, error) {
+	expired, err := s.repo.ListExpired(ctx, reapBatch)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, u := range expired {
+		if err := s.discard(tenant.WithCompany(ctx, u.Company), u); err != nil {
+			s.logger.Error().Err(err).Str("upload", u.ID.String()).Msg("discard expired upload")
+			continue
+		}
+		ok, err := s.repo.DeleteExpired(ctx, u.ID)
+		if err != nil {
+			return removed, err
+		}
+		if ok {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// discard drops the stored data of an unfinished session: the multipart upload, or the
+// assembled object unless the registry knows it.
+func (s *Service) discard(ctx context.Context, u Upload) error {
+	switch {
+	case u.Completed():
+		return nil
+	case !u.Assembled():
+		return s.storage.AbortMultipart(ctx, u.Key, u.StorageUploadID)
+	}
+	registered, err := s.registry.Registered(ctx, u.Key, *u.StorageVersion)
+	if err != nil || registered {
+		return err
+	}
+	if err := s.storage.Remove(ctx, u.Key, *u.StorageVersion); err != nil && !errors.Is(err, s3.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// flushPart sends data as the next part and records the progress.
+func (s *Service) flushPart(ctx context.Context, u *Upload, data []byte, digest hash.Hash) error {
+	part, err := s.storage.UploadPart(ctx, u.Key, u.StorageUploadID, len(u.Parts)+1, bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	u.Parts = append(u.Parts, part)
+	u.Pending = nil
+	return s.saveProgress(ctx, u, digest)
+}
+
+func (s *Service) saveProgress(ctx context.Context, u *Upload, digest hash.Hash) error {
+	state, err := digest.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("save upload checksum: %w", err)
+	}
+	u.HashState = state
+	u.ExpiresAt = time.Now().Add(s.expiry).UTC()
+	return s.repo.SaveProgress(ctx, *u, lockLease)
+}
+
+// complete assembles the object, registers it in the file registry and closes the session.
+// The storage version is saved before registering, so a retry after a failed registration
+// only registers the object.
+func (s *Service) complete(ctx context.Context, actor uuid.UUID, u Upload, checksum string) (Upload, error) {
+	if !u.Assembled() {
+		versionID, err := s.storage.CompleteMultipart(ctx, u.Key, u.StorageUploadID, u.Parts)
+		if err != nil {
+			return Upload{}, err
+		}
+		if err := s.repo.SaveAssembled(ctx, u.ID, u.LockToken, versionID); err != nil {
+			return Upload{}, err
+		}
+		u.StorageVersion, u.Pending = &versionID, nil
+	}
+	versionID := *u.StorageVersion
+	file, err := s.registry.RecordUpload(ctx, files.UploadInput{
+		Key:            u.Key,
+		Filename:       u.Filename,
+		StorageVersion: versionID,
+		Size:           u.Length,
+		Checksum:       checksum,
+		ContentType:    u.ContentType,
+		UploadedBy:     actor,
+	})
+	if err != nil {
+		// A rejected object is already gone, so the session cannot be resumed.
+		if filecheck.Rejected(err) {
+			if delErr := s.repo.Delete(ctx, u.ID); delErr != nil {
+				logpkg.Ctx(ctx, s.logger).Error().Err(delErr).Str("upload", u.ID.String()).Msg("delete rejected upload")
+			}
+		}
+		return Upload{}, err
+	}
+	completedAt, err := s.repo.Complete(ctx, u.ID, file.ID)
+	if err != nil {
+		return Upload{}, err
+	}
+	u.FileID, u.CompletedAt, u.Pending = &file.ID, &completedAt, nil
+
+	if s.auditor != nil {
+		if err := s.auditor.Record(ctx, audit.Entry{
+			ActorID:  actor,
+			Action:   "gateway.file.upload",
+			Entity:   "gateway.file",
+			EntityID: u.Key,
+			Payload: map[string]any{
+				"key":         u.Key,
+				"size":        u.Length,
+				"md5":         checksum,
+				"contentType": u.ContentType,
+				"version":     versionID,
+				"resumable":   true,
+			},
+		}); err != nil {
+			logpkg.Ctx(ctx, s.logger).Error().Err(err).Msg("audit file upload")
+		}
+	}
+	return u, nil
+}
@@ -0,0 +1,327 @@
+package uploads_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"asfppro/gateway/internal/files"
+	"asfppro/gateway/internal/uploads"
+	"asfppro/pkg/s3"
+	"asfppro/pkg/tenant"
+)
+
+// memorySessions keeps upload sessions in memory with the locking rules of Repository.
+type memorySessions struct {
+	mu       sync.Mutex
+	sessions map[uuid.UUID]*memorySession
+	// skew moves the clock of expiry queries forward.
+	skew time.Duration
+}
+
+type memorySession struct {
+	upload      uploads.Upload
+	token       uuid.UUID
+	lockedUntil time.Time
+}
+
+func newMemorySessions() *memorySessions {
+	return &memorySessions{sessions: map[uuid.UUID]*memorySession{}}
+}
+
+func (m *memorySessions) Create(ctx context.Context, u uploads.Upload) (uploads.Upload, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return uploads.Upload{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u.Company = company
+	m.sessions[u.ID] = &memorySession{upload: u}
+	return u, nil
+}
+
+func (m *memorySessions) Get(_ context.Context, id uuid.UUID) (uploads.Upload, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok {
+		return uploads.Upload{}, uploads.ErrUploadNotFound
+	}
+	return s.upload, nil
+}
+
+func (m *memorySessions) Acquire(_ context.Context, id uuid.UUID, lease time.Duration) (uploads.Upload, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok {
+		return uploads.Upload{}, uploads.ErrUploadNotFound
+	}
+	if time.Now().Before(s.lockedUntil) {
+		return uploads.Upload{}, uploads.ErrUploadLocked
+	}
+	s.token, s.lockedUntil = uuid.New(), time.Now().Add(lease)
+	u := s.upload
+	u.LockToken = s.token
+	return u, nil
+}
+
+func (m *memorySessions) Release(_ context.Context, id, token uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.sessions[id]; ok && s.token == token {
+		s.token, s.lockedUntil = uuid.Nil, time.Time{}
+	}
+	return nil
+}
+
+func (m *memorySessions) SaveProgress(_ context.Context, u uploads.Upload, lease time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[u.ID]
+	if !ok || s.token != u.LockToken {
+		return uploads.ErrUploadLocked
+	}
+	s.upload.Offset, s.upload.Parts, s.upload.Pending, s.upload.HashState = u.Offset, u.Parts, u.Pending, u.HashState
+	s.upload.ExpiresAt, s.lockedUntil = u.ExpiresAt, time.Now().Add(lease)
+	return nil
+}
+
+func (m *memorySessions) SaveAssembled(_ context.Context, id, token uuid.UUID, storageVersion string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok || s.token != token {
+		return uploads.ErrUploadLocked
+	}
+	s.upload.StorageVersion, s.upload.Pending = &storageVersion, nil
+	return nil
+}
+
+func (m *memorySessions) Complete(_ context.Context, id, fileID uuid.UUID) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now().UTC()
+	s := m.sessions[id]
+	s.upload.FileID, s.upload.CompletedAt, s.upload.Pending = &fileID, &now, nil
+	return now, nil
+}
+
+func (m *memorySessions) Delete(_ context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, id)
+	return nil
+}
+
+func (m *memorySessions) ListExpired(_ context.Context, limit int) ([]uploads.Upload, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var items []uploads.Upload
+	for _, s := range m.sessions {
+		if m.expired(s) && len(items) < limit {
+			items = append(items, s.upload)
+		}
+	}
+	return items, nil
+}
+
+func (m *memorySessions) DeleteExpired(_ context.Context, id uuid.UUID) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok || !m.expired(s) {
+		return false, nil
+	}
+	delete(m.sessions, id)
+	return true, nil
+}
+
+func (m *memorySessions) expired(s *memorySession) bool {
+	now := time.Now().Add(m.skew)
+	return s.upload.ExpiresAt.Before(now) && !now.Before(s.lockedUntil)
+}
+
+// memoryFiles registers file versions in memory; failures make the next registrations
+// fail. Methods uploads do not reach fall through to the nil Store and panic.
+type memoryFiles struct {
+	files.Store
+
+	mu       sync.Mutex
+	versions map[string][]string
+	failures int
+}
+
+func (m *memoryFiles) AddVersion(_ context.Context, input files.UploadInput, _ *int) (files.File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failures > 0 {
+		m.failures--
+		return files.File{}, errors.New("database unavailable")
+	}
+	if m.versions == nil {
+		m.versions = map[string][]string{}
+	}
+	m.versions[input.Key] = append(m.versions[input.Key], input.StorageVersion)
+	return files.File{ID: uuid.New(), Key: input.Key, CurrentVersion: len(m.versions[input.Key]), Checksum: input.Checksum}, nil
+}
+
+func (m *memoryFiles) VersionRegistered(_ context.Context, key, storageVersion string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, v := range m.versions[key] {
+		if v == storageVersion {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// brokenBody yields data and then fails like a dropped connection.
+type brokenBody struct{ r io.Reader }
+
+func (b brokenBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if errors.Is(err, io.EOF) {
+		return n, io.ErrClosedPipe
+	}
+	return n, err
+}
+
+func newService(t *testing.T, expiry time.Duration) (*uploads.Service, *memorySessions, *memoryFiles, *s3.FS) {
+	t.Helper()
+	storage, err := s3.NewFS(t.TempDir(), "secret")
+	require.NoError(t, err)
+	registry := &memoryFiles{}
+	sessions := newMemorySessions()
+	filesSvc := files.NewService(registry, storage, files.Checks{}, nil, zerolog.Nop())
+	return uploads.NewService(sessions, storage, filesSvc, nil, expiry, zerolog.Nop()), sessions, registry, storage
+}
+
+// content returns n bytes of a PDF so the stored type survives sniffing.
+func content(n int) []byte {
+	data := bytes.Repeat([]byte("0123456789abcdef"), n/16+1)[:n]
+	copy(data, "%PDF-1.7\n")
+	return data
+}
+
+func TestWriteResumesFromTheStoredOffset(t *testing.T) {
+	ctx := tenant.WithCompany(context.Background(), "MAIN")
+	svc, _, registry, storage := newService(t, time.Hour)
+	data := content(s3.MinPartSize + 3<<20)
+
+	u, err := svc.Create(ctx, uploads.CreateInput{Key: "MAIN/layouts/poster.pdf", Filename: "poster.pdf", ContentType: "application/pdf", Length: int64(len(data))})
+	require.NoError(t, err)
+
+	// The connection drops after 3 MiB; what arrived is kept.
+	first := 3 << 20
+	u, err = svc.Write(ctx, uuid.Nil, u.ID, 0, brokenBody{bytes.NewReader(data[:first])})
+	require.NoError(t, err)
+	require.Equal(t, int64(first), u.Offset)
+	require.False(t, u.Completed())
+
+	head, err := svc.Get(ctx, u.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(first), head.Offset)
+
+	_, err = svc.Write(ctx, uuid.Nil, u.ID, 0, bytes.NewReader(data))
+	require.ErrorIs(t, err, uploads.ErrOffsetMismatch)
+
+	u, err = svc.Write(ctx, uuid.Nil, u.ID, int64(first), bytes.NewReader(data[first:]))
+	require.NoError(t, err)
+	require.True(t, u.Completed())
+	require.NotNil(t, u.FileID)
+
+	info, err := storage.Stat(ctx, u.Key, "")
+	require.NoError(t, err)
+	sum := md5.Sum(data)
+	require.Equal(t, int64(len(data)), info.Size)
+	require.Equal(t, hex.EncodeToString(sum[:]), info.ETag)
+	require.Equal(t, []string{info.VersionID}, registry.versions[u.Key])
+}
+
+func TestWriteRetriesOnlyTheRegistration(t *testing.T) {
+	ctx := tenant.WithCompany(context.Background(), "MAIN")
+	svc, _, registry, storage := newService(t, time.Hour)
+	data := content(1 << 10)
+	registry.failures = 1
+
+	u, err := svc.Create(ctx, uploads.CreateInput{Key: "MAIN/uploads/act.pdf", Filename: "act.pdf", Length: int64(len(data))})
+	require.NoError(t, err)
+	_, err = svc.Write(ctx, uuid.Nil, u.ID, 0, bytes.NewReader(data))
+	require.Error(t, err)
+
+	// The object is assembled; the retry registers it without completing the upload again.
+	u, err = svc.Write(ctx, uuid.Nil, u.ID, int64(len(data)), bytes.NewReader(nil))
+	require.NoError(t, err)
+	require.True(t, u.Completed())
+	require.NotNil(t, u.StorageVersion)
+
+	info, err := storage.Stat(ctx, u.Key, "")
+	require.NoError(t, err)
+	require.Equal(t, *u.StorageVersion, info.VersionID)
+	require.Equal(t, []string{info.VersionID}, registry.versions[u.Key])
+}
+
+func TestWriteRefusesLockedSessions(t *testing.T) {
+	ctx := tenant.WithCompany(context.Background(), "MAIN")
+	svc, sessions, _, _ := newService(t, time.Hour)
+
+	u, err := svc.Create(ctx, uploads.CreateInput{Key: "MAIN/uploads/a.pdf", Filename: "a.pdf", Length: 10})
+	require.NoError(t, err)
+	held, err := sessions.Acquire(ctx, u.ID, time.Minute)
+	require.NoError(t, err)
+
+	_, err = svc.Write(ctx, uuid.Nil, u.ID, 0, bytes.NewReader(content(10)))
+	require.ErrorIs(t, err, uploads.ErrUploadLocked)
+
+	// The refused request must not release the lease of the holder.
+	_, err = sessions.Acquire(ctx, u.ID, time.Minute)
+	require.ErrorIs(t, err, uploads.ErrUploadLocked)
+	require.NoError(t, sessions.Release(ctx, u.ID, held.LockToken))
+	u, err = svc.Write(ctx, uuid.Nil, u.ID, 0, bytes.NewReader(content(10)))
+	require.NoError(t, err)
+	require.True(t, u.Completed())
+}
+
+func TestReapRemovesAbandonedUploads(t *testing.T) {
+	ctx := tenant.WithCompany(context.Background(), "MAIN")
+	svc, sessions, registry, storage := newService(t, time.Hour)
+	data := content(1 << 10)
+
+	partial, err := svc.Create(ctx, uploads.CreateInput{Key: "MAIN/uploads/partial.pdf", Filename: "partial.pdf", Length: int64(len(data))})
+	require.NoError(t, err)
+	_, err = svc.Write(ctx, uuid.Nil, partial.ID, 0, bytes.NewReader(data[:100]))
+	require.NoError(t, err)
+
+	// Assembled, but its registration failed and the client never came back.
+	registry.failures = 1
+	assembled, err := svc.Create(ctx, uploads.CreateInput{Key: "MAIN/uploads/assembled.pdf", Filename: "assembled.pdf", Length: int64(len(data))})
+	require.NoError(t, err)
+	_, err = svc.Write(ctx, uuid.Nil, assembled.ID, 0, bytes.NewReader(data))
+	require.Error(t, err)
+	stored, err := sessions.Get(ctx, assembled.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.StorageVersion)
+
+	sessions.skew = 2 * time.Hour
+	n, err := svc.Reap(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	_, err = storage.Stat(ctx, stored.Key, *stored.StorageVersion)
+	require.ErrorIs(t, err, s3.ErrNotFound)
+	_, err = sessions.Get(ctx, partial.ID)
+	require.ErrorIs(t, err, uploads.ErrUploadNotFound)
+}
@@ -10,16 +10,28 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"asfppro/pkg/db"
+	"asfppro/pkg/tenant"
 )
 
-// Repository talks to wms.* tables.
+// Repository talks to wms.* tables. Warehouses and catalogs are shared between companies;
+// stock belongs to the active company in ctx. Warehouse and stock queries run under the
+// row-level security session in ctx.
 type Repository struct {
 	pool *pgxpool.Pool
+	read db.SessionReader
 }
 
 // NewRepository constructs repository.
 func NewRepository(pool *pgxpool.Pool) *Repository {
-	return &Repository{pool: pool}
+	return &Repository{pool: pool, read: pool}
+}
+
+// WithReader routes list queries through read, typically a db.Router sending them to replicas.
+func (r *Repository) WithReader(read db.SessionReader) *Repository {
+	r.read = read
+	return r
 }
 
 // ListCatalogNodes fetches catalog entries for provided type.
@@ -27,7 +39,7 @@ func (r *Repository) ListCatalogNodes(ctx context.Context, catalogType string) (
 	const query = `SELECT id, catalog_type, code, name, COALESCE(description, ''), is_active
 FROM wms.catalog_node WHERE catalog_type = $1 ORDER BY sort_order, name`
 
-	rows, err := r.pool.Query(ctx, query, catalogType)
+	rows, err := r.read.Query(ctx, query, catalogType)
 	if err != nil {
 		return nil, fmt.Errorf("list catalog nodes: %w", err)
 	}
@@ -106,44 +118,32 @@ func (r *Repository) ListWarehouses(ctx context.Context, scopes []string, allowA
 		return []Warehouse{}, nil
 	}
 
-	baseQuery := `SELECT id, code, name, COALESCE(description, ''), status, org_unit_code FROM wms.warehouse`
+	query := `SELECT id, code, name, COALESCE(description, ''), status, org_unit_code FROM wms.warehouse`
 	args := make([]any, 0, 1)
-	if allowAll || len(scopes) == 0 {
-		baseQuery += " ORDER BY created_at DESC"
-		rows, err := r.pool.Query(ctx, baseQuery)
+	if !allowAll {
+		query += " WHERE org_unit_code = ANY($1)"
+		args = append(args, scopes)
+	}
+	query += " ORDER BY created_at DESC"
+
+	var warehouses []Warehouse
+	err := db.InSession(ctx, r.read, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, args...)
 		if err != nil {
-			return nil, fmt.Errorf("list warehouses: %w", err)
+			return fmt.Errorf("list warehouses: %w", err)
 		}
 		defer rows.Close()
 
-		var warehouses []Warehouse
 		for rows.Next() {
 			var wh Warehouse
 			if err := rows.Scan(&wh.ID, &wh.Code, &wh.Name, &wh.Description, &wh.Status, &wh.OrgUnitCode); err != nil {
-				return nil, fmt.Errorf("scan warehouse: %w", err)
+				return fmt.Errorf("scan warehouse: %w", err)
 			}
 			warehouses = append(warehouses, wh)
 		}
-		return warehouses, rows.Err()
-	}
-
-	baseQuery += " WHERE org_unit_code = ANY($1) ORDER BY created_at DESC"
-	args = append(args, scopes)
-	rows, err := r.pool.Query(ctx, baseQuery, args...)
-	if err != nil {
-		return nil, fmt.Errorf("list warehouses: %w", err)
-	}
-	defer rows.Close()
-
-	var warehouses []Warehouse
-	for rows.Next() {
-		var wh Warehouse
-		if err := rows.Scan(&wh.ID, &wh.Code, &wh.Name, &wh.Description, &wh.Status, &wh.OrgUnitCode); err != nil {
-			return nil, fmt.Errorf("scan warehouse: %w", err)
-		}
-		warehouses = append(warehouses, wh)
-	}
-	return warehouses, rows.Err()
+		return rows.Err()
+	})
+	return warehouses, err
 }
 
 // CreateWarehouse inserts warehouse.
@@ -154,8 +154,10 @@ RETURNING id, code, name, COALESCE(description, ''), status, org_unit_code`
 
 	var wh Warehouse
 	id := uuid.New()
-	if err := r.pool.QueryRow(ctx, query, id, input.Code, input.Name, input.Description, input.Status, input.OrgUnitCode).
-		Scan(&wh.ID, &wh.Code, &wh.Name, &wh.Description, &wh.Status, &wh.OrgUnitCode); err != nil {
+	if err := db.InSession(ctx, r.pool, func(tx pgx.Tx) error {
+		return tx.QueryRow(ctx, query, id, input.Code, input.Name, input.Description, input.Status, input.OrgUnitCode).
+			Scan(&wh.ID, &wh.Code, &wh.Name, &wh.Description, &wh.Status, &wh.OrgUnitCode)
+	}); err != nil {
 		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
 			return Warehouse{}, fmt.Errorf("warehouse code already exists")
 		}
@@ -196,7 +198,9 @@ func (r *Repository) UpdateWarehouse(ctx context.Context, id uuid.UUID, input Up
 	args = append(args, id)
 
 	var wh Warehouse
-	if err := r.pool.QueryRow(ctx, query, args...).Scan(&wh.ID, &wh.Code, &wh.Name, &wh.Description, &wh.Status, &wh.OrgUnitCode); err != nil {
+	if err := db.InSession(ctx, r.pool, func(tx pgx.Tx) error {
+		return tx.QueryRow(ctx, query, args...).Scan(&wh.ID, &wh.Code, &wh.Name, &wh.Description, &wh.Status, &wh.OrgUnitCode)
+	}); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return Warehouse{}, fmt.Errorf("warehouse not found")
 		}
@@ -209,7 +213,9 @@ func (r *Repository) UpdateWarehouse(ctx context.Context, id uuid.UUID, input Up
 func (r *Repository) GetWarehouseByID(ctx context.Context, id uuid.UUID) (Warehouse, error) {
 	const query = `SELECT id, code, name, COALESCE(description, ''), status, org_unit_code FROM wms.warehouse WHERE id = $1`
 	var wh Warehouse
-	if err := r.pool.QueryRow(ctx, query, id).Scan(&wh.ID, &wh.Code, &wh.Name, &wh.Description, &wh.Status, &wh.OrgUnitCode); err != nil {
+	if err := db.InSession(ctx, r.pool, func(tx pgx.Tx) error {
+		return tx.QueryRow(ctx, query, id).Scan(&wh.ID, &wh.Code, &wh.Name, &wh.Description, &wh.Status, &wh.OrgUnitCode)
+	}); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return Warehouse{}, fmt.Errorf("warehouse not found")
 		}
@@ -222,7 +228,9 @@ func (r *Repository) GetWarehouseByID(ctx context.Context, id uuid.UUID) (Wareho
 func (r *Repository) GetWarehouseByCode(ctx context.Context, code string) (Warehouse, error) {
 	const query = `SELECT id, code, name, COALESCE(description, ''), status, org_unit_code FROM wms.warehouse WHERE code = $1`
 	var wh Warehouse
-	if err := r.pool.QueryRow(ctx, query, code).Scan(&wh.ID, &wh.Code, &wh.Name, &wh.Description, &wh.Status, &wh.OrgUnitCode); err != nil {
+	if err := db.InSession(ctx, r.pool, func(tx pgx.Tx) error {
+		return tx.QueryRow(ctx, query, code).Scan(&wh.ID, &wh.Code, &wh.Name, &wh.Description, &wh.Status, &wh.OrgUnitCode)
+	}); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return Warehouse{}, fmt.Errorf("warehouse not found")
 		}
@@ -233,11 +241,15 @@ func (r *Repository) GetWarehouseByCode(ctx context.Context, code string) (Wareh
 
 // DeleteWarehouse removes warehouse.
 func (r *Repository) DeleteWarehouse(ctx context.Context, id uuid.UUID) error {
-	cmd, err := r.pool.Exec(ctx, "DELETE FROM wms.warehouse WHERE id = $1", id)
-	if err != nil {
+	var deleted int64
+	if err := db.InSession(ctx, r.pool, func(tx pgx.Tx) error {
+		cmd, err := tx.Exec(ctx, "DELETE FROM wms.warehouse WHERE id = $1", id)
+		deleted = cmd.RowsAffected()
+		return err
+	}); err != nil {
 		return fmt.Errorf("delete warehouse: %w", err)
 	}
-	if cmd.RowsAffected() == 0 {
+	if deleted == 0 {
 		return fmt.Errorf("warehouse not found")
 	}
 	return nil
@@ -245,17 +257,23 @@ func (r *Repository) DeleteWarehouse(ctx context.Context, id uuid.UUID) error {
 
 // UpsertStock inserts new record or updates existing quantity.
 func (r *Repository) UpsertStock(ctx context.Context, input UpsertStockInput) (StockRecord, error) {
-	const query = `INSERT INTO wms.stock (sku, warehouse, quantity, uom)
-VALUES ($1, $2, $3, COALESCE(NULLIF($4, ''), 'pcs'))
-ON CONFLICT (sku, warehouse) DO UPDATE
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return StockRecord{}, err
+	}
+	const query = `INSERT INTO wms.stock (sku, warehouse, quantity, uom, company_code)
+VALUES ($1, $2, $3, COALESCE(NULLIF($4, ''), 'pcs'), $5)
+ON CONFLICT (company_code, sku, warehouse) DO UPDATE
 SET quantity = EXCLUDED.quantity,
     uom = EXCLUDED.uom,
     updated_at = NOW()
 RETURNING sku, warehouse, quantity, uom, updated_at`
 
 	var stock StockRecord
-	if err := r.pool.QueryRow(ctx, query, input.SKU, input.Warehouse, input.Quantity, input.UOM).
-		Scan(&stock.SKU, &stock.Warehouse, &stock.Quantity, &stock.UOM, &stock.UpdatedAt); err != nil {
+	if err := db.InSession(ctx, r.pool, func(tx pgx.Tx) error {
+		return tx.QueryRow(ctx, query, input.SKU, input.Warehouse, input.Quantity, input.UOM, company).
+			Scan(&stock.SKU, &stock.Warehouse, &stock.Quantity, &stock.UOM, &stock.UpdatedAt)
+	}); err != nil {
 		return StockRecord{}, fmt.Errorf("upsert stock: %w", err)
 	}
 	return stock, nil
@@ -263,6 +281,10 @@ RETURNING sku, warehouse, quantity, uom, updated_at`
 
 // ListStock returns stock records optionally filtered by warehouse or sku.
 func (r *Repository) ListStock(ctx context.Context, scopes []string, allowAll bool, sku, warehouse string) ([]StockRecord, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
 	if !allowAll && len(scopes) == 0 {
 		return []StockRecord{}, nil
 	}
@@ -270,28 +292,32 @@ func (r *Repository) ListStock(ctx context.Context, scopes []string, allowAll bo
 	query := `SELECT s.sku, s.warehouse, s.quantity, s.uom, s.updated_at
 FROM wms.stock s
 JOIN wms.warehouse w ON w.code = s.warehouse
-WHERE ($1 = '' OR s.sku = $1)
-  AND ($2 = '' OR s.warehouse = $2)`
-	args := []any{sku, warehouse}
+WHERE s.company_code = $1
+  AND ($2 = '' OR s.sku = $2)
+  AND ($3 = '' OR s.warehouse = $3)`
+	args := []any{company, sku, warehouse}
 	if !allowAll {
-		query += " AND w.org_unit_code = ANY($3)"
+		query += " AND w.org_unit_code = ANY($4)"
 		args = append(args, scopes)
 	}
 	query += " ORDER BY s.updated_at DESC"
 
-	rows, err := r.pool.Query(ctx, query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("list stock: %w", err)
-	}
-	defer rows.Close()
-
 	var records []StockRecord
-	for rows.Next() {
-		var record StockRecord
-		if err := rows.Scan(&record.SKU, &record.Warehouse, &record.Quantity, &record.UOM, &record.UpdatedAt); err != nil {
-			return nil, fmt.Errorf("scan stock: %w", err)
+	err = db.InSession(ctx, r.read, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("list stock: %w", err)
 		}
-		records = append(records, record)
-	}
-	return records, rows.Err()
+		defer rows.Close()
+
+		for rows.Next() {
+			var record StockRecord
+			if err := rows.Scan(&record.SKU, &record.Warehouse, &record.Quantity, &record.UOM, &record.UpdatedAt); err != nil {
+				return fmt.Errorf("scan stock: %w", err)
+			}
+			records = append(records, record)
+		}
+		return rows.Err()
+	})
+	return records, err
 }
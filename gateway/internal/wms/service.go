@@ -8,12 +8,21 @@ import (
 	"strings"
 
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 
 	corepkg "asfppro/gateway/internal/core"
 	"asfppro/pkg/audit"
+	"asfppro/pkg/db"
+	logpkg "asfppro/pkg/log"
+	"asfppro/pkg/metrics"
 )
 
+var stockUpserts = metrics.Register(prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "wms_stock_upserts_total",
+	Help: "Stock records created or updated.",
+}))
+
 // ErrForbidden is returned when subject has no access to resource scope.
 var ErrForbidden = errors.New("wms: forbidden")
 
@@ -83,12 +92,14 @@ func (s *Service) DeleteCatalogNode(ctx context.Context, actor uuid.UUID, id uui
 
 // ListWarehouses returns warehouses.
 func (s *Service) ListWarehouses(ctx context.Context, subject corepkg.Subject) ([]Warehouse, error) {
+	ctx = withSession(ctx, subject)
 	allowAll, scopes := extractScopes(subject)
 	return s.repo.ListWarehouses(ctx, scopes, allowAll)
 }
 
 // CreateWarehouse creates warehouse.
 func (s *Service) CreateWarehouse(ctx context.Context, actor uuid.UUID, subject corepkg.Subject, input CreateWarehouseInput) (Warehouse, error) {
+	ctx = withSession(ctx, subject)
 	input.Code = strings.TrimSpace(strings.ToUpper(input.Code))
 	input.Name = strings.TrimSpace(input.Name)
 	input.Status = strings.TrimSpace(strings.ToLower(input.Status))
@@ -125,6 +136,7 @@ func (s *Service) CreateWarehouse(ctx context.Context, actor uuid.UUID, subject
 
 // UpdateWarehouse updates basic fields.
 func (s *Service) UpdateWarehouse(ctx context.Context, actor uuid.UUID, subject corepkg.Subject, id uuid.UUID, input UpdateWarehouseInput) (Warehouse, error) {
+	ctx = withSession(ctx, subject)
 	warehouse, err := s.repo.GetWarehouseByID(ctx, id)
 	if err != nil {
 		return Warehouse{}, err
@@ -159,6 +171,7 @@ func (s *Service) UpdateWarehouse(ctx context.Context, actor uuid.UUID, subject
 
 // DeleteWarehouse deletes record.
 func (s *Service) DeleteWarehouse(ctx context.Context, actor uuid.UUID, subject corepkg.Subject, id uuid.UUID) error {
+	ctx = withSession(ctx, subject)
 	warehouse, err := s.repo.GetWarehouseByID(ctx, id)
 	if err != nil {
 		return err
@@ -176,6 +189,7 @@ func (s *Service) DeleteWarehouse(ctx context.Context, actor uuid.UUID, subject
 
 // UpsertStock persists stock record.
 func (s *Service) UpsertStock(ctx context.Context, actor uuid.UUID, subject corepkg.Subject, input UpsertStockInput) (StockRecord, error) {
+	ctx = withSession(ctx, subject)
 	input.SKU = strings.TrimSpace(strings.ToUpper(input.SKU))
 	input.Warehouse = strings.TrimSpace(strings.ToUpper(input.Warehouse))
 	if input.SKU == "" || input.Warehouse == "" {
@@ -200,16 +214,24 @@ func (s *Service) UpsertStock(ctx context.Context, actor uuid.UUID, subject core
 		return StockRecord{}, err
 	}
 
+	stockUpserts.Inc()
 	s.recordAudit(ctx, actor, "wms.stock.upsert", fmt.Sprintf("%s:%s", stock.SKU, stock.Warehouse), stock)
 	return stock, nil
 }
 
 // ListStock lists inventory records.
 func (s *Service) ListStock(ctx context.Context, subject corepkg.Subject, sku, warehouse string) ([]StockRecord, error) {
+	ctx = withSession(ctx, subject)
 	allowAll, scopes := extractScopes(subject)
 	return s.repo.ListStock(ctx, scopes, allowAll, strings.TrimSpace(strings.ToUpper(sku)), strings.TrimSpace(strings.ToUpper(warehouse)))
 }
 
+// withSession attaches the subject's org unit scopes to ctx for row-level security policies.
+func withSession(ctx context.Context, subject corepkg.Subject) context.Context {
+	allowAll, scopes := extractScopes(subject)
+	return db.WithSession(ctx, db.Session{UserID: subject.ID.String(), Scopes: scopes, AllowAll: allowAll})
+}
+
 func extractScopes(subject corepkg.Subject) (bool, []string) {
 	scopeSet := make(map[string]struct{})
 	allowAll := false
@@ -278,6 +300,6 @@ func (s *Service) recordAudit(ctx context.Context, actor uuid.UUID, action, enti
 		Payload:  payload,
 	}
 	if err := s.auditor.Record(ctx, entry); err != nil {
-		s.logger.Error().Err(err).Msg("wms audit record")
+		logpkg.Ctx(ctx, s.logger).Error().Err(err).Msg("wms audit record")
 	}
 }
@@ -13,47 +13,93 @@ import (
 	"asfppro/pkg/audit"
 	"asfppro/pkg/config"
 	"asfppro/pkg/db"
+	"asfppro/pkg/diagnostics"
+	"asfppro/pkg/lifecycle"
 	logpkg "asfppro/pkg/log"
+	"asfppro/pkg/tracing"
 )
 
 func main() {
-	cfg, err := config.Load("ANALYTICS")
+	if handled, err := config.Command("ANALYTICS", os.Args[1:], os.Stdout); handled {
+		if err != nil {
+			stdlog.Fatalf("config: %v", err)
+		}
+		return
+	}
+
+	cfg, err := config.LoadArgs("ANALYTICS", os.Args[1:])
 	if err != nil {
 		stdlog.Fatalf("load config: %v", err)
 	}
 
-	logger := logpkg.Init(cfg.Env)
+	logger, err := logpkg.New(logpkg.Options{Env: cfg.Env, Service: cfg.AppName, Level: cfg.LogLevel, Format: cfg.LogFormat})
+	if err != nil {
+		stdlog.Fatalf("init logger: %v", err)
+	}
+
+	lc := lifecycle.New(logger, cfg.ShutdownTimeout)
+
+	if cfg.EnablePprof || cfg.MetricsEnable {
+		diag, err := diagnostics.Start(diagnostics.Config{
+			Addr:    cfg.PprofAddr,
+			Token:   cfg.PprofToken,
+			Pprof:   cfg.EnablePprof,
+			Metrics: cfg.MetricsEnable,
+		}, logger)
+		if err != nil {
+			lc.Abort(err, "start admin listener")
+		}
+		lc.Append(lifecycle.Hook{Name: "admin", Stop: diag.Shutdown})
+	}
+
+	flushTraces, err := tracing.Init(context.Background(), tracing.Config{
+		ServiceName: cfg.AppName,
+		Environment: cfg.Env,
+		Endpoint:    cfg.OTLPEndpoint,
+		SampleRatio: cfg.TraceSample,
+	}, logger)
+	if err != nil {
+		lc.Abort(err, "init tracing")
+	}
+	lc.Close("tracing", flushTraces)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	pool, err := db.NewPostgresPool(ctx, cfg.DatabaseURL)
+	poolCfg := db.PoolConfig{
+		MaxConns:        int32(cfg.DBMaxConns),
+		MinConns:        int32(cfg.DBMinConns),
+		MaxConnIdleTime: cfg.DBMaxConnIdle,
+		MaxConnLifetime: cfg.DBMaxConnLife,
+	}
+	pool, err := db.NewPostgresPoolWithConfig(ctx, cfg.DatabaseURL, poolCfg)
 	if err != nil {
-		logger.Fatal().Err(err).Msg("postgres connect")
+		lc.Abort(err, "postgres connect")
 	}
-	defer pool.Close()
+	lc.Close("postgres", pool.Close)
 
 	auditor := audit.NewRecorder(pool, logger)
 
 	conn, err := db.NewClickHouse(context.Background(), cfg.ClickHouseDSN)
 	if err != nil {
-		logger.Fatal().Err(err).Msg("clickhouse connect")
+		lc.Abort(err, "clickhouse connect")
 	}
-	defer func() { _ = conn.Close() }()
+	lc.Append(lifecycle.Hook{Name: "clickhouse", Stop: func(context.Context) error { return conn.Close() }})
 
 	repo := repository.NewEventRepository(conn)
 	spec, err := readOpenAPI("modules/analytics/docs/openapi/openapi.json", "ANALYTICS_OPENAPI_PATH")
 	if err != nil {
-		logger.Fatal().Err(err).Msg("load openapi")
+		lc.Abort(err, "load openapi")
 	}
 
 	server, err := analyticshttp.NewServer(cfg, logger, repo, conn, auditor, spec)
 	if err != nil {
-		logger.Fatal().Err(err).Msg("init analytics api")
+		lc.Abort(err, "init analytics api")
 	}
 
-	if err := server.Run(); err != nil {
-		logger.Fatal().Err(err).Msg("analytics api stopped")
+	server.Register(lc)
+	if err := lc.Run(context.Background()); err != nil {
+		os.Exit(1)
 	}
 }
 
@@ -3,63 +3,142 @@ package main
 
 import (
 	"context"
+	"fmt"
 	stdlog "log"
 	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
+	"github.com/gofiber/fiber/v2"
+
 	"asfppro/modules/analytics/internal/handler"
 	"asfppro/modules/analytics/internal/repository"
 	"asfppro/pkg/audit"
 	"asfppro/pkg/config"
 	"asfppro/pkg/db"
+	"asfppro/pkg/diagnostics"
+	"asfppro/pkg/health"
+	"asfppro/pkg/lifecycle"
 	logpkg "asfppro/pkg/log"
 	"asfppro/pkg/queue"
+	"asfppro/pkg/tracing"
 )
 
 func main() {
-	cfg, err := config.Load("ANALYTICS")
+	if handled, err := config.Command("ANALYTICS", os.Args[1:], os.Stdout); handled {
+		if err != nil {
+			stdlog.Fatalf("config: %v", err)
+		}
+		return
+	}
+
+	cfg, err := config.LoadArgs("ANALYTICS", os.Args[1:])
 	if err != nil {
 		stdlog.Fatalf("load config: %v", err)
 	}
 
-	logger := logpkg.Init(cfg.Env)
+	logger, err := logpkg.New(logpkg.Options{Env: cfg.Env, Service: cfg.AppName, Level: cfg.LogLevel, Format: cfg.LogFormat})
+	if err != nil {
+		stdlog.Fatalf("init logger: %v", err)
+	}
+
+	lc := lifecycle.New(logger, cfg.ShutdownTimeout)
+
+	if cfg.EnablePprof || cfg.MetricsEnable {
+		diag, err := diagnostics.Start(diagnostics.Config{
+			Addr:    cfg.PprofAddr,
+			Token:   cfg.PprofToken,
+			Pprof:   cfg.EnablePprof,
+			Metrics: cfg.MetricsEnable,
+		}, logger)
+		if err != nil {
+			lc.Abort(err, "start admin listener")
+		}
+		lc.Append(lifecycle.Hook{Name: "admin", Stop: diag.Shutdown})
+	}
+
+	flushTraces, err := tracing.Init(context.Background(), tracing.Config{
+		ServiceName: cfg.AppName,
+		Environment: cfg.Env,
+		Endpoint:    cfg.OTLPEndpoint,
+		SampleRatio: cfg.TraceSample,
+	}, logger)
+	if err != nil {
+		lc.Abort(err, "init tracing")
+	}
+	lc.Close("tracing", flushTraces)
 
 	setupCtx, setupCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer setupCancel()
 
-	pool, err := db.NewPostgresPool(setupCtx, cfg.DatabaseURL)
+	poolCfg := db.PoolConfig{
+		MaxConns:        int32(cfg.DBMaxConns),
+		MinConns:        int32(cfg.DBMinConns),
+		MaxConnIdleTime: cfg.DBMaxConnIdle,
+		MaxConnLifetime: cfg.DBMaxConnLife,
+	}
+	pool, err := db.NewPostgresPoolWithConfig(setupCtx, cfg.DatabaseURL, poolCfg)
 	if err != nil {
-		logger.Fatal().Err(err).Msg("postgres connect")
+		lc.Abort(err, "postgres connect")
 	}
-	defer pool.Close()
+	lc.Close("postgres", pool.Close)
 
 	auditor := audit.NewRecorder(pool, logger)
 
-	queueConsumer, err := queue.NewConsumer(cfg.TarantoolAddr, cfg.TarantoolQueue)
+	routes, err := queue.ParseSubscriptions(cfg.QueueRoutes)
 	if err != nil {
-		logger.Fatal().Err(err).Msg("tarantool connect")
+		lc.Abort(err, "parse queue subscriptions")
 	}
-	defer func() { queueConsumer.Close() }()
+
+	var topics []string
+	if cfg.QueueGroup != "" {
+		sub, ok := queue.FindSubscription(routes, cfg.QueueGroup)
+		if !ok {
+			lc.Abort(fmt.Errorf("group %q has no subscription", cfg.QueueGroup), "parse queue subscriptions")
+		}
+		topics = sub.Topics
+	}
+
+	queueConsumer, err := queue.NewConsumer(queue.ConsumerConfig{
+		Backend:        cfg.QueueBackend,
+		Addr:           cfg.TarantoolAddr,
+		Tube:           cfg.TarantoolQueue,
+		Group:          cfg.QueueGroup,
+		Topics:         topics,
+		DeadLetterTube: cfg.TarantoolDLQ,
+		MaxAttempts:    cfg.QueueMaxRetries,
+	})
+	if err != nil {
+		lc.Abort(err, "queue connect")
+	}
+	lc.Close("queue", queueConsumer.Close)
 
 	click, err := db.NewClickHouse(context.Background(), cfg.ClickHouseDSN)
 	if err != nil {
-		logger.Fatal().Err(err).Msg("clickhouse connect")
+		lc.Abort(err, "clickhouse connect")
 	}
-	defer func() { _ = click.Close() }()
+	lc.Append(lifecycle.Hook{Name: "clickhouse", Stop: func(context.Context) error { return click.Close() }})
 
 	repo := repository.NewEventRepository(click)
-	worker := handler.NewConsumer(queueConsumer, repo, auditor, logger)
+	logger.Info().Str("tube", queueConsumer.Tube()).Strs("topics", topics).Msg("queue subscription")
+	group := cfg.QueueGroup
+	if group == "" {
+		group = "analytics"
+	}
+	dedup := queue.NewIdempotent(queue.NewPostgresProcessedStore(pool), group, cfg.QueueDedupTTL)
+	worker := handler.NewConsumer(queueConsumer, dedup, repo, auditor, logger)
 
-	runCtx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	// The worker stops before the queue and stores it uses, finishing its in-flight job.
+	lc.Go("consumer", func(ctx context.Context) error {
+		worker.Run(ctx)
+		return nil
+	})
 
-	go worker.Run(runCtx)
+	// The consumer has no API; it serves /health on HTTP_PORT and /metrics on the admin listener.
+	app := fiber.New(fiber.Config{AppName: cfg.AppName, DisableStartupMessage: true})
+	app.Get("/health", health.LiveHandler())
+	lc.Fiber("health", app, ":"+cfg.HTTPPort)
 
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-	<-sigs
-	logger.Info().Msg("shutting down analytics consumer")
-	cancel()
+	if err := lc.Run(context.Background()); err != nil {
+		os.Exit(1)
+	}
 }
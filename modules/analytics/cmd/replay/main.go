@@ -0,0 +1,137 @@
+// Package main replays CRM deal events to rebuild analytics projections.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	stdlog "log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"asfppro/modules/analytics/internal/replay"
+	"asfppro/modules/analytics/internal/repository"
+	"asfppro/pkg/config"
+	"asfppro/pkg/db"
+	logpkg "asfppro/pkg/log"
+	"asfppro/pkg/queue"
+)
+
+func main() {
+	var (
+		fromFlag = flag.String("from", "", "start of the replay range, RFC3339 or YYYY-MM-DD (required)")
+		toFlag   = flag.String("to", "", "end of the replay range, exclusive (default now)")
+		mode     = flag.String("mode", "direct", "direct writes ClickHouse, publish republishes events to the queue")
+		batch    = flag.Int("batch", 500, "events per batch")
+		dryRun   = flag.Bool("dry-run", false, "read and convert events without writing")
+	)
+	flag.Parse()
+
+	from, err := parseTime(*fromFlag)
+	if err != nil || from.IsZero() {
+		stdlog.Fatalf("invalid -from %q: a start time is required", *fromFlag)
+	}
+	to, err := parseTime(*toFlag)
+	if err != nil {
+		stdlog.Fatalf("invalid -to %q: %v", *toFlag, err)
+	}
+
+	cfg, err := config.Load("ANALYTICS")
+	if err != nil {
+		stdlog.Fatalf("load config: %v", err)
+	}
+
+	logger, err := logpkg.New(logpkg.Options{Env: cfg.Env, Service: cfg.AppName, Level: cfg.LogLevel, Format: cfg.LogFormat})
+
+	if err != nil {
+
+		stdlog.Fatalf("init logger: %v", err)
+
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	poolCfg := db.PoolConfig{
+		MaxConns:        int32(cfg.DBMaxConns),
+		MinConns:        int32(cfg.DBMinConns),
+		MaxConnIdleTime: cfg.DBMaxConnIdle,
+		MaxConnLifetime: cfg.DBMaxConnLife,
+	}
+	pool, err := db.NewPostgresPoolWithConfig(ctx, cfg.DatabaseURL, poolCfg)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("postgres connect")
+	}
+	defer pool.Close()
+
+	// The event log is read in bulk, so it comes from a replica when one is configured.
+	router, err := db.NewRouter(ctx, pool, cfg.Replicas(), poolCfg, cfg.DBReplicaMaxLag, logger)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("postgres replicas")
+	}
+	defer router.Close()
+
+	var sink replay.Sink
+	switch *mode {
+	case "direct":
+		click, err := db.NewClickHouse(ctx, cfg.ClickHouseDSN)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("clickhouse connect")
+		}
+		defer func() { _ = click.Close() }()
+		sink = replay.NewDirectSink(repository.NewEventRepository(click))
+	case "publish":
+		routes, err := queue.ParseSubscriptions(cfg.QueueRoutes)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("parse queue subscriptions")
+		}
+		publisher, err := queue.NewPublisher(queue.PublisherConfig{
+			Backend:       cfg.QueueBackend,
+			Addr:          cfg.TarantoolAddr,
+			Tube:          cfg.TarantoolQueue,
+			Producer:      "crm",
+			Subscriptions: routes,
+		})
+		if err != nil {
+			logger.Fatal().Err(err).Msg("queue connect")
+		}
+		defer publisher.Close()
+		sink = replay.NewPublishSink(publisher, fmt.Sprintf("replay-%d", time.Now().Unix()))
+	default:
+		logger.Fatal().Str("mode", *mode).Msg("unknown replay mode")
+	}
+
+	replayer := replay.New(repository.NewDealEventSource(router), sink, logger, func(p replay.Progress) {
+		logger.Info().
+			Int64("processed", p.Processed).
+			Int64("skipped", p.Skipped).
+			Int64("total", p.Total).
+			Int64("lastId", p.LastID).
+			Dur("elapsed", p.Elapsed).
+			Msg("replay progress")
+	})
+
+	logger.Info().Time("from", from).Time("to", to).Str("mode", *mode).Bool("dryRun", *dryRun).Msg("replay started")
+	progress, err := replayer.Run(ctx, replay.Options{From: from, To: to, BatchSize: *batch, DryRun: *dryRun})
+	if err != nil {
+		logger.Error().Err(err).Int64("lastId", progress.LastID).Msg("replay failed")
+		os.Exit(1)
+	}
+	logger.Info().
+		Int64("processed", progress.Processed).
+		Int64("skipped", progress.Skipped).
+		Dur("elapsed", progress.Elapsed).
+		Msg("replay finished")
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if ts, err := time.Parse(time.RFC3339, value); err == nil {
+		return ts, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
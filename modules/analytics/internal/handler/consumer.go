@@ -3,7 +3,7 @@ package handler
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"strings"
 	"time"
 
@@ -12,12 +12,14 @@ import (
 
 	"asfppro/modules/analytics/internal/repository"
 	"asfppro/pkg/audit"
+	logpkg "asfppro/pkg/log"
 	"asfppro/pkg/queue"
 )
 
 // Consumer handles incoming Tarantool jobs.
 type Consumer struct {
-	queue   *queue.Consumer
+	queue   queue.Consumer
+	dedup   *queue.Idempotent
 	repo    *repository.EventRepository
 	auditor *audit.Recorder
 	logger  zerolog.Logger
@@ -31,14 +33,16 @@ type dealCreatedPayload struct {
 	CustomerID string  `json:"customerId"`
 	CreatedBy  string  `json:"createdBy"`
 	CreatedAt  string  `json:"createdAt"`
+	Company    string  `json:"companyCode"`
 }
 
-// NewConsumer constructs consumer worker.
-func NewConsumer(queue *queue.Consumer, repo *repository.EventRepository, auditor *audit.Recorder, logger zerolog.Logger) *Consumer {
-	return &Consumer{queue: queue, repo: repo, auditor: auditor, logger: logger}
+// NewConsumer constructs consumer worker. dedup may be nil to disable duplicate detection.
+func NewConsumer(queue queue.Consumer, dedup *queue.Idempotent, repo *repository.EventRepository, auditor *audit.Recorder, logger zerolog.Logger) *Consumer {
+	return &Consumer{queue: queue, dedup: dedup, repo: repo, auditor: auditor, logger: logger}
 }
 
-// Run begins polling loop until ctx cancelled.
+// Run begins polling loop until ctx cancelled. A job already taken when ctx is cancelled
+// is still processed and settled before Run returns.
 func (c *Consumer) Run(ctx context.Context) {
 	c.logger.Info().Msg("analytics consumer started")
 	for {
@@ -49,51 +53,147 @@ func (c *Consumer) Run(ctx context.Context) {
 		default:
 		}
 
-		var message struct {
-			EventType string          `json:"event_type"`
-			Payload   json.RawMessage `json:"payload"`
+		job, err := c.queue.Next(ctx)
+		if errors.Is(err, queue.ErrInvalidEnvelope) {
+			c.logger.Warn().Err(err).Msg("invalid event moved to dead-letter tube")
+			continue
 		}
-
-		_, err := c.queue.Next(ctx, &message)
 		if err != nil {
-			c.logger.Error().Err(err).Msg("fetch event")
-			time.Sleep(time.Second)
+			if ctx.Err() == nil {
+				c.logger.Error().Err(err).Msg("fetch event")
+			}
+			sleep(ctx, time.Second)
 			continue
 		}
-		if len(message.Payload) == 0 {
-			time.Sleep(250 * time.Millisecond)
+		if job == nil {
+			sleep(ctx, 250*time.Millisecond)
 			continue
 		}
 
-		switch message.EventType {
-		case "DealCreated":
-			var payload dealCreatedPayload
-			if err := json.Unmarshal(message.Payload, &payload); err != nil {
-				c.logger.Error().Err(err).Bytes("payload", message.Payload).Msg("decode deal event")
-				continue
-			}
-			ts, err := time.Parse(time.RFC3339, payload.CreatedAt)
-			if err != nil {
-				c.logger.Error().Err(err).Str("value", payload.CreatedAt).Msg("parse timestamp")
-				continue
-			}
-			if err := c.repo.InsertDealCreated(ctx, repository.DealCreatedEvent{
-				ID:         payload.ID,
-				Stage:      payload.Stage,
-				Amount:     payload.Amount,
-				Currency:   payload.Currency,
-				CustomerID: payload.CustomerID,
-				CreatedBy:  payload.CreatedBy,
-				CreatedAt:  ts,
-			}); err != nil {
-				c.logger.Error().Err(err).Msg("persist deal analytics")
-				continue
-			}
-			c.recordAudit(ctx, payload, ts)
-		default:
-			c.logger.Warn().Str("event", message.EventType).Msg("skip unknown event")
+		c.handle(context.WithoutCancel(ctx), job)
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// handle processes one job and settles it: ack on success, release on transient
+// failures, postpone while another consumer holds the event and bury when the message
+// can never be processed.
+func (c *Consumer) handle(ctx context.Context, job *queue.Job) {
+	ctx = job.Context(ctx)
+	env := job.Envelope()
+	logger := *logpkg.Ctx(ctx, c.logger.With().
+		Str("jobId", job.ID()).
+		Str("eventId", env.ID).
+		Str("event", env.Type).
+		Int("version", env.Version).
+		Str("correlationId", env.CorrelationID).
+		Int("attempts", job.Attempts()).
+		Logger())
+
+	switch job.EventType() {
+	case "DealCreated":
+		var payload dealCreatedPayload
+		if err := job.Decode(&payload); err != nil {
+			logger.Error().Err(err).Bytes("payload", job.Payload()).Msg("decode deal event")
+			c.bury(ctx, job, logger, "decode payload: "+err.Error())
+			return
+		}
+		ts, err := time.Parse(time.RFC3339, payload.CreatedAt)
+		if err != nil {
+			logger.Error().Err(err).Str("value", payload.CreatedAt).Msg("parse timestamp")
+			c.bury(ctx, job, logger, "parse timestamp: "+err.Error())
+			return
 		}
+		duplicate, err := c.dedup.Handle(ctx, env, func(ctx context.Context) error {
+			return c.repo.InsertDealCreated(ctx, repository.DealCreatedEvent{
+				ID:          payload.ID,
+				Stage:       payload.Stage,
+				Amount:      payload.Amount,
+				Currency:    payload.Currency,
+				CustomerID:  payload.CustomerID,
+				CreatedBy:   payload.CreatedBy,
+				CreatedAt:   ts,
+				CompanyCode: payload.Company,
+				OccurredAt:  env.OccurredAt,
+			})
+		})
+		if errors.Is(err, queue.ErrEventInProgress) {
+			logger.Info().Msg("deal event is handled by another consumer")
+			c.postpone(ctx, job, logger)
+			return
+		}
+		if err != nil && !errors.Is(err, queue.ErrMarkProcessed) {
+			logger.Error().Err(err).Msg("persist deal analytics")
+			c.release(ctx, job, logger)
+			return
+		}
+		if err != nil {
+			// The row is already in ClickHouse; retrying would only rewrite it.
+			logger.Warn().Err(err).Msg("record processed deal event")
+		}
+		if err := job.Ack(ctx); err != nil {
+			logger.Error().Err(err).Msg("ack deal event")
+		}
+		if duplicate {
+			logger.Debug().Msg("skip duplicate deal event")
+			return
+		}
+		c.recordAudit(ctx, payload, ts)
+	default:
+		logger.Warn().Msg("skip unknown event")
+		if err := job.Ack(ctx); err != nil {
+			logger.Error().Err(err).Msg("ack unknown event")
+		}
+	}
+}
+
+func (c *Consumer) release(ctx context.Context, job *queue.Job, logger zerolog.Logger) {
+	delay := retryDelay(job.Attempts())
+	dead, err := job.Release(ctx, delay)
+	if err != nil {
+		logger.Error().Err(err).Msg("release event")
+		return
+	}
+	if dead {
+		logger.Warn().Msg("event moved to dead-letter tube after max attempts")
+		return
+	}
+	logger.Info().Dur("delay", delay).Msg("event released for retry")
+}
+
+// postpone retries the job once the claim held by another consumer has expired, without
+// counting the delivery as a failed attempt.
+func (c *Consumer) postpone(ctx context.Context, job *queue.Job, logger zerolog.Logger) {
+	if err := job.Postpone(ctx, queue.ClaimLease); err != nil {
+		logger.Error().Err(err).Msg("postpone event")
+		return
+	}
+	logger.Info().Dur("delay", queue.ClaimLease).Msg("event postponed until the claim expires")
+}
+
+func (c *Consumer) bury(ctx context.Context, job *queue.Job, logger zerolog.Logger, reason string) {
+	if err := job.Bury(ctx, reason); err != nil {
+		logger.Error().Err(err).Msg("bury event")
+		return
+	}
+	logger.Warn().Str("reason", reason).Msg("event moved to dead-letter tube")
+}
+
+// retryDelay grows linearly with attempts and is capped at one minute.
+func retryDelay(attempts int) time.Duration {
+	delay := time.Duration(attempts+1) * 5 * time.Second
+	if delay > time.Minute {
+		return time.Minute
 	}
+	return delay
 }
 
 func (c *Consumer) recordAudit(ctx context.Context, payload dealCreatedPayload, occurred time.Time) {
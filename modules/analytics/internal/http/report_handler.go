@@ -14,6 +14,7 @@ import (
 
 	"asfppro/modules/analytics/internal/repository"
 	"asfppro/pkg/audit"
+	"asfppro/pkg/tenant"
 )
 
 // ReportHandler exposes analytics endpoints.
@@ -30,7 +31,7 @@ func NewReportHandler(repo *repository.EventRepository, auditor *audit.Recorder,
 
 // Register wires endpoints.
 func (h *ReportHandler) Register(app *fiber.App) {
-	base := app.Group("/api/v1/analytics")
+	base := app.Group("/api/v1/analytics", tenant.Middleware())
 	reports := base.Group("/reports")
 	reports.Get("/conversion", h.conversion)
 	reports.Get("/manager-load", h.managerLoad)
@@ -46,7 +47,7 @@ func (h *ReportHandler) conversion(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
-	rows, err := h.repo.ConversionReport(c.Context(), from, to)
+	rows, err := h.repo.ConversionReport(c.UserContext(), from, to)
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
@@ -82,7 +83,7 @@ func (h *ReportHandler) managerLoad(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
-	rows, err := h.repo.ManagerLoad(c.Context(), from, to)
+	rows, err := h.repo.ManagerLoad(c.UserContext(), from, to)
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
@@ -111,7 +112,7 @@ func (h *ReportHandler) exportConversion(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
-	rows, err := h.repo.ConversionReport(c.Context(), from, to)
+	rows, err := h.repo.ConversionReport(c.UserContext(), from, to)
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
@@ -142,7 +143,7 @@ func (h *ReportHandler) exportManagerLoad(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
-	rows, err := h.repo.ManagerLoad(c.Context(), from, to)
+	rows, err := h.repo.ManagerLoad(c.UserContext(), from, to)
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
@@ -4,9 +4,6 @@ package http
 import (
 	"context"
 	"fmt"
-	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	ch "github.com/ClickHouse/clickhouse-go/v2"
@@ -18,14 +15,17 @@ import (
 	"asfppro/pkg/audit"
 	"asfppro/pkg/config"
 	"asfppro/pkg/health"
+	"asfppro/pkg/lifecycle"
+	logpkg "asfppro/pkg/log"
+	"asfppro/pkg/metrics"
+	"asfppro/pkg/tracing"
 )
 
 // Server wraps Fiber application for analytics API.
 type Server struct {
-	app      *fiber.App
-	cfg      config.AppConfig
-	logger   zerolog.Logger
-	shutdown chan os.Signal
+	app    *fiber.App
+	cfg    config.AppConfig
+	logger zerolog.Logger
 }
 
 // NewServer creates configured HTTP server instance.
@@ -39,50 +39,31 @@ func NewServer(cfg config.AppConfig, logger zerolog.Logger, repo *repository.Eve
 	reportHandler := NewReportHandler(repo, auditor, logger)
 
 	app.Use(recover.New())
-	app.Use(loggerMiddleware(logger))
+	app.Use(tracing.Middleware())
+	if cfg.MetricsEnable {
+		app.Use(metrics.Middleware(metrics.Default()))
+	}
+	app.Use(logpkg.Middleware(logger, uint32(cfg.LogSampleBurst)))
 	app.Get("/health", health.LiveHandler())
 	app.Get("/ready", readyHandler(conn))
 	app.Get("/openapi.json", OpenAPI(openapi))
 	reportHandler.Register(app)
 
 	return &Server{
-		app:      app,
-		cfg:      cfg,
-		logger:   logger,
-		shutdown: make(chan os.Signal, 1),
+		app:    app,
+		cfg:    cfg,
+		logger: logger,
 	}, nil
 }
 
-// Run starts server and handles graceful shutdown.
-func (s *Server) Run() error {
-	addr := fmt.Sprintf(":%s", s.cfg.HTTPPort)
-	go func() {
-		signal.Notify(s.shutdown, syscall.SIGINT, syscall.SIGTERM)
-		sig := <-s.shutdown
-		s.logger.Info().Str("signal", sig.String()).Msg("analytics api shutdown")
-		tx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
-		defer cancel()
-		if err := s.app.Shutdown(); err != nil {
-			s.logger.Error().Err(err).Msg("analytics api shutdown error")
-		}
-		<-tx.Done()
-	}()
-
-	s.logger.Info().Str("addr", addr).Msg("analytics api listening")
-	if err := s.app.Listen(addr); err != nil {
-		return fmt.Errorf("start fiber: %w", err)
-	}
-	return nil
+// Register adds the server to lc; on shutdown it drains in-flight requests.
+func (s *Server) Register(lc *lifecycle.Manager) {
+	lc.Fiber("http", s.app, fmt.Sprintf(":%s", s.cfg.HTTPPort))
 }
 
 func readyHandler(conn ch.Conn) fiber.Handler {
 	if conn == nil {
-		return func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
-				"status": "degraded",
-				"error":  "clickhouse connection not initialised",
-			})
-		}
+		return health.Unavailable("clickhouse connection not initialised")
 	}
 
 	checks := []health.Check{
@@ -97,19 +78,3 @@ func readyHandler(conn ch.Conn) fiber.Handler {
 
 	return health.FiberHandler(checks)
 }
-
-func loggerMiddleware(logger zerolog.Logger) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		start := time.Now()
-		if err := c.Next(); err != nil {
-			return err
-		}
-		logger.Info().Fields(map[string]any{
-			"path":   c.Path(),
-			"method": c.Method(),
-			"status": c.Response().StatusCode(),
-			"took":   time.Since(start).String(),
-		}).Msg("analytics-api")
-		return nil
-	}
-}
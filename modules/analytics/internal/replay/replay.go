@@ -0,0 +1,220 @@
+// Package replay rebuilds analytics projections from the CRM event log.
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"asfppro/modules/analytics/internal/repository"
+	"asfppro/pkg/queue"
+)
+
+// sourceEventType is the CRM event log type replayed into analytics.
+const sourceEventType = "deal.created"
+
+const defaultBatchSize = 500
+
+// Source reads historical events page by page.
+type Source interface {
+	Count(ctx context.Context, eventType string, from, to time.Time) (int64, error)
+	Fetch(ctx context.Context, eventType string, from, to time.Time, afterID int64, limit int) ([]repository.SourceEvent, error)
+}
+
+// Sink writes a batch of replayed events into the projection.
+type Sink interface {
+	Write(ctx context.Context, events []repository.DealCreatedEvent) error
+}
+
+// Options bound the replay run.
+type Options struct {
+	From      time.Time
+	To        time.Time
+	BatchSize int
+	// DryRun reads and converts events without writing them.
+	DryRun bool
+}
+
+// Progress describes replay state after each batch.
+type Progress struct {
+	Total     int64
+	Processed int64
+	Skipped   int64
+	LastID    int64
+	Elapsed   time.Duration
+}
+
+// Replayer copies events from Source to Sink in batches.
+type Replayer struct {
+	source     Source
+	sink       Sink
+	logger     zerolog.Logger
+	onProgress func(Progress)
+}
+
+// New constructs replayer. onProgress may be nil.
+func New(source Source, sink Sink, logger zerolog.Logger, onProgress func(Progress)) *Replayer {
+	return &Replayer{source: source, sink: sink, logger: logger, onProgress: onProgress}
+}
+
+// Run replays events created within [opts.From, opts.To). Sinks overwrite rows by deal ID,
+// so a run can be repeated or resumed safely.
+func (r *Replayer) Run(ctx context.Context, opts Options) (Progress, error) {
+	if opts.To.IsZero() {
+		opts.To = time.Now()
+	}
+	if !opts.From.Before(opts.To) {
+		return Progress{}, errors.New("replay range is empty")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+
+	started := time.Now()
+	total, err := r.source.Count(ctx, sourceEventType, opts.From, opts.To)
+	if err != nil {
+		return Progress{}, err
+	}
+
+	progress := Progress{Total: total}
+	for {
+		batch, err := r.source.Fetch(ctx, sourceEventType, opts.From, opts.To, progress.LastID, opts.BatchSize)
+		if err != nil {
+			return progress, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		events := make([]repository.DealCreatedEvent, 0, len(batch))
+		for _, src := range batch {
+			event, err := toDealCreated(src)
+			if err != nil {
+				progress.Skipped++
+				r.logger.Warn().Err(err).Int64("sourceId", src.ID).Msg("skip malformed event")
+				continue
+			}
+			events = append(events, event)
+		}
+
+		if !opts.DryRun {
+			if err := r.sink.Write(ctx, events); err != nil {
+				return progress, fmt.Errorf("write batch after id %d: %w", progress.LastID, err)
+			}
+		}
+
+		progress.Processed += int64(len(events))
+		progress.LastID = batch[len(batch)-1].ID
+		progress.Elapsed = time.Since(started)
+		if r.onProgress != nil {
+			r.onProgress(progress)
+		}
+	}
+
+	progress.Elapsed = time.Since(started)
+	return progress, nil
+}
+
+// toDealCreated converts CRM log entry into analytics row. Older entries lack id and createdAt
+// in the payload, so the log row columns fill the gaps.
+func toDealCreated(src repository.SourceEvent) (repository.DealCreatedEvent, error) {
+	var payload struct {
+		ID         string  `json:"id"`
+		Stage      string  `json:"stage"`
+		Amount     float64 `json:"amount"`
+		Currency   string  `json:"currency"`
+		CustomerID string  `json:"customerId"`
+		CreatedBy  string  `json:"createdBy"`
+		CreatedAt  string  `json:"createdAt"`
+	}
+	if err := json.Unmarshal(src.Payload, &payload); err != nil {
+		return repository.DealCreatedEvent{}, fmt.Errorf("decode payload: %w", err)
+	}
+
+	event := repository.DealCreatedEvent{
+		ID:         strings.TrimSpace(payload.ID),
+		Stage:      payload.Stage,
+		Amount:     payload.Amount,
+		Currency:   payload.Currency,
+		CustomerID: payload.CustomerID,
+		CreatedBy:  payload.CreatedBy,
+		CreatedAt:  src.CreatedAt.UTC(),
+		OccurredAt: src.CreatedAt.UTC(),
+		// The deal row is authoritative, also for events logged before companies existed.
+		CompanyCode: src.CompanyCode,
+	}
+	if event.ID == "" {
+		event.ID = src.DealID
+	}
+	if payload.CreatedAt != "" {
+		ts, err := time.Parse(time.RFC3339, payload.CreatedAt)
+		if err != nil {
+			return repository.DealCreatedEvent{}, fmt.Errorf("parse createdAt: %w", err)
+		}
+		event.CreatedAt = ts
+	}
+	if event.Stage == "" {
+		return repository.DealCreatedEvent{}, errors.New("stage is missing")
+	}
+
+	return event, nil
+}
+
+// DirectSink writes straight into ClickHouse through the analytics repository.
+type DirectSink struct {
+	repo *repository.EventRepository
+}
+
+// NewDirectSink builds sink replacing rows in analytics.events.
+func NewDirectSink(repo *repository.EventRepository) *DirectSink {
+	return &DirectSink{repo: repo}
+}
+
+// Write replaces rows for the batch deals.
+func (s *DirectSink) Write(ctx context.Context, events []repository.DealCreatedEvent) error {
+	return s.repo.ReplaceDealCreated(ctx, events)
+}
+
+// PublishSink republishes events to the queue with fresh IDs, so idempotent consumers do not
+// drop them as duplicates of the original deliveries. The consumer replaces the row of each
+// deal, so a replayed event overwrites the projection instead of adding a row.
+type PublishSink struct {
+	publisher     queue.Publisher
+	correlationID string
+}
+
+// NewPublishSink builds sink publishing DealCreated envelopes tagged with correlationID.
+func NewPublishSink(publisher queue.Publisher, correlationID string) *PublishSink {
+	return &PublishSink{publisher: publisher, correlationID: correlationID}
+}
+
+// Write publishes each event of the batch.
+func (s *PublishSink) Write(ctx context.Context, events []repository.DealCreatedEvent) error {
+	ctx = queue.WithCorrelationID(ctx, s.correlationID)
+	for _, event := range events {
+		payload := map[string]any{
+			"id":          event.ID,
+			"stage":       event.Stage,
+			"amount":      event.Amount,
+			"currency":    event.Currency,
+			"customerId":  event.CustomerID,
+			"createdBy":   event.CreatedBy,
+			"createdAt":   event.CreatedAt.UTC().Format(time.RFC3339),
+			"companyCode": event.CompanyCode,
+		}
+		env, err := queue.NewEnvelope(ctx, "DealCreated", 1, "crm", payload)
+		if err != nil {
+			return err
+		}
+		env.OccurredAt = event.OccurredAt
+		if err := s.publisher.PublishEnvelope(ctx, env); err != nil {
+			return fmt.Errorf("publish deal %s: %w", event.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"asfppro/modules/analytics/internal/repository"
+	"asfppro/pkg/queue"
+)
+
+type fakeSource struct {
+	events []repository.SourceEvent
+}
+
+func (s *fakeSource) Count(context.Context, string, time.Time, time.Time) (int64, error) {
+	return int64(len(s.events)), nil
+}
+
+func (s *fakeSource) Fetch(_ context.Context, _ string, _, _ time.Time, afterID int64, limit int) ([]repository.SourceEvent, error) {
+	var out []repository.SourceEvent
+	for _, e := range s.events {
+		if e.ID > afterID && len(out) < limit {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+type fakeSink struct {
+	batches [][]repository.DealCreatedEvent
+}
+
+func (s *fakeSink) Write(_ context.Context, events []repository.DealCreatedEvent) error {
+	s.batches = append(s.batches, events)
+	return nil
+}
+
+func TestReplayerRun(t *testing.T) {
+	created := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	source := &fakeSource{events: []repository.SourceEvent{
+		{ID: 1, DealID: "d-1", Payload: json.RawMessage(`{"stage":"new","amount":10,"currency":"RUB"}`), CreatedAt: created, CompanyCode: "MAIN"},
+		{ID: 2, DealID: "d-2", Payload: json.RawMessage(`{"id":"d-2","stage":"won","createdAt":"2024-02-01T00:00:00Z"}`), CreatedAt: created},
+		{ID: 3, DealID: "d-3", Payload: json.RawMessage(`{"amount":5}`), CreatedAt: created},
+	}}
+	sink := &fakeSink{}
+
+	var reports []Progress
+	replayer := New(source, sink, zerolog.Nop(), func(p Progress) { reports = append(reports, p) })
+
+	progress, err := replayer.Run(context.Background(), Options{From: created.Add(-time.Hour), To: created.Add(time.Hour), BatchSize: 2})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), progress.Total)
+	require.Equal(t, int64(2), progress.Processed)
+	require.Equal(t, int64(1), progress.Skipped)
+	require.Equal(t, int64(3), progress.LastID)
+	require.Len(t, reports, 2)
+
+	require.Len(t, sink.batches, 2)
+	first := sink.batches[0]
+	require.Equal(t, "d-1", first[0].ID)
+	require.Equal(t, created, first[0].CreatedAt)
+	require.Equal(t, "MAIN", first[0].CompanyCode)
+	require.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), first[1].CreatedAt)
+	require.Empty(t, sink.batches[1])
+}
+
+func TestReplayerDryRun(t *testing.T) {
+	created := time.Now().UTC()
+	source := &fakeSource{events: []repository.SourceEvent{
+		{ID: 1, DealID: "d-1", Payload: json.RawMessage(`{"stage":"new"}`), CreatedAt: created},
+	}}
+	sink := &fakeSink{}
+
+	progress, err := New(source, sink, zerolog.Nop(), nil).Run(context.Background(), Options{From: created.Add(-time.Minute), DryRun: true})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), progress.Processed)
+	require.Empty(t, sink.batches)
+}
+
+func TestPublishSinkUsesFreshIDs(t *testing.T) {
+	ctx := context.Background()
+	broker := queue.NewMemoryBroker()
+	sub := queue.Subscription{Group: "analytics", Topics: []string{"DealCreated"}}
+	publisher, err := broker.Publisher(queue.PublisherConfig{Tube: "events", Subscriptions: []queue.Subscription{sub}})
+	require.NoError(t, err)
+	consumer, err := broker.Consumer(queue.ConsumerConfig{Tube: "events", Group: sub.Group, Topics: sub.Topics, TakeTimeout: 10 * time.Millisecond})
+	require.NoError(t, err)
+
+	event := repository.DealCreatedEvent{ID: "d-1", Stage: "new", Currency: "RUB", CreatedAt: time.Now().UTC(), OccurredAt: time.Now().UTC()}
+	sink := NewPublishSink(publisher, "replay-1")
+	require.NoError(t, sink.Write(ctx, []repository.DealCreatedEvent{event}))
+	require.NoError(t, sink.Write(ctx, []repository.DealCreatedEvent{event}))
+
+	seen := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		job, err := consumer.Next(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, job)
+		env := job.Envelope()
+		require.NotEqual(t, queue.StableID("crm.deal", "d-1", "DealCreated"), env.ID)
+		require.Equal(t, "replay-1", env.CorrelationID)
+		seen[env.ID] = true
+		require.NoError(t, job.Ack(ctx))
+	}
+	require.Len(t, seen, 2)
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"asfppro/pkg/db"
+)
+
+// SourceEvent is a historical CRM event read for replay.
+type SourceEvent struct {
+	ID        int64
+	DealID    string
+	EventType string
+	Payload   json.RawMessage
+	CreatedAt time.Time
+	// CompanyCode is the owning company of the deal; older payloads do not carry it.
+	CompanyCode string
+}
+
+// DealEventSource reads the CRM deal event log (crm.deal_events), the source of truth for replay.
+type DealEventSource struct {
+	pool db.Querier
+}
+
+// NewDealEventSource builds source bound to a pgx pool, or a db.Router to read from replicas.
+func NewDealEventSource(pool db.Querier) *DealEventSource {
+	return &DealEventSource{pool: pool}
+}
+
+// Count returns number of events of eventType within [from, to).
+func (s *DealEventSource) Count(ctx context.Context, eventType string, from, to time.Time) (int64, error) {
+	const query = `SELECT COUNT(*) FROM crm.deal_events WHERE event_type = $1 AND created_at >= $2 AND created_at < $3`
+
+	var total int64
+	if err := s.pool.QueryRow(ctx, query, eventType, from, to).Scan(&total); err != nil {
+		return 0, fmt.Errorf("count deal events: %w", err)
+	}
+	return total, nil
+}
+
+// Fetch returns next page of events of eventType within [from, to) ordered by ID after afterID.
+func (s *DealEventSource) Fetch(ctx context.Context, eventType string, from, to time.Time, afterID int64, limit int) ([]SourceEvent, error) {
+	const query = `
+	SELECT e.id, e.deal_id, e.event_type, e.payload, e.created_at, COALESCE(d.company_code, '')
+	FROM crm.deal_events e
+	LEFT JOIN crm.deals d ON d.id = e.deal_id
+	WHERE e.event_type = $1 AND e.created_at >= $2 AND e.created_at < $3 AND e.id > $4
+	ORDER BY e.id
+	LIMIT $5
+	`
+	rows, err := s.pool.Query(ctx, query, eventType, from, to, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("select deal events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []SourceEvent
+	for rows.Next() {
+		var (
+			event   SourceEvent
+			payload []byte
+		)
+		if err := rows.Scan(&event.ID, &event.DealID, &event.EventType, &payload, &event.CreatedAt, &event.CompanyCode); err != nil {
+			return nil, fmt.Errorf("scan deal event: %w", err)
+		}
+		event.Payload = payload
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
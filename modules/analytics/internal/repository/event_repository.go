@@ -7,6 +7,7 @@ import (
 	"time"
 
 	ch "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
 )
 
 // EventRepository stores analytics events in ClickHouse.
@@ -19,20 +20,54 @@ func NewEventRepository(conn ch.Conn) *EventRepository {
 	return &EventRepository{conn: conn}
 }
 
-// InsertDealCreated saves deal created analytics event.
+// InsertDealCreated saves deal created analytics event. analytics.events is a
+// ReplacingMergeTree keyed by event type and deal, so a redelivered event collapses into
+// the row written before.
 func (r *EventRepository) InsertDealCreated(ctx context.Context, payload DealCreatedEvent) error {
 	batch, err := r.conn.PrepareBatch(ctx, "INSERT INTO analytics.events")
 	if err != nil {
 		return fmt.Errorf("prepare batch: %w", err)
 	}
 
-	if err := batch.Append(time.Now(), "deal.created", payload.ID, payload.Stage, payload.Amount, payload.Currency, payload.CustomerID, payload.CreatedBy, payload.CreatedAt); err != nil {
-		return fmt.Errorf("append batch: %w", err)
+	if err := appendDealCreated(batch, payload); err != nil {
+		return err
 	}
 
 	return batch.Send()
 }
 
+// ReplaceDealCreated writes deal created rows that supersede the stored rows of the same
+// deals: the newest ingested_at wins on merge and in FINAL queries, so replaying the same
+// events twice leaves a single row per deal.
+func (r *EventRepository) ReplaceDealCreated(ctx context.Context, events []DealCreatedEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	batch, err := r.conn.PrepareBatch(ctx, "INSERT INTO analytics.events")
+	if err != nil {
+		return fmt.Errorf("prepare batch: %w", err)
+	}
+	for _, event := range events {
+		if err := appendDealCreated(batch, event); err != nil {
+			return err
+		}
+	}
+
+	return batch.Send()
+}
+
+func appendDealCreated(batch driver.Batch, payload DealCreatedEvent) error {
+	occurredAt := payload.OccurredAt
+	if occurredAt.IsZero() {
+		occurredAt = time.Now()
+	}
+	if err := batch.Append(occurredAt, "deal.created", payload.ID, payload.Stage, payload.Amount, payload.Currency, payload.CustomerID, payload.CreatedBy, payload.CreatedAt, payload.CompanyCode, time.Now()); err != nil {
+		return fmt.Errorf("append batch: %w", err)
+	}
+	return nil
+}
+
 // DealCreatedEvent mirrors payload from CRM event.
 type DealCreatedEvent struct {
 	ID         string
@@ -42,4 +77,8 @@ type DealCreatedEvent struct {
 	CustomerID string
 	CreatedBy  string
 	CreatedAt  time.Time
+	// CompanyCode scopes the row to a company in reports.
+	CompanyCode string
+	// OccurredAt is the original event time; zero means now.
+	OccurredAt time.Time
 }
@@ -3,6 +3,8 @@ package repository
 import (
 	"context"
 	"time"
+
+	"asfppro/pkg/tenant"
 )
 
 // ConversionRow represents conversion metrics per period.
@@ -21,8 +23,12 @@ type ManagerLoadRow struct {
 	TotalAmount float64
 }
 
-// ConversionReport gathers aggregated conversion data within range.
+// ConversionReport gathers aggregated conversion data of the active company within range.
 func (r *EventRepository) ConversionReport(ctx context.Context, from, to time.Time) ([]ConversionRow, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
 	query := `
 SELECT
     toStartOfMonth(created_at) AS period,
@@ -30,11 +36,11 @@ SELECT
     countIf(stage = 'won') AS won_count,
     sum(amount) AS total_amount,
     sumIf(amount, stage = 'won') AS won_amount
-FROM analytics.events
-WHERE event_type = 'deal.created' AND created_at BETWEEN ? AND ?
+FROM analytics.events FINAL
+WHERE event_type = 'deal.created' AND company_code = ? AND created_at BETWEEN ? AND ?
 GROUP BY period
 ORDER BY period`
-	rows, err := r.conn.Query(ctx, query, from, to)
+	rows, err := r.conn.Query(ctx, query, company, from, to)
 	if err != nil {
 		return nil, err
 	}
@@ -52,18 +58,22 @@ ORDER BY period`
 	return result, rows.Err()
 }
 
-// ManagerLoad gathers deal counts grouped by manager (created_by).
+// ManagerLoad gathers deal counts of the active company grouped by manager (created_by).
 func (r *EventRepository) ManagerLoad(ctx context.Context, from, to time.Time) ([]ManagerLoadRow, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
 	query := `
 SELECT
     if(length(created_by) = 0, 'unknown', created_by) AS manager,
     count() AS total_count,
     sum(amount) AS total_amount
-FROM analytics.events
-WHERE event_type = 'deal.created' AND created_at BETWEEN ? AND ?
+FROM analytics.events FINAL
+WHERE event_type = 'deal.created' AND company_code = ? AND created_at BETWEEN ? AND ?
 GROUP BY manager
 ORDER BY total_count DESC`
-	rows, err := r.conn.Query(ctx, query, from, to)
+	rows, err := r.conn.Query(ctx, query, company, from, to)
 	if err != nil {
 		return nil, err
 	}
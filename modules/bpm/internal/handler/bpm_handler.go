@@ -12,6 +12,7 @@ import (
 	"asfppro/modules/bpm/internal/entity"
 	"asfppro/modules/bpm/internal/repository"
 	"asfppro/modules/bpm/internal/service"
+	logpkg "asfppro/pkg/log"
 )
 
 // RegisterRoutes регистрирует BPM minimal API.
@@ -40,7 +41,7 @@ func listProcesses(svc *service.Service) fiber.Handler {
 			Limit:  parseLimit(c.Query("limit"), 50),
 			Status: c.Query("status"),
 		}
-		processes, err := svc.ListProcesses(c.Context(), filter)
+		processes, err := svc.ListProcesses(c.UserContext(), filter)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
@@ -55,11 +56,11 @@ func createProcess(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 		}
 
-		proc, err := svc.CreateProcess(c.Context(), req)
+		proc, err := svc.CreateProcess(c.UserContext(), req)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
-		logger.Info().Str("processId", proc.ID).Msg("bpm process created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("processId", proc.ID).Msg("bpm process created")
 		return c.Status(fiber.StatusCreated).JSON(proc)
 	}
 }
@@ -111,7 +112,7 @@ func updateProcess(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			input.Version = &version
 		}
 
-		proc, err := svc.UpdateProcess(c.Context(), id, input)
+		proc, err := svc.UpdateProcess(c.UserContext(), id, input)
 		if err != nil {
 			status := fiber.StatusBadRequest
 			if errors.Is(err, repository.ErrProcessNotFound) {
@@ -119,14 +120,14 @@ func updateProcess(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			}
 			return fiber.NewError(status, err.Error())
 		}
-		logger.Info().Str("processId", proc.ID).Msg("bpm process updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("processId", proc.ID).Msg("bpm process updated")
 		return c.JSON(proc)
 	}
 }
 
 func listForms(svc *service.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		forms, err := svc.ListForms(c.Context(), parseLimit(c.Query("limit"), 50))
+		forms, err := svc.ListForms(c.UserContext(), parseLimit(c.Query("limit"), 50))
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -141,11 +142,11 @@ func createForm(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 		}
 
-		form, err := svc.CreateForm(c.Context(), req)
+		form, err := svc.CreateForm(c.UserContext(), req)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
-		logger.Info().Str("formId", form.ID).Msg("bpm form created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("formId", form.ID).Msg("bpm form created")
 		return c.Status(fiber.StatusCreated).JSON(form)
 	}
 }
@@ -188,7 +189,7 @@ func updateForm(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			input.UISchema = &clone
 		}
 
-		form, err := svc.UpdateForm(c.Context(), id, input)
+		form, err := svc.UpdateForm(c.UserContext(), id, input)
 		if err != nil {
 			status := fiber.StatusBadRequest
 			if errors.Is(err, repository.ErrFormNotFound) {
@@ -196,7 +197,7 @@ func updateForm(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			}
 			return fiber.NewError(status, err.Error())
 		}
-		logger.Info().Str("formId", form.ID).Msg("bpm form updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("formId", form.ID).Msg("bpm form updated")
 		return c.JSON(form)
 	}
 }
@@ -207,7 +208,7 @@ func listTasks(svc *service.Service) fiber.Handler {
 			Limit:  parseLimit(c.Query("limit"), 50),
 			Status: c.Query("status"),
 		}
-		tasks, err := svc.ListTasks(c.Context(), filter)
+		tasks, err := svc.ListTasks(c.UserContext(), filter)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
@@ -222,11 +223,11 @@ func createTask(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 		}
 
-		task, err := svc.CreateTask(c.Context(), req)
+		task, err := svc.CreateTask(c.UserContext(), req)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
-		logger.Info().Str("taskId", task.ID).Msg("bpm task created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("taskId", task.ID).Msg("bpm task created")
 		return c.Status(fiber.StatusCreated).JSON(task)
 	}
 }
@@ -257,7 +258,7 @@ func updateTask(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			Payload:  payload.Payload,
 		}
 
-		task, err := svc.UpdateTask(c.Context(), id, input)
+		task, err := svc.UpdateTask(c.UserContext(), id, input)
 		if err != nil {
 			status := fiber.StatusBadRequest
 			if errors.Is(err, repository.ErrTaskNotFound) {
@@ -265,7 +266,7 @@ func updateTask(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			}
 			return fiber.NewError(status, err.Error())
 		}
-		logger.Info().Str("taskId", task.ID).Msg("bpm task updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("taskId", task.ID).Msg("bpm task updated")
 		return c.JSON(task)
 	}
 }
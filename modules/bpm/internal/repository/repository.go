@@ -15,6 +15,7 @@ import (
     "github.com/jackc/pgx/v5/pgxpool"
 
     "asfppro/modules/bpm/internal/entity"
+    "asfppro/pkg/db"
 )
 
 var (
@@ -29,11 +30,18 @@ var (
 // Repository provides BPM persistence.
 type Repository struct {
     pool *pgxpool.Pool
+    read db.Querier
 }
 
 // New creates repository.
 func New(pool *pgxpool.Pool) *Repository {
-    return &Repository{pool: pool}
+    return &Repository{pool: pool, read: pool}
+}
+
+// WithReader routes list queries through read, typically a db.Router sending them to replicas.
+func (r *Repository) WithReader(read db.Querier) *Repository {
+    r.read = read
+    return r
 }
 
 // ListProcesses returns limited processes.
@@ -53,7 +61,7 @@ func (r *Repository) ListProcesses(ctx context.Context, limit int, status string
         args = append(args, limit)
     }
 
-    rows, err := r.pool.Query(ctx, builder.String(), args...)
+    rows, err := r.read.Query(ctx, builder.String(), args...)
     if err != nil {
         return nil, fmt.Errorf("list processes: %w", err)
     }
@@ -167,7 +175,7 @@ func (r *Repository) getProcess(ctx context.Context, id uuid.UUID) (entity.Proce
 // ListForms returns forms for processes.
 func (r *Repository) ListForms(ctx context.Context, limit int) ([]entity.Form, error) {
     const query = `SELECT id, process_id, code, name, version, schema, ui_schema, created_at, updated_at FROM bpm.form ORDER BY created_at DESC LIMIT $1`
-    rows, err := r.pool.Query(ctx, query, limit)
+    rows, err := r.read.Query(ctx, query, limit)
     if err != nil {
         return nil, fmt.Errorf("list forms: %w", err)
     }
@@ -286,7 +294,7 @@ func (r *Repository) ListTasks(ctx context.Context, filter entity.TaskListFilter
     builder.WriteString(fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d", idx))
     args = append(args, filter.Limit)
 
-    rows, err := r.pool.Query(ctx, builder.String(), args...)
+    rows, err := r.read.Query(ctx, builder.String(), args...)
     if err != nil {
         return nil, fmt.Errorf("list tasks: %w", err)
     }
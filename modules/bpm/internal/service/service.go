@@ -12,6 +12,7 @@ import (
 
     "asfppro/modules/bpm/internal/entity"
     "asfppro/modules/bpm/internal/repository"
+    logpkg "asfppro/pkg/log"
 )
 
 var (
@@ -75,7 +76,7 @@ func (s *Service) CreateProcess(ctx context.Context, input entity.ProcessCreateI
     if err != nil {
         return entity.ProcessDefinition{}, err
     }
-    s.logger.Info().Str("processId", proc.ID).Msg("bpm process created")
+    logpkg.Ctx(ctx, s.logger).Info().Str("processId", proc.ID).Msg("bpm process created")
     return proc, nil
 }
 
@@ -120,7 +121,7 @@ func (s *Service) UpdateProcess(ctx context.Context, id uuid.UUID, input entity.
     if err != nil {
         return entity.ProcessDefinition{}, err
     }
-    s.logger.Info().Str("processId", proc.ID).Msg("bpm process updated")
+    logpkg.Ctx(ctx, s.logger).Info().Str("processId", proc.ID).Msg("bpm process updated")
     return proc, nil
 }
 
@@ -166,7 +167,7 @@ func (s *Service) CreateForm(ctx context.Context, input entity.FormCreateInput)
     if err != nil {
         return entity.Form{}, err
     }
-    s.logger.Info().Str("formId", form.ID).Msg("bpm form created")
+    logpkg.Ctx(ctx, s.logger).Info().Str("formId", form.ID).Msg("bpm form created")
     return form, nil
 }
 
@@ -205,7 +206,7 @@ func (s *Service) UpdateForm(ctx context.Context, id uuid.UUID, input entity.For
     if err != nil {
         return entity.Form{}, err
     }
-    s.logger.Info().Str("formId", form.ID).Msg("bpm form updated")
+    logpkg.Ctx(ctx, s.logger).Info().Str("formId", form.ID).Msg("bpm form updated")
     return form, nil
 }
 
@@ -258,7 +259,7 @@ func (s *Service) CreateTask(ctx context.Context, input entity.TaskCreateInput)
     if err != nil {
         return entity.Task{}, err
     }
-    s.logger.Info().Str("taskId", task.ID).Msg("bpm task created")
+    logpkg.Ctx(ctx, s.logger).Info().Str("taskId", task.ID).Msg("bpm task created")
     return task, nil
 }
 
@@ -305,6 +306,6 @@ func (s *Service) UpdateTask(ctx context.Context, id uuid.UUID, input entity.Tas
     if err != nil {
         return entity.Task{}, err
     }
-    s.logger.Info().Str("taskId", task.ID).Msg("bpm task updated")
+    logpkg.Ctx(ctx, s.logger).Info().Str("taskId", task.ID).Msg("bpm task updated")
     return task, nil
 }
@@ -17,6 +17,7 @@ type Deal struct {
 	CreatedBy   string
 	CreatedAt   time.Time
 	OrgUnitCode string
+	CompanyCode string
 }
 
 // DealEvent represents change log entry for a deal.
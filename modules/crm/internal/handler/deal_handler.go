@@ -10,6 +10,8 @@ import (
 	"github.com/google/uuid"
 
 	"asfppro/modules/crm/internal/service"
+	"asfppro/pkg/queue"
+	"asfppro/pkg/tenant"
 )
 
 // DealHandler exposes HTTP endpoints for deals.
@@ -24,7 +26,7 @@ func NewDealHandler(service *service.DealService) *DealHandler {
 
 // Register wires endpoints into router.
 func (h *DealHandler) Register(app *fiber.App) {
-	group := app.Group("/api/v1/deals")
+	group := app.Group("/api/v1/deals", tenant.Middleware())
 	group.Get("/", h.list)
 	group.Post("/", h.create)
 	group.Get(":id/history", h.history)
@@ -32,7 +34,7 @@ func (h *DealHandler) Register(app *fiber.App) {
 
 func (h *DealHandler) list(c *fiber.Ctx) error {
 	limit, _ := strconv.Atoi(c.Query("limit", "20"))
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	deals, err := h.service.List(ctx, limit)
@@ -49,8 +51,9 @@ func (h *DealHandler) create(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
+	ctx = queue.WithCorrelationID(ctx, c.Get("X-Request-ID"))
 
 	deal, err := h.service.Create(ctx, input)
 	if err != nil {
@@ -67,7 +70,7 @@ func (h *DealHandler) history(c *fiber.Ctx) error {
 	}
 
 	limit, _ := strconv.Atoi(c.Query("limit", "50"))
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	events, err := h.service.History(ctx, dealID, limit)
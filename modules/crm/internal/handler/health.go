@@ -5,8 +5,8 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/jackc/pgx/v5/pgxpool"
 
+	"asfppro/pkg/db"
 	"asfppro/pkg/health"
 	"asfppro/pkg/queue"
 )
@@ -16,33 +16,21 @@ func Health() fiber.Handler {
 	return health.LiveHandler()
 }
 
-// Ready validates storage and queue dependencies.
-func Ready(pool *pgxpool.Pool, publisher *queue.Publisher) fiber.Handler {
-	if pool == nil || publisher == nil {
-		return func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
-				"status": "degraded",
-				"error":  "dependencies not initialised",
-			})
-		}
+// Ready validates storage and queue dependencies. The queue only degrades CRM: deals are still
+// stored with their events, which analytics replay can republish.
+func Ready(router *db.Router, publisher queue.Publisher) fiber.Handler {
+	if router == nil || publisher == nil {
+		return health.Unavailable("dependencies not initialised")
 	}
 
-	checks := []health.Check{
-		{
-			Name:    "postgres",
-			Timeout: 3 * time.Second,
-			Probe: func(ctx context.Context) error {
-				return pool.Ping(ctx)
-			},
+	checks := append(router.Checks(), health.Check{
+		Name:    "tarantool",
+		Timeout: 3 * time.Second,
+		Impact:  health.Degrading,
+		Probe: func(ctx context.Context) error {
+			return publisher.Ping(ctx)
 		},
-		{
-			Name:    "tarantool",
-			Timeout: 3 * time.Second,
-			Probe: func(ctx context.Context) error {
-				return publisher.Ping(ctx)
-			},
-		},
-	}
+	})
 
 	return health.FiberHandler(checks)
 }
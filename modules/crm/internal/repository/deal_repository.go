@@ -3,32 +3,56 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"asfppro/modules/crm/internal/entity"
+	"asfppro/pkg/db"
+	"asfppro/pkg/tenant"
 )
 
-// DealRepository persists deals in Postgres.
+// ErrCustomerNotFound is returned when a deal refers to a customer outside the active company.
+var ErrCustomerNotFound = errors.New("customer not found")
+
+// DealRepository persists deals in Postgres, scoped to the active company in ctx.
 type DealRepository struct {
 	pool *pgxpool.Pool
+	read db.Querier
 }
 
 // NewDealRepository returns repository instance.
 func NewDealRepository(pool *pgxpool.Pool) *DealRepository {
-	return &DealRepository{pool: pool}
+	return &DealRepository{pool: pool, read: pool}
+}
+
+// WithReader routes list queries through read, typically a db.Router sending them to replicas.
+func (r *DealRepository) WithReader(read db.Querier) *DealRepository {
+	r.read = read
+	return r
 }
 
-// Create inserts deal row and returns stored entity.
+// Create inserts deal row for the active company and returns stored entity. The customer
+// must belong to the same company.
 func (r *DealRepository) Create(ctx context.Context, deal entity.Deal) (entity.Deal, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return entity.Deal{}, err
+	}
 	query := `
-	INSERT INTO crm.deals (id, title, customer_id, stage, amount, currency, created_by, org_unit_code)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-	RETURNING created_at
+	INSERT INTO crm.deals (id, title, customer_id, stage, amount, currency, created_by, org_unit_code, company_code)
+	SELECT $1, $2, c.id, $4, $5, $6, $7, $8, c.company_code
+	FROM crm.customers c
+	WHERE c.id = $3 AND c.company_code = $9
+	RETURNING created_at, company_code
 	`
-	row := r.pool.QueryRow(ctx, query, deal.ID, deal.Title, deal.CustomerID, deal.Stage, deal.Amount, deal.Currency, deal.CreatedBy, deal.OrgUnitCode)
-	if err := row.Scan(&deal.CreatedAt); err != nil {
+	row := r.pool.QueryRow(ctx, query, deal.ID, deal.Title, deal.CustomerID, deal.Stage, deal.Amount, deal.Currency, deal.CreatedBy, deal.OrgUnitCode, company)
+	if err := row.Scan(&deal.CreatedAt, &deal.CompanyCode); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Deal{}, ErrCustomerNotFound
+		}
 		return entity.Deal{}, fmt.Errorf("insert deal: %w", err)
 	}
 	return deal, nil
@@ -36,13 +60,18 @@ func (r *DealRepository) Create(ctx context.Context, deal entity.Deal) (entity.D
 
 // List returns limited deals page.
 func (r *DealRepository) List(ctx context.Context, limit int) ([]entity.Deal, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
 	query := `
-	SELECT id, title, customer_id, stage, amount, currency, created_by, created_at, org_unit_code
+	SELECT id, title, customer_id, stage, amount, currency, created_by, created_at, org_unit_code, company_code
 	FROM crm.deals
+	WHERE company_code = $1
 	ORDER BY created_at DESC
-	LIMIT $1
+	LIMIT $2
 	`
-	rows, err := r.pool.Query(ctx, query, limit)
+	rows, err := r.read.Query(ctx, query, company, limit)
 	if err != nil {
 		return nil, fmt.Errorf("select deals: %w", err)
 	}
@@ -51,7 +80,7 @@ func (r *DealRepository) List(ctx context.Context, limit int) ([]entity.Deal, er
 	var deals []entity.Deal
 	for rows.Next() {
 		var d entity.Deal
-		if err := rows.Scan(&d.ID, &d.Title, &d.CustomerID, &d.Stage, &d.Amount, &d.Currency, &d.CreatedBy, &d.CreatedAt, &d.OrgUnitCode); err != nil {
+		if err := rows.Scan(&d.ID, &d.Title, &d.CustomerID, &d.Stage, &d.Amount, &d.Currency, &d.CreatedBy, &d.CreatedAt, &d.OrgUnitCode, &d.CompanyCode); err != nil {
 			return nil, fmt.Errorf("scan deal: %w", err)
 		}
 		deals = append(deals, d)
@@ -62,14 +91,19 @@ func (r *DealRepository) List(ctx context.Context, limit int) ([]entity.Deal, er
 
 // History returns chronological events for specific deal.
 func (r *DealRepository) History(ctx context.Context, dealID string, limit int) ([]entity.DealEvent, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
 	query := `
-	SELECT id, deal_id, event_type, payload, created_at
-	FROM crm.deal_events
-	WHERE deal_id = $1
-	ORDER BY created_at DESC
-	LIMIT $2
+	SELECT e.id, e.deal_id, e.event_type, e.payload, e.created_at
+	FROM crm.deal_events e
+	JOIN crm.deals d ON d.id = e.deal_id
+	WHERE e.deal_id = $1 AND d.company_code = $2
+	ORDER BY e.created_at DESC
+	LIMIT $3
 	`
-	rows, err := r.pool.Query(ctx, query, dealID, limit)
+	rows, err := r.read.Query(ctx, query, dealID, company, limit)
 	if err != nil {
 		return nil, fmt.Errorf("select deal events: %w", err)
 	}
@@ -9,14 +9,26 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 
 	"asfppro/modules/crm/internal/entity"
-	"asfppro/modules/crm/internal/repository"
 	"asfppro/pkg/audit"
+	logpkg "asfppro/pkg/log"
+	"asfppro/pkg/metrics"
 	"asfppro/pkg/queue"
 )
 
+const (
+	dealCreatedEvent   = "DealCreated"
+	dealCreatedVersion = 1
+)
+
+var dealsCreated = metrics.Register(prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "crm_deals_created_total",
+	Help: "Deals created.",
+}))
+
 // DealCreateInput describes payload required to create deal.
 type DealCreateInput struct {
 	Title       string  `json:"title"`
@@ -28,16 +40,24 @@ type DealCreateInput struct {
 	OrgUnitCode string  `json:"orgUnitCode"`
 }
 
+// DealStore persists deals and their event history; repository.DealRepository implements it.
+type DealStore interface {
+	Create(ctx context.Context, deal entity.Deal) (entity.Deal, error)
+	AppendEvent(ctx context.Context, event entity.DealEvent) error
+	List(ctx context.Context, limit int) ([]entity.Deal, error)
+	History(ctx context.Context, dealID string, limit int) ([]entity.DealEvent, error)
+}
+
 // DealService wraps business logic around deals.
 type DealService struct {
-	repo      *repository.DealRepository
-	publisher *queue.Publisher
+	repo      DealStore
+	publisher queue.Publisher
 	auditor   *audit.Recorder
 	logger    zerolog.Logger
 }
 
 // NewDealService instantiates service.
-func NewDealService(repo *repository.DealRepository, publisher *queue.Publisher, auditor *audit.Recorder, logger zerolog.Logger) *DealService {
+func NewDealService(repo DealStore, publisher queue.Publisher, auditor *audit.Recorder, logger zerolog.Logger) *DealService {
 	return &DealService{repo: repo, publisher: publisher, auditor: auditor, logger: logger}
 }
 
@@ -71,6 +91,7 @@ func (s *DealService) Create(ctx context.Context, input DealCreateInput) (entity
 	if err != nil {
 		return entity.Deal{}, err
 	}
+	dealsCreated.Inc()
 
 	payload := struct {
 		ID         string  `json:"id"`
@@ -81,6 +102,7 @@ func (s *DealService) Create(ctx context.Context, input DealCreateInput) (entity
 		CustomerID string  `json:"customerId"`
 		CreatedBy  string  `json:"createdBy"`
 		CreatedAt  string  `json:"createdAt"`
+		Company    string  `json:"companyCode"`
 	}{
 		ID:         stored.ID,
 		Stage:      stored.Stage,
@@ -90,23 +112,24 @@ func (s *DealService) Create(ctx context.Context, input DealCreateInput) (entity
 		CustomerID: stored.CustomerID,
 		CreatedBy:  stored.CreatedBy,
 		CreatedAt:  stored.CreatedAt.UTC().Format(time.RFC3339),
+		Company:    stored.CompanyCode,
 	}
 
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		s.logger.Error().Err(err).Msg("marshal deal payload")
+		logpkg.Ctx(ctx, s.logger).Error().Err(err).Msg("marshal deal payload")
 	} else {
 		if err := s.repo.AppendEvent(ctx, entity.DealEvent{
 			DealID:    stored.ID,
 			EventType: "deal.created",
 			Payload:   payloadBytes,
 		}); err != nil {
-			s.logger.Error().Err(err).Msg("store deal event")
+			logpkg.Ctx(ctx, s.logger).Error().Err(err).Msg("store deal event")
 		}
 	}
 
-	if err := s.publisher.Publish(ctx, "DealCreated", payload); err != nil {
-		s.logger.Error().Err(err).Msg("publish deal created")
+	if err := s.publishDealCreated(ctx, stored.ID, payload); err != nil {
+		logpkg.Ctx(ctx, s.logger).Error().Err(err).Msg("publish deal created")
 	}
 
 	s.recordAudit(ctx, stored, input)
@@ -114,6 +137,16 @@ func (s *DealService) Create(ctx context.Context, input DealCreateInput) (entity
 	return stored, nil
 }
 
+// publishDealCreated publishes event with ID derived from the deal, so publishing it again is deduplicated.
+func (s *DealService) publishDealCreated(ctx context.Context, dealID string, payload any) error {
+	env, err := queue.NewEnvelope(ctx, dealCreatedEvent, dealCreatedVersion, "crm", payload)
+	if err != nil {
+		return err
+	}
+	env.ID = queue.StableID("crm.deal", dealID, dealCreatedEvent)
+	return s.publisher.PublishEnvelope(ctx, env)
+}
+
 // List returns latest deals.
 func (s *DealService) List(ctx context.Context, limit int) ([]entity.Deal, error) {
 	if limit <= 0 {
@@ -142,7 +175,7 @@ func (s *DealService) recordAudit(ctx context.Context, deal entity.Deal, input D
 	if id, err := uuid.Parse(strings.TrimSpace(input.CreatedBy)); err == nil {
 		actorID = id
 	} else if strings.TrimSpace(input.CreatedBy) != "" {
-		s.logger.Warn().Str("createdBy", input.CreatedBy).Msg("deal audit actor parse failed")
+		logpkg.Ctx(ctx, s.logger).Warn().Str("createdBy", input.CreatedBy).Msg("deal audit actor parse failed")
 	}
 
 	payload := map[string]any{
@@ -161,6 +194,6 @@ func (s *DealService) recordAudit(ctx context.Context, deal entity.Deal, input D
 		EntityID: deal.ID,
 		Payload:  payload,
 	}); err != nil {
-		s.logger.Error().Err(err).Msg("audit deal create")
+		logpkg.Ctx(ctx, s.logger).Error().Err(err).Msg("audit deal create")
 	}
 }
@@ -0,0 +1,112 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"asfppro/modules/crm/internal/entity"
+	"asfppro/modules/crm/internal/service"
+	"asfppro/pkg/queue"
+)
+
+type memoryDeals struct {
+	deals  []entity.Deal
+	events []entity.DealEvent
+}
+
+func (m *memoryDeals) Create(_ context.Context, deal entity.Deal) (entity.Deal, error) {
+	deal.CreatedAt = time.Date(2025, 10, 1, 10, 0, 0, 0, time.UTC)
+	deal.CompanyCode = "MAIN"
+	m.deals = append(m.deals, deal)
+	return deal, nil
+}
+
+func (m *memoryDeals) AppendEvent(_ context.Context, event entity.DealEvent) error {
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *memoryDeals) List(_ context.Context, limit int) ([]entity.Deal, error) {
+	if limit > len(m.deals) {
+		limit = len(m.deals)
+	}
+	return m.deals[:limit], nil
+}
+
+func (m *memoryDeals) History(_ context.Context, dealID string, _ int) ([]entity.DealEvent, error) {
+	var out []entity.DealEvent
+	for _, event := range m.events {
+		if event.DealID == dealID {
+			out = append(out, event)
+		}
+	}
+	return out, nil
+}
+
+func TestDealServiceCreatePublishesEvent(t *testing.T) {
+	ctx := context.Background()
+	broker := queue.NewMemoryBroker()
+	sub := queue.Subscription{Group: "analytics", Topics: []string{"DealCreated"}}
+
+	publisher, err := broker.Publisher(queue.PublisherConfig{Tube: "events", Producer: "crm", Subscriptions: []queue.Subscription{sub}})
+	require.NoError(t, err)
+	consumer, err := broker.Consumer(queue.ConsumerConfig{Tube: "events", Group: sub.Group, Topics: sub.Topics, TakeTimeout: 10 * time.Millisecond})
+	require.NoError(t, err)
+
+	store := &memoryDeals{}
+	svc := service.NewDealService(store, publisher, nil, zerolog.Nop())
+
+	deal, err := svc.Create(ctx, service.DealCreateInput{
+		Title:      "Signage",
+		CustomerID: "c-1",
+		Amount:     1500,
+		Currency:   "rub",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "new", deal.Stage)
+	require.Equal(t, "RUB", deal.Currency)
+	require.Equal(t, "HQ-SALES", deal.OrgUnitCode)
+
+	history, err := svc.History(ctx, deal.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, "deal.created", history[0].EventType)
+
+	job, err := consumer.Next(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	require.Equal(t, "DealCreated", job.EventType())
+	require.Equal(t, queue.StableID("crm.deal", deal.ID, "DealCreated"), job.Envelope().ID)
+
+	var payload struct {
+		ID          string  `json:"id"`
+		Amount      float64 `json:"amount"`
+		CompanyCode string  `json:"companyCode"`
+	}
+	require.NoError(t, job.Decode(&payload))
+	require.Equal(t, deal.ID, payload.ID)
+	require.Equal(t, 1500.0, payload.Amount)
+	require.Equal(t, "MAIN", payload.CompanyCode)
+	require.NoError(t, job.Ack(ctx))
+}
+
+func TestDealServiceValidation(t *testing.T) {
+	ctx := context.Background()
+	broker := queue.NewMemoryBroker()
+	publisher, err := broker.Publisher(queue.PublisherConfig{Tube: "events"})
+	require.NoError(t, err)
+
+	store := &memoryDeals{}
+	svc := service.NewDealService(store, publisher, nil, zerolog.Nop())
+
+	_, err = svc.Create(ctx, service.DealCreateInput{Title: "  "})
+	require.Error(t, err)
+	require.Empty(t, store.deals)
+
+	_, err = svc.History(ctx, "not-a-uuid", 0)
+	require.Error(t, err)
+}
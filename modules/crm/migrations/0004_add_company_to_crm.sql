@@ -0,0 +1,22 @@
+-- +goose Up
+-- Customers and deals belong to a legal entity; deal events follow their deal.
+ALTER TABLE crm.customers
+    ADD COLUMN company_code TEXT NOT NULL DEFAULT 'MAIN' REFERENCES core.companies(code);
+
+ALTER TABLE crm.deals
+    ADD COLUMN company_code TEXT NOT NULL DEFAULT 'MAIN' REFERENCES core.companies(code);
+
+ALTER TABLE crm.customers
+    ALTER COLUMN company_code DROP DEFAULT;
+
+ALTER TABLE crm.deals
+    ALTER COLUMN company_code DROP DEFAULT;
+
+CREATE INDEX IF NOT EXISTS idx_crm_customers_company ON crm.customers (company_code, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_crm_deals_company ON crm.deals (company_code, created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS crm.idx_crm_deals_company;
+DROP INDEX IF EXISTS crm.idx_crm_customers_company;
+ALTER TABLE crm.deals DROP COLUMN IF EXISTS company_code;
+ALTER TABLE crm.customers DROP COLUMN IF EXISTS company_code;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- Deals are visible only within the org units in app.scopes; events follow their deal.
+-- FORCE applies the policies to the table owner too.
+ALTER TABLE crm.deals ENABLE ROW LEVEL SECURITY;
+ALTER TABLE crm.deals FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY deals_org_unit_scope ON crm.deals
+    USING (core.rls_org_unit_visible(org_unit_code))
+    WITH CHECK (core.rls_org_unit_visible(org_unit_code));
+
+ALTER TABLE crm.deal_events ENABLE ROW LEVEL SECURITY;
+ALTER TABLE crm.deal_events FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY deal_events_org_unit_scope ON crm.deal_events
+    USING (core.rls_unrestricted() OR EXISTS (SELECT 1 FROM crm.deals d WHERE d.id = deal_id))
+    WITH CHECK (core.rls_unrestricted() OR EXISTS (SELECT 1 FROM crm.deals d WHERE d.id = deal_id));
+
+-- +goose Down
+DROP POLICY IF EXISTS deal_events_org_unit_scope ON crm.deal_events;
+ALTER TABLE crm.deal_events NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE crm.deal_events DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS deals_org_unit_scope ON crm.deals;
+ALTER TABLE crm.deals NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE crm.deals DISABLE ROW LEVEL SECURITY;
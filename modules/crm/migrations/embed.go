@@ -0,0 +1,11 @@
+package migrations
+
+import "embed"
+
+// Table tracks applied versions, shared with the goose CLI.
+const Table = "goose_db_version_crm"
+
+// Files embeds SQL migration files for CRM service.
+//
+//go:embed *.sql
+var Files embed.FS
@@ -13,6 +13,8 @@ import (
 	"asfppro/modules/docs/internal/entity"
 	"asfppro/modules/docs/internal/repository"
 	"asfppro/modules/docs/internal/service"
+	logpkg "asfppro/pkg/log"
+	"asfppro/pkg/tenant"
 )
 
 // RegisterRoutes подключает REST-эндпоинты Docs.
@@ -21,7 +23,7 @@ func RegisterRoutes(app *fiber.App, svc *service.Service, logger zerolog.Logger)
 		return
 	}
 
-	api := app.Group("/api/v1/docs")
+	api := app.Group("/api/v1/docs", tenant.Middleware())
 	api.Get("/templates", listTemplates(svc))
 	api.Post("/templates", createTemplate(svc, logger))
 	api.Put("/templates/:id", updateTemplate(svc, logger))
@@ -38,7 +40,7 @@ func RegisterRoutes(app *fiber.App, svc *service.Service, logger zerolog.Logger)
 func listTemplates(svc *service.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		limit := parseLimit(c.Query("limit"), 50)
-		templates, err := svc.ListTemplates(c.Context(), limit)
+		templates, err := svc.ListTemplates(c.UserContext(), limit)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -53,11 +55,11 @@ func createTemplate(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 		}
 
-		template, err := svc.CreateTemplate(c.Context(), req)
+		template, err := svc.CreateTemplate(c.UserContext(), req)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
-		logger.Info().Str("templateId", template.ID).Msg("docs template created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("templateId", template.ID).Msg("docs template created")
 		return c.Status(fiber.StatusCreated).JSON(template)
 	}
 }
@@ -102,7 +104,7 @@ func updateTemplate(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			input.Version = &version
 		}
 
-		template, err := svc.UpdateTemplate(c.Context(), id, input)
+		template, err := svc.UpdateTemplate(c.UserContext(), id, input)
 		if err != nil {
 			status := fiber.StatusBadRequest
 			if errors.Is(err, repository.ErrTemplateNotFound) {
@@ -110,7 +112,7 @@ func updateTemplate(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			}
 			return fiber.NewError(status, err.Error())
 		}
-		logger.Info().Str("templateId", template.ID).Msg("docs template updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("templateId", template.ID).Msg("docs template updated")
 		return c.JSON(template)
 	}
 }
@@ -118,7 +120,7 @@ func updateTemplate(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 func listSigners(svc *service.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		limit := parseLimit(c.Query("limit"), 50)
-		signers, err := svc.ListSigners(c.Context(), limit)
+		signers, err := svc.ListSigners(c.UserContext(), limit)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -133,11 +135,11 @@ func createSigner(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 		}
 
-		signer, err := svc.CreateSigner(c.Context(), req)
+		signer, err := svc.CreateSigner(c.UserContext(), req)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
-		logger.Info().Str("signerId", signer.ID).Msg("docs signer created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("signerId", signer.ID).Msg("docs signer created")
 		return c.Status(fiber.StatusCreated).JSON(signer)
 	}
 }
@@ -168,7 +170,7 @@ func updateSigner(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			input.Phone = ptrString(val)
 		}
 
-		signer, err := svc.UpdateSigner(c.Context(), id, input)
+		signer, err := svc.UpdateSigner(c.UserContext(), id, input)
 		if err != nil {
 			status := fiber.StatusBadRequest
 			if errors.Is(err, repository.ErrSignerNotFound) {
@@ -176,7 +178,7 @@ func updateSigner(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			}
 			return fiber.NewError(status, err.Error())
 		}
-		logger.Info().Str("signerId", signer.ID).Msg("docs signer updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("signerId", signer.ID).Msg("docs signer updated")
 		return c.JSON(signer)
 	}
 }
@@ -185,7 +187,7 @@ func listDocuments(svc *service.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		limit := parseLimit(c.Query("limit"), 50)
 		status := strings.TrimSpace(c.Query("status"))
-		docs, err := svc.ListDocuments(c.Context(), entity.DocumentListFilter{Limit: limit, Status: status})
+		docs, err := svc.ListDocuments(c.UserContext(), entity.DocumentListFilter{Limit: limit, Status: status})
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
@@ -218,11 +220,11 @@ func createDocument(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			Status:       req.Status,
 		}
 
-		doc, err := svc.CreateDocument(c.Context(), input)
+		doc, err := svc.CreateDocument(c.UserContext(), input)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
-		logger.Info().Str("documentId", doc.ID).Msg("docs document created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("documentId", doc.ID).Msg("docs document created")
 		return c.Status(fiber.StatusCreated).JSON(doc)
 	}
 }
@@ -260,7 +262,7 @@ func updateDocument(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			input.SignerStatuses = statuses
 		}
 
-		doc, err := svc.UpdateDocument(c.Context(), id, input)
+		doc, err := svc.UpdateDocument(c.UserContext(), id, input)
 		if err != nil {
 			status := fiber.StatusBadRequest
 			if errors.Is(err, repository.ErrDocumentNotFound) {
@@ -268,7 +270,7 @@ func updateDocument(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			}
 			return fiber.NewError(status, err.Error())
 		}
-		logger.Info().Str("documentId", doc.ID).Msg("docs document updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("documentId", doc.ID).Msg("docs document updated")
 		return c.JSON(doc)
 	}
 }
@@ -11,6 +11,7 @@ import (
 
 	"asfppro/modules/docs/internal/entity"
 	"asfppro/modules/docs/internal/repository"
+	logpkg "asfppro/pkg/log"
 )
 
 var (
@@ -68,7 +69,7 @@ func (s *Service) CreateTemplate(ctx context.Context, input entity.TemplateCreat
 	if err != nil {
 		return entity.Template{}, err
 	}
-	s.logger.Info().Str("templateId", tpl.ID).Msg("docs template created")
+	logpkg.Ctx(ctx, s.logger).Info().Str("templateId", tpl.ID).Msg("docs template created")
 	return tpl, nil
 }
 
@@ -102,7 +103,7 @@ func (s *Service) UpdateTemplate(ctx context.Context, id uuid.UUID, input entity
 	if err != nil {
 		return entity.Template{}, err
 	}
-	s.logger.Info().Str("templateId", tpl.ID).Msg("docs template updated")
+	logpkg.Ctx(ctx, s.logger).Info().Str("templateId", tpl.ID).Msg("docs template updated")
 	return tpl, nil
 }
 
@@ -133,7 +134,7 @@ func (s *Service) CreateSigner(ctx context.Context, input entity.SignerCreateInp
 	if err != nil {
 		return entity.Signer{}, err
 	}
-	s.logger.Info().Str("signerId", signer.ID).Msg("docs signer created")
+	logpkg.Ctx(ctx, s.logger).Info().Str("signerId", signer.ID).Msg("docs signer created")
 	return signer, nil
 }
 
@@ -163,7 +164,7 @@ func (s *Service) UpdateSigner(ctx context.Context, id uuid.UUID, input entity.S
 	if err != nil {
 		return entity.Signer{}, err
 	}
-	s.logger.Info().Str("signerId", signer.ID).Msg("docs signer updated")
+	logpkg.Ctx(ctx, s.logger).Info().Str("signerId", signer.ID).Msg("docs signer updated")
 	return signer, nil
 }
 
@@ -231,7 +232,7 @@ func (s *Service) CreateDocument(ctx context.Context, input entity.DocumentCreat
 	if err != nil {
 		return entity.Document{}, err
 	}
-	s.logger.Info().Str("documentId", doc.ID).Msg("docs document issued")
+	logpkg.Ctx(ctx, s.logger).Info().Str("documentId", doc.ID).Msg("docs document issued")
 	return doc, nil
 }
 
@@ -279,6 +280,6 @@ func (s *Service) UpdateDocument(ctx context.Context, id uuid.UUID, input entity
 	if err != nil {
 		return entity.Document{}, err
 	}
-	s.logger.Info().Str("documentId", doc.ID).Msg("docs document updated")
+	logpkg.Ctx(ctx, s.logger).Info().Str("documentId", doc.ID).Msg("docs document updated")
 	return doc, nil
 }
@@ -16,6 +16,7 @@ import (
 	"asfppro/modules/docs/internal/repository"
 	"asfppro/modules/docs/internal/service"
 	"asfppro/pkg/db"
+	"asfppro/pkg/tenant"
 )
 
 func TestService_DocumentFlow(t *testing.T) {
@@ -33,6 +34,7 @@ func TestService_DocumentFlow(t *testing.T) {
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
+	ctx = tenant.WithCompany(ctx, "MAIN")
 
 	pool, err := db.NewPostgresPool(ctx, dsn)
 	require.NoError(t, err)
@@ -93,6 +95,10 @@ func TestService_DocumentFlow(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, docs, 1)
 	require.Equal(t, doc.ID, docs[0].ID)
+
+	docs, err = svc.ListDocuments(tenant.WithCompany(ctx, "OTHER"), entity.DocumentListFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Empty(t, docs)
 }
 
 func cleanup(ctx context.Context, t *testing.T, pool *pgxpool.Pool) {
@@ -103,6 +109,6 @@ func cleanup(ctx context.Context, t *testing.T, pool *pgxpool.Pool) {
 
 func seedSequence(ctx context.Context, t *testing.T, pool *pgxpool.Pool) {
 	t.Helper()
-	_, err := pool.Exec(ctx, `INSERT INTO docs.number_sequence (id, code, prefix, padding, current_value) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (code) DO UPDATE SET prefix = EXCLUDED.prefix`, uuid.New(), "DOC-TEST", "DT-", 4, 0)
+	_, err := pool.Exec(ctx, `INSERT INTO docs.number_sequence (id, code, prefix, padding, current_value, company_code) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (company_code, code) DO UPDATE SET prefix = EXCLUDED.prefix`, uuid.New(), "DOC-TEST", "DT-", 4, 0, "MAIN")
 	require.NoError(t, err)
 }
@@ -0,0 +1,39 @@
+-- +goose Up
+-- Documents, signers and numbering belong to a legal entity; templates stay shared.
+-- Sequence codes and document numbers are unique within a company only.
+ALTER TABLE docs.number_sequence
+    ADD COLUMN company_code TEXT NOT NULL DEFAULT 'MAIN' REFERENCES core.companies(code);
+
+ALTER TABLE docs.signer
+    ADD COLUMN company_code TEXT NOT NULL DEFAULT 'MAIN' REFERENCES core.companies(code);
+
+ALTER TABLE docs.document
+    ADD COLUMN company_code TEXT NOT NULL DEFAULT 'MAIN' REFERENCES core.companies(code);
+
+ALTER TABLE docs.number_sequence ALTER COLUMN company_code DROP DEFAULT;
+ALTER TABLE docs.signer ALTER COLUMN company_code DROP DEFAULT;
+ALTER TABLE docs.document ALTER COLUMN company_code DROP DEFAULT;
+
+ALTER TABLE docs.number_sequence DROP CONSTRAINT IF EXISTS number_sequence_code_key;
+ALTER TABLE docs.number_sequence ADD CONSTRAINT uq_docs_number_sequence_company_code UNIQUE (company_code, code);
+
+ALTER TABLE docs.signer DROP CONSTRAINT IF EXISTS signer_code_key;
+ALTER TABLE docs.signer ADD CONSTRAINT uq_docs_signer_company_code UNIQUE (company_code, code);
+
+ALTER TABLE docs.document DROP CONSTRAINT IF EXISTS document_number_key;
+ALTER TABLE docs.document ADD CONSTRAINT uq_docs_document_company_number UNIQUE (company_code, number);
+
+CREATE INDEX IF NOT EXISTS idx_docs_document_company ON docs.document (company_code, created_at DESC);
+
+-- +goose Down
+-- Fails while codes or numbers repeat across companies.
+DROP INDEX IF EXISTS docs.idx_docs_document_company;
+ALTER TABLE docs.document DROP CONSTRAINT IF EXISTS uq_docs_document_company_number;
+ALTER TABLE docs.document ADD CONSTRAINT document_number_key UNIQUE (number);
+ALTER TABLE docs.signer DROP CONSTRAINT IF EXISTS uq_docs_signer_company_code;
+ALTER TABLE docs.signer ADD CONSTRAINT signer_code_key UNIQUE (code);
+ALTER TABLE docs.number_sequence DROP CONSTRAINT IF EXISTS uq_docs_number_sequence_company_code;
+ALTER TABLE docs.number_sequence ADD CONSTRAINT number_sequence_code_key UNIQUE (code);
+ALTER TABLE docs.document DROP COLUMN IF EXISTS company_code;
+ALTER TABLE docs.signer DROP COLUMN IF EXISTS company_code;
+ALTER TABLE docs.number_sequence DROP COLUMN IF EXISTS company_code;
@@ -2,6 +2,9 @@ package migrations
 
 import "embed"
 
+// Table tracks applied versions, shared with the goose CLI.
+const Table = "goose_db_version_docs"
+
 // Files embeds SQL migration files for Docs service.
 //
 //go:embed *.sql
@@ -13,6 +13,7 @@ import (
 	"asfppro/modules/mes/internal/entity"
 	"asfppro/modules/mes/internal/repository"
 	"asfppro/modules/mes/internal/service"
+	logpkg "asfppro/pkg/log"
 )
 
 // RegisterRoutes wires MES endpoints.
@@ -38,7 +39,7 @@ func RegisterRoutes(app *fiber.App, svc *service.Service, logger zerolog.Logger)
 func listWorkCentersHandler(svc *service.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		limit := parseLimit(c.Query("limit"), 50)
-		centers, err := svc.ListWorkCenters(c.Context(), limit)
+		centers, err := svc.ListWorkCenters(c.UserContext(), limit)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -53,12 +54,12 @@ func createWorkCenterHandler(svc *service.Service, logger zerolog.Logger) fiber.
 			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 		}
 
-		center, err := svc.CreateWorkCenter(c.Context(), input)
+		center, err := svc.CreateWorkCenter(c.UserContext(), input)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
 
-		logger.Info().Str("workCenterId", center.ID).Msg("mes work center created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("workCenterId", center.ID).Msg("mes work center created")
 		return c.Status(fiber.StatusCreated).JSON(center)
 	}
 }
@@ -83,7 +84,7 @@ func updateWorkCenterHandler(svc *service.Service, logger zerolog.Logger) fiber.
 			input.Description = ptr(desc)
 		}
 
-		center, err := svc.UpdateWorkCenter(c.Context(), id, input)
+		center, err := svc.UpdateWorkCenter(c.UserContext(), id, input)
 		if err != nil {
 			status := fiber.StatusBadRequest
 			if errors.Is(err, repository.ErrWorkCenterNotFound) {
@@ -92,7 +93,7 @@ func updateWorkCenterHandler(svc *service.Service, logger zerolog.Logger) fiber.
 			return fiber.NewError(status, err.Error())
 		}
 
-		logger.Info().Str("workCenterId", center.ID).Msg("mes work center updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("workCenterId", center.ID).Msg("mes work center updated")
 		return c.JSON(center)
 	}
 }
@@ -100,7 +101,7 @@ func updateWorkCenterHandler(svc *service.Service, logger zerolog.Logger) fiber.
 func listOperationsHandler(svc *service.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		limit := parseLimit(c.Query("limit"), 50)
-		ops, err := svc.ListOperations(c.Context(), limit)
+		ops, err := svc.ListOperations(c.UserContext(), limit)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -115,12 +116,12 @@ func createOperationHandler(svc *service.Service, logger zerolog.Logger) fiber.H
 			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 		}
 
-		op, err := svc.CreateOperation(c.Context(), input)
+		op, err := svc.CreateOperation(c.UserContext(), input)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
 
-		logger.Info().Str("operationId", op.ID).Msg("mes operation created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("operationId", op.ID).Msg("mes operation created")
 		return c.Status(fiber.StatusCreated).JSON(op)
 	}
 }
@@ -163,7 +164,7 @@ func updateOperationHandler(svc *service.Service, logger zerolog.Logger) fiber.H
 			}
 		}
 
-		op, err := svc.UpdateOperation(c.Context(), id, input)
+		op, err := svc.UpdateOperation(c.UserContext(), id, input)
 		if err != nil {
 			status := fiber.StatusBadRequest
 			if errors.Is(err, repository.ErrOperationNotFound) {
@@ -172,7 +173,7 @@ func updateOperationHandler(svc *service.Service, logger zerolog.Logger) fiber.H
 			return fiber.NewError(status, err.Error())
 		}
 
-		logger.Info().Str("operationId", op.ID).Msg("mes operation updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("operationId", op.ID).Msg("mes operation updated")
 		return c.JSON(op)
 	}
 }
@@ -180,7 +181,7 @@ func updateOperationHandler(svc *service.Service, logger zerolog.Logger) fiber.H
 func listRoutesHandler(svc *service.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		limit := parseLimit(c.Query("limit"), 50)
-		routes, err := svc.ListRoutes(c.Context(), limit)
+		routes, err := svc.ListRoutes(c.UserContext(), limit)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -195,12 +196,12 @@ func createRouteHandler(svc *service.Service, logger zerolog.Logger) fiber.Handl
 			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 		}
 
-		route, err := svc.CreateRoute(c.Context(), input)
+		route, err := svc.CreateRoute(c.UserContext(), input)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
 
-		logger.Info().Str("routeId", route.ID).Msg("mes route created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("routeId", route.ID).Msg("mes route created")
 		return c.Status(fiber.StatusCreated).JSON(route)
 	}
 }
@@ -225,7 +226,7 @@ func updateRouteHandler(svc *service.Service, logger zerolog.Logger) fiber.Handl
 			input.Description = ptr(desc)
 		}
 
-		route, err := svc.UpdateRoute(c.Context(), id, input)
+		route, err := svc.UpdateRoute(c.UserContext(), id, input)
 		if err != nil {
 			status := fiber.StatusBadRequest
 			if errors.Is(err, repository.ErrRouteNotFound) {
@@ -234,7 +235,7 @@ func updateRouteHandler(svc *service.Service, logger zerolog.Logger) fiber.Handl
 			return fiber.NewError(status, err.Error())
 		}
 
-		logger.Info().Str("routeId", route.ID).Msg("mes route updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("routeId", route.ID).Msg("mes route updated")
 		return c.JSON(route)
 	}
 }
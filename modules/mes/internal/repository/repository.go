@@ -12,6 +12,7 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"asfppro/modules/mes/internal/entity"
+	"asfppro/pkg/db"
 )
 
 var (
@@ -26,18 +27,25 @@ var (
 // Repository wraps Postgres access for MES tables.
 type Repository struct {
 	pool *pgxpool.Pool
+	read db.Querier
 }
 
 // New creates repository instance.
 func New(pool *pgxpool.Pool) *Repository {
-	return &Repository{pool: pool}
+	return &Repository{pool: pool, read: pool}
+}
+
+// WithReader routes list queries through read, typically a db.Router sending them to replicas.
+func (r *Repository) WithReader(read db.Querier) *Repository {
+	r.read = read
+	return r
 }
 
 // ListWorkCenters returns work centers ordered by creation date.
 func (r *Repository) ListWorkCenters(ctx context.Context, limit int) ([]entity.WorkCenter, error) {
 	const query = `SELECT id, code, name, description, created_at, updated_at FROM mes.work_center ORDER BY created_at DESC LIMIT $1`
 
-	rows, err := r.pool.Query(ctx, query, limit)
+	rows, err := r.read.Query(ctx, query, limit)
 	if err != nil {
 		return nil, fmt.Errorf("list work centers: %w", err)
 	}
@@ -121,7 +129,7 @@ func (r *Repository) ListOperations(ctx context.Context, limit int) ([]entity.Op
 	const query = `SELECT id, code, name, description, default_duration_minutes, created_at, updated_at
 FROM mes.operation ORDER BY created_at DESC LIMIT $1`
 
-	rows, err := r.pool.Query(ctx, query, limit)
+	rows, err := r.read.Query(ctx, query, limit)
 	if err != nil {
 		return nil, fmt.Errorf("list operations: %w", err)
 	}
@@ -210,7 +218,7 @@ func (r *Repository) getOperation(ctx context.Context, id uuid.UUID) (entity.Ope
 func (r *Repository) ListRoutes(ctx context.Context, limit int) ([]entity.Route, error) {
 	const query = `SELECT id, code, name, description, created_at, updated_at FROM mes.route ORDER BY created_at DESC LIMIT $1`
 
-	rows, err := r.pool.Query(ctx, query, limit)
+	rows, err := r.read.Query(ctx, query, limit)
 	if err != nil {
 		return nil, fmt.Errorf("list routes: %w", err)
 	}
@@ -11,6 +11,7 @@ import (
 
 	"asfppro/modules/mes/internal/entity"
 	"asfppro/modules/mes/internal/repository"
+	logpkg "asfppro/pkg/log"
 )
 
 // Service aggregates MES use cases.
@@ -53,7 +54,7 @@ func (s *Service) CreateWorkCenter(ctx context.Context, input entity.WorkCenterC
 		return entity.WorkCenter{}, err
 	}
 
-	s.logger.Info().Str("workCenterId", wc.ID).Msg("mes work center created")
+	logpkg.Ctx(ctx, s.logger).Info().Str("workCenterId", wc.ID).Msg("mes work center created")
 	return wc, nil
 }
 
@@ -76,7 +77,7 @@ func (s *Service) UpdateWorkCenter(ctx context.Context, id uuid.UUID, input enti
 		return entity.WorkCenter{}, err
 	}
 
-	s.logger.Info().Str("workCenterId", wc.ID).Msg("mes work center updated")
+	logpkg.Ctx(ctx, s.logger).Info().Str("workCenterId", wc.ID).Msg("mes work center updated")
 	return wc, nil
 }
 
@@ -109,7 +110,7 @@ func (s *Service) CreateOperation(ctx context.Context, input entity.OperationCre
 		return entity.Operation{}, err
 	}
 
-	s.logger.Info().Str("operationId", op.ID).Msg("mes operation created")
+	logpkg.Ctx(ctx, s.logger).Info().Str("operationId", op.ID).Msg("mes operation created")
 	return op, nil
 }
 
@@ -135,7 +136,7 @@ func (s *Service) UpdateOperation(ctx context.Context, id uuid.UUID, input entit
 		return entity.Operation{}, err
 	}
 
-	s.logger.Info().Str("operationId", op.ID).Msg("mes operation updated")
+	logpkg.Ctx(ctx, s.logger).Info().Str("operationId", op.ID).Msg("mes operation updated")
 	return op, nil
 }
 
@@ -165,7 +166,7 @@ func (s *Service) CreateRoute(ctx context.Context, input entity.RouteCreateInput
 		return entity.Route{}, err
 	}
 
-	s.logger.Info().Str("routeId", rt.ID).Msg("mes route created")
+	logpkg.Ctx(ctx, s.logger).Info().Str("routeId", rt.ID).Msg("mes route created")
 	return rt, nil
 }
 
@@ -188,6 +189,6 @@ func (s *Service) UpdateRoute(ctx context.Context, id uuid.UUID, input entity.Ro
 		return entity.Route{}, err
 	}
 
-	s.logger.Info().Str("routeId", rt.ID).Msg("mes route updated")
+	logpkg.Ctx(ctx, s.logger).Info().Str("routeId", rt.ID).Msg("mes route updated")
 	return rt, nil
 }
@@ -2,6 +2,9 @@ package migrations
 
 import "embed"
 
+// Table tracks applied versions, shared with the goose CLI.
+const Table = "goose_db_version_mes"
+
 // Files embeds SQL migration files for MES service.
 //
 //go:embed *.sql
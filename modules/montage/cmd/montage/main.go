@@ -14,34 +14,101 @@ import (
 	"asfppro/modules/montage/internal/handler"
 	"asfppro/modules/montage/internal/repository"
 	"asfppro/modules/montage/internal/service"
+	"asfppro/modules/montage/migrations"
 	"asfppro/pkg/config"
 	"asfppro/pkg/db"
+	"asfppro/pkg/diagnostics"
+	"asfppro/pkg/lifecycle"
 	logpkg "asfppro/pkg/log"
+	"asfppro/pkg/metrics"
+	"asfppro/pkg/migrate"
+	"asfppro/pkg/tracing"
 )
 
 func main() {
-	cfg, err := config.Load("MONTAGE")
+	if handled, err := config.Command("MONTAGE", os.Args[1:], os.Stdout); handled {
+		if err != nil {
+			stdlog.Fatalf("config: %v", err)
+		}
+		return
+	}
+
+	src := migrate.Source{Table: migrations.Table, Files: migrations.Files}
+	if handled, err := migrate.Command(context.Background(), "MONTAGE", src, os.Args[1:], os.Stdout); handled {
+		if err != nil {
+			stdlog.Fatalf("migrate: %v", err)
+		}
+		return
+	}
+
+	cfg, err := config.LoadArgs("MONTAGE", os.Args[1:])
 	if err != nil {
 		stdlog.Fatalf("load config: %v", err)
 	}
 
-	logger := logpkg.Init(cfg.Env)
+	logger, err := logpkg.New(logpkg.Options{Env: cfg.Env, Service: cfg.AppName, Level: cfg.LogLevel, Format: cfg.LogFormat})
+	if err != nil {
+		stdlog.Fatalf("init logger: %v", err)
+	}
+
+	lc := lifecycle.New(logger, cfg.ShutdownTimeout)
+
+	if cfg.EnablePprof || cfg.MetricsEnable {
+		diag, err := diagnostics.Start(diagnostics.Config{
+			Addr:    cfg.PprofAddr,
+			Token:   cfg.PprofToken,
+			Pprof:   cfg.EnablePprof,
+			Metrics: cfg.MetricsEnable,
+		}, logger)
+		if err != nil {
+			lc.Abort(err, "start admin listener")
+		}
+		lc.Append(lifecycle.Hook{Name: "admin", Stop: diag.Shutdown})
+	}
+
+	flushTraces, err := tracing.Init(context.Background(), tracing.Config{
+		ServiceName: cfg.AppName,
+		Environment: cfg.Env,
+		Endpoint:    cfg.OTLPEndpoint,
+		SampleRatio: cfg.TraceSample,
+	}, logger)
+	if err != nil {
+		lc.Abort(err, "init tracing")
+	}
+	lc.Close("tracing", flushTraces)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	pool, err := db.NewPostgresPool(ctx, cfg.DatabaseURL)
+	poolCfg := db.PoolConfig{
+		MaxConns:        int32(cfg.DBMaxConns),
+		MinConns:        int32(cfg.DBMinConns),
+		MaxConnIdleTime: cfg.DBMaxConnIdle,
+		MaxConnLifetime: cfg.DBMaxConnLife,
+	}
+	pool, err := db.NewPostgresPoolWithConfig(ctx, cfg.DatabaseURL, poolCfg)
 	if err != nil {
-		logger.Fatal().Err(err).Msg("postgres connect")
+		lc.Abort(err, "postgres connect")
 	}
-	defer pool.Close()
+	lc.Close("postgres", pool.Close)
 
-	repo := repository.New(pool)
+	if err := migrate.OnStart(context.Background(), cfg, pool, src, logger); err != nil {
+		lc.Abort(err, "migrate")
+	}
+
+	router, err := db.NewRouter(ctx, pool, cfg.Replicas(), poolCfg, cfg.DBReplicaMaxLag, logger)
+	if err != nil {
+		lc.Abort(err, "postgres replicas")
+	}
+	lc.Close("postgres replicas", router.Close)
+	lc.Go("replica monitor", router.Run)
+
+	repo := repository.New(pool).WithReader(router)
 	svc := service.New(repo, logger)
 
 	openapi, err := readOpenAPI("modules/montage/docs/openapi/openapi.json", "MONTAGE_OPENAPI_PATH")
 	if err != nil {
-		logger.Fatal().Err(err).Msg("load openapi")
+		lc.Abort(err, "load openapi")
 	}
 
 	app := fiber.New(fiber.Config{AppName: cfg.AppName})
@@ -51,15 +118,19 @@ func main() {
 		AllowMethods: "GET,POST,PUT,PATCH,DELETE,OPTIONS",
 	}))
 	app.Use(recover.New())
+	app.Use(tracing.Middleware())
+	app.Use(logpkg.Middleware(logger, uint32(cfg.LogSampleBurst)))
+	if cfg.MetricsEnable {
+		app.Use(metrics.Middleware(metrics.Default()))
+	}
 	app.Get("/health", handler.Health())
-	app.Get("/ready", handler.Ready(pool))
+	app.Get("/ready", handler.Ready(router))
 	app.Get("/openapi.json", handler.OpenAPI(openapi))
 	handler.RegisterRoutes(app, svc, logger)
 
-	addr := ":" + cfg.HTTPPort
-	logger.Info().Str("addr", addr).Msg("montage listening")
-	if err := app.Listen(addr); err != nil {
-		logger.Fatal().Err(err).Msg("montage stopped")
+	lc.Fiber("http", app, ":"+cfg.HTTPPort)
+	if err := lc.Run(context.Background()); err != nil {
+		os.Exit(1)
 	}
 }
 
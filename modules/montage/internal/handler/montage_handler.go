@@ -13,6 +13,7 @@ import (
 	"asfppro/modules/montage/internal/entity"
 	"asfppro/modules/montage/internal/repository"
 	"asfppro/modules/montage/internal/service"
+	logpkg "asfppro/pkg/log"
 )
 
 // RegisterRoutes подключает REST-эндпоинты.
@@ -38,7 +39,7 @@ func RegisterRoutes(app *fiber.App, svc *service.Service, logger zerolog.Logger)
 func listCrews(svc *service.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		limit := parseLimit(c.Query("limit"), 50)
-		crews, err := svc.ListCrews(c.Context(), limit)
+		crews, err := svc.ListCrews(c.UserContext(), limit)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -53,12 +54,12 @@ func createCrew(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 		}
 
-		crew, err := svc.CreateCrew(c.Context(), req)
+		crew, err := svc.CreateCrew(c.UserContext(), req)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
 
-		logger.Info().Str("crewId", crew.ID).Msg("montage crew created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("crewId", crew.ID).Msg("montage crew created")
 		return c.Status(fiber.StatusCreated).JSON(crew)
 	}
 }
@@ -85,7 +86,7 @@ func updateCrew(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			input.Specialization = &trim
 		}
 
-		crew, err := svc.UpdateCrew(c.Context(), id, input)
+		crew, err := svc.UpdateCrew(c.UserContext(), id, input)
 		if err != nil {
 			status := fiber.StatusBadRequest
 			if errors.Is(err, repository.ErrCrewNotFound) {
@@ -94,7 +95,7 @@ func updateCrew(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			return fiber.NewError(status, err.Error())
 		}
 
-		logger.Info().Str("crewId", crew.ID).Msg("montage crew updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("crewId", crew.ID).Msg("montage crew updated")
 		return c.JSON(crew)
 	}
 }
@@ -102,7 +103,7 @@ func updateCrew(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 func listVehicles(svc *service.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		limit := parseLimit(c.Query("limit"), 50)
-		vehicles, err := svc.ListVehicles(c.Context(), limit)
+		vehicles, err := svc.ListVehicles(c.UserContext(), limit)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -117,12 +118,12 @@ func createVehicle(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 		}
 
-		vehicle, err := svc.CreateVehicle(c.Context(), req)
+		vehicle, err := svc.CreateVehicle(c.UserContext(), req)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
 
-		logger.Info().Str("vehicleId", vehicle.ID).Msg("montage vehicle created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("vehicleId", vehicle.ID).Msg("montage vehicle created")
 		return c.Status(fiber.StatusCreated).JSON(vehicle)
 	}
 }
@@ -153,7 +154,7 @@ func updateVehicle(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			input.Capacity = &trim
 		}
 
-		vehicle, err := svc.UpdateVehicle(c.Context(), id, input)
+		vehicle, err := svc.UpdateVehicle(c.UserContext(), id, input)
 		if err != nil {
 			status := fiber.StatusBadRequest
 			if errors.Is(err, repository.ErrVehicleNotFound) {
@@ -162,7 +163,7 @@ func updateVehicle(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			return fiber.NewError(status, err.Error())
 		}
 
-		logger.Info().Str("vehicleId", vehicle.ID).Msg("montage vehicle updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("vehicleId", vehicle.ID).Msg("montage vehicle updated")
 		return c.JSON(vehicle)
 	}
 }
@@ -170,7 +171,7 @@ func updateVehicle(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 func listTasks(svc *service.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		limit := parseLimit(c.Query("limit"), 50)
-		tasks, err := svc.ListTasks(c.Context(), limit)
+		tasks, err := svc.ListTasks(c.UserContext(), limit)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -185,12 +186,12 @@ func createTask(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 		}
 
-		task, err := svc.CreateTask(c.Context(), req)
+		task, err := svc.CreateTask(c.UserContext(), req)
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
 
-		logger.Info().Str("taskId", task.ID).Msg("montage task created")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("taskId", task.ID).Msg("montage task created")
 		return c.Status(fiber.StatusCreated).JSON(task)
 	}
 }
@@ -233,7 +234,7 @@ func updateTask(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			input.Location = &trim
 		}
 
-		task, err := svc.UpdateTask(c.Context(), id, input)
+		task, err := svc.UpdateTask(c.UserContext(), id, input)
 		if err != nil {
 			status := fiber.StatusBadRequest
 			if errors.Is(err, repository.ErrTaskNotFound) {
@@ -242,7 +243,7 @@ func updateTask(svc *service.Service, logger zerolog.Logger) fiber.Handler {
 			return fiber.NewError(status, err.Error())
 		}
 
-		logger.Info().Str("taskId", task.ID).Msg("montage task updated")
+		logpkg.Ctx(c.UserContext(), logger).Info().Str("taskId", task.ID).Msg("montage task updated")
 		return c.JSON(task)
 	}
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"asfppro/modules/montage/internal/entity"
+	"asfppro/pkg/db"
 )
 
 var (
@@ -26,17 +27,24 @@ var (
 // Repository инкапсулирует работу с Postgres.
 type Repository struct {
 	pool *pgxpool.Pool
+	read db.Querier
 }
 
 // New создаёт репозиторий.
 func New(pool *pgxpool.Pool) *Repository {
-	return &Repository{pool: pool}
+	return &Repository{pool: pool, read: pool}
+}
+
+// WithReader routes list queries through read, typically a db.Router sending them to replicas.
+func (r *Repository) WithReader(read db.Querier) *Repository {
+	r.read = read
+	return r
 }
 
 // ListCrews возвращает список бригад.
 func (r *Repository) ListCrews(ctx context.Context, limit int) ([]entity.Crew, error) {
 	const query = `SELECT id, code, name, specialization, created_at, updated_at FROM montage.crew ORDER BY created_at DESC LIMIT $1`
-	rows, err := r.pool.Query(ctx, query, limit)
+	rows, err := r.read.Query(ctx, query, limit)
 	if err != nil {
 		return nil, fmt.Errorf("list crews: %w", err)
 	}
@@ -117,7 +125,7 @@ func (r *Repository) getCrew(ctx context.Context, id uuid.UUID) (entity.Crew, er
 // ListVehicles возвращает транспорт.
 func (r *Repository) ListVehicles(ctx context.Context, limit int) ([]entity.Vehicle, error) {
 	const query = `SELECT id, code, name, plate, capacity, created_at, updated_at FROM montage.vehicle ORDER BY created_at DESC LIMIT $1`
-	rows, err := r.pool.Query(ctx, query, limit)
+	rows, err := r.read.Query(ctx, query, limit)
 	if err != nil {
 		return nil, fmt.Errorf("list vehicles: %w", err)
 	}
@@ -205,7 +213,7 @@ func (r *Repository) ListTasks(ctx context.Context, limit int) ([]entity.Task, e
 	const query = `SELECT id, code, title, status, COALESCE(crew_id::text, ''), COALESCE(vehicle_id::text, ''), COALESCE(scheduled_at, '1970-01-01'), location, created_at, updated_at
 FROM montage.task ORDER BY created_at DESC LIMIT $1`
 
-	rows, err := r.pool.Query(ctx, query, limit)
+	rows, err := r.read.Query(ctx, query, limit)
 	if err != nil {
 		return nil, fmt.Errorf("list tasks: %w", err)
 	}
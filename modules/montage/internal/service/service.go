@@ -11,6 +11,7 @@ import (
 
 	"asfppro/modules/montage/internal/entity"
 	"asfppro/modules/montage/internal/repository"
+	logpkg "asfppro/pkg/log"
 )
 
 // Service объединяет бизнес-логику монтажа.
@@ -50,7 +51,7 @@ func (s *Service) CreateCrew(ctx context.Context, input entity.CrewCreateInput)
 		return entity.Crew{}, err
 	}
 
-	s.logger.Info().Str("crewId", crew.ID).Msg("montage crew created")
+	logpkg.Ctx(ctx, s.logger).Info().Str("crewId", crew.ID).Msg("montage crew created")
 	return crew, nil
 }
 
@@ -73,7 +74,7 @@ func (s *Service) UpdateCrew(ctx context.Context, id uuid.UUID, input entity.Cre
 		return entity.Crew{}, err
 	}
 
-	s.logger.Info().Str("crewId", crew.ID).Msg("montage crew updated")
+	logpkg.Ctx(ctx, s.logger).Info().Str("crewId", crew.ID).Msg("montage crew updated")
 	return crew, nil
 }
 
@@ -104,7 +105,7 @@ func (s *Service) CreateVehicle(ctx context.Context, input entity.VehicleCreateI
 		return entity.Vehicle{}, err
 	}
 
-	s.logger.Info().Str("vehicleId", vehicle.ID).Msg("montage vehicle created")
+	logpkg.Ctx(ctx, s.logger).Info().Str("vehicleId", vehicle.ID).Msg("montage vehicle created")
 	return vehicle, nil
 }
 
@@ -131,7 +132,7 @@ func (s *Service) UpdateVehicle(ctx context.Context, id uuid.UUID, input entity.
 		return entity.Vehicle{}, err
 	}
 
-	s.logger.Info().Str("vehicleId", vehicle.ID).Msg("montage vehicle updated")
+	logpkg.Ctx(ctx, s.logger).Info().Str("vehicleId", vehicle.ID).Msg("montage vehicle updated")
 	return vehicle, nil
 }
 
@@ -170,7 +171,7 @@ func (s *Service) CreateTask(ctx context.Context, input entity.TaskCreateInput)
 		return entity.Task{}, err
 	}
 
-	s.logger.Info().Str("taskId", task.ID).Msg("montage task created")
+	logpkg.Ctx(ctx, s.logger).Info().Str("taskId", task.ID).Msg("montage task created")
 	return task, nil
 }
 
@@ -213,6 +214,6 @@ func (s *Service) UpdateTask(ctx context.Context, id uuid.UUID, input entity.Tas
 		return entity.Task{}, err
 	}
 
-	s.logger.Info().Str("taskId", task.ID).Msg("montage task updated")
+	logpkg.Ctx(ctx, s.logger).Info().Str("taskId", task.ID).Msg("montage task updated")
 	return task, nil
 }
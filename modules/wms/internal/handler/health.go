@@ -2,12 +2,9 @@
 package handler
 
 import (
-	"context"
-	"time"
-
 	"github.com/gofiber/fiber/v2"
-	"github.com/jackc/pgx/v5/pgxpool"
 
+	"asfppro/pkg/db"
 	"asfppro/pkg/health"
 )
 
@@ -17,25 +14,9 @@ func Health() fiber.Handler {
 }
 
 // Ready checks database availability.
-func Ready(pool *pgxpool.Pool) fiber.Handler {
-	if pool == nil {
-		return func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
-				"status": "degraded",
-				"error":  "dependencies not initialised",
-			})
-		}
-	}
-
-	checks := []health.Check{
-		{
-			Name:    "postgres",
-			Timeout: 3 * time.Second,
-			Probe: func(ctx context.Context) error {
-				return pool.Ping(ctx)
-			},
-		},
+func Ready(router *db.Router) fiber.Handler {
+	if router == nil {
+		return health.Unavailable("dependencies not initialised")
 	}
-
-	return health.FiberHandler(checks)
+	return health.FiberHandler(router.Checks())
 }
@@ -8,6 +8,7 @@ import (
 
 	"asfppro/modules/wms/internal/entity"
 	"asfppro/modules/wms/internal/service"
+	"asfppro/pkg/tenant"
 )
 
 // InventoryHandler exposes REST endpoints.
@@ -22,7 +23,7 @@ func NewInventoryHandler(service *service.InventoryService) *InventoryHandler {
 
 // Register binds routes.
 func (h *InventoryHandler) Register(app *fiber.App) {
-	group := app.Group("/api/v1/stock")
+	group := app.Group("/api/v1/stock", tenant.Middleware())
 	group.Get("/", h.list)
 	group.Post("/", h.upsert)
 }
@@ -30,7 +31,7 @@ func (h *InventoryHandler) Register(app *fiber.App) {
 func (h *InventoryHandler) list(c *fiber.Ctx) error {
 	warehouse := c.Query("warehouse")
 	limit := c.QueryInt("limit", 50)
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	items, err := h.service.List(ctx, warehouse, limit)
@@ -47,7 +48,7 @@ func (h *InventoryHandler) upsert(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	stored, err := h.service.Upsert(ctx, item)
@@ -75,7 +75,7 @@ func (h *MasterDataHandler) Register(app *fiber.App) {
 // listCatalogNodes returns catalog nodes of provided type.
 func (h *MasterDataHandler) listCatalogNodes(c *fiber.Ctx) error {
 	typ := c.Params("type")
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	nodes, err := h.service.ListCatalogNodes(ctx, typ)
@@ -96,7 +96,7 @@ func (h *MasterDataHandler) createCatalogNode(c *fiber.Ctx) error {
 	if err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	created, err := h.service.CreateCatalogNode(ctx, typ, node)
@@ -121,7 +121,7 @@ func (h *MasterDataHandler) updateCatalogNode(c *fiber.Ctx) error {
 	if err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	updated, err := h.service.UpdateCatalogNode(ctx, typ, nodeID, node)
@@ -138,7 +138,7 @@ func (h *MasterDataHandler) deleteCatalogNode(c *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	if err := h.service.DeleteCatalogNode(ctx, typ, nodeID); err != nil {
@@ -150,7 +150,7 @@ func (h *MasterDataHandler) deleteCatalogNode(c *fiber.Ctx) error {
 // listAttributeTemplates returns dynamic attribute templates.
 func (h *MasterDataHandler) listAttributeTemplates(c *fiber.Ctx) error {
 	target := c.Query("target", "item")
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	templates, err := h.service.ListAttributeTemplates(ctx, target)
@@ -197,7 +197,7 @@ func (h *MasterDataHandler) createAttributeTemplate(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 	}
 	template := req.toEntity()
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	created, err := h.service.CreateAttributeTemplate(ctx, template)
@@ -219,7 +219,7 @@ func (h *MasterDataHandler) updateAttributeTemplate(c *fiber.Ctx) error {
 	}
 
 	template := req.toEntity()
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	updated, err := h.service.UpdateAttributeTemplate(ctx, templateID, template)
@@ -240,7 +240,7 @@ func (h *MasterDataHandler) deleteAttributeTemplate(c *fiber.Ctx) error {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	if err := h.service.DeleteAttributeTemplate(ctx, templateID); err != nil {
@@ -256,7 +256,7 @@ func (h *MasterDataHandler) deleteAttributeTemplate(c *fiber.Ctx) error {
 
 // listItems returns item master data.
 func (h *MasterDataHandler) listItems(c *fiber.Ctx) error {
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	items, err := h.service.ListItems(ctx)
@@ -272,7 +272,7 @@ func (h *MasterDataHandler) getItem(c *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	item, err := h.service.GetItem(ctx, itemID)
@@ -299,7 +299,7 @@ func (h *MasterDataHandler) createItem(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	created, err := h.service.CreateItem(ctx, item, attrs)
@@ -327,7 +327,7 @@ func (h *MasterDataHandler) updateItem(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	updated, err := h.service.UpdateItem(ctx, itemID, item, attrs)
@@ -347,7 +347,7 @@ func (h *MasterDataHandler) deleteItem(c *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	if err := h.service.DeleteItem(ctx, itemID); err != nil {
@@ -363,7 +363,7 @@ func (h *MasterDataHandler) listCatalogLinks(c *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	links, err := h.service.ListCatalogLinks(ctx, leftType, leftID)
@@ -394,7 +394,7 @@ func (h *MasterDataHandler) replaceCatalogLinks(c *fiber.Ctx) error {
 		links = append(links, link)
 	}
 
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	if err := h.service.ReplaceCatalogLinks(ctx, leftType, leftID, links); err != nil {
@@ -405,7 +405,7 @@ func (h *MasterDataHandler) replaceCatalogLinks(c *fiber.Ctx) error {
 
 // listWarehouses returns warehouses.
 func (h *MasterDataHandler) listWarehouses(c *fiber.Ctx) error {
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	warehouses, err := h.service.ListWarehouses(ctx)
@@ -422,7 +422,7 @@ func (h *MasterDataHandler) getWarehouse(c *fiber.Ctx) error {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	details, err := h.service.GetWarehouseDetails(ctx, warehouseID)
@@ -446,7 +446,7 @@ func (h *MasterDataHandler) createWarehouse(c *fiber.Ctx) error {
 	}
 
 	model := req.toEntity()
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	warehouse, err := h.service.CreateWarehouse(ctx, model)
@@ -470,7 +470,7 @@ func (h *MasterDataHandler) updateWarehouse(c *fiber.Ctx) error {
 	}
 
 	model := req.toEntity()
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	warehouse, err := h.service.UpdateWarehouse(ctx, warehouseID, model)
@@ -491,7 +491,7 @@ func (h *MasterDataHandler) deleteWarehouse(c *fiber.Ctx) error {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	if err := h.service.DeleteWarehouse(ctx, warehouseID); err != nil {
@@ -507,7 +507,7 @@ func (h *MasterDataHandler) listZones(c *fiber.Ctx) error {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	zones, err := h.service.ListZones(ctx, warehouseID)
@@ -528,7 +528,7 @@ func (h *MasterDataHandler) createZone(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 	}
 
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	zone, err := h.service.CreateZone(ctx, warehouseID, req.toEntity())
@@ -554,7 +554,7 @@ func (h *MasterDataHandler) updateZone(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 	}
 
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	zone, err := h.service.UpdateZone(ctx, warehouseID, zoneID, req.toEntity())
@@ -578,7 +578,7 @@ func (h *MasterDataHandler) deleteZone(c *fiber.Ctx) error {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	if err := h.service.DeleteZone(ctx, warehouseID, zoneID); err != nil {
@@ -598,7 +598,7 @@ func (h *MasterDataHandler) listCells(c *fiber.Ctx) error {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	cells, err := h.service.ListCells(ctx, warehouseID, zoneID)
@@ -624,7 +624,7 @@ func (h *MasterDataHandler) createCell(c *fiber.Ctx) error {
 	}
 
 	model := req.toEntity()
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	cell, err := h.service.CreateCell(ctx, warehouseID, zoneID, model)
@@ -654,7 +654,7 @@ func (h *MasterDataHandler) updateCell(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 	}
 
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	cell, err := h.service.UpdateCell(ctx, warehouseID, zoneID, cellID, req.toEntity())
@@ -689,7 +689,7 @@ func (h *MasterDataHandler) deleteCell(c *fiber.Ctx) error {
 		}
 	}
 
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	if err := h.service.DeleteCell(ctx, warehouseID, zoneID, cellID, actorID); err != nil {
@@ -708,7 +708,7 @@ func (h *MasterDataHandler) listEquipment(c *fiber.Ctx) error {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	equipment, err := h.service.ListEquipment(ctx, warehouseID)
@@ -728,7 +728,7 @@ func (h *MasterDataHandler) createEquipment(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 	}
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	equipment, err := h.service.CreateEquipment(ctx, warehouseID, req.toEntity())
@@ -754,7 +754,7 @@ func (h *MasterDataHandler) updateEquipment(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 	}
 
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	equipment, err := h.service.UpdateEquipment(ctx, warehouseID, equipmentID, req.toEntity())
@@ -775,7 +775,7 @@ func (h *MasterDataHandler) deleteEquipment(c *fiber.Ctx) error {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	if err := h.service.DeleteEquipment(ctx, warehouseID, equipmentID); err != nil {
@@ -802,7 +802,7 @@ func (h *MasterDataHandler) assignEquipment(c *fiber.Ctx) error {
 		}
 	}
 
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	if err := h.service.AssignEquipment(ctx, cellID, equipmentID, actorID); err != nil {
@@ -822,7 +822,7 @@ func (h *MasterDataHandler) unassignEquipment(c *fiber.Ctx) error {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	if err := h.service.UnassignEquipment(ctx, cellID, equipmentID); err != nil {
@@ -839,7 +839,7 @@ func (h *MasterDataHandler) cellHistory(c *fiber.Ctx) error {
 	}
 	limit := c.QueryInt("limit", 50)
 
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
 	history, err := h.service.ListCellHistory(ctx, cellID, limit)
@@ -24,7 +24,7 @@ func (r *MasterDataRepository) ListCatalogNodes(ctx context.Context, catalogType
         WHERE catalog_type = $1
         ORDER BY sort_order, name`
 
-	rows, err := r.pool.Query(ctx, query, string(catalogType))
+	rows, err := r.read.Query(ctx, query, string(catalogType))
 	if err != nil {
 		return nil, fmt.Errorf("list catalog nodes: %w", err)
 	}
@@ -258,7 +258,7 @@ func (r *MasterDataRepository) ListAttributeTemplates(ctx context.Context, targe
         WHERE target_type = $1
         ORDER BY position, name`
 
-	rows, err := r.pool.Query(ctx, query, targetType)
+	rows, err := r.read.Query(ctx, query, targetType)
 	if err != nil {
 		return nil, fmt.Errorf("list attribute templates: %w", err)
 	}
@@ -460,7 +460,7 @@ func (r *MasterDataRepository) ListCatalogLinks(ctx context.Context, leftType st
         WHERE left_type = $1 AND left_id = $2
         ORDER BY relation_code, right_type`
 
-	rows, err := r.pool.Query(ctx, query, leftType, leftID)
+	rows, err := r.read.Query(ctx, query, leftType, leftID)
 	if err != nil {
 		return nil, fmt.Errorf("list catalog links: %w", err)
 	}
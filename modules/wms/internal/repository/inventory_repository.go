@@ -8,28 +8,42 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"asfppro/modules/wms/internal/entity"
+	"asfppro/pkg/db"
+	"asfppro/pkg/tenant"
 )
 
-// InventoryRepository stores stock balances.
+// InventoryRepository stores stock balances. Warehouses are shared between companies, so
+// each balance belongs to the active company in ctx.
 type InventoryRepository struct {
 	pool *pgxpool.Pool
+	read db.Querier
 }
 
 // NewInventoryRepository builds repository.
 func NewInventoryRepository(pool *pgxpool.Pool) *InventoryRepository {
-	return &InventoryRepository{pool: pool}
+	return &InventoryRepository{pool: pool, read: pool}
+}
+
+// WithReader routes list queries through read, typically a db.Router sending them to replicas.
+func (r *InventoryRepository) WithReader(read db.Querier) *InventoryRepository {
+	r.read = read
+	return r
 }
 
 // Upsert stores item quantity for warehouse.
 func (r *InventoryRepository) Upsert(ctx context.Context, item entity.StockItem) (entity.StockItem, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return entity.StockItem{}, err
+	}
 	query := `
-	INSERT INTO wms.stock (sku, warehouse, quantity, uom)
-	VALUES ($1, $2, $3, $4)
-	ON CONFLICT (sku, warehouse)
+	INSERT INTO wms.stock (company_code, sku, warehouse, quantity, uom)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (company_code, sku, warehouse)
 	DO UPDATE SET quantity = EXCLUDED.quantity, updated_at = NOW()
 	RETURNING updated_at
 	`
-	row := r.pool.QueryRow(ctx, query, item.SKU, item.Warehouse, item.Quantity, item.UOM)
+	row := r.pool.QueryRow(ctx, query, company, item.SKU, item.Warehouse, item.Quantity, item.UOM)
 	if err := row.Scan(&item.UpdatedAt); err != nil {
 		return entity.StockItem{}, fmt.Errorf("upsert stock: %w", err)
 	}
@@ -38,14 +52,18 @@ func (r *InventoryRepository) Upsert(ctx context.Context, item entity.StockItem)
 
 // List returns stock items per warehouse.
 func (r *InventoryRepository) List(ctx context.Context, warehouse string, limit int) ([]entity.StockItem, error) {
+	company, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
 	query := `
 	SELECT sku, warehouse, quantity, uom, updated_at
 	FROM wms.stock
-	WHERE ($1 = '' OR warehouse = $1)
+	WHERE company_code = $1 AND ($2 = '' OR warehouse = $2)
 	ORDER BY updated_at DESC
-	LIMIT $2
+	LIMIT $3
 	`
-	rows, err := r.pool.Query(ctx, query, warehouse, limit)
+	rows, err := r.read.Query(ctx, query, company, warehouse, limit)
 	if err != nil {
 		return nil, fmt.Errorf("list stock: %w", err)
 	}
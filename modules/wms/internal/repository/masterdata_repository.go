@@ -12,16 +12,24 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"asfppro/modules/wms/internal/entity"
+	"asfppro/pkg/db"
 )
 
 // MasterDataRepository manages warehouses, zones and cells.
 type MasterDataRepository struct {
 	pool *pgxpool.Pool
+	read db.Querier
 }
 
 // NewMasterDataRepository creates repository instance.
 func NewMasterDataRepository(pool *pgxpool.Pool) *MasterDataRepository {
-	return &MasterDataRepository{pool: pool}
+	return &MasterDataRepository{pool: pool, read: pool}
+}
+
+// WithReader routes list queries through read, typically a db.Router sending them to replicas.
+func (r *MasterDataRepository) WithReader(read db.Querier) *MasterDataRepository {
+	r.read = read
+	return r
 }
 
 // ListWarehouses returns available warehouses.
@@ -32,7 +40,7 @@ func (r *MasterDataRepository) ListWarehouses(ctx context.Context) ([]entity.War
 		FROM wms.warehouse
 		ORDER BY name
 	`
-	rows, err := r.pool.Query(ctx, query)
+	rows, err := r.read.Query(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("list warehouses: %w", err)
 	}
@@ -230,7 +238,7 @@ func (r *MasterDataRepository) ListZones(ctx context.Context, warehouseID uuid.U
 		WHERE warehouse_id = $1
 		ORDER BY name
 	`
-	rows, err := r.pool.Query(ctx, query, warehouseID)
+	rows, err := r.read.Query(ctx, query, warehouseID)
 	if err != nil {
 		return nil, fmt.Errorf("list zones: %w", err)
 	}
@@ -362,7 +370,7 @@ func (r *MasterDataRepository) ListCells(ctx context.Context, warehouseID, zoneI
 		WHERE warehouse_id = $1 AND zone_id = $2
 		ORDER BY code
 	`
-	rows, err := r.pool.Query(ctx, query, warehouseID, zoneID)
+	rows, err := r.read.Query(ctx, query, warehouseID, zoneID)
 	if err != nil {
 		return nil, fmt.Errorf("list cells: %w", err)
 	}
@@ -475,7 +483,7 @@ func (r *MasterDataRepository) ListEquipment(ctx context.Context, warehouseID uu
 		WHERE warehouse_id = $1
 		ORDER BY name
 	`
-	rows, err := r.pool.Query(ctx, query, warehouseID)
+	rows, err := r.read.Query(ctx, query, warehouseID)
 	if err != nil {
 		return nil, fmt.Errorf("list equipment: %w", err)
 	}
@@ -524,7 +532,7 @@ func (r *MasterDataRepository) ListCellHistory(ctx context.Context, cellID uuid.
 		ORDER BY changed_at DESC
 		LIMIT $2
 	`
-	rows, err := r.pool.Query(ctx, query, cellID, limit)
+	rows, err := r.read.Query(ctx, query, cellID, limit)
 	if err != nil {
 		return nil, fmt.Errorf("list cell history: %w", err)
 	}
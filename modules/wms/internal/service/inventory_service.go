@@ -8,13 +8,21 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 
 	"asfppro/modules/wms/internal/entity"
 	"asfppro/modules/wms/internal/repository"
 	"asfppro/pkg/audit"
+	logpkg "asfppro/pkg/log"
+	"asfppro/pkg/metrics"
 )
 
+var stockUpserts = metrics.Register(prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "wms_stock_upserts_total",
+	Help: "Stock records created or updated.",
+}))
+
 // InventoryService orchestrates stock operations.
 type InventoryService struct {
 	repo    *repository.InventoryRepository
@@ -48,6 +56,7 @@ func (s *InventoryService) Upsert(ctx context.Context, item entity.StockItem) (e
 		return entity.StockItem{}, err
 	}
 
+	stockUpserts.Inc()
 	s.recordAudit(ctx, stored)
 
 	return stored, nil
@@ -82,6 +91,6 @@ func (s *InventoryService) recordAudit(ctx context.Context, item entity.StockIte
 		EntityID: entityID,
 		Payload:  payload,
 	}); err != nil {
-		s.logger.Error().Err(err).Msg("audit stock upsert")
+		logpkg.Ctx(ctx, s.logger).Error().Err(err).Msg("audit stock upsert")
 	}
 }
@@ -16,6 +16,7 @@ import (
 
 	"asfppro/modules/wms/internal/entity"
 	"asfppro/modules/wms/internal/repository"
+	logpkg "asfppro/pkg/log"
 )
 
 var (
@@ -386,7 +387,7 @@ func (s *MasterDataService) logCellHistory(ctx context.Context, cellID uuid.UUID
 		EntityID: cellID.String(),
 		Payload:  auditPayload,
 	}); err != nil {
-		s.logger.Error().Err(err).Msg("audit cell change")
+		logpkg.Ctx(ctx, s.logger).Error().Err(err).Msg("audit cell change")
 	}
 }
 
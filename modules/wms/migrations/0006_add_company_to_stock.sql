@@ -0,0 +1,16 @@
+-- +goose Up
+-- Warehouses and the item catalogue are shared; stock is owned by a legal entity.
+ALTER TABLE wms.stock
+    ADD COLUMN company_code TEXT NOT NULL DEFAULT 'MAIN' REFERENCES core.companies(code);
+
+ALTER TABLE wms.stock
+    ALTER COLUMN company_code DROP DEFAULT;
+
+ALTER TABLE wms.stock DROP CONSTRAINT IF EXISTS stock_pkey;
+ALTER TABLE wms.stock ADD PRIMARY KEY (company_code, sku, warehouse);
+
+-- +goose Down
+-- Fails while several companies hold the same SKU in one warehouse.
+ALTER TABLE wms.stock DROP CONSTRAINT IF EXISTS stock_pkey;
+ALTER TABLE wms.stock ADD PRIMARY KEY (sku, warehouse);
+ALTER TABLE wms.stock DROP COLUMN IF EXISTS company_code;
@@ -0,0 +1,44 @@
+-- +goose Up
+-- Warehouses are visible only within the org units in app.scopes; zones, cells and stock
+-- follow their warehouse. FORCE applies the policies to the table owner too.
+ALTER TABLE wms.warehouse ENABLE ROW LEVEL SECURITY;
+ALTER TABLE wms.warehouse FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY warehouse_org_unit_scope ON wms.warehouse
+    USING (core.rls_org_unit_visible(org_unit_code))
+    WITH CHECK (core.rls_org_unit_visible(org_unit_code));
+
+ALTER TABLE wms.warehouse_zone ENABLE ROW LEVEL SECURITY;
+ALTER TABLE wms.warehouse_zone FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY warehouse_zone_org_unit_scope ON wms.warehouse_zone
+    USING (core.rls_unrestricted() OR EXISTS (SELECT 1 FROM wms.warehouse w WHERE w.id = warehouse_id))
+    WITH CHECK (core.rls_unrestricted() OR EXISTS (SELECT 1 FROM wms.warehouse w WHERE w.id = warehouse_id));
+
+ALTER TABLE wms.warehouse_cell ENABLE ROW LEVEL SECURITY;
+ALTER TABLE wms.warehouse_cell FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY warehouse_cell_org_unit_scope ON wms.warehouse_cell
+    USING (core.rls_unrestricted() OR EXISTS (SELECT 1 FROM wms.warehouse w WHERE w.id = warehouse_id))
+    WITH CHECK (core.rls_unrestricted() OR EXISTS (SELECT 1 FROM wms.warehouse w WHERE w.id = warehouse_id));
+
+ALTER TABLE wms.stock ENABLE ROW LEVEL SECURITY;
+ALTER TABLE wms.stock FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY stock_org_unit_scope ON wms.stock
+    USING (core.rls_unrestricted() OR EXISTS (SELECT 1 FROM wms.warehouse w WHERE w.code = warehouse))
+    WITH CHECK (core.rls_unrestricted() OR EXISTS (SELECT 1 FROM wms.warehouse w WHERE w.code = warehouse));
+
+-- +goose Down
+DROP POLICY IF EXISTS stock_org_unit_scope ON wms.stock;
+ALTER TABLE wms.stock NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE wms.stock DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS warehouse_cell_org_unit_scope ON wms.warehouse_cell;
+ALTER TABLE wms.warehouse_cell NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE wms.warehouse_cell DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS warehouse_zone_org_unit_scope ON wms.warehouse_zone;
+ALTER TABLE wms.warehouse_zone NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE wms.warehouse_zone DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS warehouse_org_unit_scope ON wms.warehouse;
+ALTER TABLE wms.warehouse NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE wms.warehouse DISABLE ROW LEVEL SECURITY;
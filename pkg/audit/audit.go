@@ -14,7 +14,11 @@ import (
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
+
+	"asfppro/pkg/metrics"
+	"asfppro/pkg/tenant"
 )
 
 var (
@@ -24,6 +28,11 @@ var (
 	ErrInvalidEntry = errors.New("invalid audit entry")
 )
 
+var recordFailures = metrics.Register(prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "audit_record_failures_total",
+	Help: "Audit entries that could not be stored.",
+}, []string{"reason"}))
+
 // Entry describes payload to persist in audit_log.
 type Entry struct {
 	ActorID  uuid.UUID
@@ -39,6 +48,7 @@ type Filter struct {
 	Action       string
 	Entity       string
 	EntityID     string
+	Company      string
 	AfterID      int64
 	Limit        int
 	OccurredFrom *time.Time
@@ -53,6 +63,7 @@ type Record struct {
 	Action     string          `json:"action"`
 	Entity     string          `json:"entity"`
 	EntityID   *string         `json:"entityId,omitempty"`
+	Company    string          `json:"company,omitempty"`
 	Payload    json.RawMessage `json:"payload,omitempty"`
 }
 
@@ -78,13 +89,14 @@ func NewRecorderWithDB(db execQuerier, logger zerolog.Logger) *Recorder {
 	return &Recorder{db: db, logger: &l}
 }
 
-// Record persists audit entry in core.audit_log.
+// Record persists audit entry in core.audit_log, tagged with the active company if any.
 func (r *Recorder) Record(ctx context.Context, entry Entry) error {
 	if r == nil || r.db == nil {
 		return ErrRecorderNotConfigured
 	}
 
 	if err := validateEntry(entry); err != nil {
+		recordFailures.WithLabelValues("invalid").Inc()
 		return err
 	}
 
@@ -93,8 +105,10 @@ func (r *Recorder) Record(ctx context.Context, entry Entry) error {
 		r.logError("marshal payload", err)
 	}
 
-	const query = `INSERT INTO core.audit_log (actor_id, action, entity, entity_id, payload)
-VALUES ($1, $2, $3, $4, $5)`
+	const query = `INSERT INTO core.audit_log (actor_id, action, entity, entity_id, payload, company_code)
+VALUES ($1, $2, $3, $4, $5, $6)`
+
+	company, _ := tenant.Company(ctx)
 
 	_, execErr := r.db.Exec(ctx, query,
 		nullUUID(entry.ActorID),
@@ -102,8 +116,10 @@ VALUES ($1, $2, $3, $4, $5)`
 		strings.TrimSpace(entry.Entity),
 		nullString(entry.EntityID),
 		payload,
+		nullString(company),
 	)
 	if execErr != nil {
+		recordFailures.WithLabelValues("insert").Inc()
 		r.logError("insert audit log", execErr)
 		return fmt.Errorf("insert audit log: %w", execErr)
 	}
@@ -151,6 +167,11 @@ func (r *Recorder) List(ctx context.Context, filter Filter) ([]Record, error) {
 		args = append(args, entityID)
 		idx++
 	}
+	if company := tenant.Normalize(filter.Company); company != "" {
+		clauses = append(clauses, fmt.Sprintf("company_code = $%d", idx))
+		args = append(args, company)
+		idx++
+	}
 	if filter.AfterID > 0 {
 		clauses = append(clauses, fmt.Sprintf("id < $%d", idx))
 		args = append(args, filter.AfterID)
@@ -167,7 +188,7 @@ func (r *Recorder) List(ctx context.Context, filter Filter) ([]Record, error) {
 		idx++
 	}
 
-	query := `SELECT id, occurred_at, actor_id, action, entity, entity_id, payload FROM core.audit_log`
+	query := `SELECT id, occurred_at, actor_id, action, entity, entity_id, payload, COALESCE(company_code, '') FROM core.audit_log`
 	if len(clauses) > 0 {
 		query += " WHERE " + strings.Join(clauses, " AND ")
 	}
@@ -189,7 +210,7 @@ func (r *Recorder) List(ctx context.Context, filter Filter) ([]Record, error) {
 			payload  []byte
 		)
 
-		if err := rows.Scan(&record.ID, &record.OccurredAt, &actorID, &record.Action, &record.Entity, &entityID, &payload); err != nil {
+		if err := rows.Scan(&record.ID, &record.OccurredAt, &actorID, &record.Action, &record.Entity, &entityID, &payload, &record.Company); err != nil {
 			return nil, fmt.Errorf("scan audit log: %w", err)
 		}
 
@@ -15,6 +15,8 @@ import (
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/rs/zerolog"
+
+	"asfppro/pkg/tenant"
 )
 
 type stubDB struct {
@@ -54,6 +56,7 @@ type fakeRow struct {
 	entity   string
 	entityID sql.NullString
 	payload  []byte
+	company  string
 }
 
 type fakeRows struct {
@@ -83,7 +86,7 @@ func (r *fakeRows) Scan(dest ...any) error {
 		return fmt.Errorf("scan called without next")
 	}
 	row := r.rows[r.idx-1]
-	if len(dest) != 7 {
+	if len(dest) != 8 {
 		return fmt.Errorf("unexpected dest length: %d", len(dest))
 	}
 	if v, ok := dest[0].(*int64); ok {
@@ -107,6 +110,9 @@ func (r *fakeRows) Scan(dest ...any) error {
 	if v, ok := dest[6].(*[]byte); ok {
 		*v = append([]byte(nil), row.payload...)
 	}
+	if v, ok := dest[7].(*string); ok {
+		*v = row.company
+	}
 	return nil
 }
 
@@ -124,7 +130,8 @@ func TestRecorderRecord(t *testing.T) {
 	actorID := uuid.MustParse("aaaaaaaa-aaaa-4aaa-8aaa-aaaaaaaaaaaa")
 	payload := map[string]any{"size": 123}
 
-	if err := recorder.Record(context.Background(), Entry{
+	ctx := tenant.WithCompany(context.Background(), "main")
+	if err := recorder.Record(ctx, Entry{
 		ActorID:  actorID,
 		Action:   "file.upload",
 		Entity:   "file",
@@ -134,11 +141,11 @@ func TestRecorderRecord(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if db.execSQL != "INSERT INTO core.audit_log (actor_id, action, entity, entity_id, payload)\nVALUES ($1, $2, $3, $4, $5)" {
+	if db.execSQL != "INSERT INTO core.audit_log (actor_id, action, entity, entity_id, payload, company_code)\nVALUES ($1, $2, $3, $4, $5, $6)" {
 		t.Fatalf("unexpected exec sql: %s", db.execSQL)
 	}
 
-	if len(db.execArgs) != 5 {
+	if len(db.execArgs) != 6 {
 		t.Fatalf("unexpected exec args len: %d", len(db.execArgs))
 	}
 	if got := db.execArgs[0]; got != actorID {
@@ -156,6 +163,9 @@ func TestRecorderRecord(t *testing.T) {
 	if got, ok := db.execArgs[4].([]byte); !ok || string(got) != `{"size":123}` {
 		t.Fatalf("unexpected payload arg: %#v", db.execArgs[4])
 	}
+	if got := db.execArgs[5]; got != "MAIN" {
+		t.Fatalf("unexpected company arg: %#v", got)
+	}
 }
 
 func TestRecorderList(t *testing.T) {
@@ -172,6 +182,7 @@ func TestRecorderList(t *testing.T) {
 				entity:   "crm.deal",
 				entityID: sql.NullString{String: "deal-1", Valid: true},
 				payload:  json.RawMessage(`{"foo":"bar"}`),
+				company:  "MAIN",
 			},
 		},
 	}
@@ -198,6 +209,9 @@ func TestRecorderList(t *testing.T) {
 	if string(rec.Payload) != `{"foo":"bar"}` {
 		t.Fatalf("unexpected payload: %s", string(rec.Payload))
 	}
+	if rec.Company != "MAIN" {
+		t.Fatalf("unexpected company: %q", rec.Company)
+	}
 }
 
 func TestRecorderListFiltersByActionAndDates(t *testing.T) {
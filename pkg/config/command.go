@@ -0,0 +1,85 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redactedValue = "******"
+
+// Command runs the "config print [--redacted] [flags]" subcommand for prefix and reports
+// whether args selected it. The output is YAML accepted by <PREFIX>_CONFIG_FILE.
+func Command(prefix string, args []string, stdout io.Writer) (bool, error) {
+	if len(args) == 0 || args[0] != "config" {
+		return false, nil
+	}
+	if len(args) < 2 || args[1] != "print" {
+		return true, errors.New("usage: config print [--redacted] [flags]")
+	}
+
+	l := newLoader(prefix)
+	fs := l.flagSet(os.Stderr)
+	redacted := fs.Bool("redacted", false, "mask secrets in the output")
+	if err := fs.Parse(args[2:]); err != nil {
+		return true, fmt.Errorf("parse flags: %w", err)
+	}
+
+	cfg, err := l.resolve(fs)
+	if err != nil {
+		return true, err
+	}
+	return true, l.print(stdout, cfg, *redacted)
+}
+
+// print writes cfg as YAML in field order, masking secrets when redacted is set.
+func (l *loader) print(w io.Writer, cfg AppConfig, redacted bool) error {
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	for _, f := range l.fields {
+		value := f.get(cfg)
+		if redacted && f.secret {
+			value = redact(value)
+		}
+		doc.Content = append(doc.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: f.yamlKey()},
+			&yaml.Node{Kind: yaml.ScalarNode, Value: value, Style: scalarStyle(value)},
+		)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("print config: %w", err)
+	}
+	return enc.Close()
+}
+
+// scalarStyle quotes empty values so they survive a round trip through the file loader.
+func scalarStyle(value string) yaml.Style {
+	if value == "" {
+		return yaml.DoubleQuotedStyle
+	}
+	return 0
+}
+
+// redact masks a secret; URLs keep everything but the password. DATABASE_REPLICAS holds
+// several URLs, so each one is redacted on its own.
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	urls := strings.Split(value, ",")
+	for i, part := range urls {
+		u, err := url.Parse(strings.TrimSpace(part))
+		if err != nil || u.Scheme == "" {
+			return redactedValue
+		}
+		urls[i] = u.Redacted()
+	}
+	return strings.Join(urls, ",")
+}
@@ -4,12 +4,21 @@ package db
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	ch "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"asfppro/pkg/metrics"
+	"asfppro/pkg/tracing"
 )
 
-// NewClickHouse returns a configured native ClickHouse connection.
+// NewClickHouse returns a configured native ClickHouse connection with query timings
+// exposed on the default metrics registry and a span per query.
 func NewClickHouse(ctx context.Context, dsn string) (ch.Conn, error) {
 	options, err := ch.ParseDSN(dsn)
 	if err != nil {
@@ -32,5 +41,111 @@ func NewClickHouse(ctx context.Context, dsn string) (ch.Conn, error) {
 		return nil, fmt.Errorf("ping clickhouse: %w", err)
 	}
 
-	return conn, nil
+	return &instrumentedConn{Conn: conn}, nil
+}
+
+var clickHouseQueryDuration = metrics.Register(prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "clickhouse_query_duration_seconds",
+	Help:    "ClickHouse query latency.",
+	Buckets: metrics.DefBuckets,
+}, []string{"operation", "status"}))
+
+// instrumentedConn records query timings and spans; batches are timed on Send.
+type instrumentedConn struct {
+	driver.Conn
+}
+
+func (c *instrumentedConn) Select(ctx context.Context, dest any, query string, args ...any) error {
+	ctx, done := startClickHouse(ctx, query)
+	err := c.Conn.Select(ctx, dest, query, args...)
+	done(err)
+	return err
+}
+
+func (c *instrumentedConn) Query(ctx context.Context, query string, args ...any) (driver.Rows, error) {
+	ctx, done := startClickHouse(ctx, query)
+	rows, err := c.Conn.Query(ctx, query, args...)
+	done(err)
+	return rows, err
+}
+
+func (c *instrumentedConn) QueryRow(ctx context.Context, query string, args ...any) driver.Row {
+	ctx, done := startClickHouse(ctx, query)
+	row := c.Conn.QueryRow(ctx, query, args...)
+	done(row.Err())
+	return row
+}
+
+func (c *instrumentedConn) Exec(ctx context.Context, query string, args ...any) error {
+	ctx, done := startClickHouse(ctx, query)
+	err := c.Conn.Exec(ctx, query, args...)
+	done(err)
+	return err
+}
+
+func (c *instrumentedConn) AsyncInsert(ctx context.Context, query string, wait bool, args ...any) error {
+	ctx, done := startClickHouse(ctx, query)
+	err := c.Conn.AsyncInsert(ctx, query, wait, args...)
+	done(err)
+	return err
+}
+
+func (c *instrumentedConn) PrepareBatch(ctx context.Context, query string, opts ...driver.PrepareBatchOption) (driver.Batch, error) {
+	batch, err := c.Conn.PrepareBatch(ctx, query, opts...)
+	if err != nil {
+		_, done := startClickHouse(ctx, query)
+		done(err)
+		return nil, err
+	}
+	return &instrumentedBatch{Batch: batch, ctx: ctx, query: query}, nil
+}
+
+type instrumentedBatch struct {
+	driver.Batch
+	ctx   context.Context
+	query string
+}
+
+func (b *instrumentedBatch) Send() error {
+	_, done := startClickHouse(b.ctx, b.query)
+	err := b.Batch.Send()
+	done(err)
+	return err
+}
+
+// startClickHouse opens a client span for query; done ends it and records the latency metric.
+func startClickHouse(ctx context.Context, query string) (context.Context, func(error)) {
+	start := time.Now()
+	operation := queryOperation(query)
+	ctx, span := tracing.Tracer().Start(ctx, "clickhouse "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "clickhouse"),
+			attribute.String("db.query.text", query),
+		),
+	)
+	return ctx, func(err error) {
+		status := "ok"
+		if err != nil {
+			status = "error"
+		}
+		clickHouseQueryDuration.WithLabelValues(operation, status).Observe(time.Since(start).Seconds())
+		tracing.End(span, err)
+	}
+}
+
+// queryOperation returns the leading SQL keyword, keeping the label set small.
+func queryOperation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "other"
+	}
+	switch op := strings.ToLower(fields[0]); op {
+	case "select", "insert", "alter", "create", "drop", "optimize":
+		return op
+	case "with":
+		return "select"
+	default:
+		return "other"
+	}
 }
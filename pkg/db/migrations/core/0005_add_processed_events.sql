@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS core.processed_events (
+    consumer_group TEXT NOT NULL,
+    event_id TEXT NOT NULL,
+    processed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (consumer_group, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_core_processed_events_expires_at ON core.processed_events (expires_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_core_processed_events_expires_at;
+DROP TABLE IF EXISTS core.processed_events;
@@ -0,0 +1,40 @@
+-- +goose Up
+-- Legal entities sharing the platform. CRM, documents and numbering are kept per company;
+-- users act for one of the companies granted to them.
+CREATE TABLE IF NOT EXISTS core.companies (
+    code TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    inn TEXT,
+    kpp TEXT,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS core.user_companies (
+    user_id UUID NOT NULL REFERENCES core.users(id) ON DELETE CASCADE,
+    company_code TEXT NOT NULL REFERENCES core.companies(code) ON DELETE CASCADE,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (user_id, company_code)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_core_user_companies_default ON core.user_companies (user_id) WHERE is_default;
+
+INSERT INTO core.companies (code, name)
+VALUES ('MAIN', 'Основное юрлицо')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO core.user_companies (user_id, company_code, is_default)
+SELECT id, 'MAIN', TRUE FROM core.users
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE core.audit_log
+    ADD COLUMN IF NOT EXISTS company_code TEXT REFERENCES core.companies(code);
+
+CREATE INDEX IF NOT EXISTS idx_core_audit_log_company ON core.audit_log (company_code, occurred_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS core.idx_core_audit_log_company;
+ALTER TABLE core.audit_log DROP COLUMN IF EXISTS company_code;
+DROP TABLE IF EXISTS core.user_companies;
+DROP TABLE IF EXISTS core.companies;
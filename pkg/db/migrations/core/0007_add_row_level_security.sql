@@ -0,0 +1,33 @@
+-- +goose Up
+-- Row-level security helpers. Policies compare org unit codes with app.scopes, which the
+-- gateway sets per transaction ('*' for global scope). Members of asfp_rls_bypass, used by
+-- background workers and module services, see every row.
+-- +goose StatementBegin
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'asfp_rls_bypass') THEN
+        CREATE ROLE asfp_rls_bypass NOLOGIN;
+    END IF;
+END
+$$;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION core.rls_unrestricted() RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+    SELECT pg_has_role(current_user, 'asfp_rls_bypass', 'MEMBER')
+        OR COALESCE(current_setting('app.scopes', true), '') = '*'
+$$;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION core.rls_org_unit_visible(org_unit TEXT) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+    SELECT core.rls_unrestricted()
+        OR org_unit = ANY (string_to_array(NULLIF(current_setting('app.scopes', true), ''), ','))
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION IF EXISTS core.rls_org_unit_visible(TEXT);
+DROP FUNCTION IF EXISTS core.rls_unrestricted();
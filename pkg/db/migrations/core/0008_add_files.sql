@@ -0,0 +1,44 @@
+-- +goose Up
+-- Registry of stored files. Every upload of a key adds a version; storage_version is the
+-- object store's version ID, so earlier versions stay downloadable and restorable.
+CREATE TABLE IF NOT EXISTS core.files (
+    id UUID PRIMARY KEY,
+    company_code TEXT NOT NULL REFERENCES core.companies(code),
+    object_key TEXT NOT NULL,
+    filename TEXT NOT NULL,
+    current_version INT NOT NULL DEFAULT 0,
+    created_by UUID REFERENCES core.users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (company_code, object_key)
+);
+
+CREATE TABLE IF NOT EXISTS core.file_versions (
+    file_id UUID NOT NULL REFERENCES core.files(id) ON DELETE CASCADE,
+    version_no INT NOT NULL,
+    storage_version TEXT NOT NULL DEFAULT '',
+    size_bytes BIGINT NOT NULL,
+    checksum TEXT NOT NULL DEFAULT '',
+    content_type TEXT NOT NULL DEFAULT '',
+    uploaded_by UUID REFERENCES core.users(id) ON DELETE SET NULL,
+    restored_from INT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (file_id, version_no)
+);
+
+-- Links files to records of any module, e.g. crm.deal, docs.document or montage.task.
+CREATE TABLE IF NOT EXISTS core.file_attachments (
+    file_id UUID NOT NULL REFERENCES core.files(id) ON DELETE CASCADE,
+    entity TEXT NOT NULL,
+    entity_id TEXT NOT NULL,
+    attached_by UUID REFERENCES core.users(id) ON DELETE SET NULL,
+    attached_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (file_id, entity, entity_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_core_file_attachments_entity ON core.file_attachments (entity, entity_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS core.file_attachments;
+DROP TABLE IF EXISTS core.file_versions;
+DROP TABLE IF EXISTS core.files;
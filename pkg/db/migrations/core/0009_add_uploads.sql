@@ -0,0 +1,29 @@
+-- +goose Up
+-- Resumable (tus) upload sessions. Data goes to an S3 multipart upload; the tail shorter than
+-- the minimum part size waits in pending, and hash_state keeps the running MD5 of the file.
+CREATE TABLE IF NOT EXISTS core.uploads (
+    id UUID PRIMARY KEY,
+    company_code TEXT NOT NULL REFERENCES core.companies(code),
+    object_key TEXT NOT NULL,
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL DEFAULT '',
+    metadata TEXT NOT NULL DEFAULT '',
+    length BIGINT NOT NULL CHECK (length >= 0),
+    upload_offset BIGINT NOT NULL DEFAULT 0,
+    storage_upload_id TEXT NOT NULL,
+    parts JSONB NOT NULL DEFAULT '[]',
+    pending BYTEA NOT NULL DEFAULT ''::bytea,
+    hash_state BYTEA NOT NULL DEFAULT ''::bytea,
+    file_id UUID REFERENCES core.files(id) ON DELETE SET NULL,
+    created_by UUID REFERENCES core.users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    locked_until TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_core_uploads_expires_at ON core.uploads (expires_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS core.uploads;
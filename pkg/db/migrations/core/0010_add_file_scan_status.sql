@@ -0,0 +1,7 @@
+-- +goose Up
+-- Virus scan outcome per version: clean, skipped (too large to scan) or empty when scanning
+-- was disabled at upload time.
+ALTER TABLE core.file_versions ADD COLUMN IF NOT EXISTS scan_status TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE core.file_versions DROP COLUMN IF EXISTS scan_status;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Preview state per version: pending while the worker renders it, then ready, failed or
+-- skipped (too large); empty for content types without previews.
+ALTER TABLE core.file_versions ADD COLUMN IF NOT EXISTS preview_status TEXT NOT NULL DEFAULT '';
+
+-- Rendered previews stored next to the original object, one per version and kind.
+CREATE TABLE IF NOT EXISTS core.file_previews (
+    file_id UUID NOT NULL,
+    version_no INT NOT NULL,
+    kind TEXT NOT NULL,
+    object_key TEXT NOT NULL,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (file_id, version_no, kind),
+    FOREIGN KEY (file_id, version_no) REFERENCES core.file_versions (file_id, version_no) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS core.file_previews;
+ALTER TABLE core.file_versions DROP COLUMN IF EXISTS preview_status;
@@ -0,0 +1,59 @@
+-- +goose Up
+-- Soft delete and retention. A deleted file stays in the trash until purge_after; files
+-- with retain_until in the future or a legal hold can be neither deleted nor purged.
+ALTER TABLE core.files
+    ADD COLUMN IF NOT EXISTS document_type TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS retain_until TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS legal_hold BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS deleted_by UUID REFERENCES core.users(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS purge_after TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_core_files_purge_after ON core.files (purge_after) WHERE deleted_at IS NOT NULL;
+
+-- Previews are purged with their file, so their object versions are kept too.
+ALTER TABLE core.file_previews ADD COLUMN IF NOT EXISTS storage_version TEXT NOT NULL DEFAULT '';
+
+-- The database enforces retention itself, for buckets without object lock and for anything
+-- bypassing the gateway: retention cannot be shortened and protected files cannot be
+-- trashed or deleted.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION core.files_enforce_retention() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        IF OLD.legal_hold OR OLD.retain_until > NOW() THEN
+            RAISE EXCEPTION 'file % is under retention or legal hold', OLD.id
+                USING ERRCODE = 'integrity_constraint_violation';
+        END IF;
+        RETURN OLD;
+    END IF;
+    IF OLD.retain_until IS NOT NULL AND (NEW.retain_until IS NULL OR NEW.retain_until < OLD.retain_until) THEN
+        RAISE EXCEPTION 'retention of file % cannot be shortened', OLD.id
+            USING ERRCODE = 'integrity_constraint_violation';
+    END IF;
+    IF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL AND (NEW.legal_hold OR NEW.retain_until > NOW()) THEN
+        RAISE EXCEPTION 'file % is under retention or legal hold', OLD.id
+            USING ERRCODE = 'integrity_constraint_violation';
+    END IF;
+    RETURN NEW;
+END
+$$;
+-- +goose StatementEnd
+
+DROP TRIGGER IF EXISTS files_enforce_retention ON core.files;
+CREATE TRIGGER files_enforce_retention BEFORE UPDATE OR DELETE ON core.files
+    FOR EACH ROW EXECUTE FUNCTION core.files_enforce_retention();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS files_enforce_retention ON core.files;
+DROP FUNCTION IF EXISTS core.files_enforce_retention();
+ALTER TABLE core.file_previews DROP COLUMN IF EXISTS storage_version;
+DROP INDEX IF EXISTS core.idx_core_files_purge_after;
+ALTER TABLE core.files
+    DROP COLUMN IF EXISTS purge_after,
+    DROP COLUMN IF EXISTS deleted_by,
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS legal_hold,
+    DROP COLUMN IF EXISTS retain_until,
+    DROP COLUMN IF EXISTS document_type;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Consumers claim an event before handling it; done is set once the handler succeeded.
+ALTER TABLE core.processed_events ADD COLUMN IF NOT EXISTS done BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- +goose Down
+ALTER TABLE core.processed_events DROP COLUMN IF EXISTS done;
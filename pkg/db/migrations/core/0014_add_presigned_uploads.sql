@@ -0,0 +1,20 @@
+-- +goose Up
+-- Presigned uploads waiting for confirmation. An upload that is not confirmed before
+-- expires_at is removed from storage by the trash purger, unless it was registered anyway.
+CREATE TABLE IF NOT EXISTS core.presigned_uploads (
+    id UUID PRIMARY KEY,
+    company_code TEXT NOT NULL REFERENCES core.companies(code),
+    object_key TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    md5 TEXT NOT NULL,
+    created_by UUID REFERENCES core.users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    confirmed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_core_presigned_uploads_key ON core.presigned_uploads (company_code, object_key);
+CREATE INDEX IF NOT EXISTS idx_core_presigned_uploads_expires ON core.presigned_uploads (expires_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS core.presigned_uploads;
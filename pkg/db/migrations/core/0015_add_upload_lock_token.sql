@@ -0,0 +1,12 @@
+-- +goose Up
+-- lock_token identifies the request holding an upload, so a request whose lease ran out
+-- cannot overwrite the progress of the next one. storage_version is set once the multipart
+-- upload is assembled, so a retried completion only registers the object.
+ALTER TABLE core.uploads
+    ADD COLUMN IF NOT EXISTS lock_token UUID,
+    ADD COLUMN IF NOT EXISTS storage_version TEXT;
+
+-- +goose Down
+ALTER TABLE core.uploads
+    DROP COLUMN IF EXISTS storage_version,
+    DROP COLUMN IF EXISTS lock_token;
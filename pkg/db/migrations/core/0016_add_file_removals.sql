@@ -0,0 +1,17 @@
+-- +goose Up
+-- Stored objects of purged files waiting to be removed from storage. Purging deletes the
+-- registry rows and queues their objects in one transaction, so a purged file can no longer
+-- be restored; the trash purger then removes the objects and drops their rows.
+CREATE TABLE IF NOT EXISTS core.file_removals (
+    id BIGSERIAL PRIMARY KEY,
+    company_code TEXT NOT NULL REFERENCES core.companies(code),
+    file_id UUID NOT NULL,
+    object_key TEXT NOT NULL,
+    storage_version TEXT NOT NULL DEFAULT '',
+    queued_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_core_file_removals_queued ON core.file_removals (queued_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS core.file_removals;
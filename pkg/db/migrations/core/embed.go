@@ -0,0 +1,12 @@
+// Package core embeds migrations for the shared core schema, applied by the gateway.
+package core
+
+import "embed"
+
+// Table tracks applied versions, shared with the goose CLI.
+const Table = "goose_db_version_core"
+
+// Files embeds SQL migration files for the core schema.
+//
+//go:embed *.sql
+var Files embed.FS
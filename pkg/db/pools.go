@@ -0,0 +1,118 @@
+package db
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"asfppro/pkg/metrics"
+)
+
+var (
+	poolsMu       sync.Mutex
+	pools         = make(map[string][]*pgxpool.Pool)
+	poolStatsOnce sync.Once
+)
+
+// trackPool exposes pool statistics on the default metrics registry, labelled by database.
+// Pools connected to the same database are summed.
+func trackPool(database string, pool *pgxpool.Pool) {
+	poolsMu.Lock()
+	pools[database] = append(pools[database], pool)
+	poolsMu.Unlock()
+
+	poolStatsOnce.Do(func() { metrics.Register(poolCollector{}) })
+}
+
+type poolMetric struct {
+	desc  *prometheus.Desc
+	kind  prometheus.ValueType
+	value func(*pgxpool.Stat) float64
+}
+
+func newPoolMetric(name, help string, kind prometheus.ValueType, value func(*pgxpool.Stat) float64) poolMetric {
+	return poolMetric{desc: prometheus.NewDesc(name, help, []string{"database"}, nil), kind: kind, value: value}
+}
+
+var poolMetrics = []poolMetric{
+	newPoolMetric("db_pool_total_conns", "Connections currently in the pool.", prometheus.GaugeValue, func(s *pgxpool.Stat) float64 { return float64(s.TotalConns()) }),
+	newPoolMetric("db_pool_idle_conns", "Idle connections in the pool.", prometheus.GaugeValue, func(s *pgxpool.Stat) float64 { return float64(s.IdleConns()) }),
+	newPoolMetric("db_pool_acquired_conns", "Connections currently acquired.", prometheus.GaugeValue, func(s *pgxpool.Stat) float64 { return float64(s.AcquiredConns()) }),
+	newPoolMetric("db_pool_max_conns", "Maximum pool size.", prometheus.GaugeValue, func(s *pgxpool.Stat) float64 { return float64(s.MaxConns()) }),
+	newPoolMetric("db_pool_acquire_total", "Successful connection acquisitions.", prometheus.CounterValue, func(s *pgxpool.Stat) float64 { return float64(s.AcquireCount()) }),
+	newPoolMetric("db_pool_empty_acquire_total", "Acquisitions that waited for a connection.", prometheus.CounterValue, func(s *pgxpool.Stat) float64 { return float64(s.EmptyAcquireCount()) }),
+	newPoolMetric("db_pool_canceled_acquire_total", "Acquisitions canceled by context.", prometheus.CounterValue, func(s *pgxpool.Stat) float64 { return float64(s.CanceledAcquireCount()) }),
+	newPoolMetric("db_pool_acquire_wait_seconds_total", "Time spent acquiring connections.", prometheus.CounterValue, func(s *pgxpool.Stat) float64 { return s.AcquireDuration().Seconds() }),
+}
+
+// poolCollector reads pool statistics at scrape time.
+type poolCollector struct{}
+
+func (poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range poolMetrics {
+		ch <- m.desc
+	}
+}
+
+func (poolCollector) Collect(ch chan<- prometheus.Metric) {
+	poolsMu.Lock()
+	defer poolsMu.Unlock()
+
+	for name, list := range pools {
+		stats := make([]*pgxpool.Stat, 0, len(list))
+		for _, pool := range list {
+			stats = append(stats, pool.Stat())
+		}
+		for _, m := range poolMetrics {
+			var total float64
+			for _, stat := range stats {
+				total += m.value(stat)
+			}
+			ch <- prometheus.MustNewConstMetric(m.desc, m.kind, total, name)
+		}
+	}
+}
+
+// PoolState is a snapshot of pool statistics for one database.
+type PoolState struct {
+	Database             string        `json:"database"`
+	Pools                int           `json:"pools"`
+	TotalConns           int32         `json:"totalConns"`
+	IdleConns            int32         `json:"idleConns"`
+	AcquiredConns        int32         `json:"acquiredConns"`
+	ConstructingConns    int32         `json:"constructingConns"`
+	MaxConns             int32         `json:"maxConns"`
+	AcquireCount         int64         `json:"acquireCount"`
+	EmptyAcquireCount    int64         `json:"emptyAcquireCount"`
+	CanceledAcquireCount int64         `json:"canceledAcquireCount"`
+	AcquireDuration      time.Duration `json:"acquireDurationNs"`
+}
+
+// PoolStates returns statistics of pools opened with NewPostgresPool, summed per database.
+func PoolStates() []PoolState {
+	poolsMu.Lock()
+	defer poolsMu.Unlock()
+
+	states := make([]PoolState, 0, len(pools))
+	for name, list := range pools {
+		state := PoolState{Database: name, Pools: len(list)}
+		for _, pool := range list {
+			stat := pool.Stat()
+			state.TotalConns += stat.TotalConns()
+			state.IdleConns += stat.IdleConns()
+			state.AcquiredConns += stat.AcquiredConns()
+			state.ConstructingConns += stat.ConstructingConns()
+			state.MaxConns += stat.MaxConns()
+			state.AcquireCount += stat.AcquireCount()
+			state.EmptyAcquireCount += stat.EmptyAcquireCount()
+			state.CanceledAcquireCount += stat.CanceledAcquireCount()
+			state.AcquireDuration += stat.AcquireDuration()
+		}
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Database < states[j].Database })
+	return states
+}
@@ -6,23 +6,34 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"asfppro/pkg/tracing"
 )
 
+// PoolConfig sizes a connection pool.
+type PoolConfig struct {
+	MaxConns        int32
+	MinConns        int32
+	MaxConnIdleTime time.Duration
+	MaxConnLifetime time.Duration
+}
+
+// DefaultPoolConfig returns the sizing used when a service does not configure one.
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{MaxConns: 25, MinConns: 5, MaxConnIdleTime: 5 * time.Minute, MaxConnLifetime: 30 * time.Minute}
+}
+
 // NewPostgresPool initializes a pgx connection pool with sane defaults.
 func NewPostgresPool(ctx context.Context, url string) (*pgxpool.Pool, error) {
-	config, err := pgxpool.ParseConfig(url)
-	if err != nil {
-		return nil, fmt.Errorf("parse postgres url: %w", err)
-	}
-
-	config.MaxConns = 25
-	config.MinConns = 5
-	config.MaxConnIdleTime = 5 * time.Minute
-	config.MaxConnLifetime = 30 * time.Minute
+	return NewPostgresPoolWithConfig(ctx, url, DefaultPoolConfig())
+}
 
-	pool, err := pgxpool.NewWithConfig(ctx, config)
+// NewPostgresPoolWithConfig initializes a pgx connection pool sized by pc and verifies it
+// with a ping.
+func NewPostgresPoolWithConfig(ctx context.Context, url string, pc PoolConfig) (*pgxpool.Pool, error) {
+	pool, poolCfg, err := openPool(ctx, url, pc)
 	if err != nil {
-		return nil, fmt.Errorf("connect postgres: %w", err)
+		return nil, err
 	}
 
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
@@ -33,5 +44,26 @@ func NewPostgresPool(ctx context.Context, url string) (*pgxpool.Pool, error) {
 		return nil, fmt.Errorf("ping postgres: %w", err)
 	}
 
+	trackPool(poolCfg.ConnConfig.Database, pool)
 	return pool, nil
 }
+
+// openPool creates a pool without waiting for a connection.
+func openPool(ctx context.Context, url string, pc PoolConfig) (*pgxpool.Pool, *pgxpool.Config, error) {
+	config, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse postgres url: %w", err)
+	}
+
+	config.MaxConns = pc.MaxConns
+	config.MinConns = pc.MinConns
+	config.MaxConnIdleTime = pc.MaxConnIdleTime
+	config.MaxConnLifetime = pc.MaxConnLifetime
+	config.ConnConfig.Tracer = tracing.PgxTracer{Database: config.ConnConfig.Database}
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connect postgres: %w", err)
+	}
+	return pool, config, nil
+}
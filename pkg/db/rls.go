@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrNoSession is returned when a row-level security transaction starts without a session in ctx.
+var ErrNoSession = errors.New("no row-level security session")
+
+// BypassRole is the Postgres role whose members skip row-level security policies; background
+// workers and module services connect as its members.
+const BypassRole = "asfp_rls_bypass"
+
+// allScopes grants every org unit in app.scopes.
+const allScopes = "*"
+
+// setSessionQuery sets the policy variables for the current transaction only.
+const setSessionQuery = `SELECT set_config('app.user_id', $1, true), set_config('app.scopes', $2, true)`
+
+// Session identifies who a transaction runs for; policies read it from app.user_id and
+// app.scopes.
+type Session struct {
+	UserID   string
+	Scopes   []string
+	AllowAll bool
+}
+
+// scopesSetting renders the org unit scopes as the comma-separated app.scopes value.
+func (s Session) scopesSetting() string {
+	if s.AllowAll {
+		return allScopes
+	}
+	scopes := make([]string, 0, len(s.Scopes))
+	for _, scope := range s.Scopes {
+		if scope = strings.TrimSpace(scope); scope != "" && scope != allScopes && !strings.Contains(scope, ",") {
+			scopes = append(scopes, scope)
+		}
+	}
+	return strings.Join(scopes, ",")
+}
+
+type sessionKey struct{}
+
+// WithSession returns ctx carrying s for InSession.
+func WithSession(ctx context.Context, s Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+// SessionFrom returns the session stored in ctx.
+func SessionFrom(ctx context.Context) (Session, bool) {
+	s, ok := ctx.Value(sessionKey{}).(Session)
+	return s, ok
+}
+
+// TxBeginner starts transactions; *pgxpool.Pool implements it.
+type TxBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// InSession runs fn in a transaction with the session from ctx applied, so row-level security
+// policies see the caller's org unit scopes. Errors from fn are returned unwrapped.
+func InSession(ctx context.Context, b TxBeginner, fn func(pgx.Tx) error) (err error) {
+	s, ok := SessionFrom(ctx)
+	if !ok {
+		return ErrNoSession
+	}
+
+	tx, err := b.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	if _, err = tx.Exec(ctx, setSessionQuery, s.UserID, s.scopesSetting()); err != nil {
+		return fmt.Errorf("set rls session: %w", err)
+	}
+	if err = fn(tx); err != nil {
+		return err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestSessionScopesSetting(t *testing.T) {
+	cases := []struct {
+		name    string
+		session Session
+		want    string
+	}{
+		{"all", Session{AllowAll: true, Scopes: []string{"HQ"}}, "*"},
+		{"scopes", Session{Scopes: []string{"HQ", " HQ-SALES "}}, "HQ,HQ-SALES"},
+		{"drops wildcard and separators", Session{Scopes: []string{"*", "A,B", "", "WH"}}, "WH"},
+		{"none", Session{}, ""},
+	}
+	for _, tc := range cases {
+		if got := tc.session.scopesSetting(); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+type failingBeginner struct{ called bool }
+
+func (b *failingBeginner) Begin(context.Context) (pgx.Tx, error) {
+	b.called = true
+	return nil, errors.New("unreachable")
+}
+
+func TestInSessionRequiresSession(t *testing.T) {
+	b := &failingBeginner{}
+	err := InSession(context.Background(), b, func(pgx.Tx) error { return nil })
+	if !errors.Is(err, ErrNoSession) {
+		t.Fatalf("expected ErrNoSession, got %v", err)
+	}
+	if b.called {
+		t.Fatal("transaction must not start without a session")
+	}
+
+	ctx := WithSession(context.Background(), Session{UserID: "u1", Scopes: []string{"HQ"}})
+	if s, ok := SessionFrom(ctx); !ok || s.UserID != "u1" {
+		t.Fatalf("session = %+v, %v", s, ok)
+	}
+}
@@ -0,0 +1,191 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+
+	"asfppro/pkg/health"
+)
+
+// Querier runs read queries; *pgxpool.Pool and *Router implement it.
+type Querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// SessionReader runs read queries directly or in transactions, as InSession needs;
+// *pgxpool.Pool and *Router implement it.
+type SessionReader interface {
+	Querier
+	TxBeginner
+}
+
+// replicaLagQuery reports replay delay in seconds. A replica that has replayed everything it
+// received is not lagging, however old its last transaction is.
+const replicaLagQuery = `
+SELECT CASE
+	WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END::float8`
+
+// RouterCheckInterval is how often replica health and lag are refreshed.
+const RouterCheckInterval = 5 * time.Second
+
+// Router sends read-only queries to healthy replicas in turn and falls back to the primary
+// when none is usable or all lag more than the configured maximum. Writes and reads that
+// must see them use Primary.
+type Router struct {
+	primary  *pgxpool.Pool
+	replicas []*replica
+	maxLag   time.Duration
+	next     atomic.Uint32
+	logger   zerolog.Logger
+}
+
+type replica struct {
+	name   string
+	pool   *pgxpool.Pool
+	usable atomic.Bool
+}
+
+// NewRouter opens pools for replicaURLs next to primary. Replicas that are down at startup
+// do not fail it; they join once their checks pass.
+func NewRouter(ctx context.Context, primary *pgxpool.Pool, replicaURLs []string, pc PoolConfig, maxLag time.Duration, logger zerolog.Logger) (*Router, error) {
+	r := &Router{
+		primary: primary,
+		maxLag:  maxLag,
+		logger:  logger.With().Str("component", "db-router").Logger(),
+	}
+	for _, url := range replicaURLs {
+		pool, cfg, err := openPool(ctx, url, pc)
+		if err != nil {
+			r.Close()
+			return nil, fmt.Errorf("replica: %w", err)
+		}
+		name := fmt.Sprintf("%s:%d", cfg.ConnConfig.Host, cfg.ConnConfig.Port)
+		trackPool(cfg.ConnConfig.Database+"@"+name, pool)
+		r.replicas = append(r.replicas, &replica{name: name, pool: pool})
+	}
+	r.refresh(ctx)
+	return r, nil
+}
+
+// Primary returns the primary pool.
+func (r *Router) Primary() *pgxpool.Pool {
+	return r.primary
+}
+
+// Query runs sql on a replica chosen by Reader.
+func (r *Router) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return r.Reader().Query(ctx, sql, args...)
+}
+
+// QueryRow runs sql on a replica chosen by Reader.
+func (r *Router) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return r.Reader().QueryRow(ctx, sql, args...)
+}
+
+// Begin starts a read-only transaction on a replica chosen by Reader, so reads that set
+// session variables (InSession) can use replicas too.
+func (r *Router) Begin(ctx context.Context) (pgx.Tx, error) {
+	return r.Reader().BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+}
+
+// Reader returns the next usable replica pool, or the primary when there is none.
+func (r *Router) Reader() *pgxpool.Pool {
+	n := len(r.replicas)
+	if n == 0 {
+		return r.primary
+	}
+	start := int(r.next.Add(1))
+	for i := 0; i < n; i++ {
+		if rep := r.replicas[(start+i)%n]; rep.usable.Load() {
+			return rep.pool
+		}
+	}
+	return r.primary
+}
+
+// Run refreshes replica state until ctx is cancelled.
+func (r *Router) Run(ctx context.Context) error {
+	if len(r.replicas) == 0 {
+		<-ctx.Done()
+		return nil
+	}
+	ticker := time.NewTicker(RouterCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			r.refresh(ctx)
+		}
+	}
+}
+
+func (r *Router) refresh(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, rep := range r.replicas {
+		wg.Add(1)
+		go func(rep *replica) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+			defer cancel()
+			_ = r.probe(checkCtx, rep)
+		}(rep)
+	}
+	wg.Wait()
+}
+
+// probe measures lag and updates whether rep may serve reads.
+func (r *Router) probe(ctx context.Context, rep *replica) error {
+	var seconds float64
+	err := rep.pool.QueryRow(ctx, replicaLagQuery).Scan(&seconds)
+	lag := time.Duration(seconds * float64(time.Second))
+	if err == nil && lag > r.maxLag {
+		err = fmt.Errorf("replication lag %s exceeds %s", lag.Round(time.Millisecond), r.maxLag)
+	}
+
+	was := rep.usable.Swap(err == nil)
+	if was && err != nil {
+		r.logger.Warn().Err(err).Str("replica", rep.name).Msg("replica removed from reads")
+	} else if !was && err == nil {
+		r.logger.Info().Str("replica", rep.name).Dur("lag", lag).Msg("replica serving reads")
+	}
+	return err
+}
+
+// Checks returns readiness checks for every node. Only the primary is critical; a failing
+// replica degrades the service while its reads move to the primary.
+func (r *Router) Checks() []health.Check {
+	checks := []health.Check{{
+		Name:    "postgres",
+		Timeout: 3 * time.Second,
+		Probe:   r.primary.Ping,
+	}}
+	for _, rep := range r.replicas {
+		rep := rep
+		checks = append(checks, health.Check{
+			Name:    "postgres-replica " + rep.name,
+			Timeout: 3 * time.Second,
+			Impact:  health.Degrading,
+			Probe:   func(ctx context.Context) error { return r.probe(ctx, rep) },
+		})
+	}
+	return checks
+}
+
+// Close closes replica pools; the primary stays with its owner.
+func (r *Router) Close() {
+	for _, rep := range r.replicas {
+		rep.pool.Close()
+	}
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"asfppro/pkg/health"
+)
+
+// lazyPools returns pools that never connect because no query is issued.
+func lazyPools(t *testing.T, urls ...string) []*replica {
+	t.Helper()
+	var replicas []*replica
+	for _, url := range urls {
+		pool, _, err := openPool(context.Background(), url, PoolConfig{MaxConns: 1})
+		if err != nil {
+			t.Fatalf("open %s: %v", url, err)
+		}
+		t.Cleanup(pool.Close)
+		replicas = append(replicas, &replica{name: url, pool: pool})
+	}
+	return replicas
+}
+
+func TestRouterFallsBackToPrimary(t *testing.T) {
+	nodes := lazyPools(t, "postgres://primary/app", "postgres://replica-1/app", "postgres://replica-2/app")
+	r := &Router{primary: nodes[0].pool, replicas: nodes[1:], maxLag: time.Second, logger: zerolog.Nop()}
+
+	if got := r.Reader(); got != r.primary {
+		t.Fatal("reads must go to the primary while no replica is usable")
+	}
+
+	r.replicas[1].usable.Store(true)
+	for i := 0; i < 3; i++ {
+		if got := r.Reader(); got != r.replicas[1].pool {
+			t.Fatal("reads must skip the unusable replica")
+		}
+	}
+
+	r.replicas[0].usable.Store(true)
+	seen := map[any]bool{}
+	for i := 0; i < 4; i++ {
+		seen[r.Reader()] = true
+	}
+	if len(seen) != 2 || seen[r.primary] {
+		t.Fatalf("reads must alternate between usable replicas, got %d targets", len(seen))
+	}
+}
+
+func TestRouterChecks(t *testing.T) {
+	nodes := lazyPools(t, "postgres://primary/app", "postgres://replica-1:5433/app")
+	r := &Router{primary: nodes[0].pool, replicas: nodes[1:], maxLag: time.Second, logger: zerolog.Nop()}
+
+	checks := r.Checks()
+	if len(checks) != 2 {
+		t.Fatalf("expected primary and replica checks, got %d", len(checks))
+	}
+	if checks[0].Name != "postgres" || checks[0].Impact != health.Critical {
+		t.Fatalf("primary check must be critical: %+v", checks[0])
+	}
+	if checks[1].Impact != health.Degrading {
+		t.Fatal("replica check must only degrade readiness")
+	}
+}
@@ -0,0 +1,218 @@
+// Package diagnostics serves Prometheus metrics, pprof profiles and runtime state on a
+// private admin listener, separate from the public Fiber port.
+package diagnostics
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	rpprof "runtime/pprof"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"asfppro/pkg/db"
+	"asfppro/pkg/metrics"
+)
+
+var started = time.Now()
+
+// Config describes the admin listener.
+type Config struct {
+	// Addr defaults to loopback; binding other interfaces requires Token.
+	Addr string
+	// Token, when set, must be sent as "Authorization: Bearer <token>".
+	Token string
+	// Pprof serves /debug/pprof/* and /debug/diagnostics.
+	Pprof bool
+	// Metrics serves the default metrics registry on /metrics.
+	Metrics bool
+}
+
+// Server is a running admin listener.
+type Server struct {
+	srv    *http.Server
+	logger zerolog.Logger
+}
+
+// Start validates cfg and serves the enabled endpoints on cfg.Addr in the background.
+func Start(cfg Config, logger zerolog.Logger) (*Server, error) {
+	if strings.TrimSpace(cfg.Addr) == "" {
+		return nil, errors.New("admin address is required")
+	}
+	if cfg.Token == "" && !loopback(cfg.Addr) {
+		return nil, fmt.Errorf("admin address %s is not loopback: set a token", cfg.Addr)
+	}
+
+	listener, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen admin: %w", err)
+	}
+
+	s := &Server{
+		srv: &http.Server{
+			Handler:           Handler(cfg),
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+		logger: logger,
+	}
+
+	go func() {
+		if err := s.srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error().Err(err).Msg("admin listener stopped")
+		}
+	}()
+	logger.Info().Str("addr", listener.Addr().String()).Bool("pprof", cfg.Pprof).Bool("metrics", cfg.Metrics).Msg("admin listening")
+
+	return s, nil
+}
+
+// Shutdown stops the listener.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	return s.srv.Shutdown(ctx)
+}
+
+// Close stops the listener within a short timeout.
+func (s *Server) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		s.logger.Error().Err(err).Msg("admin shutdown")
+	}
+}
+
+// Handler returns mux with the endpoints enabled in cfg, guarded by cfg.Token when set.
+func Handler(cfg Config) http.Handler {
+	mux := http.NewServeMux()
+	if cfg.Pprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		mux.HandleFunc("/debug/diagnostics", diagnosticsHandler)
+	}
+	if cfg.Metrics {
+		mux.Handle("/metrics", metrics.Handler())
+	}
+
+	if cfg.Token == "" {
+		return mux
+	}
+	expected := []byte("Bearer " + cfg.Token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// Report is a snapshot of runtime state.
+type Report struct {
+	Uptime     string         `json:"uptime"`
+	GoVersion  string         `json:"goVersion"`
+	NumCPU     int            `json:"numCpu"`
+	GOMAXPROCS int            `json:"gomaxprocs"`
+	Goroutines int            `json:"goroutines"`
+	Memory     MemoryStats    `json:"memory"`
+	GC         GCStats        `json:"gc"`
+	Pools      []db.PoolState `json:"pools"`
+	// Stacks holds the full goroutine dump when requested.
+	Stacks string `json:"stacks,omitempty"`
+}
+
+// MemoryStats holds heap figures in bytes.
+type MemoryStats struct {
+	HeapAlloc   uint64 `json:"heapAlloc"`
+	HeapInuse   uint64 `json:"heapInuse"`
+	HeapObjects uint64 `json:"heapObjects"`
+	StackInuse  uint64 `json:"stackInuse"`
+	Sys         uint64 `json:"sys"`
+}
+
+// GCStats summarises garbage collector activity.
+type GCStats struct {
+	NumGC        uint32     `json:"numGc"`
+	PauseTotal   string     `json:"pauseTotal"`
+	LastPause    string     `json:"lastPause"`
+	LastGC       *time.Time `json:"lastGc,omitempty"`
+	NextGC       uint64     `json:"nextGc"`
+	CPUFraction  float64    `json:"cpuFraction"`
+	ForcedGCRuns uint32     `json:"forcedGcRuns"`
+}
+
+// Snapshot collects runtime, GC and pool state. With stacks set it includes every goroutine stack.
+func Snapshot(stacks bool) Report {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	report := Report{
+		Uptime:     time.Since(started).Round(time.Second).String(),
+		GoVersion:  runtime.Version(),
+		NumCPU:     runtime.NumCPU(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		Goroutines: runtime.NumGoroutine(),
+		Memory: MemoryStats{
+			HeapAlloc:   mem.HeapAlloc,
+			HeapInuse:   mem.HeapInuse,
+			HeapObjects: mem.HeapObjects,
+			StackInuse:  mem.StackInuse,
+			Sys:         mem.Sys,
+		},
+		GC: GCStats{
+			NumGC:        mem.NumGC,
+			PauseTotal:   time.Duration(mem.PauseTotalNs).String(),
+			LastPause:    time.Duration(mem.PauseNs[(mem.NumGC+255)%256]).String(),
+			NextGC:       mem.NextGC,
+			CPUFraction:  mem.GCCPUFraction,
+			ForcedGCRuns: mem.NumForcedGC,
+		},
+		Pools: db.PoolStates(),
+	}
+	if mem.LastGC > 0 {
+		last := time.Unix(0, int64(mem.LastGC)).UTC()
+		report.GC.LastGC = &last
+	}
+
+	if stacks {
+		var buf bytes.Buffer
+		if err := rpprof.Lookup("goroutine").WriteTo(&buf, 2); err == nil {
+			report.Stacks = buf.String()
+		}
+	}
+	return report
+}
+
+func diagnosticsHandler(w http.ResponseWriter, r *http.Request) {
+	report := Snapshot(r.URL.Query().Get("stacks") == "1")
+
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(report)
+}
+
+func loopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,71 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestStartRequiresTokenOffLoopback(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Start(Config{Addr: ":0"}, zerolog.Nop()); err == nil {
+		t.Fatal("expected error for wildcard address without token")
+	}
+
+	srv, err := Start(Config{Addr: "127.0.0.1:0"}, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("loopback listener: %v", err)
+	}
+	srv.Close()
+}
+
+func TestHandlerToken(t *testing.T) {
+	t.Parallel()
+
+	handler := Handler(Config{Token: "secret", Pprof: true})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/diagnostics", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/diagnostics?stacks=1", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var report Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	if report.Goroutines == 0 || report.Stacks == "" {
+		t.Fatalf("expected goroutine count and stacks, got %+v", report)
+	}
+}
+
+func TestHandlerServesEnabledEndpoints(t *testing.T) {
+	t.Parallel()
+
+	handler := Handler(Config{Metrics: true})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "go_goroutines") {
+		t.Fatalf("expected metrics, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("pprof must be off unless enabled, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,100 @@
+package filecheck
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParsePolicyAndCheck(t *testing.T) {
+	p, err := ParsePolicy("*:10M:*; designs:5G:application/pdf,image/*; designs/archive:1K:application/zip")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	cases := []struct {
+		folder      string
+		size        int64
+		contentType string
+		want        error
+	}{
+		{"uploads", 1 << 20, "application/x-msdownload", nil},
+		{"uploads", 11 << 20, "text/plain", ErrTooLarge},
+		{"designs/layouts", 3 << 30, "image/tiff", nil},
+		{"designs", 1 << 20, "text/html; charset=utf-8", ErrTypeNotAllowed},
+		{"designs/archive", 2048, "application/zip", ErrTooLarge},
+		{"designsx", 11 << 20, "image/png", ErrTooLarge},
+	}
+	for _, tc := range cases {
+		err := p.Check(tc.folder, tc.size, tc.contentType)
+		if (tc.want == nil && err != nil) || (tc.want != nil && !errors.Is(err, tc.want)) {
+			t.Errorf("%s %d %s: got %v, want %v", tc.folder, tc.size, tc.contentType, err, tc.want)
+		}
+	}
+
+	strict, err := ParsePolicy("photos:50M:image/jpeg")
+	if err != nil {
+		t.Fatalf("parse strict: %v", err)
+	}
+	if err := strict.Check("uploads", 1, "image/jpeg"); !errors.Is(err, ErrFolderNotAllowed) {
+		t.Fatalf("expected ErrFolderNotAllowed, got %v", err)
+	}
+	if err := (Policy{}).Check("anything", 1<<40, "application/octet-stream"); err != nil {
+		t.Fatalf("empty policy must allow everything, got %v", err)
+	}
+}
+
+func TestParsePolicyRejectsMalformedRules(t *testing.T) {
+	for _, spec := range []string{"designs:5G", ":1M:*", "designs:0:*", "designs:5X:*", "designs:1M: , "} {
+		if _, err := ParsePolicy(spec); err == nil {
+			t.Errorf("%q: expected error", spec)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	cases := []struct {
+		name    string
+		head    string
+		claimed string
+		want    string
+	}{
+		{"png", "\x89PNG\r\n\x1a\n....", "image/jpeg", "image/png"},
+		{"tiff", "II*\x00\x08\x00", "", "image/tiff"},
+		{"pdf", "%PDF-1.7\n", "application/octet-stream", "application/pdf"},
+		{"exe claiming png", "MZ\x90\x00\x03\x00\x00\x00\x04", "image/png", "application/octet-stream"},
+		{"csv", "a,b,c\n1,2,3\n", "text/csv", "text/csv"},
+		{"text claiming image", "hello", "image/png", "text/plain"},
+		{"docx", "PK\x03\x04\x14\x00", "application/vnd.openxmlformats-officedocument.wordprocessingml.document", "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+	}
+	for _, tc := range cases {
+		if got := Detect([]byte(tc.head), tc.claimed); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	cases := map[string]string{
+		"report.pdf":       "report.pdf",
+		"../../etc/passwd": "passwd",
+		`C:\Users\a\макет  баннера.tif`: "макет баннера.tif",
+		"a<b>c:d|e?f*.png":              "abcdef.png",
+		" name\x00\n.txt. ":             "name.txt",
+	}
+	for in, want := range cases {
+		got, err := SanitizeFilename(in)
+		if err != nil || got != want {
+			t.Errorf("%q: got %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "..", "dir/", " . "} {
+		if _, err := SanitizeFilename(in); !errors.Is(err, ErrInvalidFilename) {
+			t.Errorf("%q: expected ErrInvalidFilename, got %v", in, err)
+		}
+	}
+
+	long, err := SanitizeFilename(strings.Repeat("я", 150) + ".pdf")
+	if err != nil || len(long) > maxFilenameBytes || !strings.HasSuffix(long, ".pdf") {
+		t.Fatalf("long name: %q (%d bytes), %v", long, len(long), err)
+	}
+}
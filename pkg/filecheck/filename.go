@@ -0,0 +1,59 @@
+package filecheck
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrInvalidFilename is returned when nothing usable is left of a filename.
+var ErrInvalidFilename = errors.New("invalid filename")
+
+// maxFilenameBytes keeps names within common filesystem and S3 tooling limits.
+const maxFilenameBytes = 200
+
+// SanitizeFilename reduces a client-supplied name to a safe base name: directory parts,
+// control and reserved characters are dropped, whitespace is collapsed and long names are
+// shortened keeping the extension.
+func SanitizeFilename(name string) (string, error) {
+	name = strings.ToValidUTF8(name, "")
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+
+	var b strings.Builder
+	space := false
+	for _, r := range name {
+		switch {
+		case unicode.IsControl(r), strings.ContainsRune(`<>:"|?*`, r):
+			continue
+		case unicode.IsSpace(r):
+			space = true
+			continue
+		}
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteRune(r)
+	}
+	name = strings.Trim(b.String(), ". ")
+	if name == "" {
+		return "", ErrInvalidFilename
+	}
+
+	if len(name) > maxFilenameBytes {
+		ext := path.Ext(name)
+		if len(ext) > 16 {
+			ext = ""
+		}
+		stem := name[:maxFilenameBytes-len(ext)]
+		for !utf8.ValidString(stem) {
+			stem = stem[:len(stem)-1]
+		}
+		name = strings.TrimRight(stem, ". ") + ext
+	}
+	return name, nil
+}
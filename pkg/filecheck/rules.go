@@ -0,0 +1,173 @@
+// Package filecheck validates uploaded files: per-folder size and type rules, content type
+// sniffing, filename sanitization and virus scanning.
+package filecheck
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrTooLarge is returned when a file exceeds the size allowed for its folder.
+	ErrTooLarge = errors.New("file too large")
+	// ErrTypeNotAllowed is returned when a file's content type is not allowed in its folder.
+	ErrTypeNotAllowed = errors.New("file type not allowed")
+	// ErrFolderNotAllowed is returned when no rule covers the folder.
+	ErrFolderNotAllowed = errors.New("uploads to this folder are not allowed")
+)
+
+// Rejected reports whether err means a file failed validation, as opposed to the check
+// itself failing.
+func Rejected(err error) bool {
+	return errors.Is(err, ErrTooLarge) || errors.Is(err, ErrTypeNotAllowed) || errors.Is(err, ErrFolderNotAllowed) ||
+		errors.Is(err, ErrInfected) || errors.Is(err, ErrInvalidFilename)
+}
+
+// AnyFolder is the rule folder matching folders no other rule covers.
+const AnyFolder = "*"
+
+// Rule limits the files stored in a folder and its subfolders. Types holds content types,
+// "image/*"-style wildcards or "*".
+type Rule struct {
+	Folder  string
+	MaxSize int64
+	Types   []string
+}
+
+// Policy picks the rule for a folder and checks files against it. An empty policy allows
+// everything.
+type Policy struct {
+	rules []Rule
+}
+
+// NewPolicy builds a policy from rules.
+func NewPolicy(rules []Rule) Policy {
+	return Policy{rules: rules}
+}
+
+// ParsePolicy reads rules written as "folder:max-size:type,type" separated by ";", e.g.
+// "*:100M:*;designs:5G:application/pdf,image/*". Sizes take K, M and G suffixes.
+func ParsePolicy(spec string) (Policy, error) {
+	var rules []Rule
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 {
+			return Policy{}, fmt.Errorf("rule %q: want folder:max-size:types", entry)
+		}
+		folder := strings.Trim(strings.TrimSpace(parts[0]), "/")
+		if folder == "" {
+			return Policy{}, fmt.Errorf("rule %q: folder is empty", entry)
+		}
+		size, err := ParseSize(parts[1])
+		if err != nil {
+			return Policy{}, fmt.Errorf("rule %q: %w", entry, err)
+		}
+		var types []string
+		for _, t := range strings.Split(parts[2], ",") {
+			if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+				types = append(types, t)
+			}
+		}
+		if len(types) == 0 {
+			return Policy{}, fmt.Errorf("rule %q: no types", entry)
+		}
+		rules = append(rules, Rule{Folder: folder, MaxSize: size, Types: types})
+	}
+	return NewPolicy(rules), nil
+}
+
+// ParseSize reads a byte count with an optional K, M or G suffix (powers of 1024).
+func ParseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	shift := 0
+	switch {
+	case strings.HasSuffix(s, "K"):
+		shift = 10
+	case strings.HasSuffix(s, "M"):
+		shift = 20
+	case strings.HasSuffix(s, "G"):
+		shift = 30
+	}
+	if shift > 0 {
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 || n > (1<<62)>>shift {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n << shift, nil
+}
+
+// Rule returns the rule of the longest folder that contains folder, falling back to AnyFolder.
+func (p Policy) Rule(folder string) (Rule, bool) {
+	folder = strings.Trim(path.Clean("/"+folder), "/")
+	var (
+		best  Rule
+		found bool
+	)
+	for _, r := range p.rules {
+		if r.Folder == AnyFolder {
+			if !found {
+				best, found = r, true
+			}
+			continue
+		}
+		if folder != r.Folder && !strings.HasPrefix(folder, r.Folder+"/") {
+			continue
+		}
+		if !found || best.Folder == AnyFolder || len(r.Folder) > len(best.Folder) {
+			best, found = r, true
+		}
+	}
+	return best, found
+}
+
+// Check reports whether a file of size bytes and contentType may be stored in folder. A
+// negative size skips the size check.
+func (p Policy) Check(folder string, size int64, contentType string) error {
+	if len(p.rules) == 0 {
+		return nil
+	}
+	rule, ok := p.Rule(folder)
+	if !ok {
+		return ErrFolderNotAllowed
+	}
+	if size > rule.MaxSize {
+		return fmt.Errorf("%w: %d bytes allowed in %s", ErrTooLarge, rule.MaxSize, rule.Folder)
+	}
+	if !typeAllowed(rule.Types, contentType) {
+		return fmt.Errorf("%w: %s in %s", ErrTypeNotAllowed, baseType(contentType), rule.Folder)
+	}
+	return nil
+}
+
+func typeAllowed(allowed []string, contentType string) bool {
+	contentType = baseType(contentType)
+	major, _, _ := strings.Cut(contentType, "/")
+	for _, t := range allowed {
+		switch {
+		case t == "*", t == contentType:
+			return true
+		case strings.HasSuffix(t, "/*") && strings.TrimSuffix(t, "/*") == major:
+			return true
+		}
+	}
+	return false
+}
+
+// baseType strips parameters such as charset and lowercases a content type.
+func baseType(contentType string) string {
+	t, _, _ := strings.Cut(contentType, ";")
+	t = strings.ToLower(strings.TrimSpace(t))
+	if t == "" {
+		return octetStream
+	}
+	return t
+}
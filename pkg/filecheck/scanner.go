@@ -0,0 +1,116 @@
+package filecheck
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// ErrInfected is returned for files a scanner found malware in.
+var ErrInfected = errors.New("file is infected")
+
+// Verdict is the outcome of a scan.
+type Verdict struct {
+	Infected  bool
+	Signature string
+}
+
+// Scanner checks file contents for malware.
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (Verdict, error)
+}
+
+// clamChunkSize is the INSTREAM chunk size; clamd accepts up to StreamMaxLength in total.
+const clamChunkSize = 64 << 10
+
+// ClamAV scans files with a clamd daemon over its INSTREAM command.
+type ClamAV struct {
+	addr    string
+	timeout time.Duration
+}
+
+// NewClamAV returns a scanner for clamd at addr ("host:port" or "unix:/path/clamd.sock").
+// timeout bounds a single scan.
+func NewClamAV(addr string, timeout time.Duration) *ClamAV {
+	return &ClamAV{addr: addr, timeout: timeout}
+}
+
+// Scan streams r to clamd and parses its reply.
+func (c *ClamAV) Scan(ctx context.Context, r io.Reader) (Verdict, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	network, addr := "tcp", c.addr
+	if rest, ok := strings.CutPrefix(addr, "unix:"); ok {
+		network, addr = "unix", rest
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("dial clamd: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if err := writeInstream(conn, r); err != nil {
+		return Verdict{}, err
+	}
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !(errors.Is(err, io.EOF) && reply != "") {
+		return Verdict{}, fmt.Errorf("read clamd reply: %w", err)
+	}
+	return parseClamReply(reply)
+}
+
+// writeInstream sends the zINSTREAM command followed by length-prefixed chunks and the
+// zero-length terminator.
+func writeInstream(w io.Writer, r io.Reader) error {
+	if _, err := io.WriteString(w, "zINSTREAM\x00"); err != nil {
+		return fmt.Errorf("send clamd command: %w", err)
+	}
+	buf := make([]byte, 4+clamChunkSize)
+	for {
+		n, err := io.ReadFull(r, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, werr := w.Write(buf[:4+n]); werr != nil {
+				return fmt.Errorf("send clamd chunk: %w", werr)
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read scanned file: %w", err)
+		}
+	}
+	if _, err := w.Write([]byte{0, 0, 0, 0}); err != nil {
+		return fmt.Errorf("send clamd terminator: %w", err)
+	}
+	return nil
+}
+
+// parseClamReply reads "stream: OK", "stream: <signature> FOUND" or "<message> ERROR".
+func parseClamReply(reply string) (Verdict, error) {
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	_, result, _ := strings.Cut(reply, ": ")
+	switch {
+	case result == "OK":
+		return Verdict{}, nil
+	case strings.HasSuffix(result, " FOUND"):
+		return Verdict{Infected: true, Signature: strings.TrimSuffix(result, " FOUND")}, nil
+	default:
+		return Verdict{}, fmt.Errorf("clamd: %s", reply)
+	}
+}
@@ -0,0 +1,78 @@
+package filecheck
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClamd accepts one INSTREAM session and replies with reply(content).
+func fakeClamd(t *testing.T, reply func(content []byte) string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		r := bufio.NewReader(conn)
+		if cmd, err := r.ReadString(0); err != nil || cmd != "zINSTREAM\x00" {
+			return
+		}
+		var content bytes.Buffer
+		for {
+			var size uint32
+			if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+				return
+			}
+			if size == 0 {
+				break
+			}
+			if _, err := io.CopyN(&content, r, int64(size)); err != nil {
+				return
+			}
+		}
+		_, _ = io.WriteString(conn, reply(content.Bytes())+"\x00")
+	}()
+	return ln.Addr().String()
+}
+
+func TestClamAVScan(t *testing.T) {
+	eicar := "X5O!P%@AP[4\\PZX54(P^)7CC)7}$EICAR"
+	reply := func(content []byte) string {
+		if bytes.Contains(content, []byte(eicar)) {
+			return "stream: Eicar-Test-Signature FOUND"
+		}
+		return "stream: OK"
+	}
+
+	clean := NewClamAV(fakeClamd(t, reply), time.Second)
+	v, err := clean.Scan(context.Background(), strings.NewReader(strings.Repeat("a", 3*clamChunkSize+7)))
+	if err != nil || v.Infected {
+		t.Fatalf("clean file: %+v, %v", v, err)
+	}
+
+	infected := NewClamAV(fakeClamd(t, reply), time.Second)
+	v, err = infected.Scan(context.Background(), strings.NewReader(eicar))
+	if err != nil || !v.Infected || v.Signature != "Eicar-Test-Signature" {
+		t.Fatalf("infected file: %+v, %v", v, err)
+	}
+}
+
+func TestParseClamReplyError(t *testing.T) {
+	if _, err := parseClamReply("INSTREAM size limit exceeded. ERROR\x00"); err == nil {
+		t.Fatal("expected error reply to fail")
+	}
+}
@@ -0,0 +1,66 @@
+package filecheck
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+)
+
+// SniffLen is how many leading bytes Detect looks at.
+const SniffLen = 512
+
+const octetStream = "application/octet-stream"
+
+// magic adds print and design formats net/http does not recognise.
+var magic = []struct {
+	prefix      []byte
+	contentType string
+}{
+	{[]byte("II*\x00"), "image/tiff"},
+	{[]byte("MM\x00*"), "image/tiff"},
+	{[]byte("8BPS"), "image/vnd.adobe.photoshop"},
+	{[]byte("%!PS"), "application/postscript"},
+	{[]byte("\xc5\xd0\xd3\xc6"), "application/postscript"},
+	{[]byte("\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1"), "application/x-ole-storage"},
+}
+
+// Detect returns the content type of a file from its first bytes. The claimed type is only
+// used to refine generic results (plain text, zip containers, OLE documents); a client cannot
+// turn unknown binary data into an allowed type by claiming one.
+func Detect(head []byte, claimed string) string {
+	if len(head) > SniffLen {
+		head = head[:SniffLen]
+	}
+	claimed = baseType(claimed)
+
+	sniffed := ""
+	for _, m := range magic {
+		if bytes.HasPrefix(head, m.prefix) {
+			sniffed = m.contentType
+			break
+		}
+	}
+	if sniffed == "" {
+		sniffed = baseType(http.DetectContentType(head))
+	}
+
+	switch sniffed {
+	case "text/plain":
+		if strings.HasPrefix(claimed, "text/") || claimed == "application/json" || claimed == "image/svg+xml" {
+			return claimed
+		}
+	case "text/xml":
+		if claimed == "image/svg+xml" || strings.HasSuffix(claimed, "+xml") {
+			return claimed
+		}
+	case "application/zip":
+		if strings.Contains(claimed, "openxmlformats") || strings.Contains(claimed, "opendocument") {
+			return claimed
+		}
+	case "application/x-ole-storage":
+		if claimed == "application/msword" || claimed == "application/vnd.ms-excel" || claimed == "application/vnd.ms-powerpoint" {
+			return claimed
+		}
+	}
+	return sniffed
+}
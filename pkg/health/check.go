@@ -3,36 +3,68 @@ package health
 
 import (
 	"context"
+	"sort"
+	"sync"
 	"time"
 )
 
+// Impact tells how a failing check affects the overall status.
+type Impact int
+
+const (
+	// Critical failures make the service not ready.
+	Critical Impact = iota
+	// Degrading failures are reported while the service stays ready.
+	Degrading
+)
+
+// Status is the overall state reported by readiness endpoints.
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+// DefaultRefresh is how long readiness results are reused before probes run again.
+const DefaultRefresh = 2 * time.Second
+
 // Check represents one dependency probe.
 type Check struct {
 	Name    string
 	Timeout time.Duration
+	Impact  Impact
 	Probe   func(context.Context) error
 }
 
-// Run executes probes and returns a map with results keyed by check name.
+// Result is the outcome of one check.
+type Result struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report aggregates check results into the JSON body shared by gateway and modules.
+type Report struct {
+	Status    Status    `json:"status"`
+	CheckedAt time.Time `json:"checkedAt"`
+	Checks    []Result  `json:"checks,omitempty"`
+}
+
+// Ready reports whether the service can take traffic; degraded services still can.
+func (r Report) Ready() bool {
+	return r.Status != StatusDown
+}
+
+// Run executes probes in parallel and returns a map with results keyed by check name.
 // The resulting map stores nil on success or concrete errors on failure.
 func Run(ctx context.Context, checks []Check) map[string]error {
 	results := make(map[string]error, len(checks))
-	for _, check := range checks {
-		if check.Probe == nil {
-			continue
-		}
-
-		probeCtx := ctx
-		if check.Timeout > 0 {
-			var cancel context.CancelFunc
-			probeCtx, cancel = context.WithTimeout(ctx, check.Timeout)
-			err := check.Probe(probeCtx)
-			cancel()
-			results[check.Name] = err
-			continue
-		}
-
-		results[check.Name] = check.Probe(probeCtx)
+	for _, o := range runAll(ctx, checks) {
+		results[o.check.Name] = o.err
 	}
 	return results
 }
@@ -46,3 +78,99 @@ func Healthy(results map[string]error) bool {
 	}
 	return true
 }
+
+// Evaluate runs checks in parallel and summarises them: any critical failure makes the
+// report down, other failures make it degraded.
+func Evaluate(ctx context.Context, checks []Check) Report {
+	report := Report{Status: StatusOK, CheckedAt: time.Now().UTC()}
+	for _, o := range runAll(ctx, checks) {
+		result := Result{
+			Name:      o.check.Name,
+			Status:    "ok",
+			Critical:  o.check.Impact == Critical,
+			LatencyMS: float64(o.latency.Microseconds()) / 1000,
+		}
+		if o.err != nil {
+			result.Status = "fail"
+			result.Error = o.err.Error()
+			if result.Critical {
+				report.Status = StatusDown
+			} else if report.Status == StatusOK {
+				report.Status = StatusDegraded
+			}
+		}
+		report.Checks = append(report.Checks, result)
+	}
+	sort.Slice(report.Checks, func(i, j int) bool { return report.Checks[i].Name < report.Checks[j].Name })
+	return report
+}
+
+type outcome struct {
+	check   Check
+	err     error
+	latency time.Duration
+}
+
+func runAll(ctx context.Context, checks []Check) []outcome {
+	outcomes := make([]outcome, 0, len(checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range checks {
+		if check.Probe == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+
+			probeCtx := ctx
+			if check.Timeout > 0 {
+				var cancel context.CancelFunc
+				probeCtx, cancel = context.WithTimeout(ctx, check.Timeout)
+				defer cancel()
+			}
+
+			start := time.Now()
+			err := check.Probe(probeCtx)
+			latency := time.Since(start)
+
+			mu.Lock()
+			outcomes = append(outcomes, outcome{check: check, err: err, latency: latency})
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+	return outcomes
+}
+
+// Checker caches the latest report so frequent readiness polls do not hammer dependencies.
+type Checker struct {
+	checks  []Check
+	refresh time.Duration
+
+	mu   sync.Mutex
+	last Report
+}
+
+// NewChecker returns a checker reusing results for refresh; zero selects DefaultRefresh.
+func NewChecker(checks []Check, refresh time.Duration) *Checker {
+	if refresh <= 0 {
+		refresh = DefaultRefresh
+	}
+	return &Checker{checks: checks, refresh: refresh}
+}
+
+// Report returns the cached report or evaluates checks when it is older than the refresh
+// interval. Concurrent callers wait for a single evaluation.
+func (c *Checker) Report(ctx context.Context) Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.last.CheckedAt.IsZero() && time.Since(c.last.CheckedAt) < c.refresh {
+		return c.last
+	}
+	// Probes must not inherit the cancellation of the request that happened to trigger them.
+	c.last = Evaluate(context.WithoutCancel(ctx), c.checks)
+	return c.last
+}
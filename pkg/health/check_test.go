@@ -2,9 +2,14 @@ package health
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/gofiber/fiber/v2"
 )
 
 func TestRun(t *testing.T) {
@@ -47,3 +52,89 @@ func TestRun(t *testing.T) {
 		t.Fatalf("expected healthy when no errors")
 	}
 }
+
+func TestRunIsParallel(t *testing.T) {
+	t.Parallel()
+
+	slow := func(ctx context.Context) error {
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	}
+	checks := []Check{{Name: "a", Probe: slow}, {Name: "b", Probe: slow}, {Name: "c", Probe: slow}}
+
+	start := time.Now()
+	Run(context.Background(), checks)
+	if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+		t.Fatalf("checks ran sequentially: %s", elapsed)
+	}
+}
+
+func TestEvaluateImpact(t *testing.T) {
+	t.Parallel()
+
+	fail := func(context.Context) error { return errors.New("boom") }
+	ok := func(context.Context) error { return nil }
+
+	degraded := Evaluate(context.Background(), []Check{
+		{Name: "postgres", Probe: ok},
+		{Name: "clickhouse", Impact: Degrading, Probe: fail},
+	})
+	if degraded.Status != StatusDegraded || !degraded.Ready() {
+		t.Fatalf("expected ready degraded report, got %+v", degraded)
+	}
+	if degraded.Checks[0].Name != "clickhouse" || degraded.Checks[0].Error != "boom" || degraded.Checks[0].Critical {
+		t.Fatalf("unexpected clickhouse result %+v", degraded.Checks[0])
+	}
+
+	down := Evaluate(context.Background(), []Check{
+		{Name: "postgres", Probe: fail},
+		{Name: "clickhouse", Impact: Degrading, Probe: fail},
+	})
+	if down.Status != StatusDown || down.Ready() {
+		t.Fatalf("expected down report, got %+v", down)
+	}
+}
+
+func TestCheckerCachesReport(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	checker := NewChecker([]Check{{Name: "counted", Probe: func(context.Context) error {
+		calls.Add(1)
+		return nil
+	}}}, 50*time.Millisecond)
+
+	checker.Report(context.Background())
+	checker.Report(context.Background())
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("expected cached report, probe ran %d times", got)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	checker.Report(context.Background())
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("expected refresh after interval, probe ran %d times", got)
+	}
+}
+
+func TestHandlerStatusCodes(t *testing.T) {
+	t.Parallel()
+
+	app := fiber.New()
+	app.Get("/degraded", FiberHandler([]Check{{Name: "s3", Impact: Degrading, Probe: func(context.Context) error { return errors.New("slow") }}}))
+	app.Get("/down", Unavailable("not initialised"))
+
+	for path, want := range map[string]int{"/degraded": fiber.StatusOK, "/down": fiber.StatusServiceUnavailable} {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		var report Report
+		if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+			t.Fatalf("%s: decode: %v", path, err)
+		}
+		if resp.StatusCode != want || len(report.Checks) != 1 {
+			t.Fatalf("%s: status %d, report %+v", path, resp.StatusCode, report)
+		}
+	}
+}
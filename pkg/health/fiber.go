@@ -1,47 +1,42 @@
 package health
 
 import (
-	"context"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-// FiberHandler returns Fiber handler evaluating provided checks.
+// FiberHandler returns Fiber handler evaluating provided checks with results cached for DefaultRefresh.
 func FiberHandler(checks []Check) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		ctx := c.UserContext()
-		if ctx == nil {
-			ctx = context.Background()
-		}
+	return Handler(NewChecker(checks, DefaultRefresh))
+}
 
-		results := Run(ctx, checks)
-		payload := fiber.Map{
-			"status": "ok",
-			"checks": map[string]string{},
-		}
+// Handler serves checker reports: 200 when ok or degraded, 503 when a critical check fails.
+func Handler(checker *Checker) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		report := checker.Report(c.UserContext())
 		status := fiber.StatusOK
-
-		details := payload["checks"].(map[string]string)
-		for name, err := range results {
-			if err != nil {
-				status = fiber.StatusServiceUnavailable
-				details[name] = err.Error()
-				continue
-			}
-			details[name] = "ok"
-		}
-
-		if len(details) == 0 {
-			delete(payload, "checks")
+		if !report.Ready() {
+			status = fiber.StatusServiceUnavailable
 		}
+		return c.Status(status).JSON(report)
+	}
+}
 
-		return c.Status(status).JSON(payload)
+// Unavailable returns a handler reporting the service down, for dependencies that failed to initialise.
+func Unavailable(reason string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(Report{
+			Status:    StatusDown,
+			CheckedAt: time.Now().UTC(),
+			Checks:    []Result{{Name: "dependencies", Status: "fail", Critical: true, Error: reason}},
+		})
 	}
 }
 
 // LiveHandler returns a minimal 200 OK handler.
 func LiveHandler() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "ok"})
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": StatusOK})
 	}
 }
@@ -0,0 +1,236 @@
+// Package lifecycle starts service components in order and stops them in reverse on shutdown.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+)
+
+// DefaultTimeout bounds shutdown when no timeout is configured.
+const DefaultTimeout = 10 * time.Second
+
+// Hook describes one component. Start must not block; long-running work belongs in a
+// goroutine reporting failures through Manager.Fail. Either function may be nil.
+type Hook struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// Manager owns registered components. Hooks stop in reverse registration order, so
+// servers registered last are drained before the pools and clients they depend on.
+type Manager struct {
+	logger  zerolog.Logger
+	timeout time.Duration
+
+	mu      sync.Mutex
+	hooks   []Hook
+	started int
+	stopped bool
+
+	failed chan error
+}
+
+// New returns a manager stopping all components within timeout; zero selects DefaultTimeout.
+func New(logger zerolog.Logger, timeout time.Duration) *Manager {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Manager{
+		logger:  logger.With().Str("component", "lifecycle").Logger(),
+		timeout: timeout,
+		failed:  make(chan error, 1),
+	}
+}
+
+// Append registers a component. Hooks without Start count as running immediately, which
+// suits clients opened during setup.
+func (m *Manager) Append(h Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, h)
+	if h.Start == nil && m.started == len(m.hooks)-1 {
+		m.started++
+	}
+}
+
+// Close registers a resource released by fn on shutdown.
+func (m *Manager) Close(name string, fn func()) {
+	m.Append(Hook{Name: name, Stop: func(context.Context) error {
+		fn()
+		return nil
+	}})
+}
+
+// Fiber registers an HTTP server listening on addr. Shutdown stops accepting connections
+// and waits for in-flight requests.
+func (m *Manager) Fiber(name string, app *fiber.App, addr string) {
+	m.Append(Hook{
+		Name: name,
+		Start: func(context.Context) error {
+			ln, err := net.Listen("tcp", addr)
+			if err != nil {
+				return fmt.Errorf("listen %s: %w", addr, err)
+			}
+			m.logger.Info().Str("name", name).Str("addr", addr).Msg("listening")
+			go func() {
+				if err := app.Listener(ln); err != nil {
+					m.Fail(fmt.Errorf("%s: %w", name, err))
+				}
+			}()
+			return nil
+		},
+		Stop: app.ShutdownWithContext,
+	})
+}
+
+// Go registers a background worker. Its context is cancelled on shutdown and the manager
+// waits for run to return, so workers should finish the job at hand before returning.
+func (m *Manager) Go(name string, run func(ctx context.Context) error) {
+	var (
+		cancel context.CancelFunc
+		done   = make(chan struct{})
+	)
+	m.Append(Hook{
+		Name: name,
+		Start: func(context.Context) error {
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			go func() {
+				defer close(done)
+				if err := run(ctx); err != nil && ctx.Err() == nil {
+					m.Fail(fmt.Errorf("%s: %w", name, err))
+				}
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+}
+
+// Fail asks the manager to shut down because a component stopped unexpectedly.
+func (m *Manager) Fail(err error) {
+	select {
+	case m.failed <- err:
+	default:
+	}
+}
+
+// Run starts components in order and blocks until ctx is done, SIGINT or SIGTERM arrives
+// or a component fails, then stops everything. It returns the startup or component error.
+func (m *Manager) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	m.mu.Lock()
+	hooks := m.hooks
+	m.mu.Unlock()
+
+	for i := range hooks {
+		h := hooks[i]
+		if h.Start != nil {
+			if err := h.Start(ctx); err != nil {
+				err = fmt.Errorf("start %s: %w", h.Name, err)
+				m.logger.Error().Err(err).Msg("startup failed")
+				m.Shutdown()
+				return err
+			}
+		}
+		m.mu.Lock()
+		m.started = i + 1
+		m.mu.Unlock()
+	}
+	m.logger.Info().Int("components", len(hooks)).Msg("started")
+
+	var err error
+	select {
+	case <-ctx.Done():
+		m.logger.Info().Msg("shutdown requested")
+	case err = <-m.failed:
+		m.logger.Error().Err(err).Msg("component failed, shutting down")
+	}
+	m.Shutdown()
+	return err
+}
+
+// Shutdown stops started components in reverse order, logging progress. A component still
+// running when the timeout expires is abandoned and the remaining ones are stopped anyway.
+// It is safe to call more than once.
+func (m *Manager) Shutdown() {
+	m.mu.Lock()
+	if m.stopped {
+		m.mu.Unlock()
+		return
+	}
+	m.stopped = true
+	hooks := m.hooks[:m.started]
+	m.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	begin := time.Now()
+	m.logger.Info().Dur("timeout", m.timeout).Int("components", len(hooks)).Msg("shutting down")
+	var failed int
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		if h.Stop == nil {
+			continue
+		}
+		start := time.Now()
+		err := stopWithin(ctx, h.Stop)
+		event := m.logger.Info()
+		if err != nil {
+			failed++
+			event = m.logger.Error().Err(err)
+		}
+		event.Str("name", h.Name).Dur("duration", time.Since(start)).Int("remaining", i).Msg("component stopped")
+	}
+
+	event := m.logger.Info()
+	if failed > 0 {
+		event = m.logger.Warn().Int("failed", failed)
+	}
+	event.Dur("duration", time.Since(begin)).Msg("shutdown complete")
+}
+
+// Abort logs a setup error, stops components registered so far and exits the process.
+func (m *Manager) Abort(err error, msg string) {
+	m.logger.Error().Err(err).Msg(msg)
+	m.Shutdown()
+	os.Exit(1)
+}
+
+// stopWithin runs stop but returns once ctx expires even if stop does not honour it.
+// Components reached after the deadline are still asked to stop, just not waited for.
+func stopWithin(ctx context.Context, stop func(context.Context) error) error {
+	done := make(chan error, 1)
+	go func() { done <- stop(ctx) }()
+	select {
+	case err := <-done:
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("drain timed out: %w", err)
+		}
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("abandoned: %w", ctx.Err())
+	}
+}
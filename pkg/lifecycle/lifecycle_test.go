@@ -0,0 +1,127 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) add(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func (r *recorder) hook(name string) Hook {
+	return Hook{
+		Name:  name,
+		Start: func(context.Context) error { r.add("start " + name); return nil },
+		Stop:  func(context.Context) error { r.add("stop " + name); return nil },
+	}
+}
+
+func TestRunStopsInReverseOrder(t *testing.T) {
+	rec := &recorder{}
+	m := New(zerolog.Nop(), time.Second)
+	m.Close("pool", func() { rec.add("stop pool") })
+	m.Append(rec.hook("queue"))
+	m.Append(rec.hook("http"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := m.Run(ctx); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	want := []string{"start queue", "start http", "stop http", "stop queue", "stop pool"}
+	if !reflect.DeepEqual(rec.calls, want) {
+		t.Fatalf("calls = %v, want %v", rec.calls, want)
+	}
+}
+
+func TestStartFailureStopsStartedOnly(t *testing.T) {
+	rec := &recorder{}
+	m := New(zerolog.Nop(), time.Second)
+	m.Append(rec.hook("pool"))
+	m.Append(Hook{Name: "http", Start: func(context.Context) error { return errors.New("port in use") }})
+	m.Append(rec.hook("worker"))
+
+	if err := m.Run(context.Background()); err == nil {
+		t.Fatal("expected startup error")
+	}
+	want := []string{"start pool", "stop pool"}
+	if !reflect.DeepEqual(rec.calls, want) {
+		t.Fatalf("calls = %v, want %v", rec.calls, want)
+	}
+}
+
+func TestWorkerFinishesInFlightJob(t *testing.T) {
+	m := New(zerolog.Nop(), time.Second)
+	taken := make(chan struct{})
+	var finished bool
+	m.Go("consumer", func(ctx context.Context) error {
+		close(taken)
+		<-ctx.Done()
+		time.Sleep(20 * time.Millisecond)
+		finished = true
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-taken
+		cancel()
+	}()
+	if err := m.Run(ctx); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !finished {
+		t.Fatal("shutdown returned before the worker drained")
+	}
+}
+
+func TestFailTriggersShutdown(t *testing.T) {
+	rec := &recorder{}
+	m := New(zerolog.Nop(), time.Second)
+	m.Append(rec.hook("pool"))
+	m.Go("consumer", func(context.Context) error { return errors.New("connection lost") })
+
+	err := m.Run(context.Background())
+	if err == nil || err.Error() != "consumer: connection lost" {
+		t.Fatalf("err = %v", err)
+	}
+	if got := rec.calls[len(rec.calls)-1]; got != "stop pool" {
+		t.Fatalf("last call = %q", got)
+	}
+}
+
+func TestShutdownAbandonsSlowComponent(t *testing.T) {
+	rec := &recorder{}
+	m := New(zerolog.Nop(), 30*time.Millisecond)
+	m.Close("pool", func() { rec.add("stop pool") })
+	m.Append(Hook{Name: "stuck", Stop: func(context.Context) error {
+		select {}
+	}})
+
+	start := time.Now()
+	m.Shutdown()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("shutdown took %s", elapsed)
+	}
+	time.Sleep(10 * time.Millisecond)
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.calls) != 1 {
+		t.Fatalf("pool was not asked to stop after the timeout: %v", rec.calls)
+	}
+}
@@ -2,28 +2,105 @@
 package log
 
 import (
+	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/rs/zerolog"
-	zlog "github.com/rs/zerolog/log"
 )
 
+// Options selects logger output.
+type Options struct {
+	Env string
+	// Service is added to every line as "service" when set.
+	Service string
+	// Level overrides the environment default (trace, debug, info, warn, error).
+	Level string
+	// Format is "json" or "console"; empty selects JSON in prod and console elsewhere.
+	Format string
+}
+
 // Init configures zerolog according to environment.
 func Init(env string) zerolog.Logger {
-	level := zerolog.InfoLevel
+	logger, _ := New(Options{Env: env})
+	return logger
+}
+
+// New builds the process logger from opts. Invalid values fall back to environment defaults
+// and are reported in the returned error.
+func New(opts Options) (zerolog.Logger, error) {
+	return build(opts, os.Stdout)
+}
+
+func build(opts Options, out io.Writer) (zerolog.Logger, error) {
+	var problems []string
+
+	level := envLevel(opts.Env)
+	if opts.Level != "" {
+		parsed, err := zerolog.ParseLevel(strings.ToLower(opts.Level))
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("unknown log level %q", opts.Level))
+		} else {
+			level = parsed
+		}
+	}
+
+	format := strings.ToLower(opts.Format)
+	if format == "" {
+		format = "console"
+		if isProd(opts.Env) {
+			format = "json"
+		}
+	}
+	switch format {
+	case "json":
+	case "console":
+		out = zerolog.ConsoleWriter{Out: out, TimeFormat: time.RFC3339}
+	default:
+		problems = append(problems, fmt.Sprintf("unknown log format %q", opts.Format))
+		out = zerolog.ConsoleWriter{Out: out, TimeFormat: time.RFC3339}
+	}
+
+	zerolog.TimeFieldFormat = time.RFC3339Nano
+	ctx := zerolog.New(out).With().Timestamp()
+	if opts.Service != "" {
+		ctx = ctx.Str("service", opts.Service)
+	}
+	logger := ctx.Logger().Level(level)
+
+	if len(problems) > 0 {
+		return logger, fmt.Errorf("logger: %s", strings.Join(problems, "; "))
+	}
+	return logger, nil
+}
+
+// Sampled returns logger limited to burst lines per second, for messages that repeat on
+// every request or poll. Zero burst disables sampling. Errors should use the unsampled logger.
+func Sampled(logger zerolog.Logger, burst uint32) zerolog.Logger {
+	if burst == 0 {
+		return logger
+	}
+	return logger.Sample(&zerolog.BurstSampler{Burst: burst, Period: time.Second})
+}
+
+func envLevel(env string) zerolog.Level {
 	switch strings.ToLower(env) {
 	case "debug", "dev", "development":
-		level = zerolog.DebugLevel
+		return zerolog.DebugLevel
 	case "test":
-		level = zerolog.WarnLevel
-	case "prod", "production":
-		level = zerolog.InfoLevel
+		return zerolog.WarnLevel
+	default:
+		return zerolog.InfoLevel
 	}
+}
 
-	zerolog.TimeFieldFormat = time.RFC3339Nano
-	logger := zlog.Output(zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339})
-	logger = logger.Level(level)
-	return logger
+func isProd(env string) bool {
+	switch strings.ToLower(env) {
+	case "prod", "production":
+		return true
+	default:
+		return false
+	}
 }
@@ -0,0 +1,146 @@
+package log
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const loggerLocalsKey = "log.request"
+
+// requestFields identify the request behind a log line. Route is read from the Fiber
+// context while the request is in flight and frozen when it completes.
+type requestFields struct {
+	mu        sync.Mutex
+	c         *fiber.Ctx
+	requestID string
+	traceID   string
+	userID    string
+	route     string
+}
+
+func (f *requestFields) apply(ctx zerolog.Context) zerolog.Context {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	route := f.route
+	if f.c != nil {
+		route = f.c.Route().Path
+	}
+	ctx = ctx.Str("requestId", f.requestID).Str("route", route)
+	if f.traceID != "" {
+		ctx = ctx.Str("traceId", f.traceID)
+	}
+	if f.userID != "" {
+		ctx = ctx.Str("userId", f.userID)
+	}
+	return ctx
+}
+
+type fieldsKey struct{}
+
+type requestLogger struct {
+	base   zerolog.Logger
+	fields *requestFields
+}
+
+// Middleware assigns the X-Request-ID, stores a request-scoped logger in the Fiber context
+// and writes one access line per request. Successful requests are logged through a sampler
+// limited to sampleBurst lines per second; client and server errors are always logged.
+// It must run after the tracing middleware so lines carry the trace ID.
+func Middleware(base zerolog.Logger, sampleBurst uint32) fiber.Handler {
+	access := Sampled(base, sampleBurst)
+
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		id := c.Get(fiber.HeaderXRequestID)
+		if id == "" {
+			id = uuid.NewString()
+		}
+		c.Set(fiber.HeaderXRequestID, id)
+
+		fields := &requestFields{c: c, requestID: id}
+		if span := trace.SpanContextFromContext(c.UserContext()); span.IsValid() {
+			fields.traceID = span.TraceID().String()
+		}
+		c.Locals(loggerLocalsKey, &requestLogger{base: base, fields: fields})
+		c.SetUserContext(context.WithValue(c.UserContext(), fieldsKey{}, fields))
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			if fe, ok := err.(*fiber.Error); ok {
+				status = fe.Code
+			}
+		}
+
+		fields.mu.Lock()
+		fields.route = c.Route().Path
+		fields.c = nil
+		fields.mu.Unlock()
+
+		logger := access
+		level := zerolog.InfoLevel
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			logger, level = base, zerolog.ErrorLevel
+		case status >= fiber.StatusBadRequest:
+			logger, level = base, zerolog.WarnLevel
+		}
+		logger = fields.apply(logger.With()).Logger()
+
+		event := logger.WithLevel(level).
+			Str("method", c.Method()).
+			Str("path", c.Path()).
+			Int("status", status).
+			Dur("duration", time.Since(start))
+		if err != nil {
+			event = event.Err(err)
+		}
+		event.Msg("http")
+		return err
+	}
+}
+
+// FromCtx returns the request-scoped logger stored by Middleware, or a disabled logger
+// outside of it.
+func FromCtx(c *fiber.Ctx) *zerolog.Logger {
+	if rl, ok := c.Locals(loggerLocalsKey).(*requestLogger); ok {
+		logger := rl.fields.apply(rl.base.With()).Logger()
+		return &logger
+	}
+	logger := zerolog.Nop()
+	return &logger
+}
+
+// Ctx returns logger enriched with the request fields carried by ctx, so services keep
+// their component fields while still correlating with the request. Outside of a request,
+// such as in queue consumers, only the trace ID is added.
+func Ctx(ctx context.Context, logger zerolog.Logger) *zerolog.Logger {
+	if ctx == nil {
+		return &logger
+	}
+	if fields, ok := ctx.Value(fieldsKey{}).(*requestFields); ok {
+		logger = fields.apply(logger.With()).Logger()
+	} else if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		logger = logger.With().Str("traceId", span.TraceID().String()).Logger()
+	}
+	return &logger
+}
+
+// SetUser records the authenticated user on the request logger.
+func SetUser(c *fiber.Ctx, userID string) {
+	if rl, ok := c.Locals(loggerLocalsKey).(*requestLogger); ok {
+		rl.fields.mu.Lock()
+		rl.fields.userID = userID
+		rl.fields.mu.Unlock()
+	}
+}
@@ -0,0 +1,109 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+)
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, raw := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if raw == "" {
+			continue
+		}
+		var line map[string]any
+		if err := json.Unmarshal([]byte(raw), &line); err != nil {
+			t.Fatalf("line is not JSON: %q", raw)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestBuildJSONInProd(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := build(Options{Env: "prod", Service: "crm", Level: "warn"}, &buf)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	logger.Info().Msg("dropped")
+	logger.Warn().Msg("kept")
+
+	lines := decodeLines(t, &buf)
+	if len(lines) != 1 || lines[0]["message"] != "kept" || lines[0]["service"] != "crm" {
+		t.Fatalf("unexpected output %v", lines)
+	}
+
+	if _, err := build(Options{Env: "dev", Level: "loud"}, &buf); err == nil {
+		t.Fatal("expected error for unknown level")
+	}
+}
+
+func TestMiddlewareScopesLoggerToRequest(t *testing.T) {
+	var buf bytes.Buffer
+	base := zerolog.New(&buf)
+
+	app := fiber.New()
+	app.Use(Middleware(base, 0))
+	app.Get("/deals/:id", func(c *fiber.Ctx) error {
+		SetUser(c, "user-1")
+		Ctx(c.UserContext(), base.With().Str("component", "deals").Logger()).Info().Msg("loaded deal")
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest("GET", "/deals/42", nil)
+	req.Header.Set(fiber.HeaderXRequestID, "req-1")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	if resp.Header.Get(fiber.HeaderXRequestID) != "req-1" {
+		t.Fatalf("request id not echoed")
+	}
+
+	lines := decodeLines(t, &buf)
+	if len(lines) != 2 {
+		t.Fatalf("expected handler and access lines, got %v", lines)
+	}
+	for _, line := range lines {
+		if line["requestId"] != "req-1" || line["route"] != "/deals/:id" || line["userId"] != "user-1" {
+			t.Fatalf("line lacks request fields: %v", line)
+		}
+	}
+	if lines[0]["component"] != "deals" {
+		t.Fatalf("service fields lost: %v", lines[0])
+	}
+	if lines[1]["status"] != float64(200) {
+		t.Fatalf("unexpected access line %v", lines[1])
+	}
+}
+
+func TestMiddlewareSamplesOnlySuccessfulRequests(t *testing.T) {
+	var buf bytes.Buffer
+
+	app := fiber.New()
+	app.Use(Middleware(zerolog.New(&buf), 1))
+	app.Get("/ok", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	app.Get("/fail", func(c *fiber.Ctx) error { return fiber.ErrBadGateway })
+
+	for _, path := range []string{"/ok", "/ok", "/ok", "/fail", "/fail"} {
+		if _, err := app.Test(httptest.NewRequest("GET", path, nil)); err != nil {
+			t.Fatalf("request %s: %v", path, err)
+		}
+	}
+
+	counts := map[string]int{}
+	for _, line := range decodeLines(t, &buf) {
+		counts[line["path"].(string)]++
+	}
+	if counts["/ok"] != 1 || counts["/fail"] != 2 {
+		t.Fatalf("unexpected sampling %v", counts)
+	}
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Middleware records request count and latency per method, route template and status on
+// reg. The metrics are served by the admin listener, not by the app itself.
+func Middleware(reg prometheus.Registerer) fiber.Handler {
+	labels := []string{"method", "route", "status"}
+	requests := RegisterWith(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests processed.",
+	}, labels))
+	duration := RegisterWith(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency.",
+		Buckets: DefBuckets,
+	}, labels))
+
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			if fe, ok := err.(*fiber.Error); ok {
+				status = fe.Code
+			}
+		}
+
+		// The route template keeps label cardinality bounded; unmatched paths share one label.
+		route := c.Route().Path
+		if status == fiber.StatusNotFound && route == "/" && c.Path() != "/" {
+			route = "unmatched"
+		}
+
+		code := strconv.Itoa(status)
+		requests.WithLabelValues(c.Method(), route, code).Inc()
+		duration.WithLabelValues(c.Method(), route, code).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
@@ -0,0 +1,64 @@
+// Package metrics holds the process-wide Prometheus registry and its HTTP exposition.
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DefBuckets are latency buckets in seconds suited for HTTP and database calls.
+var DefBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+var defaultRegistry = NewRegistry()
+
+// NewRegistry returns a registry with the Go runtime and process collectors.
+func NewRegistry() *prometheus.Registry {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return reg
+}
+
+// Default returns the process-wide registry used by shared packages.
+func Default() *prometheus.Registry {
+	return defaultRegistry
+}
+
+// Register adds c to the default registry and returns it; see RegisterWith.
+func Register[T prometheus.Collector](c T) T {
+	return RegisterWith(defaultRegistry, c)
+}
+
+// RegisterWith adds c to reg and returns it. When an equal collector is already registered,
+// the existing one is returned, so several packages may declare the same metric. It panics
+// when the name is reused with other labels or type, which is a programming error.
+func RegisterWith[T prometheus.Collector](reg prometheus.Registerer, c T) T {
+	err := reg.Register(c)
+	if err == nil {
+		return c
+	}
+	var registered prometheus.AlreadyRegisteredError
+	if errors.As(err, &registered) {
+		if existing, ok := registered.ExistingCollector.(T); ok {
+			return existing
+		}
+	}
+	panic(fmt.Sprintf("metrics: %v", err))
+}
+
+// Handler serves the default registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return HandlerFor(defaultRegistry)
+}
+
+// HandlerFor serves reg in the Prometheus exposition format.
+func HandlerFor(reg *prometheus.Registry) http.Handler {
+	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
+}
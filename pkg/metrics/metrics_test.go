@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func scrape(t *testing.T, reg *prometheus.Registry) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	HandlerFor(reg).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	return rec.Body.String()
+}
+
+func TestRegistryExposesRuntimeMetrics(t *testing.T) {
+	t.Parallel()
+
+	out := scrape(t, NewRegistry())
+	for _, want := range []string{"go_goroutines", "go_memstats_heap_alloc_bytes", "process_cpu_seconds_total"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %s in:\n%s", want, out)
+		}
+	}
+}
+
+func TestRegisterWithReusesMetric(t *testing.T) {
+	t.Parallel()
+
+	reg := NewRegistry()
+	opts := prometheus.CounterOpts{Name: "events_total", Help: "Events."}
+	RegisterWith(reg, prometheus.NewCounter(opts)).Inc()
+	RegisterWith(reg, prometheus.NewCounter(opts)).Inc()
+
+	if out := scrape(t, reg); !strings.Contains(out, "events_total 2\n") {
+		t.Fatalf("expected shared counter, got:\n%s", out)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic on label conflict")
+		}
+	}()
+	RegisterWith(reg, prometheus.NewCounterVec(opts, []string{"kind"}))
+}
+
+func TestMiddlewareRecordsRouteTemplate(t *testing.T) {
+	t.Parallel()
+
+	reg := NewRegistry()
+	app := fiber.New()
+	app.Use(Middleware(reg))
+	app.Get("/deals/:id", func(c *fiber.Ctx) error { return c.SendString(c.Params("id")) })
+
+	for _, path := range []string{"/deals/1", "/deals/2", "/missing"} {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		if err != nil {
+			t.Fatalf("request %s: %v", path, err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}
+
+	out := scrape(t, reg)
+	if !strings.Contains(out, `http_requests_total{method="GET",route="/deals/:id",status="200"} 2`) {
+		t.Fatalf("expected per-route counter, got:\n%s", out)
+	}
+	if !strings.Contains(out, `http_requests_total{method="GET",route="unmatched",status="404"} 1`) {
+		t.Fatalf("expected unmatched counter, got:\n%s", out)
+	}
+	if !strings.Contains(out, `http_request_duration_seconds_count{method="GET",route="/deals/:id",status="200"} 2`) {
+		t.Fatalf("expected latency histogram, got:\n%s", out)
+	}
+}
@@ -0,0 +1,128 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+
+	"asfppro/pkg/config"
+	"asfppro/pkg/db"
+	logpkg "asfppro/pkg/log"
+)
+
+const usage = "usage: migrate up|down|down-to VERSION|status [flags]"
+
+// Command runs the "migrate" subcommand for prefix and reports whether args selected it.
+// Flags after the action are the usual configuration flags, e.g. -database-url.
+func Command(ctx context.Context, prefix string, src Source, args []string, stdout io.Writer) (bool, error) {
+	if len(args) == 0 || args[0] != "migrate" {
+		return false, nil
+	}
+	if len(args) < 2 {
+		return true, errors.New(usage)
+	}
+
+	action, rest := args[1], args[2:]
+	var target int64
+	switch action {
+	case "up", "down", "status":
+	case "down-to":
+		if len(rest) == 0 {
+			return true, errors.New(usage)
+		}
+		v, err := strconv.ParseInt(rest[0], 10, 64)
+		if err != nil || v < 0 {
+			return true, fmt.Errorf("invalid version %q", rest[0])
+		}
+		target, rest = v, rest[1:]
+	default:
+		return true, errors.New(usage)
+	}
+
+	cfg, err := config.LoadArgs(prefix, rest)
+	if err != nil {
+		return true, err
+	}
+	logger, _ := logpkg.New(logpkg.Options{Env: cfg.Env, Service: cfg.AppName, Level: cfg.LogLevel, Format: cfg.LogFormat})
+
+	pool, err := db.NewPostgresPool(ctx, cfg.DatabaseURL)
+	if err != nil {
+		return true, err
+	}
+	defer pool.Close()
+
+	m, err := New(pool, src, logger)
+	if err != nil {
+		return true, err
+	}
+
+	var done []int64
+	switch action {
+	case "up":
+		done, err = m.Up(ctx)
+	case "down":
+		done, err = m.Down(ctx)
+	case "down-to":
+		done, err = m.DownTo(ctx, target)
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return true, err
+		}
+		return true, printStatus(stdout, statuses)
+	}
+	if err != nil {
+		return true, err
+	}
+	if len(done) == 0 {
+		_, err = fmt.Fprintln(stdout, "no migrations to run")
+	} else {
+		_, err = fmt.Fprintf(stdout, "%s: %v\n", action, done)
+	}
+	return true, err
+}
+
+// OnStart applies pending migrations when cfg.MigrateOnStart is set, before the service
+// starts serving. Replicas starting together wait on the advisory lock.
+func OnStart(ctx context.Context, cfg config.AppConfig, pool *pgxpool.Pool, src Source, logger zerolog.Logger) error {
+	if !cfg.MigrateOnStart {
+		return nil
+	}
+	m, err := New(pool, src, logger)
+	if err != nil {
+		return err
+	}
+	done, err := m.Up(ctx)
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+	logger.Info().Str("table", src.Table).Ints64("applied", done).Msg("migrations up to date")
+	return nil
+}
+
+func printStatus(w io.Writer, statuses []Status) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tAPPLIED AT\tMIGRATION")
+	pendingCount := 0
+	for _, s := range statuses {
+		at := "pending"
+		if s.Applied {
+			at = s.AppliedAt.UTC().Format(time.RFC3339)
+		} else {
+			pendingCount++
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", s.Version, at, s.Name)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%d pending\n", pendingCount)
+	return err
+}
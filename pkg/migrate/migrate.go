@@ -0,0 +1,414 @@
+// Package migrate applies embedded goose-format SQL migrations under a Postgres advisory lock.
+// Version tables follow the goose layout, so databases migrated with the goose CLI carry over.
+package migrate
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+)
+
+// Source is a module's migration set and the table tracking its versions.
+type Source struct {
+	Table string
+	Files fs.FS
+}
+
+// Migration is one parsed SQL file.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      []string
+	Down    []string
+	// NoTx runs statements outside a transaction, for CREATE INDEX CONCURRENTLY and the like.
+	NoTx bool
+}
+
+// Status describes whether a migration is applied.
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+var tableName = regexp.MustCompile(`^[a-z_][a-z0-9_]*(\.[a-z_][a-z0-9_]*)?$`)
+
+// Migrator runs one source against a database.
+type Migrator struct {
+	pool       *pgxpool.Pool
+	table      string
+	migrations []Migration
+	logger     zerolog.Logger
+}
+
+// New parses the source files; it fails on malformed or duplicate versions.
+func New(pool *pgxpool.Pool, src Source, logger zerolog.Logger) (*Migrator, error) {
+	if !tableName.MatchString(src.Table) {
+		return nil, fmt.Errorf("invalid version table %q", src.Table)
+	}
+	migrations, err := Parse(src.Files)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		pool:       pool,
+		table:      src.Table,
+		migrations: migrations,
+		logger:     logger.With().Str("component", "migrate").Str("table", src.Table).Logger(),
+	}, nil
+}
+
+// Parse reads *.sql files named <version>_<name>.sql, ordered by version.
+func Parse(files fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(files, "*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("list migrations: %w", err)
+	}
+
+	migrations := make([]Migration, 0, len(names))
+	seen := make(map[int64]string, len(names))
+	for _, name := range names {
+		prefix, _, ok := strings.Cut(name, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: file name must start with a positive version", name)
+		}
+		if other, dup := seen[version]; dup {
+			return nil, fmt.Errorf("migration %s: version %d already used by %s", name, version, other)
+		}
+		seen[version] = name
+
+		data, err := fs.ReadFile(files, name)
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", name, err)
+		}
+		m, err := parseSQL(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", name, err)
+		}
+		m.Version = version
+		m.Name = path.Base(name)
+		migrations = append(migrations, m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// parseSQL splits a goose file into up and down statements. A statement ends at a line
+// ending with ';' unless it is wrapped in StatementBegin/StatementEnd.
+func parseSQL(data string) (Migration, error) {
+	var (
+		m         Migration
+		section   *[]string
+		buf       strings.Builder
+		inBlock   bool
+		sawUp     bool
+		flushStmt = func() {
+			if stmt := strings.TrimSpace(buf.String()); !commentOnly(stmt) && section != nil {
+				*section = append(*section, stmt)
+			}
+			buf.Reset()
+		}
+	)
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if directive, ok := strings.CutPrefix(trimmed, "-- +goose "); ok {
+			switch strings.TrimSpace(directive) {
+			case "Up":
+				flushStmt()
+				section, sawUp = &m.Up, true
+			case "Down":
+				flushStmt()
+				section = &m.Down
+			case "StatementBegin":
+				flushStmt()
+				inBlock = true
+			case "StatementEnd":
+				inBlock = false
+				flushStmt()
+			case "NO TRANSACTION":
+				m.NoTx = true
+			default:
+				return Migration{}, fmt.Errorf("unknown directive %q", trimmed)
+			}
+			continue
+		}
+		if section == nil {
+			continue
+		}
+
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+		if !inBlock && strings.HasSuffix(trimmed, ";") && !strings.HasPrefix(trimmed, "--") {
+			flushStmt()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Migration{}, err
+	}
+	if inBlock {
+		return Migration{}, errors.New("missing StatementEnd")
+	}
+	flushStmt()
+	if !sawUp {
+		return Migration{}, errors.New("missing -- +goose Up")
+	}
+	return m, nil
+}
+
+func commentOnly(stmt string) bool {
+	for _, line := range strings.Split(stmt, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
+
+// Up applies all pending migrations and returns their versions.
+func (m *Migrator) Up(ctx context.Context) ([]int64, error) {
+	var done []int64
+	err := m.locked(ctx, func(conn *pgx.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range pending(m.migrations, applied) {
+			if err := m.apply(ctx, conn, mig, true); err != nil {
+				return err
+			}
+			done = append(done, mig.Version)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down rolls back the latest applied migration.
+func (m *Migrator) Down(ctx context.Context) ([]int64, error) {
+	return m.rollback(ctx, func(applied []Migration) []Migration {
+		if len(applied) == 0 {
+			return nil
+		}
+		return applied[len(applied)-1:]
+	})
+}
+
+// DownTo rolls back applied migrations newer than version; zero rolls back everything.
+func (m *Migrator) DownTo(ctx context.Context, version int64) ([]int64, error) {
+	return m.rollback(ctx, func(applied []Migration) []Migration {
+		i := sort.Search(len(applied), func(i int) bool { return applied[i].Version > version })
+		return applied[i:]
+	})
+}
+
+func (m *Migrator) rollback(ctx context.Context, pick func(applied []Migration) []Migration) ([]int64, error) {
+	var done []int64
+	err := m.locked(ctx, func(conn *pgx.Conn) error {
+		versions, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		var applied []Migration
+		for _, mig := range m.migrations {
+			if _, ok := versions[mig.Version]; ok {
+				applied = append(applied, mig)
+			}
+		}
+		targets := pick(applied)
+		for i := len(targets) - 1; i >= 0; i-- {
+			if err := m.apply(ctx, conn, targets[i], false); err != nil {
+				return err
+			}
+			done = append(done, targets[i].Version)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status lists every known migration with its applied state.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.locked(ctx, func(conn *pgx.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			at, ok := applied[mig.Version]
+			statuses = append(statuses, Status{Version: mig.Version, Name: mig.Name, Applied: ok, AppliedAt: at})
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// pending returns migrations missing from applied, in version order. Out-of-order gaps
+// are applied too, matching goose with allow-missing.
+func pending(all []Migration, applied map[int64]time.Time) []Migration {
+	var out []Migration
+	for _, mig := range all {
+		if _, ok := applied[mig.Version]; !ok {
+			out = append(out, mig)
+		}
+	}
+	return out
+}
+
+// locked runs fn on a dedicated connection holding the table's advisory lock, so replicas
+// starting together apply migrations once.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *pgx.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	key := lockKey(m.table)
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", key); err != nil {
+		return fmt.Errorf("advisory lock: %w", err)
+	}
+	defer func() {
+		// The lock must be released even when ctx is already cancelled.
+		if _, err := conn.Exec(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", key); err != nil {
+			m.logger.Error().Err(err).Msg("advisory unlock")
+		}
+	}()
+
+	if err := m.ensureTable(ctx, conn.Conn()); err != nil {
+		return err
+	}
+	return fn(conn.Conn())
+}
+
+func lockKey(table string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("asfppro.migrate." + table))
+	return int64(h.Sum64())
+}
+
+func (m *Migrator) ensureTable(ctx context.Context, conn *pgx.Conn) error {
+	var exists bool
+	if err := conn.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL", m.table).Scan(&exists); err != nil {
+		return fmt.Errorf("check version table: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`CREATE TABLE %s (
+		id serial NOT NULL,
+		version_id bigint NOT NULL,
+		is_applied boolean NOT NULL,
+		tstamp timestamp NULL DEFAULT now(),
+		PRIMARY KEY (id)
+	)`, m.table)); err != nil {
+		return fmt.Errorf("create version table: %w", err)
+	}
+	if _, err := tx.Exec(ctx, fmt.Sprintf("INSERT INTO %s (version_id, is_applied) VALUES (0, true)", m.table)); err != nil {
+		return fmt.Errorf("seed version table: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+// applied returns applied versions with their timestamps. The newest row per version wins,
+// as goose records rollbacks by deleting or flagging rows.
+func (m *Migrator) applied(ctx context.Context, conn *pgx.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.Query(ctx, fmt.Sprintf("SELECT version_id, is_applied, tstamp FROM %s ORDER BY id DESC", m.table))
+	if err != nil {
+		return nil, fmt.Errorf("read versions: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	seen := make(map[int64]bool)
+	for rows.Next() {
+		var (
+			version int64
+			ok      bool
+			at      *time.Time
+		)
+		if err := rows.Scan(&version, &ok, &at); err != nil {
+			return nil, fmt.Errorf("scan version: %w", err)
+		}
+		if version == 0 || seen[version] {
+			continue
+		}
+		seen[version] = true
+		if ok {
+			var ts time.Time
+			if at != nil {
+				ts = *at
+			}
+			applied[version] = ts
+		}
+	}
+	return applied, rows.Err()
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *pgx.Conn, mig Migration, up bool) error {
+	statements, direction := mig.Up, "up"
+	record := fmt.Sprintf("INSERT INTO %s (version_id, is_applied) VALUES ($1, true)", m.table)
+	if !up {
+		statements, direction = mig.Down, "down"
+		record = fmt.Sprintf("DELETE FROM %s WHERE version_id = $1", m.table)
+	}
+
+	start := time.Now()
+	if mig.NoTx {
+		for _, stmt := range statements {
+			if _, err := conn.Exec(ctx, stmt); err != nil {
+				return fmt.Errorf("%s %s: %w", direction, mig.Name, err)
+			}
+		}
+		if _, err := conn.Exec(ctx, record, mig.Version); err != nil {
+			return fmt.Errorf("record %s: %w", mig.Name, err)
+		}
+	} else {
+		err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+			for _, stmt := range statements {
+				if _, err := tx.Exec(ctx, stmt); err != nil {
+					return fmt.Errorf("%s %s: %w", direction, mig.Name, err)
+				}
+			}
+			if _, err := tx.Exec(ctx, record, mig.Version); err != nil {
+				return fmt.Errorf("record %s: %w", mig.Name, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	m.logger.Info().Int64("version", mig.Version).Str("name", mig.Name).Str("direction", direction).
+		Dur("duration", time.Since(start)).Msg("migration applied")
+	return nil
+}
@@ -0,0 +1,115 @@
+package migrate
+
+import (
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	bpm "asfppro/modules/bpm/migrations"
+	crm "asfppro/modules/crm/migrations"
+	docs "asfppro/modules/docs/migrations"
+	mes "asfppro/modules/mes/migrations"
+	montage "asfppro/modules/montage/migrations"
+	wms "asfppro/modules/wms/migrations"
+	core "asfppro/pkg/db/migrations/core"
+)
+
+func TestParseSplitsStatements(t *testing.T) {
+	files := fstest.MapFS{
+		"0002_func.sql": {Data: []byte(`-- +goose Up
+-- +goose StatementBegin
+CREATE FUNCTION f() RETURNS int AS $$
+BEGIN
+  RETURN 1;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION f();
+`)},
+		"0001_init.sql": {Data: []byte(`-- leading comment
+-- +goose Up
+CREATE TABLE a (id int);
+INSERT INTO a VALUES (1);
+-- trailing comment
+
+-- +goose Down
+DROP TABLE a;
+`)},
+		"README.md": {Data: []byte("ignored")},
+	}
+
+	migrations, err := Parse(files)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != 1 || migrations[1].Version != 2 {
+		t.Fatalf("unexpected order: %+v", migrations)
+	}
+	if got := migrations[0].Up; len(got) != 2 || got[1] != "INSERT INTO a VALUES (1);" {
+		t.Fatalf("up statements = %q", got)
+	}
+	if got := migrations[1].Up; len(got) != 1 || !strings.Contains(got[0], "RETURN 1;\nEND;") {
+		t.Fatalf("block statement = %q", got)
+	}
+	if got := migrations[1].Down; !reflect.DeepEqual(got, []string{"DROP FUNCTION f();"}) {
+		t.Fatalf("down statements = %q", got)
+	}
+}
+
+func TestParseRejectsBadFiles(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"no version":   {"init.sql": {Data: []byte("-- +goose Up\nSELECT 1;\n")}},
+		"duplicate":    {"0001_a.sql": {Data: []byte("-- +goose Up\n")}, "01_b.sql": {Data: []byte("-- +goose Up\n")}},
+		"missing up":   {"0001_a.sql": {Data: []byte("SELECT 1;\n")}},
+		"open block":   {"0001_a.sql": {Data: []byte("-- +goose Up\n-- +goose StatementBegin\nSELECT 1;\n")}},
+		"unknown rule": {"0001_a.sql": {Data: []byte("-- +goose Up\n-- +goose Sideways\n")}},
+	}
+	for name, files := range cases {
+		if _, err := Parse(files); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestEmbeddedMigrationsParse(t *testing.T) {
+	sources := map[string]fs.FS{
+		"core": core.Files, "crm": crm.Files, "wms": wms.Files, "mes": mes.Files,
+		"montage": montage.Files, "docs": docs.Files, "bpm": bpm.Files,
+	}
+	for name, files := range sources {
+		migrations, err := Parse(files)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		for _, m := range migrations {
+			if len(m.Up) == 0 {
+				t.Errorf("%s: %s has no up statements", name, m.Name)
+			}
+		}
+	}
+}
+
+func TestPendingIncludesGaps(t *testing.T) {
+	all := []Migration{{Version: 1}, {Version: 2}, {Version: 3}}
+	applied := map[int64]time.Time{1: {}, 3: {}}
+
+	got := pending(all, applied)
+	if len(got) != 1 || got[0].Version != 2 {
+		t.Fatalf("pending = %+v", got)
+	}
+}
+
+func TestLockKeyPerTable(t *testing.T) {
+	if lockKey(wms.Table) == lockKey(crm.Table) {
+		t.Fatal("modules must not share an advisory lock")
+	}
+	if lockKey(wms.Table) != lockKey(wms.Table) {
+		t.Fatal("lock key must be stable")
+	}
+}
@@ -0,0 +1,178 @@
+// Package preview renders thumbnails of images and first pages of PDF documents.
+package preview
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MaxPixels bounds the images decoded in process, so a small file cannot expand into
+// gigabytes of pixels.
+const MaxPixels = 80_000_000
+
+// headLen is how much of a JPEG is inspected for its dimensions and EXIF orientation.
+const headLen = 256 << 10
+
+var (
+	// ErrUnsupported is returned for content types without a renderer.
+	ErrUnsupported = errors.New("preview: unsupported content type")
+	// ErrTooManyPixels is returned for images larger than MaxPixels.
+	ErrTooManyPixels = errors.New("preview: image too large")
+	// ErrNoDimensions is returned for JPEG and PNG files whose dimensions are not in the
+	// first headLen bytes, so the pixel limit cannot be checked before decoding.
+	ErrNoDimensions = errors.New("preview: image dimensions not found")
+	// ErrConverterUnavailable is returned for TIFF and PDF files when the converter is not
+	// installed.
+	ErrConverterUnavailable = errors.New("preview: converter not available")
+)
+
+// Supported reports whether previews can be rendered for contentType.
+func Supported(contentType string) bool {
+	switch baseType(contentType) {
+	case "image/jpeg", "image/png", "image/tiff", "application/pdf":
+		return true
+	}
+	return false
+}
+
+// Renderer decodes JPEG and PNG in process and hands TIFF and PDF files to vipsthumbnail,
+// which also converts CMYK prints to RGB.
+type Renderer struct {
+	converter string
+	timeout   time.Duration
+}
+
+// NewRenderer returns renderer running converter (vipsthumbnail or a compatible command)
+// for at most timeout per file.
+func NewRenderer(converter string, timeout time.Duration) *Renderer {
+	return &Renderer{converter: converter, timeout: timeout}
+}
+
+// Render decodes src and returns it no larger than side pixels on its longer edge, upright
+// and flattened onto white. PDFs yield their first page.
+func (r *Renderer) Render(ctx context.Context, src io.Reader, contentType string, side int) (image.Image, error) {
+	switch baseType(contentType) {
+	case "image/jpeg":
+		return r.decode(src, side, true)
+	case "image/png":
+		return r.decode(src, side, false)
+	case "image/tiff", "application/pdf":
+		return r.convert(ctx, src, contentType, side)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupported, contentType)
+}
+
+func (r *Renderer) decode(src io.Reader, side int, exif bool) (image.Image, error) {
+	br := bufio.NewReaderSize(src, headLen)
+	head, _ := br.Peek(headLen)
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(head))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoDimensions, err)
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > MaxPixels {
+		return nil, fmt.Errorf("%w: %dx%d", ErrTooManyPixels, cfg.Width, cfg.Height)
+	}
+	orientation := 1
+	if exif {
+		orientation = jpegOrientation(head)
+	}
+
+	img, _, err := image.Decode(br)
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+	return orient(Fit(img, side), orientation), nil
+}
+
+// convert runs the converter on a temporary copy of src and decodes the PNG it writes.
+func (r *Renderer) convert(ctx context.Context, src io.Reader, contentType string, side int) (image.Image, error) {
+	if r.converter == "" {
+		return nil, ErrConverterUnavailable
+	}
+	bin, err := exec.LookPath(r.converter)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrConverterUnavailable, err)
+	}
+
+	dir, err := os.MkdirTemp("", "preview-")
+	if err != nil {
+		return nil, fmt.Errorf("create temp dir: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	input := filepath.Join(dir, "source")
+	if err := writeFile(input, src); err != nil {
+		return nil, err
+	}
+	output := filepath.Join(dir, "preview.png")
+
+	// Raster prints are only shrunk; vector pages are rendered at the requested size.
+	size := fmt.Sprintf("%dx%d", side, side)
+	if baseType(contentType) != "application/pdf" {
+		size += ">"
+	}
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, bin, input, "--size", size, "-o", output)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("run %s: %w: %s", r.converter, err, strings.TrimSpace(string(out)))
+	}
+
+	f, err := os.Open(output)
+	if err != nil {
+		return nil, fmt.Errorf("open rendered preview: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("decode rendered preview: %w", err)
+	}
+	return Fit(img, side), nil
+}
+
+// EncodeJPEG encodes img for storage.
+func EncodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 82}); err != nil {
+		return nil, fmt.Errorf("encode preview: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func writeFile(name string, src io.Reader) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	if _, err := io.Copy(f, src); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("copy source: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close temp file: %w", err)
+	}
+	return nil
+}
+
+func baseType(contentType string) string {
+	if media, _, err := mime.ParseMediaType(contentType); err == nil {
+		return media
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
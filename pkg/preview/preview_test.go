@@ -0,0 +1,157 @@
+package preview
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestFitScalesDownAndFlattensAlpha(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 400, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 400; x++ {
+			if x < 200 {
+				src.SetNRGBA(x, y, color.NRGBA{R: 255, A: 255})
+			}
+		}
+	}
+
+	out := Fit(src, 40)
+	if got := out.Bounds().Size(); got != image.Pt(40, 10) {
+		t.Fatalf("size = %v, want 40x10", got)
+	}
+	if c := out.RGBAAt(5, 5); c != (color.RGBA{R: 255, A: 255}) {
+		t.Errorf("opaque red half = %v", c)
+	}
+	if c := out.RGBAAt(35, 5); c != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+		t.Errorf("transparent half must turn white, got %v", c)
+	}
+
+	small := Fit(image.NewGray(image.Rect(0, 0, 30, 20)), 100)
+	if got := small.Bounds().Size(); got != image.Pt(30, 20) {
+		t.Fatalf("small image must keep its size, got %v", got)
+	}
+}
+
+func TestOrient(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	marker := color.RGBA{R: 1, A: 255}
+	img.SetRGBA(0, 0, marker)
+
+	cases := map[int]image.Point{1: {0, 0}, 2: {2, 0}, 3: {2, 1}, 4: {0, 1}, 5: {0, 0}, 6: {1, 0}, 7: {1, 2}, 8: {0, 2}}
+	for orientation, want := range cases {
+		out := orient(img, orientation)
+		if orientation >= 5 && out.Bounds().Size() != image.Pt(2, 3) {
+			t.Errorf("orientation %d: size %v, want 2x3", orientation, out.Bounds().Size())
+		}
+		if out.RGBAAt(want.X, want.Y) != marker {
+			t.Errorf("orientation %d: top-left pixel not at %v", orientation, want)
+		}
+	}
+}
+
+func TestRenderJPEGAppliesOrientation(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 200, 100)), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := withOrientation(t, buf.Bytes(), 6)
+	if got := jpegOrientation(data); got != 6 {
+		t.Fatalf("orientation = %d, want 6", got)
+	}
+
+	img, err := NewRenderer("", 0).Render(context.Background(), bytes.NewReader(data), "image/jpeg", 50)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if got := img.Bounds().Size(); got != image.Pt(25, 50) {
+		t.Fatalf("size = %v, want 25x50", got)
+	}
+	if _, err := EncodeJPEG(img); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+}
+
+func TestRenderPNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 10, 300))); err != nil {
+		t.Fatal(err)
+	}
+	img, err := NewRenderer("", 0).Render(context.Background(), &buf, "image/png", 100)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if got := img.Bounds().Size(); got != image.Pt(3, 100) {
+		t.Fatalf("size = %v, want 3x100", got)
+	}
+}
+
+func TestRenderRejections(t *testing.T) {
+	r := NewRenderer("", 0)
+	ctx := context.Background()
+	if _, err := r.Render(ctx, strings.NewReader("x"), "text/plain", 100); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("text: expected ErrUnsupported, got %v", err)
+	}
+	if _, err := r.Render(ctx, strings.NewReader("%PDF-1.7"), "application/pdf", 100); !errors.Is(err, ErrConverterUnavailable) {
+		t.Errorf("pdf without converter: expected ErrConverterUnavailable, got %v", err)
+	}
+	missing := NewRenderer("preview-converter-that-does-not-exist", 0)
+	if _, err := missing.Render(ctx, strings.NewReader("II*\x00"), "image/tiff", 100); !errors.Is(err, ErrConverterUnavailable) {
+		t.Errorf("tiff with missing converter: expected ErrConverterUnavailable, got %v", err)
+	}
+	if _, err := r.Render(ctx, strings.NewReader("not an image"), "image/png", 100); !errors.Is(err, ErrNoDimensions) {
+		t.Errorf("garbage png: expected ErrNoDimensions, got %v", err)
+	}
+	if !Supported("image/jpeg; charset=binary") || Supported("image/gif") {
+		t.Error("Supported mismatch")
+	}
+}
+
+func TestRenderRejectsJPEGWithDimensionsPastTheHead(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 20, 10)), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	// APP15 segments push the frame header past headLen; the image itself still decodes.
+	padded := append([]byte{}, data[:2]...)
+	for n := 0; n*0xfffd < headLen; n++ {
+		padded = append(padded, 0xff, 0xef, 0xff, 0xff)
+		padded = append(padded, make([]byte, 0xfffd)...)
+	}
+	padded = append(padded, data[2:]...)
+	if _, _, err := image.Decode(bytes.NewReader(padded)); err != nil {
+		t.Fatalf("padded jpeg should decode: %v", err)
+	}
+
+	r := NewRenderer("", 0)
+	if _, err := r.Render(context.Background(), bytes.NewReader(padded), "image/jpeg", 100); !errors.Is(err, ErrNoDimensions) {
+		t.Fatalf("expected ErrNoDimensions, got %v", err)
+	}
+}
+
+// withOrientation inserts an APP1 EXIF segment carrying orientation after the SOI marker.
+func withOrientation(t *testing.T, data []byte, orientation uint16) []byte {
+	t.Helper()
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3)
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	out := append([]byte{}, data[:2]...)
+	out = append(out, 0xff, 0xe1)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(segment)+2))
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
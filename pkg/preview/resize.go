@@ -0,0 +1,189 @@
+package preview
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+)
+
+// Fit scales img down to at most side pixels on its longer edge by averaging the source
+// pixels behind each target pixel, and flattens transparency onto white. Smaller images
+// keep their size.
+func Fit(img image.Image, side int) *image.RGBA {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	w, h := fitted(sw, sh, side)
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	if sw == 0 || sh == 0 {
+		return dst
+	}
+
+	pixel := pixelFunc(img)
+	column := make([]int, sw)
+	for x := range column {
+		column[x] = x * w / sw
+	}
+	sums := make([]uint64, w*4)
+	counts := make([]uint64, w)
+	flush := func(row int) {
+		for tx := 0; tx < w; tx++ {
+			n := counts[tx]
+			if n == 0 {
+				continue
+			}
+			a := sums[tx*4+3] / n
+			i := dst.PixOffset(tx, row)
+			for c := 0; c < 3; c++ {
+				// Premultiplied colour over white.
+				dst.Pix[i+c] = uint8((sums[tx*4+c]/n + 0xffff - a) >> 8)
+			}
+			dst.Pix[i+3] = 0xff
+			sums[tx*4], sums[tx*4+1], sums[tx*4+2], sums[tx*4+3], counts[tx] = 0, 0, 0, 0, 0
+		}
+	}
+
+	row := 0
+	for y := 0; y < sh; y++ {
+		if ty := y * h / sh; ty != row {
+			flush(row)
+			row = ty
+		}
+		for x := 0; x < sw; x++ {
+			r, g, bl, a := pixel(b.Min.X+x, b.Min.Y+y)
+			tx := column[x]
+			sums[tx*4] += uint64(r)
+			sums[tx*4+1] += uint64(g)
+			sums[tx*4+2] += uint64(bl)
+			sums[tx*4+3] += uint64(a)
+			counts[tx]++
+		}
+	}
+	flush(row)
+	return dst
+}
+
+func fitted(w, h, side int) (int, int) {
+	if side <= 0 || (w <= side && h <= side) {
+		return w, h
+	}
+	if w >= h {
+		return side, max(1, (h*side+w/2)/w)
+	}
+	return max(1, (w*side+h/2)/h), side
+}
+
+// pixelFunc returns a premultiplied 16-bit pixel reader, avoiding the color.Color
+// allocation of At for the types decoders produce.
+func pixelFunc(img image.Image) func(x, y int) (r, g, b, a uint32) {
+	switch m := img.(type) {
+	case *image.YCbCr:
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			yi, ci := m.YOffset(x, y), m.COffset(x, y)
+			r, g, b := color.YCbCrToRGB(m.Y[yi], m.Cb[ci], m.Cr[ci])
+			return uint32(r) * 0x101, uint32(g) * 0x101, uint32(b) * 0x101, 0xffff
+		}
+	case *image.RGBA:
+		return func(x, y int) (uint32, uint32, uint32, uint32) { return m.RGBAAt(x, y).RGBA() }
+	case *image.NRGBA:
+		return func(x, y int) (uint32, uint32, uint32, uint32) { return m.NRGBAAt(x, y).RGBA() }
+	case *image.Gray:
+		return func(x, y int) (uint32, uint32, uint32, uint32) { return m.GrayAt(x, y).RGBA() }
+	}
+	return func(x, y int) (uint32, uint32, uint32, uint32) { return img.At(x, y).RGBA() }
+}
+
+// orient turns img upright according to an EXIF orientation (1-8).
+func orient(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			dst.SetRGBA(dx, dy, img.RGBAAt(x, y))
+		}
+	}
+	return dst
+}
+
+// jpegOrientation reads the EXIF orientation tag from the start of a JPEG; 1 when absent.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xff {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xda || marker == 0xd9 { // start of scan or end of image
+			return 1
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + size
+		if size < 2 || end > len(data) {
+			return 1
+		}
+		if segment := data[i+4 : end]; marker == 0xe1 && len(segment) > 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return exifOrientation(segment[6:])
+		}
+		i = end
+	}
+	return 1
+}
+
+// exifOrientation finds tag 0x0112 in IFD0 of a TIFF-structured EXIF block.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for n := 0; n < count; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if v := int(order.Uint16(tiff[entry+8:])); v >= 1 && v <= 8 {
+				return v
+			}
+			return 1
+		}
+	}
+	return 1
+}
@@ -0,0 +1,402 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tar "github.com/tarantool/go-tarantool/v2"
+)
+
+var (
+	// ErrTubeNotFound indicates an operation on a tube that does not exist.
+	ErrTubeNotFound = errors.New("tube not found")
+	// ErrMessageNotFound indicates a dead-letter message that does not exist.
+	ErrMessageNotFound = errors.New("message not found")
+)
+
+// pausedSpace lists tubes whose consumers must stop taking jobs.
+const pausedSpace = "queue_paused"
+
+// statsScript collects task counters of every tube.
+const statsScript = `
+local paused = box.space.queue_paused
+local result = {}
+for name, _ in pairs(queue.tube) do
+    local stats = queue.statistics(name) or {}
+    local tasks = stats.tasks or {}
+    table.insert(result, {
+        name,
+        tasks.ready or 0,
+        tasks.taken or 0,
+        tasks.delayed or 0,
+        tasks.buried or 0,
+        paused ~= nil and paused:get(name) ~= nil,
+    })
+end
+return result
+`
+
+// peekScript lists tasks of a tube with IDs greater than the cursor.
+const peekScript = `
+local tube, after, limit = ...
+local t = queue.tube[tube]
+if t == nil then
+    return nil
+end
+local items = {}
+for _, task in box.space[tube].index.task_id:pairs({after}, {iterator = 'GT'}) do
+    if #items >= limit then
+        break
+    end
+    task = t.raw:normalize_task(task)
+    table.insert(items, {task[1], task[3]})
+end
+return items
+`
+
+// requeueScript moves a dead-letter task back to its source tube with a fresh attempt counter.
+const requeueScript = `
+local dead, id, fallback = ...
+local t = queue.tube[dead]
+if t == nil then
+    return nil
+end
+local ok, task = pcall(t.peek, t, id)
+if not ok or task == nil then
+    return nil
+end
+local data = {}
+for k, v in pairs(task[3]) do data[k] = v end
+local target = data.source_tube or fallback
+if queue.tube[target] == nil then
+    box.error{reason = 'source tube ' .. target .. ' not found'}
+end
+data.source_tube = nil
+data.dead_reason = nil
+data.failed_at = nil
+data.attempts = 0
+queue.tube[target]:put(data)
+t:delete(id)
+return target
+`
+
+// deleteScript drops a dead-letter task.
+const deleteScript = `
+local dead, id = ...
+local t = queue.tube[dead]
+if t == nil then
+    return false
+end
+local ok, task = pcall(t.peek, t, id)
+if not ok or task == nil then
+    return false
+end
+t:delete(id)
+return true
+`
+
+// pauseScript marks a tube as paused (paused = true) or resumes it.
+const pauseScript = `
+local tube, paused = ...
+if queue.tube[tube] == nil then
+    return false
+end
+box.schema.space.create('queue_paused', {
+    if_not_exists = true,
+    format = {{name = 'tube', type = 'string'}},
+})
+box.space.queue_paused:create_index('primary', {parts = {'tube'}, if_not_exists = true})
+if paused then
+    box.space.queue_paused:replace({tube})
+else
+    box.space.queue_paused:delete(tube)
+end
+return true
+`
+
+// Admin inspects and repairs queue tubes. Dead-letter operations address the live tube;
+// its messages are read from DeadLetterTube(tube).
+type Admin interface {
+	// Stats returns task counters of live tubes. Buried includes messages waiting in the
+	// tube's dead-letter tube.
+	Stats(ctx context.Context) ([]TubeStats, error)
+	// DeadLetters lists up to limit dead-lettered messages of tube with IDs after afterID.
+	DeadLetters(ctx context.Context, tube string, afterID uint64, limit int) ([]DeadLetter, error)
+	// Requeue moves a dead-lettered message back to its source tube with attempts reset.
+	Requeue(ctx context.Context, tube string, id uint64) error
+	// Delete drops a dead-lettered message.
+	Delete(ctx context.Context, tube string, id uint64) error
+	// Pause stops consumers of tube from taking jobs until Resume is called.
+	Pause(ctx context.Context, tube string) error
+	Resume(ctx context.Context, tube string) error
+	Close()
+}
+
+// TubeStats holds task counters of one tube.
+type TubeStats struct {
+	Tube    string `json:"tube"`
+	Ready   int    `json:"ready"`
+	Taken   int    `json:"taken"`
+	Delayed int    `json:"delayed"`
+	Buried  int    `json:"buried"`
+	Paused  bool   `json:"paused"`
+}
+
+// DeadLetter is a message moved to a dead-letter tube.
+type DeadLetter struct {
+	ID         uint64          `json:"id"`
+	EventType  string          `json:"eventType"`
+	Version    int             `json:"version"`
+	Attempts   int             `json:"attempts"`
+	Reason     string          `json:"reason"`
+	SourceTube string          `json:"sourceTube"`
+	FailedAt   *time.Time      `json:"failedAt,omitempty"`
+	Envelope   json.RawMessage `json:"envelope,omitempty"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+}
+
+// AdminConfig describes admin connection settings.
+type AdminConfig struct {
+	// Backend selects BackendTarantool (default) or BackendMemory.
+	Backend string
+	Addr    string
+	// Broker is used by the memory backend; nil selects DefaultMemoryBroker.
+	Broker *MemoryBroker
+}
+
+// NewAdmin returns admin for the configured backend.
+func NewAdmin(cfg AdminConfig) (Admin, error) {
+	switch backend(cfg.Backend) {
+	case BackendTarantool:
+		return NewTarantoolAdmin(cfg.Addr)
+	case BackendMemory:
+		broker := cfg.Broker
+		if broker == nil {
+			broker = DefaultMemoryBroker()
+		}
+		return broker.Admin(), nil
+	default:
+		return nil, fmt.Errorf("unknown queue backend %q", cfg.Backend)
+	}
+}
+
+// TarantoolAdmin administers Tarantool queue tubes.
+type TarantoolAdmin struct {
+	conn *tar.Connection
+}
+
+// NewTarantoolAdmin connects to Tarantool.
+func NewTarantoolAdmin(addr string) (*TarantoolAdmin, error) {
+	conn, err := connect(addr)
+	if err != nil {
+		return nil, fmt.Errorf("connect tarantool: %w", err)
+	}
+	return &TarantoolAdmin{conn: conn}, nil
+}
+
+// Stats returns counters of live tubes; see Admin.
+func (a *TarantoolAdmin) Stats(ctx context.Context) ([]TubeStats, error) {
+	req := tar.NewEvalRequest(statsScript).Context(ctx)
+	resp, err := a.conn.Do(req).Get()
+	if err != nil {
+		return nil, fmt.Errorf("queue statistics: %w", err)
+	}
+
+	var raw []TubeStats
+	if len(resp) > 0 {
+		rows, _ := resp[0].([]any)
+		for _, row := range rows {
+			fields, ok := row.([]any)
+			if !ok || len(fields) < 6 {
+				continue
+			}
+			name, _ := fields[0].(string)
+			paused, _ := fields[5].(bool)
+			raw = append(raw, TubeStats{
+				Tube:    name,
+				Ready:   toInt(fields[1]),
+				Taken:   toInt(fields[2]),
+				Delayed: toInt(fields[3]),
+				Buried:  toInt(fields[4]),
+				Paused:  paused,
+			})
+		}
+	}
+	return foldDeadLetters(raw), nil
+}
+
+// DeadLetters lists dead-lettered messages of tube; see Admin.
+func (a *TarantoolAdmin) DeadLetters(ctx context.Context, tube string, afterID uint64, limit int) ([]DeadLetter, error) {
+	if err := checkTube(tube); err != nil {
+		return nil, err
+	}
+
+	req := tar.NewEvalRequest(peekScript).
+		Args([]any{DeadLetterTube(tube), afterID, normalizeLimit(limit)}).
+		Context(ctx)
+	resp, err := a.conn.Do(req).Get()
+	if err != nil {
+		return nil, fmt.Errorf("peek dead letters: %w", err)
+	}
+	if len(resp) == 0 || resp[0] == nil {
+		return nil, fmt.Errorf("%w: %s", ErrTubeNotFound, DeadLetterTube(tube))
+	}
+
+	rows, _ := resp[0].([]any)
+	items := make([]DeadLetter, 0, len(rows))
+	for _, row := range rows {
+		fields, ok := row.([]any)
+		if !ok || len(fields) < 2 {
+			continue
+		}
+		items = append(items, newDeadLetter(uint64(toInt(fields[0])), toStringMap(fields[1])))
+	}
+	return items, nil
+}
+
+// Requeue moves a dead-lettered message back to its source tube; see Admin.
+func (a *TarantoolAdmin) Requeue(ctx context.Context, tube string, id uint64) error {
+	if err := checkTube(tube); err != nil {
+		return err
+	}
+
+	req := tar.NewEvalRequest(requeueScript).
+		Args([]any{DeadLetterTube(tube), id, tube}).
+		Context(ctx)
+	resp, err := a.conn.Do(req).Get()
+	if err != nil {
+		return fmt.Errorf("requeue message %d: %w", id, err)
+	}
+	if len(resp) == 0 || resp[0] == nil {
+		return fmt.Errorf("%w: %d", ErrMessageNotFound, id)
+	}
+	return nil
+}
+
+// Delete drops a dead-lettered message; see Admin.
+func (a *TarantoolAdmin) Delete(ctx context.Context, tube string, id uint64) error {
+	if err := checkTube(tube); err != nil {
+		return err
+	}
+
+	req := tar.NewEvalRequest(deleteScript).
+		Args([]any{DeadLetterTube(tube), id}).
+		Context(ctx)
+	resp, err := a.conn.Do(req).Get()
+	if err != nil {
+		return fmt.Errorf("delete message %d: %w", id, err)
+	}
+	if deleted, _ := firstBool(resp); !deleted {
+		return fmt.Errorf("%w: %d", ErrMessageNotFound, id)
+	}
+	return nil
+}
+
+// Pause stops consumers of tube; see Admin.
+func (a *TarantoolAdmin) Pause(ctx context.Context, tube string) error {
+	return a.setPaused(ctx, tube, true)
+}
+
+// Resume lets consumers of tube take jobs again.
+func (a *TarantoolAdmin) Resume(ctx context.Context, tube string) error {
+	return a.setPaused(ctx, tube, false)
+}
+
+func (a *TarantoolAdmin) setPaused(ctx context.Context, tube string, paused bool) error {
+	if err := checkTube(tube); err != nil {
+		return err
+	}
+
+	req := tar.NewEvalRequest(pauseScript).
+		Args([]any{tube, paused}).
+		Context(ctx)
+	resp, err := a.conn.Do(req).Get()
+	if err != nil {
+		return fmt.Errorf("update %s: %w", pausedSpace, err)
+	}
+	if ok, _ := firstBool(resp); !ok {
+		return fmt.Errorf("%w: %s", ErrTubeNotFound, tube)
+	}
+	return nil
+}
+
+// Close terminates the underlying connection.
+func (a *TarantoolAdmin) Close() {
+	if a.conn != nil {
+		_ = a.conn.Close()
+	}
+}
+
+// foldDeadLetters adds dead-letter tube sizes to the Buried counter of their source tubes
+// and drops dead-letter tubes whose source tube exists.
+func foldDeadLetters(raw []TubeStats) []TubeStats {
+	byName := make(map[string]int, len(raw))
+	for i, s := range raw {
+		byName[s.Tube] = i
+	}
+
+	out := make([]TubeStats, 0, len(raw))
+	for _, s := range raw {
+		if source, ok := strings.CutSuffix(s.Tube, deadLetterSuffix); ok {
+			if _, exists := byName[source]; exists {
+				continue
+			}
+		}
+		if dead, ok := byName[DeadLetterTube(s.Tube)]; ok {
+			s.Buried += raw[dead].Ready + raw[dead].Delayed + raw[dead].Buried
+		}
+		out = append(out, s)
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Tube < out[j].Tube })
+	return out
+}
+
+func newDeadLetter(id uint64, data map[string]any) DeadLetter {
+	item := DeadLetter{ID: id, Attempts: toInt(data["attempts"]), Version: toInt(data["version"])}
+	item.EventType, _ = data["event_type"].(string)
+	item.Reason, _ = data["dead_reason"].(string)
+	item.SourceTube, _ = data["source_tube"].(string)
+	if raw, ok := data["envelope"].(string); ok && json.Valid([]byte(raw)) {
+		item.Envelope = json.RawMessage(raw)
+	}
+	if raw, ok := data["payload"].(string); ok && json.Valid([]byte(raw)) {
+		item.Payload = json.RawMessage(raw)
+	}
+	if ts := toInt(data["failed_at"]); ts > 0 {
+		failedAt := time.Unix(int64(ts), 0).UTC()
+		item.FailedAt = &failedAt
+	}
+	return item
+}
+
+func checkTube(tube string) error {
+	if !validName(tube) {
+		return fmt.Errorf("%w: invalid name %q", ErrTubeNotFound, tube)
+	}
+	return nil
+}
+
+func normalizeLimit(limit int) int {
+	switch {
+	case limit <= 0:
+		return 20
+	case limit > 100:
+		return 100
+	default:
+		return limit
+	}
+}
+
+func firstBool(resp []any) (bool, bool) {
+	if len(resp) == 0 {
+		return false, false
+	}
+	value, ok := resp[0].(bool)
+	return value, ok
+}
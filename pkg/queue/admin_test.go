@@ -0,0 +1,147 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFoldDeadLetters(t *testing.T) {
+	t.Parallel()
+
+	stats := foldDeadLetters([]TubeStats{
+		{Tube: "events_analytics", Ready: 2, Taken: 1},
+		{Tube: "events_analytics_dead", Ready: 3},
+		{Tube: "orphan_dead", Ready: 1},
+	})
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 tubes, got %+v", stats)
+	}
+	if stats[0].Tube != "events_analytics" || stats[0].Buried != 3 || stats[0].Ready != 2 {
+		t.Fatalf("unexpected live tube stats %+v", stats[0])
+	}
+	if stats[1].Tube != "orphan_dead" {
+		t.Fatalf("orphan dead-letter tube should stay listed, got %+v", stats[1])
+	}
+}
+
+func TestMemoryAdminDeadLetters(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	broker := NewMemoryBroker()
+	admin := broker.Admin()
+
+	publisher, err := broker.Publisher(PublisherConfig{Tube: "events", Producer: "crm"})
+	if err != nil {
+		t.Fatalf("new publisher: %v", err)
+	}
+	consumer, err := broker.Consumer(ConsumerConfig{Tube: "events", Group: "analytics", Topics: []string{"DealCreated"}, TakeTimeout: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("new consumer: %v", err)
+	}
+	tube := consumer.Tube()
+
+	for _, id := range []string{"d-1", "d-2"} {
+		if err := publisher.Publish(ctx, "DealCreated", dealPayload(id)); err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+		job, err := consumer.Next(ctx)
+		if err != nil || job == nil {
+			t.Fatalf("expected job, got %v, %v", job, err)
+		}
+		if err := job.Bury(ctx, "broken"); err != nil {
+			t.Fatalf("bury: %v", err)
+		}
+	}
+
+	stats, err := admin.Stats(ctx)
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	found := false
+	for _, s := range stats {
+		if s.Tube == tube {
+			found = true
+			if s.Buried != 2 || s.Ready != 0 {
+				t.Fatalf("unexpected stats %+v", s)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("tube %s missing from stats %+v", tube, stats)
+	}
+
+	letters, err := admin.DeadLetters(ctx, tube, 0, 10)
+	if err != nil || len(letters) != 2 {
+		t.Fatalf("expected 2 dead letters, got %+v, %v", letters, err)
+	}
+	if letters[0].Reason != "broken" || letters[0].SourceTube != tube || letters[0].EventType != "DealCreated" {
+		t.Fatalf("unexpected dead letter %+v", letters[0])
+	}
+
+	if err := admin.Delete(ctx, tube, letters[1].ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := admin.Delete(ctx, tube, letters[1].ID); !errors.Is(err, ErrMessageNotFound) {
+		t.Fatalf("expected ErrMessageNotFound, got %v", err)
+	}
+
+	if err := admin.Requeue(ctx, tube, letters[0].ID); err != nil {
+		t.Fatalf("requeue: %v", err)
+	}
+	job, err := consumer.Next(ctx)
+	if err != nil || job == nil {
+		t.Fatalf("expected requeued job, got %v, %v", job, err)
+	}
+	if job.Attempts() != 0 {
+		t.Fatalf("expected attempts reset, got %d", job.Attempts())
+	}
+	if err := job.Ack(ctx); err != nil {
+		t.Fatalf("ack: %v", err)
+	}
+
+	if letters, _ := admin.DeadLetters(ctx, tube, 0, 10); len(letters) != 0 {
+		t.Fatalf("expected empty dead-letter tube, got %+v", letters)
+	}
+}
+
+func TestMemoryAdminPause(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	broker := NewMemoryBroker()
+	admin := broker.Admin()
+
+	consumer, err := broker.Consumer(ConsumerConfig{Tube: "events", TakeTimeout: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("new consumer: %v", err)
+	}
+	publisher, err := broker.Publisher(PublisherConfig{Tube: "events", Producer: "crm"})
+	if err != nil {
+		t.Fatalf("new publisher: %v", err)
+	}
+
+	if err := admin.Pause(ctx, "missing"); !errors.Is(err, ErrTubeNotFound) {
+		t.Fatalf("expected ErrTubeNotFound, got %v", err)
+	}
+	if err := admin.Pause(ctx, "events"); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	if err := publisher.Publish(ctx, "DealCreated", dealPayload("d-1")); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if job, err := consumer.Next(ctx); job != nil || err != nil {
+		t.Fatalf("paused tube must not hand out jobs, got %v, %v", job, err)
+	}
+
+	if err := admin.Resume(ctx, "events"); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	job, err := consumer.Next(ctx)
+	if err != nil || job == nil {
+		t.Fatalf("expected job after resume, got %v, %v", job, err)
+	}
+	_ = job.Ack(ctx)
+}
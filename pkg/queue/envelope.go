@@ -0,0 +1,116 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"asfppro/pkg/tracing"
+)
+
+var (
+	// ErrInvalidEnvelope indicates an envelope that is malformed or fails schema validation.
+	ErrInvalidEnvelope = errors.New("invalid event envelope")
+	// ErrUnknownEvent indicates an event type missing from the registry.
+	ErrUnknownEvent = errors.New("unknown event type")
+)
+
+// Envelope is the standard wrapper for every event travelling through the queue.
+type Envelope struct {
+	ID            string            `json:"id"`
+	Type          string            `json:"type"`
+	Version       int               `json:"version"`
+	OccurredAt    time.Time         `json:"occurredAt"`
+	Producer      string            `json:"producer"`
+	CorrelationID string            `json:"correlationId,omitempty"`
+	TraceContext  map[string]string `json:"traceContext,omitempty"`
+	Payload       json.RawMessage   `json:"payload"`
+}
+
+// NewEnvelope wraps payload into an envelope with a fresh ID and current timestamp.
+// The correlation ID and trace context are taken from ctx when present.
+func NewEnvelope(ctx context.Context, eventType string, version int, producer string, payload any) (Envelope, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("marshal payload: %w", err)
+	}
+
+	return Envelope{
+		ID:            uuid.NewString(),
+		Type:          eventType,
+		Version:       version,
+		OccurredAt:    time.Now().UTC(),
+		Producer:      producer,
+		CorrelationID: CorrelationID(ctx),
+		TraceContext:  tracing.Inject(ctx),
+		Payload:       body,
+	}, nil
+}
+
+// stableIDNamespace seeds StableID; changing it would break deduplication of republished events.
+var stableIDNamespace = uuid.MustParse("6f1c2b7e-4d0a-5b8e-9c3f-a5f0e1d2c3b4")
+
+// StableID derives a deterministic event ID from its source identity, so an event
+// published again for the same change is recognised as a duplicate by idempotent consumers.
+// Replays meant to rebuild projections must use fresh IDs instead.
+func StableID(parts ...string) string {
+	return uuid.NewSHA1(stableIDNamespace, []byte(strings.Join(parts, "/"))).String()
+}
+
+// Decode unmarshals the envelope payload into out.
+func (e Envelope) Decode(out any) error {
+	if len(e.Payload) == 0 {
+		return errors.New("envelope payload is empty")
+	}
+	if err := json.Unmarshal(e.Payload, out); err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+	return nil
+}
+
+func (e Envelope) validateHeader() error {
+	var problems []string
+	if strings.TrimSpace(e.ID) == "" {
+		problems = append(problems, "id is required")
+	}
+	if strings.TrimSpace(e.Type) == "" {
+		problems = append(problems, "type is required")
+	}
+	if e.Version <= 0 {
+		problems = append(problems, "version must be positive")
+	}
+	if e.OccurredAt.IsZero() {
+		problems = append(problems, "occurredAt is required")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidEnvelope, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+type correlationKey struct{}
+
+// WithCorrelationID stores correlation ID in context for envelopes published downstream.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// CorrelationID extracts correlation ID from context.
+func CorrelationID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if id, ok := ctx.Value(correlationKey{}).(string); ok {
+		return id
+	}
+	return ""
+}
@@ -0,0 +1,204 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ClaimLease is how long a claimed event stays reserved for the consumer handling it.
+// A consumer that dies mid-handling blocks redeliveries until the lease expires, so jobs
+// rejected with ErrEventInProgress should be postponed by it rather than released.
+const ClaimLease = 5 * time.Minute
+
+const (
+	defaultProcessedTTL = 7 * 24 * time.Hour
+	purgeInterval       = time.Hour
+)
+
+var (
+	// ErrMarkProcessed indicates the handler succeeded but the event could not be recorded as processed.
+	ErrMarkProcessed = errors.New("mark event processed")
+	// ErrEventInProgress indicates another consumer holds the claim on the event; the job
+	// should be postponed until the claim is settled or expires, see ClaimLease.
+	ErrEventInProgress = errors.New("event is being processed by another consumer")
+)
+
+// ClaimStatus is the outcome of ProcessedStore.Claim.
+type ClaimStatus int
+
+// Claim outcomes.
+const (
+	// Claimed means the caller now owns the event and must process it.
+	Claimed ClaimStatus = iota
+	// AlreadyProcessed means the group handled the event before.
+	AlreadyProcessed
+	// InProgress means another consumer owns an unexpired claim on the event.
+	InProgress
+)
+
+// ProcessedStore remembers which events a consumer group has already handled.
+type ProcessedStore interface {
+	// Claim atomically reserves eventID for group for lease unless a live record exists.
+	Claim(ctx context.Context, group, eventID string, lease time.Duration) (ClaimStatus, error)
+	// MarkProcessed completes the claim and keeps the record until ttl elapses.
+	MarkProcessed(ctx context.Context, group, eventID string, ttl time.Duration) error
+	// Unclaim drops an unfinished claim so a redelivery can process the event.
+	Unclaim(ctx context.Context, group, eventID string) error
+	PurgeExpired(ctx context.Context) (int64, error)
+}
+
+// Idempotent skips events already handled by a consumer group. Tarantool delivers at least
+// once, so redelivered envelopes are recognised by their ID. The event is claimed before the
+// handler runs, so concurrent deliveries of one event are handled once.
+type Idempotent struct {
+	store ProcessedStore
+	group string
+	ttl   time.Duration
+
+	mu        sync.Mutex
+	lastPurge time.Time
+}
+
+// NewIdempotent returns helper remembering processed events for ttl (7 days when ttl <= 0).
+func NewIdempotent(store ProcessedStore, group string, ttl time.Duration) *Idempotent {
+	if ttl <= 0 {
+		ttl = defaultProcessedTTL
+	}
+	return &Idempotent{store: store, group: group, ttl: ttl}
+}
+
+// Handle claims env, runs fn and records the event as processed on success.
+// It reports true when the event was a duplicate and fn was skipped, and returns
+// ErrEventInProgress while another consumer handles the event. A failed fn gives the claim
+// up. Envelopes without an ID (legacy messages) are always handled.
+func (i *Idempotent) Handle(ctx context.Context, env Envelope, fn func(context.Context) error) (bool, error) {
+	eventID := strings.TrimSpace(env.ID)
+	if i == nil || i.store == nil || eventID == "" {
+		return false, fn(ctx)
+	}
+
+	i.purge(ctx)
+
+	status, err := i.store.Claim(ctx, i.group, eventID, ClaimLease)
+	if err != nil {
+		return false, fmt.Errorf("claim event: %w", err)
+	}
+	switch status {
+	case AlreadyProcessed:
+		return true, nil
+	case InProgress:
+		return false, ErrEventInProgress
+	}
+
+	if err := fn(ctx); err != nil {
+		if unclaimErr := i.store.Unclaim(context.WithoutCancel(ctx), i.group, eventID); unclaimErr != nil {
+			return false, errors.Join(err, fmt.Errorf("unclaim event: %w", unclaimErr))
+		}
+		return false, err
+	}
+
+	if err := i.store.MarkProcessed(ctx, i.group, eventID, i.ttl); err != nil {
+		return false, fmt.Errorf("%w: %v", ErrMarkProcessed, err)
+	}
+	return false, nil
+}
+
+// purge drops expired records at most once per purgeInterval.
+func (i *Idempotent) purge(ctx context.Context) {
+	i.mu.Lock()
+	if time.Since(i.lastPurge) < purgeInterval {
+		i.mu.Unlock()
+		return
+	}
+	i.lastPurge = time.Now()
+	i.mu.Unlock()
+
+	_, _ = i.store.PurgeExpired(ctx)
+}
+
+type pgExecQuerier interface {
+	Exec(context.Context, string, ...any) (pgconn.CommandTag, error)
+	QueryRow(context.Context, string, ...any) pgx.Row
+}
+
+// PostgresProcessedStore keeps processed event IDs in core.processed_events.
+type PostgresProcessedStore struct {
+	db pgExecQuerier
+}
+
+// NewPostgresProcessedStore constructs store bound to pgx pool or transaction.
+func NewPostgresProcessedStore(db pgExecQuerier) *PostgresProcessedStore {
+	return &PostgresProcessedStore{db: db}
+}
+
+// Claim reserves eventID for group with a single upsert that only overwrites an expired
+// record; see ProcessedStore.
+func (s *PostgresProcessedStore) Claim(ctx context.Context, group, eventID string, lease time.Duration) (ClaimStatus, error) {
+	const claim = `INSERT INTO core.processed_events (consumer_group, event_id, done, expires_at)
+VALUES ($1, $2, FALSE, NOW() + make_interval(secs => $3))
+ON CONFLICT (consumer_group, event_id) DO UPDATE
+SET done = FALSE, processed_at = NOW(), expires_at = EXCLUDED.expires_at
+WHERE core.processed_events.expires_at <= NOW()
+RETURNING TRUE`
+
+	var claimed bool
+	err := s.db.QueryRow(ctx, claim, group, eventID, lease.Seconds()).Scan(&claimed)
+	if err == nil {
+		return Claimed, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("claim processed event: %w", err)
+	}
+
+	var done bool
+	err = s.db.QueryRow(ctx, `SELECT done FROM core.processed_events WHERE consumer_group = $1 AND event_id = $2`, group, eventID).Scan(&done)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		// The record was purged or unclaimed meanwhile; let the redelivery claim it.
+		return InProgress, nil
+	case err != nil:
+		return 0, fmt.Errorf("select processed event: %w", err)
+	case done:
+		return AlreadyProcessed, nil
+	default:
+		return InProgress, nil
+	}
+}
+
+// MarkProcessed records eventID for group as done until ttl elapses.
+func (s *PostgresProcessedStore) MarkProcessed(ctx context.Context, group, eventID string, ttl time.Duration) error {
+	const query = `INSERT INTO core.processed_events (consumer_group, event_id, done, expires_at)
+VALUES ($1, $2, TRUE, NOW() + make_interval(secs => $3))
+ON CONFLICT (consumer_group, event_id) DO UPDATE SET done = TRUE, processed_at = NOW(), expires_at = EXCLUDED.expires_at`
+
+	if _, err := s.db.Exec(ctx, query, group, eventID, ttl.Seconds()); err != nil {
+		return fmt.Errorf("insert processed event: %w", err)
+	}
+	return nil
+}
+
+// Unclaim deletes an unfinished claim of eventID.
+func (s *PostgresProcessedStore) Unclaim(ctx context.Context, group, eventID string) error {
+	const query = `DELETE FROM core.processed_events WHERE consumer_group = $1 AND event_id = $2 AND NOT done`
+
+	if _, err := s.db.Exec(ctx, query, group, eventID); err != nil {
+		return fmt.Errorf("delete processed event claim: %w", err)
+	}
+	return nil
+}
+
+// PurgeExpired deletes records past their TTL.
+func (s *PostgresProcessedStore) PurgeExpired(ctx context.Context) (int64, error) {
+	tag, err := s.db.Exec(ctx, `DELETE FROM core.processed_events WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("purge processed events: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
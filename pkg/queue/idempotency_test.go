@@ -0,0 +1,148 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeProcessedStore struct {
+	mu      sync.Mutex
+	records map[string]bool
+	markErr error
+}
+
+func newFakeProcessedStore() *fakeProcessedStore {
+	return &fakeProcessedStore{records: map[string]bool{}}
+}
+
+func (s *fakeProcessedStore) Claim(_ context.Context, group, eventID string, _ time.Duration) (ClaimStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	done, ok := s.records[group+"/"+eventID]
+	switch {
+	case !ok:
+		s.records[group+"/"+eventID] = false
+		return Claimed, nil
+	case done:
+		return AlreadyProcessed, nil
+	default:
+		return InProgress, nil
+	}
+}
+
+func (s *fakeProcessedStore) MarkProcessed(_ context.Context, group, eventID string, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.markErr != nil {
+		return s.markErr
+	}
+	s.records[group+"/"+eventID] = true
+	return nil
+}
+
+func (s *fakeProcessedStore) Unclaim(_ context.Context, group, eventID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if done := s.records[group+"/"+eventID]; !done {
+		delete(s.records, group+"/"+eventID)
+	}
+	return nil
+}
+
+func (s *fakeProcessedStore) PurgeExpired(context.Context) (int64, error) {
+	return 0, nil
+}
+
+func TestIdempotentSkipsDuplicates(t *testing.T) {
+	t.Parallel()
+
+	store := newFakeProcessedStore()
+	dedup := NewIdempotent(store, "analytics", time.Hour)
+	env := Envelope{ID: "evt-1"}
+
+	calls := 0
+	handler := func(context.Context) error {
+		calls++
+		return nil
+	}
+
+	for i := 0; i < 2; i++ {
+		duplicate, err := dedup.Handle(context.Background(), env, handler)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if duplicate != (i == 1) {
+			t.Fatalf("delivery %d: unexpected duplicate flag %v", i, duplicate)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected handler to run once, ran %d times", calls)
+	}
+
+	if duplicate, _ := NewIdempotent(store, "bpm", time.Hour).Handle(context.Background(), env, handler); duplicate {
+		t.Fatal("expected other group to process event independently")
+	}
+}
+
+func TestIdempotentClaimsBeforeHandling(t *testing.T) {
+	t.Parallel()
+
+	store := newFakeProcessedStore()
+	dedup := NewIdempotent(store, "analytics", time.Hour)
+	env := Envelope{ID: "evt-3"}
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	first := make(chan error, 1)
+	go func() {
+		_, err := dedup.Handle(context.Background(), env, func(context.Context) error {
+			close(started)
+			<-finish
+			return nil
+		})
+		first <- err
+	}()
+	<-started
+
+	ran := false
+	if _, err := dedup.Handle(context.Background(), env, func(context.Context) error { ran = true; return nil }); !errors.Is(err, ErrEventInProgress) || ran {
+		t.Fatalf("concurrent delivery: ran=%v, err=%v", ran, err)
+	}
+	close(finish)
+	if err := <-first; err != nil {
+		t.Fatalf("first delivery: %v", err)
+	}
+	if duplicate, err := dedup.Handle(context.Background(), env, func(context.Context) error { ran = true; return nil }); !duplicate || err != nil || ran {
+		t.Fatalf("redelivery after completion: duplicate=%v, ran=%v, err=%v", duplicate, ran, err)
+	}
+}
+
+func TestIdempotentFailures(t *testing.T) {
+	t.Parallel()
+
+	store := newFakeProcessedStore()
+	dedup := NewIdempotent(store, "analytics", time.Hour)
+	env := Envelope{ID: "evt-2"}
+
+	boom := errors.New("clickhouse down")
+	if _, err := dedup.Handle(context.Background(), env, func(context.Context) error { return boom }); !errors.Is(err, boom) {
+		t.Fatalf("expected handler error, got %v", err)
+	}
+	if _, claimed := store.records["analytics/evt-2"]; claimed {
+		t.Fatal("failed event must give its claim up")
+	}
+
+	store.markErr = errors.New("postgres down")
+	if _, err := dedup.Handle(context.Background(), env, func(context.Context) error { return nil }); !errors.Is(err, ErrMarkProcessed) {
+		t.Fatalf("expected ErrMarkProcessed, got %v", err)
+	}
+
+	var nilDedup *Idempotent
+	ran := false
+	if _, err := nilDedup.Handle(context.Background(), env, func(context.Context) error { ran = true; return nil }); err != nil || !ran {
+		t.Fatalf("expected nil helper to run handler, got %v, %v", ran, err)
+	}
+}
@@ -0,0 +1,239 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// jobSettler is implemented by backends to acknowledge, retry, postpone or dead-letter taken jobs.
+type jobSettler interface {
+	ack(ctx context.Context, id any) error
+	release(ctx context.Context, id any, delay time.Duration) (bool, error)
+	postpone(ctx context.Context, id any, delay time.Duration) error
+	bury(ctx context.Context, id any, reason string) error
+}
+
+// Job is a taken queue task awaiting settlement.
+type Job struct {
+	id       any
+	tube     string
+	envelope Envelope
+	attempts int
+	legacy   bool
+	settled  bool
+	settler  jobSettler
+	span     trace.Span
+}
+
+// ID returns the Tarantool task identifier.
+func (j *Job) ID() string {
+	return fmt.Sprintf("%v", j.id)
+}
+
+// Envelope returns the event envelope carried by the job.
+func (j *Job) Envelope() Envelope {
+	return j.envelope
+}
+
+// EventType returns the event type the job was published with.
+func (j *Job) EventType() string {
+	return j.envelope.Type
+}
+
+// Payload returns the raw JSON payload.
+func (j *Job) Payload() json.RawMessage {
+	return j.envelope.Payload
+}
+
+// Attempts returns how many times the job was released before this delivery.
+func (j *Job) Attempts() int {
+	return j.attempts
+}
+
+// Context returns parent carrying the job span and correlation ID, for handlers to pass downstream.
+func (j *Job) Context(parent context.Context) context.Context {
+	ctx := WithCorrelationID(parent, j.envelope.CorrelationID)
+	if j.span != nil {
+		ctx = trace.ContextWithSpan(ctx, j.span)
+	}
+	return ctx
+}
+
+// Decode unmarshals the payload into out.
+func (j *Job) Decode(out any) error {
+	return j.envelope.Decode(out)
+}
+
+// Ack removes the job from the tube after successful processing.
+func (j *Job) Ack(ctx context.Context) error {
+	if j.settled {
+		return ErrJobSettled
+	}
+	if err := j.settler.ack(ctx, j.id); err != nil {
+		return err
+	}
+	j.settled = true
+	settledTotal.WithLabelValues(j.tube, "ack").Inc()
+	endConsume(j, "ack", "")
+	return nil
+}
+
+// Release returns the job to the tube for another attempt after delay.
+// When the attempt limit is reached the job goes to the dead-letter tube and the
+// returned flag is true.
+func (j *Job) Release(ctx context.Context, delay time.Duration) (bool, error) {
+	if j.settled {
+		return false, ErrJobSettled
+	}
+	dead, err := j.settler.release(ctx, j.id, delay)
+	if err != nil {
+		return false, err
+	}
+	j.settled = true
+	outcome := "release"
+	if dead {
+		outcome = "dead"
+	}
+	settledTotal.WithLabelValues(j.tube, outcome).Inc()
+	endConsume(j, outcome, "")
+	return dead, nil
+}
+
+// Postpone returns the job to the tube after delay without counting an attempt, for
+// deliveries that were not processed at all, e.g. while another consumer holds the claim.
+func (j *Job) Postpone(ctx context.Context, delay time.Duration) error {
+	if j.settled {
+		return ErrJobSettled
+	}
+	if err := j.settler.postpone(ctx, j.id, delay); err != nil {
+		return err
+	}
+	j.settled = true
+	settledTotal.WithLabelValues(j.tube, "postpone").Inc()
+	endConsume(j, "postpone", "")
+	return nil
+}
+
+// Bury moves the job to the dead-letter tube without further retries.
+func (j *Job) Bury(ctx context.Context, reason string) error {
+	if j.settled {
+		return ErrJobSettled
+	}
+	if err := j.settler.bury(ctx, j.id, reason); err != nil {
+		return err
+	}
+	j.settled = true
+	settledTotal.WithLabelValues(j.tube, "bury").Inc()
+	endConsume(j, "bury", reason)
+	return nil
+}
+
+// prepareJob upcasts and validates envelopes of registered event types.
+func prepareJob(registry *Registry, job *Job) error {
+	if job.envelope.Type == "" {
+		return fmt.Errorf("%w: job %s has no event type", ErrInvalidEnvelope, job.ID())
+	}
+	if _, known := registry.LatestVersion(job.envelope.Type); !known {
+		return nil
+	}
+
+	env, err := registry.Upcast(job.envelope)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+	if job.legacy {
+		err = registry.ValidatePayload(env.Type, env.Version, env.Payload)
+	} else {
+		err = registry.Validate(env)
+	}
+	if err != nil {
+		return err
+	}
+	job.envelope = env
+	return nil
+}
+
+func parseJob(resp []any) (*Job, error) {
+	if len(resp) == 0 || resp[0] == nil {
+		return nil, nil
+	}
+
+	task, ok := resp[0].([]any)
+	if !ok || len(task) < 3 {
+		return nil, fmt.Errorf("unexpected job payload: %v", resp)
+	}
+
+	job := &Job{id: task[0]}
+
+	meta := toStringMap(task[2])
+
+	job.attempts = toInt(meta["attempts"])
+
+	if raw, ok := meta["envelope"].(string); ok {
+		if err := json.Unmarshal([]byte(raw), &job.envelope); err != nil {
+			return job, fmt.Errorf("%w: decode: %v", ErrInvalidEnvelope, err)
+		}
+		return job, nil
+	}
+
+	// Messages published before envelopes were introduced carry only type and payload.
+	if eventType, ok := meta["event_type"].(string); ok {
+		job.envelope.Type = eventType
+	}
+	if payload, ok := meta["payload"].(string); ok {
+		job.envelope.Payload = json.RawMessage(payload)
+	}
+	job.envelope.Version = 1
+	job.legacy = true
+
+	return job, nil
+}
+
+// toStringMap normalises msgpack maps decoded with either string or generic keys.
+func toStringMap(value any) map[string]any {
+	switch v := value.(type) {
+	case map[string]any:
+		return v
+	case map[any]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[fmt.Sprintf("%v", k)] = item
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+func toInt(value any) int {
+	switch v := value.(type) {
+	case int:
+		return v
+	case int8:
+		return int(v)
+	case int16:
+		return int(v)
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case uint:
+		return int(v)
+	case uint8:
+		return int(v)
+	case uint16:
+		return int(v)
+	case uint32:
+		return int(v)
+	case uint64:
+		return int(v)
+	case float64:
+		return int(v)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,117 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseJob(t *testing.T) {
+	t.Parallel()
+
+	resp := []any{[]any{uint64(42), "t", map[string]any{
+		"event_type": "DealCreated",
+		"payload":    `{"id":"d-1"}`,
+		"attempts":   int8(2),
+	}}}
+
+	job, err := parseJob(resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if job == nil {
+		t.Fatal("expected job")
+	}
+	if job.ID() != "42" {
+		t.Fatalf("unexpected id: %s", job.ID())
+	}
+	if job.EventType() != "DealCreated" {
+		t.Fatalf("unexpected event type: %s", job.EventType())
+	}
+	if job.Attempts() != 2 {
+		t.Fatalf("unexpected attempts: %d", job.Attempts())
+	}
+
+	var payload struct {
+		ID string `json:"id"`
+	}
+	if err := job.Decode(&payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if payload.ID != "d-1" {
+		t.Fatalf("unexpected payload id: %s", payload.ID)
+	}
+}
+
+func TestParseJobEmpty(t *testing.T) {
+	t.Parallel()
+
+	job, err := parseJob(nil)
+	if err != nil || job != nil {
+		t.Fatalf("expected no job, got %v, %v", job, err)
+	}
+
+	job, err = parseJob([]any{nil})
+	if err != nil || job != nil {
+		t.Fatalf("expected no job for nil task, got %v, %v", job, err)
+	}
+
+	if _, err := parseJob([]any{"garbage"}); err == nil {
+		t.Fatal("expected error for malformed task")
+	}
+}
+
+func TestNewConsumerDefaults(t *testing.T) {
+	t.Parallel()
+
+	c := newTarantoolConsumer(nil, ConsumerConfig{Tube: "events_queue"})
+	if c.DeadLetterTube() != "events_queue_dead" {
+		t.Fatalf("unexpected dead-letter tube: %s", c.DeadLetterTube())
+	}
+	if c.MaxAttempts() != defaultMaxAttempts {
+		t.Fatalf("unexpected max attempts: %d", c.MaxAttempts())
+	}
+}
+
+func TestJobSettledOnce(t *testing.T) {
+	t.Parallel()
+
+	job := &Job{settled: true}
+	if err := job.Ack(context.Background()); err != ErrJobSettled {
+		t.Fatalf("expected ErrJobSettled, got %v", err)
+	}
+}
+
+func TestParseJobEnvelope(t *testing.T) {
+	t.Parallel()
+
+	env, err := NewEnvelope(WithCorrelationID(context.Background(), "req-1"), "DealCreated", 1, "crm", map[string]any{"id": "d-2"})
+	if err != nil {
+		t.Fatalf("new envelope: %v", err)
+	}
+	raw, err := json.Marshal(env)
+	if err != nil {
+		t.Fatalf("marshal envelope: %v", err)
+	}
+
+	job, err := parseJob([]any{[]any{uint64(7), "r", map[string]any{
+		"event_type": "DealCreated",
+		"envelope":   string(raw),
+	}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if job.legacy {
+		t.Fatal("expected envelope job")
+	}
+	got := job.Envelope()
+	if got.ID != env.ID || got.Producer != "crm" || got.CorrelationID != "req-1" {
+		t.Fatalf("unexpected envelope: %+v", got)
+	}
+
+	job, err = parseJob([]any{[]any{uint64(8), "r", map[string]any{"envelope": "{broken"}}})
+	if !errors.Is(err, ErrInvalidEnvelope) || job == nil {
+		t.Fatalf("expected invalid envelope job, got %v, %v", job, err)
+	}
+}
@@ -0,0 +1,584 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"asfppro/pkg/tracing"
+)
+
+// MemoryBroker is an in-process queue with the same delivery semantics as the Tarantool
+// backend: topic fan-out to group tubes, take/ack, delayed release with attempt counting
+// and dead-lettering. It is meant for tests and single-binary dev mode.
+type MemoryBroker struct {
+	mu      sync.Mutex
+	nextID  uint64
+	tubes   map[string]*memoryTube
+	routes  map[string]memoryRoute
+	paused  map[string]bool
+	changed chan struct{}
+}
+
+type memoryRoute struct {
+	tube   string
+	topics []string
+}
+
+type memoryTube struct {
+	ready   []*memoryTask
+	delayed []*memoryTask
+	taken   map[uint64]*memoryTask
+}
+
+type memoryTask struct {
+	id      uint64
+	data    map[string]any
+	readyAt time.Time
+}
+
+// NewMemoryBroker returns an empty broker.
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		tubes:   make(map[string]*memoryTube),
+		routes:  make(map[string]memoryRoute),
+		paused:  make(map[string]bool),
+		changed: make(chan struct{}),
+	}
+}
+
+var (
+	defaultMemoryBroker     *MemoryBroker
+	defaultMemoryBrokerOnce sync.Once
+)
+
+// DefaultMemoryBroker returns the process-wide broker shared by memory publishers and consumers.
+func DefaultMemoryBroker() *MemoryBroker {
+	defaultMemoryBrokerOnce.Do(func() {
+		defaultMemoryBroker = NewMemoryBroker()
+	})
+	return defaultMemoryBroker
+}
+
+// Publisher returns publisher bound to the broker and declares configured subscriptions.
+func (b *MemoryBroker) Publisher(cfg PublisherConfig) (*MemoryPublisher, error) {
+	registry := cfg.Registry
+	if registry == nil {
+		registry = DefaultRegistry()
+	}
+
+	p := &MemoryPublisher{broker: b, tube: cfg.Tube, producer: cfg.Producer, registry: registry}
+	if err := p.Declare(context.Background(), cfg.Subscriptions...); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Consumer returns consumer bound to the broker and declares the group subscription when set.
+func (b *MemoryBroker) Consumer(cfg ConsumerConfig) (*MemoryConsumer, error) {
+	if strings.TrimSpace(cfg.Tube) == "" {
+		return nil, errors.New("consumer tube is required")
+	}
+	if cfg.Group != "" {
+		if err := b.declare(cfg.Tube, Subscription{Group: cfg.Group, Topics: cfg.Topics}); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg = cfg.normalized()
+	b.mu.Lock()
+	b.tube(cfg.Tube)
+	b.mu.Unlock()
+
+	return &MemoryConsumer{broker: b, cfg: cfg}, nil
+}
+
+func (b *MemoryBroker) declare(base string, sub Subscription) error {
+	if !validName(sub.Group) || len(sub.Topics) == 0 {
+		return fmt.Errorf("declare %q: group name and topics required", sub.Group)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	tube := sub.Tube(base)
+	b.tube(tube)
+	b.routes[sub.Group] = memoryRoute{tube: tube, topics: append([]string(nil), sub.Topics...)}
+	return nil
+}
+
+func (b *MemoryBroker) fanout(topic string, message map[string]any, fallback string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delivered := b.deliver(topic, message)
+	if delivered == 0 && fallback != "" {
+		b.put(fallback, copyMessage(message), 0)
+	}
+	return delivered
+}
+
+// deliver puts message into every group tube subscribed to topic. b.mu must be held.
+func (b *MemoryBroker) deliver(topic string, message map[string]any) int {
+	delivered := 0
+	for group, route := range b.routes {
+		if !(Subscription{Group: group, Topics: route.topics}).Matches(topic) {
+			continue
+		}
+		b.put(route.tube, copyMessage(message), 0)
+		delivered++
+	}
+	return delivered
+}
+
+// drain moves ready tasks of the base tube to the group tubes subscribed to them. Tasks
+// without subscribers stay in the base tube.
+func (b *MemoryBroker) drain(base string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t := b.tube(base)
+	moved := 0
+	kept := t.ready[:0]
+	for _, task := range t.ready {
+		topic, _ := task.data["event_type"].(string)
+		if b.deliver(topic, task.data) == 0 {
+			kept = append(kept, task)
+			continue
+		}
+		moved++
+	}
+	t.ready = kept
+	return moved
+}
+
+// take pops the first ready task and reports when the next delayed task becomes ready.
+func (b *MemoryBroker) take(tube string) (*memoryTask, time.Time, <-chan struct{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.paused[tube] {
+		return nil, time.Time{}, b.changed
+	}
+
+	t := b.tube(tube)
+	now := time.Now()
+
+	var (
+		pending []*memoryTask
+		nextDue time.Time
+	)
+	for _, task := range t.delayed {
+		if !task.readyAt.After(now) {
+			t.ready = append(t.ready, task)
+			continue
+		}
+		pending = append(pending, task)
+		if nextDue.IsZero() || task.readyAt.Before(nextDue) {
+			nextDue = task.readyAt
+		}
+	}
+	t.delayed = pending
+
+	if len(t.ready) == 0 {
+		return nil, nextDue, b.changed
+	}
+
+	task := t.ready[0]
+	t.ready = t.ready[1:]
+	t.taken[task.id] = task
+	return task, nextDue, b.changed
+}
+
+func (b *MemoryBroker) settle(tube string, id any) (*memoryTask, error) {
+	taskID, ok := id.(uint64)
+	if !ok {
+		return nil, fmt.Errorf("unexpected task id %v", id)
+	}
+	t := b.tube(tube)
+	task, ok := t.taken[taskID]
+	if !ok {
+		return nil, fmt.Errorf("task %d is not taken", taskID)
+	}
+	delete(t.taken, taskID)
+	return task, nil
+}
+
+func (b *MemoryBroker) put(tube string, data map[string]any, delay time.Duration) {
+	b.nextID++
+	task := &memoryTask{id: b.nextID, data: data}
+	t := b.tube(tube)
+	if delay > 0 {
+		task.readyAt = time.Now().Add(delay)
+		t.delayed = append(t.delayed, task)
+	} else {
+		t.ready = append(t.ready, task)
+	}
+	b.notify()
+}
+
+// notify wakes consumers waiting in take.
+func (b *MemoryBroker) notify() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+func (b *MemoryBroker) tube(name string) *memoryTube {
+	t, ok := b.tubes[name]
+	if !ok {
+		t = &memoryTube{taken: make(map[uint64]*memoryTask)}
+		b.tubes[name] = t
+	}
+	return t
+}
+
+func copyMessage(message map[string]any) map[string]any {
+	out := make(map[string]any, len(message))
+	for k, v := range message {
+		out[k] = v
+	}
+	return out
+}
+
+func deadLetter(data map[string]any, tube, reason string) map[string]any {
+	out := copyMessage(data)
+	out["source_tube"] = tube
+	out["dead_reason"] = reason
+	out["failed_at"] = time.Now().Unix()
+	return out
+}
+
+// MemoryPublisher publishes events into a MemoryBroker.
+type MemoryPublisher struct {
+	broker   *MemoryBroker
+	tube     string
+	producer string
+	registry *Registry
+}
+
+// Publish wraps payload into the latest version envelope of eventType and routes it.
+func (p *MemoryPublisher) Publish(ctx context.Context, eventType string, payload any) error {
+	env, err := newLatestEnvelope(ctx, p.registry, eventType, p.producer, payload)
+	if err != nil {
+		return err
+	}
+	return p.PublishEnvelope(ctx, env)
+}
+
+// PublishEnvelope validates envelope and fans it out to subscribed group tubes.
+func (p *MemoryPublisher) PublishEnvelope(ctx context.Context, env Envelope) (err error) {
+	_, span := startPublish(ctx, &env, p.tube)
+	defer func() { tracing.End(span, err) }()
+
+	if err := p.registry.Validate(env); err != nil {
+		return observePublish(env.Type, err)
+	}
+
+	message, err := encodeMessage(env)
+	if err != nil {
+		return observePublish(env.Type, err)
+	}
+
+	p.broker.fanout(env.Type, message, p.tube)
+	return observePublish(env.Type, nil)
+}
+
+// Declare registers subscriber groups in the broker and moves the backlog of the base tube
+// to them.
+func (p *MemoryPublisher) Declare(_ context.Context, subs ...Subscription) error {
+	for _, sub := range subs {
+		if err := p.broker.declare(p.tube, sub); err != nil {
+			return err
+		}
+	}
+	if len(subs) > 0 {
+		p.broker.drain(p.tube)
+	}
+	return nil
+}
+
+// Ping always succeeds for the in-memory broker.
+func (p *MemoryPublisher) Ping(context.Context) error {
+	return nil
+}
+
+// Close is a no-op; the broker outlives its publishers.
+func (p *MemoryPublisher) Close() {}
+
+// MemoryConsumer takes jobs from a MemoryBroker tube.
+type MemoryConsumer struct {
+	broker *MemoryBroker
+	cfg    ConsumerConfig
+}
+
+// Next takes one job from the tube; see Consumer.
+func (c *MemoryConsumer) Next(ctx context.Context) (*Job, error) {
+	deadline := time.Now().Add(c.cfg.TakeTimeout)
+
+	for {
+		task, nextDue, changed := c.broker.take(c.cfg.Tube)
+		if task != nil {
+			job, err := parseJob([]any{[]any{task.id, "t", copyMessage(task.data)}})
+			if job == nil {
+				return nil, err
+			}
+			job.settler = c
+			job.tube = c.cfg.Tube
+			if err == nil {
+				err = prepareJob(c.cfg.Registry, job)
+			}
+			if err != nil {
+				if buryErr := job.Bury(ctx, err.Error()); buryErr != nil {
+					return nil, fmt.Errorf("%w (bury failed: %v)", err, buryErr)
+				}
+				return nil, err
+			}
+			observeConsumed(job)
+			startConsume(job)
+			return job, nil
+		}
+
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return nil, nil
+		}
+		if !nextDue.IsZero() && time.Until(nextDue) < wait {
+			wait = time.Until(nextDue)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("take job: %w", ctx.Err())
+		case <-changed:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// Tube returns the tube the consumer takes jobs from.
+func (c *MemoryConsumer) Tube() string {
+	return c.cfg.Tube
+}
+
+// Close is a no-op; taken jobs stay taken until settled.
+func (c *MemoryConsumer) Close() {}
+
+func (c *MemoryConsumer) ack(_ context.Context, id any) error {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+
+	if _, err := c.broker.settle(c.cfg.Tube, id); err != nil {
+		return fmt.Errorf("ack job: %w", err)
+	}
+	return nil
+}
+
+func (c *MemoryConsumer) release(_ context.Context, id any, delay time.Duration) (bool, error) {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+
+	task, err := c.broker.settle(c.cfg.Tube, id)
+	if err != nil {
+		return false, fmt.Errorf("release job: %w", err)
+	}
+
+	data := copyMessage(task.data)
+	data["attempts"] = toInt(data["attempts"]) + 1
+	if data["attempts"].(int) >= c.cfg.MaxAttempts {
+		c.broker.put(c.cfg.DeadLetterTube, deadLetter(data, c.cfg.Tube, "max attempts exceeded"), 0)
+		return true, nil
+	}
+	c.broker.put(c.cfg.Tube, data, delay)
+	return false, nil
+}
+
+func (c *MemoryConsumer) postpone(_ context.Context, id any, delay time.Duration) error {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+
+	task, err := c.broker.settle(c.cfg.Tube, id)
+	if err != nil {
+		return fmt.Errorf("postpone job: %w", err)
+	}
+	c.broker.put(c.cfg.Tube, copyMessage(task.data), delay)
+	return nil
+}
+
+func (c *MemoryConsumer) bury(_ context.Context, id any, reason string) error {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+
+	task, err := c.broker.settle(c.cfg.Tube, id)
+	if err != nil {
+		return fmt.Errorf("bury job: %w", err)
+	}
+	c.broker.put(c.cfg.DeadLetterTube, deadLetter(task.data, c.cfg.Tube, reason), 0)
+	return nil
+}
+
+// MemoryAdmin administers MemoryBroker tubes.
+type MemoryAdmin struct {
+	broker *MemoryBroker
+}
+
+// Admin returns admin bound to the broker.
+func (b *MemoryBroker) Admin() *MemoryAdmin {
+	return &MemoryAdmin{broker: b}
+}
+
+// Stats returns counters of live tubes; see Admin.
+func (a *MemoryAdmin) Stats(context.Context) ([]TubeStats, error) {
+	a.broker.mu.Lock()
+	defer a.broker.mu.Unlock()
+
+	raw := make([]TubeStats, 0, len(a.broker.tubes))
+	for name, t := range a.broker.tubes {
+		raw = append(raw, TubeStats{
+			Tube:    name,
+			Ready:   len(t.ready),
+			Taken:   len(t.taken),
+			Delayed: len(t.delayed),
+			Paused:  a.broker.paused[name],
+		})
+	}
+	return foldDeadLetters(raw), nil
+}
+
+// DeadLetters lists dead-lettered messages of tube; see Admin.
+func (a *MemoryAdmin) DeadLetters(_ context.Context, tube string, afterID uint64, limit int) ([]DeadLetter, error) {
+	if err := checkTube(tube); err != nil {
+		return nil, err
+	}
+
+	a.broker.mu.Lock()
+	defer a.broker.mu.Unlock()
+
+	dead, ok := a.broker.tubes[DeadLetterTube(tube)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTubeNotFound, DeadLetterTube(tube))
+	}
+
+	tasks := append(append([]*memoryTask(nil), dead.ready...), dead.delayed...)
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].id < tasks[j].id })
+
+	limit = normalizeLimit(limit)
+	items := make([]DeadLetter, 0, limit)
+	for _, task := range tasks {
+		if task.id <= afterID {
+			continue
+		}
+		if len(items) >= limit {
+			break
+		}
+		items = append(items, newDeadLetter(task.id, task.data))
+	}
+	return items, nil
+}
+
+// Requeue moves a dead-lettered message back to its source tube; see Admin.
+func (a *MemoryAdmin) Requeue(_ context.Context, tube string, id uint64) error {
+	if err := checkTube(tube); err != nil {
+		return err
+	}
+
+	a.broker.mu.Lock()
+	defer a.broker.mu.Unlock()
+
+	task, err := a.broker.removeDead(tube, id, true)
+	if err != nil {
+		return err
+	}
+
+	data := copyMessage(task.data)
+	target := requeueTarget(data, tube)
+	delete(data, "source_tube")
+	delete(data, "dead_reason")
+	delete(data, "failed_at")
+	data["attempts"] = 0
+
+	a.broker.put(target, data, 0)
+	return nil
+}
+
+// Delete drops a dead-lettered message; see Admin.
+func (a *MemoryAdmin) Delete(_ context.Context, tube string, id uint64) error {
+	if err := checkTube(tube); err != nil {
+		return err
+	}
+
+	a.broker.mu.Lock()
+	defer a.broker.mu.Unlock()
+
+	_, err := a.broker.removeDead(tube, id, false)
+	return err
+}
+
+// Pause stops consumers of tube; see Admin.
+func (a *MemoryAdmin) Pause(_ context.Context, tube string) error {
+	return a.setPaused(tube, true)
+}
+
+// Resume lets consumers of tube take jobs again.
+func (a *MemoryAdmin) Resume(_ context.Context, tube string) error {
+	return a.setPaused(tube, false)
+}
+
+func (a *MemoryAdmin) setPaused(tube string, paused bool) error {
+	a.broker.mu.Lock()
+	defer a.broker.mu.Unlock()
+
+	if _, ok := a.broker.tubes[tube]; !ok {
+		return fmt.Errorf("%w: %s", ErrTubeNotFound, tube)
+	}
+	if paused {
+		a.broker.paused[tube] = true
+	} else {
+		delete(a.broker.paused, tube)
+	}
+	a.broker.notify()
+	return nil
+}
+
+// Close is a no-op; the broker outlives its admins.
+func (a *MemoryAdmin) Close() {}
+
+// removeDead removes task id from the dead-letter tube of tube. When requeue is set the
+// source tube must exist. Callers hold b.mu.
+func (b *MemoryBroker) removeDead(tube string, id uint64, requeue bool) (*memoryTask, error) {
+	dead, ok := b.tubes[DeadLetterTube(tube)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrMessageNotFound, id)
+	}
+
+	for _, list := range []*[]*memoryTask{&dead.ready, &dead.delayed} {
+		for i, task := range *list {
+			if task.id != id {
+				continue
+			}
+			if requeue {
+				target := requeueTarget(task.data, tube)
+				if _, exists := b.tubes[target]; !exists {
+					return nil, fmt.Errorf("%w: %s", ErrTubeNotFound, target)
+				}
+			}
+			*list = append((*list)[:i], (*list)[i+1:]...)
+			return task, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %d", ErrMessageNotFound, id)
+}
+
+// requeueTarget returns the tube a dead letter came from, falling back to tube.
+func requeueTarget(data map[string]any, tube string) string {
+	if source, ok := data["source_tube"].(string); ok && source != "" {
+		return source
+	}
+	return tube
+}
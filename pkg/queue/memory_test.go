@@ -0,0 +1,296 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func dealPayload(id string) map[string]any {
+	return map[string]any{
+		"id":        id,
+		"stage":     "new",
+		"amount":    100.0,
+		"currency":  "RUB",
+		"createdAt": "2025-10-01T10:00:00Z",
+	}
+}
+
+func TestMemoryBrokerFanOut(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	broker := NewMemoryBroker()
+
+	publisher, err := NewPublisher(PublisherConfig{Backend: BackendMemory, Broker: broker, Tube: "events", Producer: "crm"})
+	if err != nil {
+		t.Fatalf("new publisher: %v", err)
+	}
+	analytics, err := NewConsumer(ConsumerConfig{Backend: BackendMemory, Broker: broker, Tube: "events", Group: "analytics", Topics: []string{"DealCreated"}, TakeTimeout: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("new analytics consumer: %v", err)
+	}
+	bpm, err := NewConsumer(ConsumerConfig{Backend: BackendMemory, Broker: broker, Tube: "events", Group: "bpm", Topics: []string{"Deal*"}, TakeTimeout: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("new bpm consumer: %v", err)
+	}
+
+	if err := publisher.Publish(ctx, "DealCreated", dealPayload("d-1")); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	for _, consumer := range []Consumer{analytics, bpm} {
+		job, err := consumer.Next(ctx)
+		if err != nil || job == nil {
+			t.Fatalf("%s: expected job, got %v, %v", consumer.Tube(), job, err)
+		}
+		if job.Envelope().Producer != "crm" {
+			t.Fatalf("%s: unexpected producer %q", consumer.Tube(), job.Envelope().Producer)
+		}
+		if err := job.Ack(ctx); err != nil {
+			t.Fatalf("%s: ack: %v", consumer.Tube(), err)
+		}
+		if job, _ := consumer.Next(ctx); job != nil {
+			t.Fatalf("%s: expected empty tube after ack", consumer.Tube())
+		}
+	}
+
+	if err := publisher.Publish(ctx, "Unregistered", map[string]any{}); !errors.Is(err, ErrUnknownEvent) {
+		t.Fatalf("expected ErrUnknownEvent, got %v", err)
+	}
+}
+
+func TestMemoryBrokerRetriesAndDeadLetter(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	broker := NewMemoryBroker()
+
+	publisher, err := broker.Publisher(PublisherConfig{Tube: "events", Producer: "crm"})
+	if err != nil {
+		t.Fatalf("new publisher: %v", err)
+	}
+	consumer, err := broker.Consumer(ConsumerConfig{Tube: "events", Group: "analytics", Topics: []string{"*"}, MaxAttempts: 2, TakeTimeout: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("new consumer: %v", err)
+	}
+	dead, err := broker.Consumer(ConsumerConfig{Tube: "events_analytics_dead", TakeTimeout: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("new dead-letter consumer: %v", err)
+	}
+
+	if err := publisher.Publish(ctx, "DealCreated", dealPayload("d-2")); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	job, err := consumer.Next(ctx)
+	if err != nil || job == nil {
+		t.Fatalf("expected first delivery, got %v, %v", job, err)
+	}
+	if deadLettered, err := job.Release(ctx, 20*time.Millisecond); err != nil || deadLettered {
+		t.Fatalf("expected delayed release, got %v, %v", deadLettered, err)
+	}
+	if _, err := job.Release(ctx, 0); !errors.Is(err, ErrJobSettled) {
+		t.Fatalf("expected ErrJobSettled, got %v", err)
+	}
+
+	started := time.Now()
+	job, err = consumer.Next(ctx)
+	if err != nil || job == nil {
+		t.Fatalf("expected redelivery, got %v, %v", job, err)
+	}
+	if time.Since(started) < 10*time.Millisecond {
+		t.Fatal("expected redelivery to honour release delay")
+	}
+	if job.Attempts() != 1 {
+		t.Fatalf("expected 1 previous attempt, got %d", job.Attempts())
+	}
+	if deadLettered, err := job.Release(ctx, 0); err != nil || !deadLettered {
+		t.Fatalf("expected dead-lettering on max attempts, got %v, %v", deadLettered, err)
+	}
+
+	job, err = dead.Next(ctx)
+	if err != nil || job == nil {
+		t.Fatalf("expected job in dead-letter tube, got %v, %v", job, err)
+	}
+	var payload struct {
+		ID string `json:"id"`
+	}
+	if err := job.Decode(&payload); err != nil || payload.ID != "d-2" {
+		t.Fatalf("unexpected dead-letter payload %q, %v", payload.ID, err)
+	}
+}
+
+func TestMemoryBrokerPostponeKeepsAttempts(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	broker := NewMemoryBroker()
+
+	publisher, err := broker.Publisher(PublisherConfig{Tube: "events", Producer: "crm"})
+	if err != nil {
+		t.Fatalf("new publisher: %v", err)
+	}
+	consumer, err := broker.Consumer(ConsumerConfig{Tube: "events", Group: "analytics", Topics: []string{"*"}, MaxAttempts: 2, TakeTimeout: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("new consumer: %v", err)
+	}
+
+	if err := publisher.Publish(ctx, "DealCreated", dealPayload("d-5")); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	// Postponing more often than MaxAttempts must neither count attempts nor dead-letter.
+	for i := 0; i < 3; i++ {
+		job, err := consumer.Next(ctx)
+		if err != nil || job == nil {
+			t.Fatalf("delivery %d: expected job, got %v, %v", i, job, err)
+		}
+		if job.Attempts() != 0 {
+			t.Fatalf("delivery %d: expected no previous attempts, got %d", i, job.Attempts())
+		}
+		if err := job.Postpone(ctx, 10*time.Millisecond); err != nil {
+			t.Fatalf("delivery %d: postpone: %v", i, err)
+		}
+		if err := job.Postpone(ctx, 0); !errors.Is(err, ErrJobSettled) {
+			t.Fatalf("delivery %d: expected ErrJobSettled, got %v", i, err)
+		}
+	}
+
+	job, err := consumer.Next(ctx)
+	if err != nil || job == nil {
+		t.Fatalf("expected postponed job, got %v, %v", job, err)
+	}
+	if deadLettered, err := job.Release(ctx, 0); err != nil || deadLettered {
+		t.Fatalf("expected release to count the first attempt, got %v, %v", deadLettered, err)
+	}
+}
+
+func TestMemoryBrokerFallbackTube(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	broker := NewMemoryBroker()
+
+	publisher, err := broker.Publisher(PublisherConfig{Tube: "events"})
+	if err != nil {
+		t.Fatalf("new publisher: %v", err)
+	}
+	if err := publisher.Publish(ctx, "DealCreated", dealPayload("d-3")); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	consumer, err := broker.Consumer(ConsumerConfig{Tube: "events", TakeTimeout: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("new consumer: %v", err)
+	}
+	job, err := consumer.Next(ctx)
+	if err != nil || job == nil {
+		t.Fatalf("expected unrouted message in base tube, got %v, %v", job, err)
+	}
+	if err := job.Bury(ctx, "manual"); err != nil {
+		t.Fatalf("bury: %v", err)
+	}
+}
+
+func TestMemoryBrokerPropagatesTraceContext(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	broker := NewMemoryBroker()
+	publisher, err := NewPublisher(PublisherConfig{Backend: BackendMemory, Broker: broker, Tube: "events", Producer: "crm"})
+	if err != nil {
+		t.Fatalf("new publisher: %v", err)
+	}
+	consumer, err := NewConsumer(ConsumerConfig{Backend: BackendMemory, Broker: broker, Tube: "events", Group: "analytics", Topics: []string{"DealCreated"}, TakeTimeout: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("new consumer: %v", err)
+	}
+
+	ctx, request := otel.Tracer("test").Start(context.Background(), "request")
+	if err := publisher.Publish(ctx, "DealCreated", dealPayload("d-trace")); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	request.End()
+
+	job, err := consumer.Next(context.Background())
+	if err != nil || job == nil {
+		t.Fatalf("expected job, got %v, %v", job, err)
+	}
+	if job.Envelope().TraceContext["traceparent"] == "" {
+		t.Fatalf("envelope has no trace context")
+	}
+	jobSpan := trace.SpanContextFromContext(job.Context(context.Background()))
+	if jobSpan.TraceID() != request.SpanContext().TraceID() {
+		t.Fatalf("consumer span not in request trace")
+	}
+	if err := job.Ack(context.Background()); err != nil {
+		t.Fatalf("ack: %v", err)
+	}
+
+	kinds := map[trace.SpanKind]bool{}
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID() == request.SpanContext().TraceID() {
+			kinds[span.SpanKind()] = true
+		}
+	}
+	if !kinds[trace.SpanKindProducer] || !kinds[trace.SpanKindConsumer] {
+		t.Fatalf("expected producer and consumer spans, got %v", kinds)
+	}
+}
+
+func TestMemoryBrokerDrainsBaseTube(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	broker := NewMemoryBroker()
+
+	publisher, err := broker.Publisher(PublisherConfig{Tube: "events"})
+	if err != nil {
+		t.Fatalf("new publisher: %v", err)
+	}
+	for _, id := range []string{"d-1", "d-2"} {
+		if err := publisher.Publish(ctx, "DealCreated", dealPayload(id)); err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+	}
+	if err := publisher.Declare(ctx, Subscription{Group: "analytics", Topics: []string{"DealCreated"}}); err != nil {
+		t.Fatalf("declare: %v", err)
+	}
+
+	consumer, err := broker.Consumer(ConsumerConfig{Tube: "events", Group: "analytics", Topics: []string{"DealCreated"}, TakeTimeout: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("new consumer: %v", err)
+	}
+	for _, want := range []string{"d-1", "d-2"} {
+		job, err := consumer.Next(ctx)
+		if err != nil || job == nil {
+			t.Fatalf("expected backlog message %s in group tube, got %v, %v", want, job, err)
+		}
+		var payload struct {
+			ID string `json:"id"`
+		}
+		if err := job.Decode(&payload); err != nil || payload.ID != want {
+			t.Fatalf("unexpected payload %q, %v", payload.ID, err)
+		}
+		if err := job.Ack(ctx); err != nil {
+			t.Fatalf("ack: %v", err)
+		}
+	}
+
+	base, err := broker.Consumer(ConsumerConfig{Tube: "events", TakeTimeout: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("new base consumer: %v", err)
+	}
+	if job, err := base.Next(ctx); err != nil || job != nil {
+		t.Fatalf("expected drained base tube, got %v, %v", job, err)
+	}
+}
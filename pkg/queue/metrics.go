@@ -0,0 +1,49 @@
+package queue
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"asfppro/pkg/metrics"
+)
+
+var (
+	publishedTotal = metrics.Register(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "queue_published_total",
+		Help: "Events published to the queue.",
+	}, []string{"event_type"}))
+	publishErrors = metrics.Register(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "queue_publish_errors_total",
+		Help: "Events that failed validation or delivery.",
+	}, []string{"event_type"}))
+	consumedTotal = metrics.Register(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "queue_consumed_total",
+		Help: "Jobs taken by consumers.",
+	}, []string{"tube", "event_type"}))
+	settledTotal = metrics.Register(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "queue_settled_total",
+		Help: "Jobs settled by outcome (ack, release, postpone, dead, bury).",
+	}, []string{"tube", "outcome"}))
+	consumeLag = metrics.Register(prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "queue_consume_lag_seconds",
+		Help:    "Time from event occurrence to consumption.",
+		Buckets: []float64{0.1, 0.5, 1, 5, 15, 60, 300, 900, 3600},
+	}, []string{"tube"}))
+)
+
+func observePublish(eventType string, err error) error {
+	if err != nil {
+		publishErrors.WithLabelValues(eventType).Inc()
+		return err
+	}
+	publishedTotal.WithLabelValues(eventType).Inc()
+	return nil
+}
+
+func observeConsumed(job *Job) {
+	consumedTotal.WithLabelValues(job.tube, job.envelope.Type).Inc()
+	if !job.envelope.OccurredAt.IsZero() {
+		consumeLag.WithLabelValues(job.tube).Observe(time.Since(job.envelope.OccurredAt).Seconds())
+	}
+}
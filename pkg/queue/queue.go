@@ -0,0 +1,175 @@
+// Package queue provides event messaging over Tarantool queue or an in-memory broker.
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRequestTimeout = 5 * time.Second
+	defaultReconnectDelay = 2 * time.Second
+	defaultTakeTimeout    = 2 * time.Second
+	defaultMaxAttempts    = 5
+	deadLetterSuffix      = "_dead"
+)
+
+// Supported backends.
+const (
+	BackendTarantool = "tarantool"
+	BackendMemory    = "memory"
+)
+
+// ErrJobSettled indicates the job was already acked, released or buried.
+var ErrJobSettled = errors.New("job already settled")
+
+// Publisher sends events to subscriber groups.
+type Publisher interface {
+	// Publish wraps payload into the latest version envelope of eventType, validates it and routes it.
+	Publish(ctx context.Context, eventType string, payload any) error
+	// PublishEnvelope validates a prepared envelope and fans it out to every subscriber group
+	// whose topics match the event type.
+	PublishEnvelope(ctx context.Context, env Envelope) error
+	// Declare registers subscriber groups, creates their tubes and moves ready messages of
+	// the base tube, left from before the groups existed, to the groups now subscribed.
+	Declare(ctx context.Context, subs ...Subscription) error
+	Ping(ctx context.Context) error
+	Close()
+}
+
+// Consumer hands out jobs from one tube for explicit settlement.
+type Consumer interface {
+	// Next takes one job. It returns nil when no job arrived within the take timeout.
+	// The job stays taken until the caller settles it with Ack, Release or Bury.
+	// Envelopes of registered types are upcast to the latest version and validated; jobs failing
+	// validation are buried and reported with ErrInvalidEnvelope.
+	Next(ctx context.Context) (*Job, error)
+	// Tube returns the tube the consumer takes jobs from.
+	Tube() string
+	Close()
+}
+
+// PublisherConfig describes publisher connection, envelope and routing settings.
+type PublisherConfig struct {
+	// Backend selects BackendTarantool (default) or BackendMemory.
+	Backend string
+	Addr    string
+	// Tube is the base name for group tubes and receives messages nobody subscribed to.
+	Tube     string
+	Producer string
+	Registry *Registry
+	// Subscriptions are declared on connect so routes exist before the first publish.
+	Subscriptions []Subscription
+	// Broker is used by the memory backend; nil selects DefaultMemoryBroker.
+	Broker *MemoryBroker
+}
+
+// ConsumerConfig describes consumer connection, subscription and retry settings.
+type ConsumerConfig struct {
+	// Backend selects BackendTarantool (default) or BackendMemory.
+	Backend string
+	Addr    string
+	// Tube is consumed directly, or used as base name when Group is set.
+	Tube string
+	// Group and Topics subscribe the consumer to its own group tube; see Subscription.
+	Group          string
+	Topics         []string
+	DeadLetterTube string
+	MaxAttempts    int
+	TakeTimeout    time.Duration
+	// Registry validates and upcasts consumed envelopes; nil selects DefaultRegistry.
+	Registry *Registry
+	// Broker is used by the memory backend; nil selects DefaultMemoryBroker.
+	Broker *MemoryBroker
+}
+
+// NewPublisher returns publisher for the configured backend.
+func NewPublisher(cfg PublisherConfig) (Publisher, error) {
+	switch backend(cfg.Backend) {
+	case BackendTarantool:
+		return NewTarantoolPublisher(cfg)
+	case BackendMemory:
+		broker := cfg.Broker
+		if broker == nil {
+			broker = DefaultMemoryBroker()
+		}
+		return broker.Publisher(cfg)
+	default:
+		return nil, fmt.Errorf("unknown queue backend %q", cfg.Backend)
+	}
+}
+
+// NewConsumer returns consumer for the configured backend.
+func NewConsumer(cfg ConsumerConfig) (Consumer, error) {
+	switch backend(cfg.Backend) {
+	case BackendTarantool:
+		return NewTarantoolConsumer(cfg)
+	case BackendMemory:
+		broker := cfg.Broker
+		if broker == nil {
+			broker = DefaultMemoryBroker()
+		}
+		return broker.Consumer(cfg)
+	default:
+		return nil, fmt.Errorf("unknown queue backend %q", cfg.Backend)
+	}
+}
+
+// DeadLetterTube returns the default dead-letter tube of tube, e.g. events_queue_analytics_dead.
+func DeadLetterTube(tube string) string {
+	return tube + deadLetterSuffix
+}
+
+func backend(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return BackendTarantool
+	}
+	return name
+}
+
+// normalized resolves the group tube and fills retry defaults.
+func (cfg ConsumerConfig) normalized() ConsumerConfig {
+	if cfg.Group != "" {
+		cfg.Tube = GroupTube(cfg.Tube, cfg.Group)
+	}
+	cfg.DeadLetterTube = strings.TrimSpace(cfg.DeadLetterTube)
+	if cfg.DeadLetterTube == "" {
+		cfg.DeadLetterTube = DeadLetterTube(cfg.Tube)
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultMaxAttempts
+	}
+	if cfg.TakeTimeout <= 0 {
+		cfg.TakeTimeout = defaultTakeTimeout
+	}
+	if cfg.Registry == nil {
+		cfg.Registry = DefaultRegistry()
+	}
+	return cfg
+}
+
+func newLatestEnvelope(ctx context.Context, registry *Registry, eventType, producer string, payload any) (Envelope, error) {
+	version, ok := registry.LatestVersion(eventType)
+	if !ok {
+		return Envelope{}, fmt.Errorf("%w: %s", ErrUnknownEvent, eventType)
+	}
+	return NewEnvelope(ctx, eventType, version, producer, payload)
+}
+
+// encodeMessage builds the queue message body shared by all backends.
+func encodeMessage(env Envelope) (map[string]any, error) {
+	body, err := json.Marshal(env)
+	if err != nil {
+		return nil, fmt.Errorf("marshal envelope: %w", err)
+	}
+	return map[string]any{
+		"event_type": env.Type,
+		"version":    env.Version,
+		"envelope":   string(body),
+	}, nil
+}
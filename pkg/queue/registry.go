@@ -0,0 +1,196 @@
+package queue
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+// Upcaster converts payload of one version to the next version of the same event type.
+type Upcaster func(payload json.RawMessage) (json.RawMessage, error)
+
+type eventDefinition struct {
+	latest    int
+	schemas   map[int]*Schema
+	upcasters map[int]Upcaster
+}
+
+// Registry keeps event types with their payload schemas per version and upcasting hooks.
+type Registry struct {
+	mu     sync.RWMutex
+	events map[string]*eventDefinition
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{events: make(map[string]*eventDefinition)}
+}
+
+var (
+	defaultRegistry     *Registry
+	defaultRegistryOnce sync.Once
+)
+
+// DefaultRegistry returns the registry preloaded with schemas shipped in pkg/queue/schemas.
+// Files are named <EventType>.v<version>.json.
+func DefaultRegistry() *Registry {
+	defaultRegistryOnce.Do(func() {
+		registry, err := loadEmbeddedSchemas()
+		if err != nil {
+			panic(fmt.Sprintf("load event schemas: %v", err))
+		}
+		defaultRegistry = registry
+	})
+	return defaultRegistry
+}
+
+func loadEmbeddedSchemas() (*Registry, error) {
+	registry := NewRegistry()
+
+	entries, err := schemaFiles.ReadDir("schemas")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".json")
+		dot := strings.LastIndex(name, ".v")
+		if dot <= 0 {
+			return nil, fmt.Errorf("schema file %s: expected <type>.v<version>.json", entry.Name())
+		}
+		version, err := strconv.Atoi(name[dot+2:])
+		if err != nil {
+			return nil, fmt.Errorf("schema file %s: %w", entry.Name(), err)
+		}
+
+		data, err := schemaFiles.ReadFile(path.Join("schemas", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if err := registry.Register(name[:dot], version, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return registry, nil
+}
+
+// Register adds JSON Schema for event type version.
+func (r *Registry) Register(eventType string, version int, schema []byte) error {
+	if strings.TrimSpace(eventType) == "" || version <= 0 {
+		return fmt.Errorf("register %q v%d: type and positive version required", eventType, version)
+	}
+
+	parsed, err := ParseSchema(schema)
+	if err != nil {
+		return fmt.Errorf("register %s v%d: %w", eventType, version, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	def := r.definition(eventType)
+	def.schemas[version] = parsed
+	if version > def.latest {
+		def.latest = version
+	}
+	return nil
+}
+
+// RegisterUpcaster installs conversion from fromVersion to fromVersion+1.
+func (r *Registry) RegisterUpcaster(eventType string, fromVersion int, fn Upcaster) error {
+	if fn == nil || fromVersion <= 0 {
+		return fmt.Errorf("register upcaster %s v%d: invalid arguments", eventType, fromVersion)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.definition(eventType).upcasters[fromVersion] = fn
+	return nil
+}
+
+// LatestVersion returns the newest registered version of event type.
+func (r *Registry) LatestVersion(eventType string) (int, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	def, ok := r.events[eventType]
+	if !ok || def.latest == 0 {
+		return 0, false
+	}
+	return def.latest, true
+}
+
+// Validate checks envelope header and payload against the schema of its version.
+func (r *Registry) Validate(env Envelope) error {
+	if err := env.validateHeader(); err != nil {
+		return err
+	}
+	return r.ValidatePayload(env.Type, env.Version, env.Payload)
+}
+
+// ValidatePayload checks payload against the schema registered for event type version.
+func (r *Registry) ValidatePayload(eventType string, version int, payload json.RawMessage) error {
+	r.mu.RLock()
+	def, ok := r.events[eventType]
+	var schema *Schema
+	if ok {
+		schema = def.schemas[version]
+	}
+	r.mu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownEvent, eventType)
+	}
+	if schema == nil {
+		return fmt.Errorf("%w: %s v%d has no schema", ErrInvalidEnvelope, eventType, version)
+	}
+	if err := schema.ValidateJSON(payload); err != nil {
+		return fmt.Errorf("%w: %s v%d: %v", ErrInvalidEnvelope, eventType, version, err)
+	}
+	return nil
+}
+
+// Upcast converts envelope payload to the latest registered version using upcasters.
+// Unknown event types are returned unchanged.
+func (r *Registry) Upcast(env Envelope) (Envelope, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	def, ok := r.events[env.Type]
+	if !ok {
+		return env, nil
+	}
+
+	for env.Version < def.latest {
+		fn, ok := def.upcasters[env.Version]
+		if !ok {
+			return env, fmt.Errorf("%w: no upcaster for %s v%d", ErrInvalidEnvelope, env.Type, env.Version)
+		}
+		payload, err := fn(env.Payload)
+		if err != nil {
+			return env, fmt.Errorf("upcast %s v%d: %w", env.Type, env.Version, err)
+		}
+		env.Payload = payload
+		env.Version++
+	}
+
+	return env, nil
+}
+
+func (r *Registry) definition(eventType string) *eventDefinition {
+	def, ok := r.events[eventType]
+	if !ok {
+		def = &eventDefinition{schemas: make(map[int]*Schema), upcasters: make(map[int]Upcaster)}
+		r.events[eventType] = def
+	}
+	return def
+}
@@ -0,0 +1,100 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDefaultRegistryDealCreated(t *testing.T) {
+	t.Parallel()
+
+	registry := DefaultRegistry()
+	version, ok := registry.LatestVersion("DealCreated")
+	if !ok || version != 1 {
+		t.Fatalf("expected DealCreated v1, got %d, %v", version, ok)
+	}
+
+	valid := map[string]any{
+		"id":        "31000000-0000-0000-0000-000000000001",
+		"stage":     "new",
+		"amount":    1500.5,
+		"currency":  "RUB",
+		"createdAt": "2025-10-01T10:00:00Z",
+	}
+	env, err := NewEnvelope(context.Background(), "DealCreated", version, "crm", valid)
+	if err != nil {
+		t.Fatalf("new envelope: %v", err)
+	}
+	if err := registry.Validate(env); err != nil {
+		t.Fatalf("expected valid envelope, got %v", err)
+	}
+
+	invalid := map[string]any{"id": "", "amount": "many", "createdAt": "yesterday"}
+	env, err = NewEnvelope(context.Background(), "DealCreated", version, "crm", invalid)
+	if err != nil {
+		t.Fatalf("new envelope: %v", err)
+	}
+	err = registry.Validate(env)
+	if !errors.Is(err, ErrInvalidEnvelope) {
+		t.Fatalf("expected ErrInvalidEnvelope, got %v", err)
+	}
+	for _, fragment := range []string{"$.id: shorter than 1", "$.amount: expected number", "$.createdAt: invalid date-time", "$.stage: required"} {
+		if !strings.Contains(err.Error(), fragment) {
+			t.Fatalf("expected %q in %v", fragment, err)
+		}
+	}
+}
+
+func TestRegistryUnknownEvent(t *testing.T) {
+	t.Parallel()
+
+	env, err := NewEnvelope(context.Background(), "Missing", 1, "test", map[string]any{})
+	if err != nil {
+		t.Fatalf("new envelope: %v", err)
+	}
+	if err := NewRegistry().Validate(env); !errors.Is(err, ErrUnknownEvent) {
+		t.Fatalf("expected ErrUnknownEvent, got %v", err)
+	}
+}
+
+func TestRegistryUpcast(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	if err := registry.Register("Renamed", 1, []byte(`{"type":"object","required":["name"]}`)); err != nil {
+		t.Fatalf("register v1: %v", err)
+	}
+	if err := registry.Register("Renamed", 2, []byte(`{"type":"object","required":["title"],"additionalProperties":false,"properties":{"title":{"type":"string"}}}`)); err != nil {
+		t.Fatalf("register v2: %v", err)
+	}
+	if err := registry.RegisterUpcaster("Renamed", 1, func(payload json.RawMessage) (json.RawMessage, error) {
+		var v1 struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(payload, &v1); err != nil {
+			return nil, err
+		}
+		return json.Marshal(map[string]string{"title": v1.Name})
+	}); err != nil {
+		t.Fatalf("register upcaster: %v", err)
+	}
+
+	env, err := NewEnvelope(context.Background(), "Renamed", 1, "test", map[string]string{"name": "Banner"})
+	if err != nil {
+		t.Fatalf("new envelope: %v", err)
+	}
+
+	upcast, err := registry.Upcast(env)
+	if err != nil {
+		t.Fatalf("upcast: %v", err)
+	}
+	if upcast.Version != 2 {
+		t.Fatalf("expected version 2, got %d", upcast.Version)
+	}
+	if err := registry.Validate(upcast); err != nil {
+		t.Fatalf("expected upcast envelope to validate, got %v", err)
+	}
+}
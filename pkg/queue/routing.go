@@ -0,0 +1,254 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	tar "github.com/tarantool/go-tarantool/v2"
+)
+
+// routesSpace keeps subscriber groups and the topics they listen to.
+const routesSpace = "queue_routes"
+
+// declareScript registers a subscriber group route and creates its tube on demand.
+const declareScript = `
+local group, tube, topics = ...
+box.schema.space.create('queue_routes', {
+    if_not_exists = true,
+    format = {
+        {name = 'group', type = 'string'},
+        {name = 'tube', type = 'string'},
+        {name = 'topics', type = 'array'},
+    },
+})
+box.space.queue_routes:create_index('primary', {parts = {'group'}, if_not_exists = true})
+if queue.tube[tube] == nil then
+    queue.create_tube(tube, 'fifottl', {if_not_exists = true})
+end
+box.space.queue_routes:replace({group, tube, topics})
+return true
+`
+
+// routeLib defines deliver(topic, data), which puts data into every group tube subscribed
+// to topic and returns the number of tubes.
+const routeLib = `
+local function matches(topics, value)
+    for _, pattern in ipairs(topics) do
+        if pattern == '*' or pattern == value then
+            return true
+        end
+        if string.sub(pattern, -1) == '*' and string.sub(value, 1, #pattern - 1) == string.sub(pattern, 1, -2) then
+            return true
+        end
+    end
+    return false
+end
+local function deliver(topic, data)
+    local delivered = 0
+    local routes = box.space.queue_routes
+    if routes == nil then
+        return 0
+    end
+    for _, route in routes:pairs() do
+        if matches(route[3], topic) and queue.tube[route[2]] ~= nil then
+            queue.tube[route[2]]:put(data)
+            delivered = delivered + 1
+        end
+    end
+    return delivered
+end
+`
+
+// fanoutScript puts one message into every group tube subscribed to the topic in a single
+// transaction, so either all groups receive it or none does. Messages without subscribers
+// go to the fallback tube when it is set.
+const fanoutScript = routeLib + `
+local topic, data, fallback = ...
+return box.atomic(function()
+    local delivered = deliver(topic, data)
+    if delivered == 0 and fallback ~= '' and queue.tube[fallback] ~= nil then
+        queue.tube[fallback]:put(data)
+    end
+    return delivered
+end)
+`
+
+// drainScript scans up to limit messages of the base tube with IDs after the cursor and
+// moves the ready ones into the group tubes subscribed to them. The base tube holds the
+// backlog consumed before per-group routing and messages published while nobody subscribed;
+// messages still without subscribers stay. Every message is moved in its own transaction.
+// Returns the number of moved messages, the number of scanned ones and the last scanned ID.
+const drainScript = routeLib + `
+local base, after, limit = ...
+local t = queue.tube[base]
+if t == nil then
+    return 0, 0, after
+end
+local ids = {}
+local scanned, last = 0, after
+for _, task in box.space[base].index.task_id:pairs({after}, {iterator = 'GT'}) do
+    if scanned >= limit then
+        break
+    end
+    scanned, last = scanned + 1, task[1]
+    if task[2] == 'r' then
+        table.insert(ids, task[1])
+    end
+end
+local moved = 0
+for _, id in ipairs(ids) do
+    moved = moved + box.atomic(function()
+        local task = box.space[base]:get(id)
+        if task == nil or task[2] ~= 'r' then
+            return 0
+        end
+        local data = t.raw:normalize_task(task)[3]
+        if deliver(data.event_type or '', data) == 0 then
+            return 0
+        end
+        t:delete(id)
+        return 1
+    end)
+end
+return moved, scanned, last
+`
+
+// drainBatch bounds the messages scanned by one drain call.
+const drainBatch = 500
+
+// Subscription binds a subscriber group to topics. Topics are event types; "*" matches
+// everything and a trailing "*" matches by prefix (e.g. "Deal*").
+type Subscription struct {
+	Group  string
+	Topics []string
+}
+
+// Tube returns the group tube name derived from base tube.
+func (s Subscription) Tube(base string) string {
+	return GroupTube(base, s.Group)
+}
+
+// Matches reports whether topic is routed to the subscription.
+func (s Subscription) Matches(topic string) bool {
+	for _, pattern := range s.Topics {
+		if pattern == "*" || pattern == topic {
+			return true
+		}
+		if strings.HasSuffix(pattern, "*") && strings.HasPrefix(topic, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// GroupTube returns tube name for subscriber group, e.g. events_queue_analytics.
+func GroupTube(base, group string) string {
+	return base + "_" + group
+}
+
+// ParseSubscriptions reads declarative subscription spec of the form
+// "analytics=DealCreated,DealUpdated;bpm=Deal*".
+func ParseSubscriptions(spec string) ([]Subscription, error) {
+	var subs []Subscription
+	seen := make(map[string]struct{})
+
+	for _, chunk := range strings.Split(spec, ";") {
+		chunk = strings.TrimSpace(chunk)
+		if chunk == "" {
+			continue
+		}
+
+		group, rawTopics, ok := strings.Cut(chunk, "=")
+		group = strings.TrimSpace(group)
+		if !ok || !validName(group) {
+			return nil, fmt.Errorf("subscription %q: expected <group>=<topic>[,<topic>]", chunk)
+		}
+		if _, dup := seen[group]; dup {
+			return nil, fmt.Errorf("subscription group %s declared twice", group)
+		}
+		seen[group] = struct{}{}
+
+		var topics []string
+		for _, topic := range strings.Split(rawTopics, ",") {
+			if topic = strings.TrimSpace(topic); topic != "" {
+				topics = append(topics, topic)
+			}
+		}
+		if len(topics) == 0 {
+			return nil, fmt.Errorf("subscription group %s has no topics", group)
+		}
+		sort.Strings(topics)
+
+		subs = append(subs, Subscription{Group: group, Topics: topics})
+	}
+
+	return subs, nil
+}
+
+// FindSubscription returns subscription of group from list.
+func FindSubscription(subs []Subscription, group string) (Subscription, bool) {
+	for _, sub := range subs {
+		if sub.Group == group {
+			return sub, true
+		}
+	}
+	return Subscription{}, false
+}
+
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+func declare(ctx context.Context, conn *tar.Connection, base string, sub Subscription) error {
+	if conn == nil {
+		return fmt.Errorf("declare %s: connection is nil", sub.Group)
+	}
+	if !validName(sub.Group) || len(sub.Topics) == 0 {
+		return fmt.Errorf("declare %q: group name and topics required", sub.Group)
+	}
+
+	req := tar.NewEvalRequest(declareScript).
+		Args([]any{sub.Group, sub.Tube(base), sub.Topics}).
+		Context(ctx)
+	if _, err := conn.Do(req).Get(); err != nil {
+		return fmt.Errorf("declare %s in %s: %w", sub.Group, routesSpace, err)
+	}
+	return nil
+}
+
+// drain moves the backlog of the base tube into the group tubes; see drainScript.
+func drain(ctx context.Context, conn *tar.Connection, base string) (int, error) {
+	if conn == nil {
+		return 0, fmt.Errorf("drain %s: connection is nil", base)
+	}
+
+	moved := 0
+	var after uint64
+	for {
+		req := tar.NewEvalRequest(drainScript).
+			Args([]any{base, after, drainBatch}).
+			Context(ctx)
+		resp, err := conn.Do(req).Get()
+		if err != nil {
+			return moved, fmt.Errorf("drain %s: %w", base, err)
+		}
+		if len(resp) < 3 {
+			return moved, nil
+		}
+		moved += toInt(resp[0])
+		if toInt(resp[1]) < drainBatch {
+			return moved, nil
+		}
+		after = uint64(toInt(resp[2]))
+	}
+}
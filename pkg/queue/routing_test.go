@@ -0,0 +1,45 @@
+package queue
+
+import "testing"
+
+func TestParseSubscriptions(t *testing.T) {
+	t.Parallel()
+
+	subs, err := ParseSubscriptions(" analytics=DealCreated ; bpm=Deal*,StockChanged ;")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(subs) != 2 {
+		t.Fatalf("expected 2 subscriptions, got %d", len(subs))
+	}
+
+	bpm, ok := FindSubscription(subs, "bpm")
+	if !ok {
+		t.Fatal("expected bpm subscription")
+	}
+	if bpm.Tube("events_queue") != "events_queue_bpm" {
+		t.Fatalf("unexpected tube: %s", bpm.Tube("events_queue"))
+	}
+	if !bpm.Matches("DealCreated") || !bpm.Matches("StockChanged") || bpm.Matches("TaskAssigned") {
+		t.Fatalf("unexpected topic matching for %v", bpm.Topics)
+	}
+
+	if subs, err := ParseSubscriptions(""); err != nil || len(subs) != 0 {
+		t.Fatalf("expected empty spec to yield nothing, got %v, %v", subs, err)
+	}
+}
+
+func TestParseSubscriptionsInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, spec := range []string{
+		"analytics",
+		"analytics=",
+		"bad-name=DealCreated",
+		"analytics=DealCreated;analytics=DealUpdated",
+	} {
+		if _, err := ParseSubscriptions(spec); err == nil {
+			t.Fatalf("expected error for %q", spec)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Schema is the subset of JSON Schema used to describe event payloads:
+// type, required, properties, additionalProperties, enum, format, minLength and minimum.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+}
+
+// ParseSchema decodes a JSON Schema document.
+func ParseSchema(data []byte) (*Schema, error) {
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	return &schema, nil
+}
+
+// ValidateJSON checks raw JSON document against the schema and aggregates all violations.
+func (s *Schema) ValidateJSON(data []byte) error {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("decode document: %w", err)
+	}
+
+	var problems []string
+	s.validate("$", doc, &problems)
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (s *Schema) validate(path string, value any, problems *[]string) {
+	if s == nil {
+		return
+	}
+
+	if s.Type != "" && !matchesType(s.Type, value) {
+		*problems = append(*problems, fmt.Sprintf("%s: expected %s", path, s.Type))
+		return
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		*problems = append(*problems, fmt.Sprintf("%s: value not allowed", path))
+	}
+
+	switch v := value.(type) {
+	case string:
+		if s.MinLength != nil && len([]rune(v)) < *s.MinLength {
+			*problems = append(*problems, fmt.Sprintf("%s: shorter than %d", path, *s.MinLength))
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, v); err != nil {
+				*problems = append(*problems, fmt.Sprintf("%s: invalid date-time", path))
+			}
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			*problems = append(*problems, fmt.Sprintf("%s: less than %v", path, *s.Minimum))
+		}
+	case []any:
+		for i, item := range v {
+			s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, problems)
+		}
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*problems = append(*problems, fmt.Sprintf("%s.%s: required", path, name))
+			}
+		}
+
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			child, ok := s.Properties[key]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					*problems = append(*problems, fmt.Sprintf("%s.%s: unexpected property", path, key))
+				}
+				continue
+			}
+			child.validate(path+"."+key, v[key], problems)
+		}
+	}
+}
+
+func matchesType(expected string, value any) bool {
+	switch expected {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	default:
+		return true
+	}
+}
+
+func inEnum(enum []any, value any) bool {
+	switch value.(type) {
+	case map[string]any, []any:
+		return false
+	}
+	for _, candidate := range enum {
+		switch candidate.(type) {
+		case map[string]any, []any:
+			continue
+		}
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -1,72 +1,112 @@
-// Package queue integrates Tarantool-based messaging.
 package queue
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"time"
 
 	tar "github.com/tarantool/go-tarantool/v2"
-)
 
-const (
-	defaultRequestTimeout = 5 * time.Second
-	defaultReconnectDelay = 2 * time.Second
+	"asfppro/pkg/tracing"
 )
 
-// Publisher wraps Tarantool queue operations.
-type Publisher struct {
-	conn *tar.Connection
-	tube string
+// TarantoolPublisher publishes events into Tarantool queue tubes.
+type TarantoolPublisher struct {
+	conn     *tar.Connection
+	tube     string
+	producer string
+	registry *Registry
 }
 
-// NewPublisher establishes a connection and ensures queue space exists.
-func NewPublisher(addr, tube string) (*Publisher, error) {
-	conn, err := connect(addr)
+// NewTarantoolPublisher establishes a connection and declares configured subscriptions.
+func NewTarantoolPublisher(cfg PublisherConfig) (*TarantoolPublisher, error) {
+	conn, err := connect(cfg.Addr)
 	if err != nil {
 		return nil, fmt.Errorf("connect tarantool: %w", err)
 	}
 
-	return &Publisher{conn: conn, tube: tube}, nil
+	registry := cfg.Registry
+	if registry == nil {
+		registry = DefaultRegistry()
+	}
+
+	p := &TarantoolPublisher{conn: conn, tube: cfg.Tube, producer: cfg.Producer, registry: registry}
+	if len(cfg.Subscriptions) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+		defer cancel()
+		if err := p.Declare(ctx, cfg.Subscriptions...); err != nil {
+			p.Close()
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// Declare registers subscriber groups, creates their tubes in Tarantool and moves the
+// backlog of the base tube to the groups now subscribed to it.
+func (p *TarantoolPublisher) Declare(ctx context.Context, subs ...Subscription) error {
+	for _, sub := range subs {
+		if err := declare(ctx, p.conn, p.tube, sub); err != nil {
+			return err
+		}
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+	_, err := drain(ctx, p.conn, p.tube)
+	return err
+}
+
+// Publish wraps payload into the latest version envelope of eventType, validates it and sends it to queue.
+func (p *TarantoolPublisher) Publish(ctx context.Context, eventType string, payload any) error {
+	env, err := newLatestEnvelope(ctx, p.registry, eventType, p.producer, payload)
+	if err != nil {
+		return err
+	}
+
+	return p.PublishEnvelope(ctx, env)
 }
 
-// Publish sends a JSON payload to queue.
-func (p *Publisher) Publish(ctx context.Context, eventType string, payload any) error {
+// PublishEnvelope validates a prepared envelope and fans it out to every subscriber group
+// whose topics match the event type.
+func (p *TarantoolPublisher) PublishEnvelope(ctx context.Context, env Envelope) (err error) {
 	if p.conn == nil {
 		return errors.New("publisher connection is nil")
 	}
 
-	body, err := json.Marshal(payload)
+	ctx, span := startPublish(ctx, &env, p.tube)
+	defer func() { tracing.End(span, err) }()
+
+	if err := p.registry.Validate(env); err != nil {
+		return observePublish(env.Type, err)
+	}
+
+	message, err := encodeMessage(env)
 	if err != nil {
-		return fmt.Errorf("marshal payload: %w", err)
+		return observePublish(env.Type, err)
 	}
 
-	expr := fmt.Sprintf("return queue.tube.%s:put(...)", p.tube)
-	req := tar.NewEvalRequest(expr).
-		Args([]any{map[string]any{
-			"event_type": eventType,
-			"payload":    string(body),
-		}}).
+	req := tar.NewEvalRequest(fanoutScript).
+		Args([]any{env.Type, message, p.tube}).
 		Context(ctx)
 
 	if _, err := p.conn.Do(req).Get(); err != nil {
-		return fmt.Errorf("publish event: %w", err)
+		return observePublish(env.Type, fmt.Errorf("publish event: %w", err))
 	}
 
-	return nil
+	return observePublish(env.Type, nil)
 }
 
 // Close terminates the underlying connection.
-func (p *Publisher) Close() {
+func (p *TarantoolPublisher) Close() {
 	if p.conn != nil {
 		_ = p.conn.Close()
 	}
 }
 
 // Ping executes a lightweight eval request to ensure Tarantool is reachable.
-func (p *Publisher) Ping(ctx context.Context) error {
+func (p *TarantoolPublisher) Ping(ctx context.Context) error {
 	if p.conn == nil {
 		return errors.New("publisher connection is nil")
 	}
@@ -79,77 +119,6 @@ func (p *Publisher) Ping(ctx context.Context) error {
 	return nil
 }
 
-// Consumer pulls messages and converts them to strongly typed events.
-type Consumer struct {
-	conn    *tar.Connection
-	tube    string
-	timeout time.Duration
-}
-
-// NewConsumer returns a queue consumer.
-func NewConsumer(addr, tube string) (*Consumer, error) {
-	conn, err := connect(addr)
-	if err != nil {
-		return nil, fmt.Errorf("connect tarantool: %w", err)
-	}
-
-	return &Consumer{conn: conn, tube: tube, timeout: 2 * time.Second}, nil
-}
-
-// Next fetches one job and acknowledges it.
-func (c *Consumer) Next(ctx context.Context, out any) (string, error) {
-	if c.conn == nil {
-		return "", errors.New("consumer connection is nil")
-	}
-
-	expr := fmt.Sprintf("return queue.tube.%s:take(...)", c.tube)
-	takeReq := tar.NewEvalRequest(expr).
-		Args([]any{c.timeout.Seconds()}).
-		Context(ctx)
-
-	resp, err := c.conn.Do(takeReq).Get()
-	if err != nil {
-		return "", fmt.Errorf("take job: %w", err)
-	}
-
-	if len(resp) == 0 {
-		return "", nil
-	}
-
-	job, ok := resp[0].([]any)
-	if !ok || len(job) < 3 {
-		return "", fmt.Errorf("unexpected job payload: %v", resp)
-	}
-
-	jobID := fmt.Sprintf("%v", job[0])
-
-	meta, ok := job[2].(map[string]any)
-	if ok && out != nil {
-		if payload, hasPayload := meta["payload"].(string); hasPayload {
-			if err := json.Unmarshal([]byte(payload), out); err != nil {
-				return "", fmt.Errorf("decode payload: %w", err)
-			}
-		}
-	}
-
-	ackExpr := fmt.Sprintf("return queue.tube.%s:ack(...)", c.tube)
-	ackReq := tar.NewEvalRequest(ackExpr).
-		Args([]any{job[0]}).
-		Context(ctx)
-	if _, err := c.conn.Do(ackReq).Get(); err != nil {
-		return "", fmt.Errorf("ack job: %w", err)
-	}
-
-	return jobID, nil
-}
-
-// Close stops consumer.
-func (c *Consumer) Close() {
-	if c.conn != nil {
-		_ = c.conn.Close()
-	}
-}
-
 func connect(addr string) (*tar.Connection, error) {
 	dialer := tar.NetDialer{Address: addr}
 
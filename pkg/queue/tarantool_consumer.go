@@ -0,0 +1,226 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tar "github.com/tarantool/go-tarantool/v2"
+)
+
+// takeScript takes a task unless the tube is paused, in which case it waits out the timeout
+// and returns nothing.
+const takeScript = `
+local tube, timeout = ...
+local paused = box.space.queue_paused
+if paused ~= nil and paused:get(tube) ~= nil then
+    require('fiber').sleep(timeout)
+    return nil
+end
+return queue.tube[tube]:take(timeout)
+`
+
+// releaseScript re-enqueues a taken task with an incremented attempt counter.
+// Once the counter reaches the limit the task is moved to the dead-letter tube instead.
+const releaseScript = `
+local tube, dead, id, delay, max = ...
+local task = queue.tube[tube]:peek(id)
+local data = {}
+for k, v in pairs(task[3]) do data[k] = v end
+data.attempts = (data.attempts or 0) + 1
+if max > 0 and data.attempts >= max then
+    if queue.tube[dead] == nil then
+        queue.create_tube(dead, 'fifo', {if_not_exists = true})
+    end
+    data.source_tube = tube
+    data.dead_reason = 'max attempts exceeded'
+    data.failed_at = os.time()
+    queue.tube[dead]:put(data)
+    queue.tube[tube]:ack(id)
+    return 'dead'
+end
+queue.tube[tube]:put(data, {delay = delay})
+queue.tube[tube]:ack(id)
+return 'released'
+`
+
+// postponeScript re-enqueues a taken task after a delay keeping its attempt counter.
+const postponeScript = `
+local tube, id, delay = ...
+local task = queue.tube[tube]:peek(id)
+local data = {}
+for k, v in pairs(task[3]) do data[k] = v end
+queue.tube[tube]:put(data, {delay = delay})
+queue.tube[tube]:ack(id)
+return true
+`
+
+// buryScript moves a taken task to the dead-letter tube preserving its body.
+const buryScript = `
+local tube, dead, id, reason = ...
+local task = queue.tube[tube]:peek(id)
+local data = {}
+for k, v in pairs(task[3]) do data[k] = v end
+if queue.tube[dead] == nil then
+    queue.create_tube(dead, 'fifo', {if_not_exists = true})
+end
+data.source_tube = tube
+data.dead_reason = reason
+data.failed_at = os.time()
+queue.tube[dead]:put(data)
+queue.tube[tube]:ack(id)
+return true
+`
+
+// TarantoolConsumer takes jobs from a Tarantool queue tube.
+type TarantoolConsumer struct {
+	conn        *tar.Connection
+	tube        string
+	deadTube    string
+	maxAttempts int
+	timeout     time.Duration
+	registry    *Registry
+}
+
+// NewTarantoolConsumer connects to Tarantool and declares the group subscription when set.
+func NewTarantoolConsumer(cfg ConsumerConfig) (*TarantoolConsumer, error) {
+	if strings.TrimSpace(cfg.Tube) == "" {
+		return nil, errors.New("consumer tube is required")
+	}
+
+	conn, err := connect(cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("connect tarantool: %w", err)
+	}
+
+	if cfg.Group != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+		defer cancel()
+		sub := Subscription{Group: cfg.Group, Topics: cfg.Topics}
+		if err := declare(ctx, conn, cfg.Tube, sub); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+
+	return newTarantoolConsumer(conn, cfg), nil
+}
+
+func newTarantoolConsumer(conn *tar.Connection, cfg ConsumerConfig) *TarantoolConsumer {
+	cfg = cfg.normalized()
+	return &TarantoolConsumer{
+		conn:        conn,
+		tube:        cfg.Tube,
+		deadTube:    cfg.DeadLetterTube,
+		maxAttempts: cfg.MaxAttempts,
+		timeout:     cfg.TakeTimeout,
+		registry:    cfg.Registry,
+	}
+}
+
+// Tube returns the tube the consumer takes jobs from.
+func (c *TarantoolConsumer) Tube() string {
+	return c.tube
+}
+
+// DeadLetterTube returns the tube receiving poison messages.
+func (c *TarantoolConsumer) DeadLetterTube() string {
+	return c.deadTube
+}
+
+// MaxAttempts returns the number of deliveries before a job is dead-lettered.
+func (c *TarantoolConsumer) MaxAttempts() int {
+	return c.maxAttempts
+}
+
+// Next takes one job from the tube; see Consumer.
+func (c *TarantoolConsumer) Next(ctx context.Context) (*Job, error) {
+	if c.conn == nil {
+		return nil, errors.New("consumer connection is nil")
+	}
+
+	takeReq := tar.NewEvalRequest(takeScript).
+		Args([]any{c.tube, c.timeout.Seconds()}).
+		Context(ctx)
+
+	resp, err := c.conn.Do(takeReq).Get()
+	if err != nil {
+		return nil, fmt.Errorf("take job: %w", err)
+	}
+
+	job, err := parseJob(resp)
+	if job == nil {
+		return nil, err
+	}
+	job.settler = c
+	job.tube = c.tube
+
+	if err == nil {
+		err = prepareJob(c.registry, job)
+	}
+	if err != nil {
+		if buryErr := job.Bury(ctx, err.Error()); buryErr != nil {
+			return nil, fmt.Errorf("%w (bury failed: %v)", err, buryErr)
+		}
+		return nil, err
+	}
+	observeConsumed(job)
+	startConsume(job)
+	return job, nil
+}
+
+// Close stops consumer.
+func (c *TarantoolConsumer) Close() {
+	if c.conn != nil {
+		_ = c.conn.Close()
+	}
+}
+
+func (c *TarantoolConsumer) ack(ctx context.Context, id any) error {
+	expr := fmt.Sprintf("return queue.tube.%s:ack(...)", c.tube)
+	req := tar.NewEvalRequest(expr).
+		Args([]any{id}).
+		Context(ctx)
+	if _, err := c.conn.Do(req).Get(); err != nil {
+		return fmt.Errorf("ack job: %w", err)
+	}
+	return nil
+}
+
+func (c *TarantoolConsumer) release(ctx context.Context, id any, delay time.Duration) (bool, error) {
+	req := tar.NewEvalRequest(releaseScript).
+		Args([]any{c.tube, c.deadTube, id, delay.Seconds(), c.maxAttempts}).
+		Context(ctx)
+	resp, err := c.conn.Do(req).Get()
+	if err != nil {
+		return false, fmt.Errorf("release job: %w", err)
+	}
+	if len(resp) > 0 {
+		if outcome, ok := resp[0].(string); ok && outcome == "dead" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (c *TarantoolConsumer) postpone(ctx context.Context, id any, delay time.Duration) error {
+	req := tar.NewEvalRequest(postponeScript).
+		Args([]any{c.tube, id, delay.Seconds()}).
+		Context(ctx)
+	if _, err := c.conn.Do(req).Get(); err != nil {
+		return fmt.Errorf("postpone job: %w", err)
+	}
+	return nil
+}
+
+func (c *TarantoolConsumer) bury(ctx context.Context, id any, reason string) error {
+	req := tar.NewEvalRequest(buryScript).
+		Args([]any{c.tube, c.deadTube, id, reason}).
+		Context(ctx)
+	if _, err := c.conn.Do(req).Get(); err != nil {
+		return fmt.Errorf("bury job: %w", err)
+	}
+	return nil
+}
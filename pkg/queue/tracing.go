@@ -0,0 +1,68 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"asfppro/pkg/tracing"
+)
+
+// startPublish opens a producer span and stores its trace context in env so consumers
+// continue the same trace. Without an active span in ctx the span is parented to the
+// context already recorded in the envelope, which keeps traces of deferred publishes intact.
+func startPublish(ctx context.Context, env *Envelope, tube string) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		ctx = tracing.Extract(ctx, env.TraceContext)
+	}
+	ctx, span := tracing.Tracer().Start(ctx, tube+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "tarantool"),
+			attribute.String("messaging.operation.type", "publish"),
+			attribute.String("messaging.destination.name", tube),
+			attribute.String("messaging.message.id", env.ID),
+			attribute.String("messaging.event_type", env.Type),
+		),
+	)
+	if carrier := tracing.Inject(ctx); carrier != nil {
+		env.TraceContext = carrier
+	}
+	return ctx, span
+}
+
+// startConsume opens a consumer span continuing the trace stored in the job envelope.
+// The span ends when the job is settled.
+func startConsume(job *Job) {
+	ctx := tracing.Extract(context.Background(), job.envelope.TraceContext)
+	_, job.span = tracing.Tracer().Start(ctx, job.tube+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "tarantool"),
+			attribute.String("messaging.operation.type", "process"),
+			attribute.String("messaging.destination.name", job.tube),
+			attribute.String("messaging.message.id", job.envelope.ID),
+			attribute.String("messaging.event_type", job.envelope.Type),
+			attribute.Int("messaging.delivery_attempt", job.attempts+1),
+		),
+	)
+}
+
+// endConsume closes the job span with the settlement outcome.
+func endConsume(job *Job, outcome, reason string) {
+	if job.span == nil {
+		return
+	}
+	job.span.SetAttributes(attribute.String("messaging.outcome", outcome))
+	if outcome != "ack" {
+		description := outcome
+		if reason != "" {
+			description = fmt.Sprintf("%s: %s", outcome, reason)
+		}
+		job.span.SetStatus(codes.Error, description)
+	}
+	job.span.End()
+}
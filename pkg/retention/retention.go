@@ -0,0 +1,182 @@
+// Package retention decides how long stored files must be kept before they may be deleted.
+package retention
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AnyFolder is the rule folder matching folders no other rule covers.
+const AnyFolder = "*"
+
+// typePrefix marks a rule selecting files by document type instead of folder.
+const typePrefix = "type="
+
+// Period is a calendar duration: retention is counted in years and months, not hours.
+type Period struct {
+	Years  int
+	Months int
+	Days   int
+}
+
+// ParsePeriod reads a period such as "5y", "18m", "30d" or "1y6m".
+func ParsePeriod(s string) (Period, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	var p Period
+	if s == "" {
+		return p, fmt.Errorf("invalid period %q", s)
+	}
+	for rest := s; rest != ""; {
+		i := strings.IndexAny(rest, "ymd")
+		if i <= 0 {
+			return Period{}, fmt.Errorf("invalid period %q", s)
+		}
+		n, err := strconv.Atoi(rest[:i])
+		if err != nil || n < 0 || n > 1000 {
+			return Period{}, fmt.Errorf("invalid period %q", s)
+		}
+		switch rest[i] {
+		case 'y':
+			p.Years += n
+		case 'm':
+			p.Months += n
+		case 'd':
+			p.Days += n
+		}
+		rest = rest[i+1:]
+	}
+	if p.IsZero() {
+		return Period{}, fmt.Errorf("invalid period %q", s)
+	}
+	return p, nil
+}
+
+// IsZero reports whether the period is empty.
+func (p Period) IsZero() bool {
+	return p.Years == 0 && p.Months == 0 && p.Days == 0
+}
+
+// AddTo returns t moved forward by the period.
+func (p Period) AddTo(t time.Time) time.Time {
+	return t.AddDate(p.Years, p.Months, p.Days)
+}
+
+func (p Period) String() string {
+	var b strings.Builder
+	for _, part := range []struct {
+		n    int
+		unit string
+	}{{p.Years, "y"}, {p.Months, "m"}, {p.Days, "d"}} {
+		if part.n > 0 {
+			b.WriteString(strconv.Itoa(part.n) + part.unit)
+		}
+	}
+	return b.String()
+}
+
+// Rule keeps the files of a folder and its subfolders, or of a document type, for Period.
+// Exactly one of Folder and DocumentType is set.
+type Rule struct {
+	Folder       string
+	DocumentType string
+	Period       Period
+}
+
+// Policy picks the retention of a file. An empty policy keeps nothing.
+type Policy struct {
+	rules []Rule
+}
+
+// NewPolicy builds a policy from rules.
+func NewPolicy(rules []Rule) Policy {
+	return Policy{rules: rules}
+}
+
+// ParsePolicy reads rules written as "folder:period" or "type=document-type:period"
+// separated by ";", e.g. "contracts:5y;type=act:5y;*:30d".
+func ParsePolicy(spec string) (Policy, error) {
+	var rules []Rule
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		selector, period, ok := strings.Cut(entry, ":")
+		if !ok {
+			return Policy{}, fmt.Errorf("rule %q: want folder:period", entry)
+		}
+		p, err := ParsePeriod(period)
+		if err != nil {
+			return Policy{}, fmt.Errorf("rule %q: %w", entry, err)
+		}
+		selector = strings.TrimSpace(selector)
+		rule := Rule{Period: p}
+		if docType, ok := strings.CutPrefix(selector, typePrefix); ok {
+			rule.DocumentType = NormalizeType(docType)
+		} else {
+			rule.Folder = strings.Trim(selector, "/")
+		}
+		if rule.Folder == "" && rule.DocumentType == "" {
+			return Policy{}, fmt.Errorf("rule %q: folder or document type is empty", entry)
+		}
+		rules = append(rules, rule)
+	}
+	return NewPolicy(rules), nil
+}
+
+// NormalizeType returns the canonical form of a document type.
+func NormalizeType(docType string) string {
+	return strings.ToLower(strings.TrimSpace(docType))
+}
+
+// Until returns when a file stored in folder with documentType at from may be deleted: the
+// later end of its folder rule and its document type rule. The zero time means no
+// retention applies.
+func (p Policy) Until(folder, documentType string, from time.Time) time.Time {
+	var until time.Time
+	if rule, ok := p.folderRule(folder); ok {
+		until = rule.Period.AddTo(from)
+	}
+	documentType = NormalizeType(documentType)
+	if documentType == "" {
+		return until
+	}
+	for _, r := range p.rules {
+		if r.DocumentType == documentType {
+			if t := r.Period.AddTo(from); t.After(until) {
+				until = t
+			}
+		}
+	}
+	return until
+}
+
+// folderRule returns the rule of the longest folder that contains folder, falling back to
+// AnyFolder.
+func (p Policy) folderRule(folder string) (Rule, bool) {
+	folder = strings.Trim(path.Clean("/"+folder), "/")
+	var (
+		best  Rule
+		found bool
+	)
+	for _, r := range p.rules {
+		switch {
+		case r.Folder == "":
+			continue
+		case r.Folder == AnyFolder:
+			if !found {
+				best, found = r, true
+			}
+			continue
+		case folder != r.Folder && !strings.HasPrefix(folder, r.Folder+"/"):
+			continue
+		}
+		if !found || best.Folder == AnyFolder || len(r.Folder) > len(best.Folder) {
+			best, found = r, true
+		}
+	}
+	return best, found
+}
@@ -0,0 +1,72 @@
+package retention
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePeriod(t *testing.T) {
+	cases := map[string]Period{
+		"5y":   {Years: 5},
+		"18m":  {Months: 18},
+		"30d":  {Days: 30},
+		"1Y6M": {Years: 1, Months: 6},
+	}
+	for spec, want := range cases {
+		got, err := ParsePeriod(spec)
+		if err != nil || got != want {
+			t.Errorf("%q: got %+v, %v; want %+v", spec, got, err, want)
+		}
+	}
+	for _, spec := range []string{"", "0d", "5", "y", "5w", "-1y", "5y3"} {
+		if _, err := ParsePeriod(spec); err == nil {
+			t.Errorf("%q: expected error", spec)
+		}
+	}
+	if s := (Period{Years: 1, Days: 2}).String(); s != "1y2d" {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func TestPolicyUntil(t *testing.T) {
+	p, err := ParsePolicy("*:30d; contracts:5y; contracts/drafts:90d; type=Act:3y; type=contract:5y")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	from := time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		folder, docType string
+		want            time.Time
+	}{
+		{"uploads", "", from.AddDate(0, 0, 30)},
+		{"contracts/2024", "", from.AddDate(5, 0, 0)},
+		{"contracts/drafts", "", from.AddDate(0, 0, 90)},
+		{"contracts/drafts", "contract", from.AddDate(5, 0, 0)},
+		{"uploads", " ACT ", from.AddDate(3, 0, 0)},
+		{"contracts", "act", from.AddDate(5, 0, 0)},
+	}
+	for _, tc := range cases {
+		if got := p.Until(tc.folder, tc.docType, from); !got.Equal(tc.want) {
+			t.Errorf("%s/%s: got %s, want %s", tc.folder, tc.docType, got, tc.want)
+		}
+	}
+
+	strict, err := ParsePolicy("type=contract:5y")
+	if err != nil {
+		t.Fatalf("parse strict: %v", err)
+	}
+	if got := strict.Until("contracts", "", from); !got.IsZero() {
+		t.Fatalf("no rule must mean no retention, got %s", got)
+	}
+	if got := (Policy{}).Until("contracts", "contract", from); !got.IsZero() {
+		t.Fatalf("empty policy must keep nothing, got %s", got)
+	}
+}
+
+func TestParsePolicyRejectsMalformedRules(t *testing.T) {
+	for _, spec := range []string{"contracts", ":5y", "type=:5y", "contracts:5", "contracts:0y"} {
+		if _, err := ParsePolicy(spec); err == nil {
+			t.Errorf("%q: expected error", spec)
+		}
+	}
+}
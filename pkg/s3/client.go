@@ -8,16 +8,29 @@ import (
 	"io"
 	"net/url"
 	"path"
+	"sync"
 	"time"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"asfppro/pkg/tracing"
 )
 
 // Client wraps MinIO SDK to work with Ceph RGW.
 type Client struct {
-	client *minio.Client
-	bucket string
+	client    *minio.Client
+	presigner *minio.Client
+	bucket    string
+	region    string
+	accessKey string
+	secretKey string
+
+	lockMu    sync.Mutex
+	lockKnown bool
+	lockOn    bool
 }
 
 // New creates an S3 client.
@@ -44,19 +57,22 @@ func New(endpoint, region, accessKey, secretKey, bucket string, useSSL bool) (*C
 		return nil, fmt.Errorf("ensure bucket: %w", err)
 	}
 	if !exists {
-		if err := client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{Region: region}); err != nil {
+		// Object lock also turns on versioning; it can only be enabled when the bucket is created.
+		if err := client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{Region: region, ObjectLocking: true}); err != nil {
 			return nil, fmt.Errorf("create bucket: %w", err)
 		}
 	}
 
-	return &Client{client: client, bucket: bucket}, nil
+	return &Client{client: client, bucket: bucket, region: region, accessKey: accessKey, secretKey: secretKey}, nil
 }
 
 // Upload stores incoming file stream and returns object path and version ID.
 func (c *Client) Upload(ctx context.Context, folder, filename string, r io.Reader, size int64, contentType string) (string, string, error) {
 	objectName := path.Join(folder, filename)
 
+	ctx, span := c.startSpan(ctx, "PutObject", objectName)
 	info, err := c.client.PutObject(ctx, c.bucket, objectName, r, size, minio.PutObjectOptions{ContentType: contentType})
+	tracing.End(span, err)
 	if err != nil {
 		return "", "", fmt.Errorf("put object: %w", err)
 	}
@@ -82,7 +98,9 @@ func (c *Client) Ping(ctx context.Context) error {
 	probeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
+	probeCtx, span := c.startSpan(probeCtx, "HeadBucket", "")
 	exists, err := c.client.BucketExists(probeCtx, c.bucket)
+	tracing.End(span, err)
 	if err != nil {
 		return fmt.Errorf("bucket exists check: %w", err)
 	}
@@ -91,3 +109,16 @@ func (c *Client) Ping(ctx context.Context) error {
 	}
 	return nil
 }
+
+// startSpan opens a client span for an S3 operation on object key.
+func (c *Client) startSpan(ctx context.Context, operation, key string) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("rpc.system", "aws-api"),
+		attribute.String("rpc.method", operation),
+		attribute.String("aws.s3.bucket", c.bucket),
+	}
+	if key != "" {
+		attrs = append(attrs, attribute.String("aws.s3.key", key))
+	}
+	return tracing.Tracer().Start(ctx, "S3."+operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
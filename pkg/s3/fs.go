@@ -0,0 +1,686 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FSRoute is where the gateway serves presigned URLs of the filesystem backend unless a
+// public endpoint is set.
+const FSRoute = "/storage"
+
+// errNoSuchUpload mirrors the S3 error for unknown multipart uploads.
+var errNoSuchUpload = errors.New("no such upload")
+
+// FS stores objects in a directory and emulates S3 versioning: every write adds a version,
+// deleting without a version adds a delete marker. Objects live under objects/ in
+// directories named by the hash of the key, so any key is a valid path; meta.json lists the
+// versions oldest first. FS is safe for one process only.
+type FS struct {
+	root   string
+	secret []byte
+	public string
+	mu     sync.Mutex
+}
+
+type fsVersion struct {
+	ID           string     `json:"id"`
+	Size         int64      `json:"size"`
+	ETag         string     `json:"etag"`
+	ContentType  string     `json:"contentType"`
+	LastModified time.Time  `json:"lastModified"`
+	DeleteMarker bool       `json:"deleteMarker,omitempty"`
+	RetainUntil  *time.Time `json:"retainUntil,omitempty"`
+	LegalHold    bool       `json:"legalHold,omitempty"`
+}
+
+type fsObject struct {
+	Key      string      `json:"key"`
+	Versions []fsVersion `json:"versions"`
+}
+
+type fsUpload struct {
+	Key         string `json:"key"`
+	ContentType string `json:"contentType"`
+}
+
+// NewFS opens or creates a filesystem store in root. Presigned URLs are signed with secret;
+// an empty secret makes them valid until the process restarts.
+func NewFS(root, secret string) (*FS, error) {
+	if strings.TrimSpace(root) == "" {
+		return nil, errors.New("storage root is required")
+	}
+	for _, dir := range []string{"objects", "multipart", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o750); err != nil {
+			return nil, fmt.Errorf("create storage dir: %w", err)
+		}
+	}
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("generate signing key: %w", err)
+		}
+	}
+	return &FS{root: root, secret: key, public: FSRoute}, nil
+}
+
+// Upload stores r as a new version of folder/filename and returns its URL and version ID.
+func (f *FS) Upload(ctx context.Context, folder, filename string, r io.Reader, size int64, contentType string) (string, string, error) {
+	key := path.Join(folder, filename)
+	v, err := f.put(key, contentType, r, size, "")
+	if err != nil {
+		return "", "", err
+	}
+	return f.objectURL(key, nil), v.ID, nil
+}
+
+// UploadBytes helper to upload arbitrary content.
+func (f *FS) UploadBytes(ctx context.Context, folder, filename string, data []byte, contentType string) (string, string, error) {
+	return f.Upload(ctx, folder, filename, bytes.NewReader(data), int64(len(data)), contentType)
+}
+
+// Ping verifies that the storage directory is reachable.
+func (f *FS) Ping(ctx context.Context) error {
+	if _, err := os.Stat(filepath.Join(f.root, "objects")); err != nil {
+		return fmt.Errorf("storage root: %w", err)
+	}
+	return nil
+}
+
+// SetPublicEndpoint makes presigned URLs point at endpoint, typically
+// http://<gateway>/storage.
+func (f *FS) SetPublicEndpoint(endpoint string) error {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || (parsed.Host == "" && !strings.HasPrefix(endpoint, "/")) {
+		return fmt.Errorf("parse public endpoint %q: invalid URL", endpoint)
+	}
+	f.public = strings.TrimSuffix(endpoint, "/")
+	return nil
+}
+
+// PresignPut returns a signed PUT for key served by ServeHTTP. Like S3 it requires the
+// signed Content-Type and Content-MD5 headers and rejects a body with another digest.
+func (f *FS) PresignPut(ctx context.Context, key, contentType, md5Hex string, expires time.Duration) (PutRequest, error) {
+	digest, err := hex.DecodeString(md5Hex)
+	if err != nil || len(digest) != 16 {
+		return PutRequest{}, fmt.Errorf("md5 must be 32 hex characters")
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	contentMD5 := base64.StdEncoding.EncodeToString(digest)
+	deadline := time.Now().Add(expires).UTC()
+
+	params := url.Values{}
+	params.Set("X-Expires", strconv.FormatInt(deadline.Unix(), 10))
+	params.Set("X-Signature", f.sign(http.MethodPut, key, "", "", contentType, contentMD5, deadline.Unix()))
+	return PutRequest{
+		URL:     f.objectURL(key, params),
+		Headers: map[string]string{"Content-Type": contentType, "Content-MD5": contentMD5},
+		Expires: deadline,
+	}, nil
+}
+
+// PresignGet returns a signed download URL for key served by ServeHTTP.
+func (f *FS) PresignGet(ctx context.Context, key, versionID, filename string, expires time.Duration) (string, time.Time, error) {
+	deadline := time.Now().Add(expires).UTC()
+	disposition := ""
+	if filename != "" {
+		disposition = mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+	}
+
+	params := url.Values{}
+	if versionID != "" {
+		params.Set("versionId", versionID)
+	}
+	if disposition != "" {
+		params.Set("response-content-disposition", disposition)
+	}
+	params.Set("X-Expires", strconv.FormatInt(deadline.Unix(), 10))
+	params.Set("X-Signature", f.sign(http.MethodGet, key, versionID, disposition, "", "", deadline.Unix()))
+	return f.objectURL(key, params), deadline, nil
+}
+
+// Stat returns metadata of key; an empty versionID selects the latest version.
+func (f *FS) Stat(ctx context.Context, key, versionID string) (ObjectInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, err := f.version(key, versionID)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return v.info(key), nil
+}
+
+// Open reads length bytes of a version of key starting at offset; length 0 reads to the end.
+func (f *FS) Open(ctx context.Context, key, versionID string, offset, length int64) (io.ReadCloser, error) {
+	f.mu.Lock()
+	v, err := f.version(key, versionID)
+	f.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(f.dataPath(key, v.ID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open object: %w", err)
+	}
+	if offset > 0 {
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			_ = file.Close()
+			return nil, fmt.Errorf("open object: %w", err)
+		}
+	}
+	if length <= 0 {
+		return file, nil
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(file, length), file}, nil
+}
+
+// Remove deletes one version of key. An empty versionID adds a delete marker, hiding the
+// object while keeping its versions, as a versioned bucket does.
+func (f *FS) Remove(ctx context.Context, key, versionID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	obj, err := f.load(key)
+	if err != nil {
+		return err
+	}
+	if versionID == "" {
+		if len(obj.Versions) == 0 {
+			return nil
+		}
+		obj.Versions = append(obj.Versions, fsVersion{ID: newVersionID(), LastModified: time.Now().UTC(), DeleteMarker: true})
+		return f.save(obj)
+	}
+	for i, v := range obj.Versions {
+		if v.ID != versionID {
+			continue
+		}
+		if v.locked(time.Now()) {
+			return fmt.Errorf("remove object: %w", ErrObjectLocked)
+		}
+		obj.Versions = append(obj.Versions[:i], obj.Versions[i+1:]...)
+		if err := os.Remove(f.dataPath(key, v.ID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("remove object: %w", err)
+		}
+		return f.save(obj)
+	}
+	return nil
+}
+
+// RestoreVersion copies versionID of key over key, making its content the latest version
+// again, and returns the version ID the copy received.
+func (f *FS) RestoreVersion(ctx context.Context, key, versionID string) (string, error) {
+	v, err := f.copy(key, versionID, key)
+	if err != nil {
+		return "", err
+	}
+	return v.ID, nil
+}
+
+// Quarantine moves a version of key under QuarantinePrefix and returns the key it got there.
+func (f *FS) Quarantine(ctx context.Context, key, versionID string) (string, error) {
+	target := QuarantinePrefix + key
+	if _, err := f.copy(key, versionID, target); err != nil {
+		return "", fmt.Errorf("quarantine object: %w", err)
+	}
+	if err := f.Remove(ctx, key, versionID); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// SetRetention protects a version of key from deletion until the given time. Like S3
+// compliance mode, the period can be extended but not shortened.
+func (f *FS) SetRetention(ctx context.Context, key, versionID string, until time.Time) error {
+	return f.update(key, versionID, func(v *fsVersion) error {
+		if v.RetainUntil != nil && until.Before(*v.RetainUntil) {
+			return fmt.Errorf("set object retention: %w: retained until %s", ErrObjectLocked, v.RetainUntil.Format(time.RFC3339))
+		}
+		until = until.UTC()
+		v.RetainUntil = &until
+		return nil
+	})
+}
+
+// SetLegalHold places or lifts a legal hold on a version of key.
+func (f *FS) SetLegalHold(ctx context.Context, key, versionID string, on bool) error {
+	return f.update(key, versionID, func(v *fsVersion) error {
+		v.LegalHold = on
+		return nil
+	})
+}
+
+// CreateMultipart starts a multipart upload of key and returns its upload ID.
+func (f *FS) CreateMultipart(ctx context.Context, key, contentType string) (string, error) {
+	id := uuid.NewString()
+	dir := filepath.Join(f.root, "multipart", id)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", fmt.Errorf("create multipart upload: %w", err)
+	}
+	if err := writeJSON(filepath.Join(dir, "upload.json"), fsUpload{Key: key, ContentType: contentType}); err != nil {
+		return "", fmt.Errorf("create multipart upload: %w", err)
+	}
+	return id, nil
+}
+
+// UploadPart stores size bytes from r as part number of the upload.
+func (f *FS) UploadPart(ctx context.Context, key, uploadID string, number int, r io.Reader, size int64) (Part, error) {
+	if number < 1 || number > MaxParts {
+		return Part{}, fmt.Errorf("part number %d out of range", number)
+	}
+	dir, err := f.upload(key, uploadID)
+	if err != nil {
+		return Part{}, fmt.Errorf("upload part %d: %w", number, err)
+	}
+	tmp, etag, written, err := f.writeTemp(r)
+	if err != nil {
+		return Part{}, fmt.Errorf("upload part %d: %w", number, err)
+	}
+	if size >= 0 && written != size {
+		_ = os.Remove(tmp)
+		return Part{}, fmt.Errorf("upload part %d: got %d bytes, want %d", number, written, size)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, strconv.Itoa(number))); err != nil {
+		_ = os.Remove(tmp)
+		return Part{}, fmt.Errorf("upload part %d: %w", number, err)
+	}
+	return Part{Number: number, ETag: etag, Size: written}, nil
+}
+
+// CompleteMultipart assembles the parts into the object and returns its version ID.
+func (f *FS) CompleteMultipart(ctx context.Context, key, uploadID string, parts []Part) (string, error) {
+	if len(parts) == 0 {
+		return "", errors.New("complete multipart upload: no parts")
+	}
+	dir, err := f.upload(key, uploadID)
+	if err != nil {
+		return "", fmt.Errorf("complete multipart upload: %w", err)
+	}
+	var info fsUpload
+	if err := readJSON(filepath.Join(dir, "upload.json"), &info); err != nil {
+		return "", fmt.Errorf("complete multipart upload: %w", err)
+	}
+
+	sorted := append([]Part(nil), parts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Number < sorted[j].Number })
+	readers := make([]io.Reader, 0, len(sorted))
+	for _, p := range sorted {
+		file, err := os.Open(filepath.Join(dir, strconv.Itoa(p.Number)))
+		if err != nil {
+			return "", fmt.Errorf("complete multipart upload: part %d: %w", p.Number, err)
+		}
+		defer func() { _ = file.Close() }()
+		readers = append(readers, file)
+	}
+
+	v, err := f.put(key, info.ContentType, io.MultiReader(readers...), -1, "")
+	if err != nil {
+		return "", fmt.Errorf("complete multipart upload: %w", err)
+	}
+	_ = os.RemoveAll(dir)
+	return v.ID, nil
+}
+
+// AbortMultipart discards an upload and its stored parts. Unknown uploads are not an error.
+func (f *FS) AbortMultipart(ctx context.Context, key, uploadID string) error {
+	dir, err := f.upload(key, uploadID)
+	if errors.Is(err, errNoSuchUpload) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("abort multipart upload: %w", err)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("abort multipart upload: %w", err)
+	}
+	return nil
+}
+
+// ServeHTTP serves the URLs issued by PresignGet and PresignPut. Mount it with the route
+// prefix stripped, so the request path is the object key.
+func (f *FS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	query := r.URL.Query()
+	expires, err := strconv.ParseInt(query.Get("X-Expires"), 10, 64)
+	if key == "" || err != nil || time.Now().Unix() > expires {
+		http.Error(w, "request has expired", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		versionID, disposition := query.Get("versionId"), query.Get("response-content-disposition")
+		if !f.verify(query.Get("X-Signature"), http.MethodGet, key, versionID, disposition, "", "", expires) {
+			http.Error(w, "signature does not match", http.StatusForbidden)
+			return
+		}
+		f.serveObject(w, r, key, versionID, disposition)
+	case http.MethodPut:
+		contentType, contentMD5 := r.Header.Get("Content-Type"), r.Header.Get("Content-MD5")
+		if !f.verify(query.Get("X-Signature"), http.MethodPut, key, "", "", contentType, contentMD5, expires) {
+			http.Error(w, "signature does not match", http.StatusForbidden)
+			return
+		}
+		digest, _ := base64.StdEncoding.DecodeString(contentMD5)
+		v, err := f.put(key, contentType, r.Body, r.ContentLength, hex.EncodeToString(digest))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("ETag", `"`+v.ETag+`"`)
+		w.Header().Set("x-amz-version-id", v.ID)
+		w.WriteHeader(http.StatusOK)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *FS) serveObject(w http.ResponseWriter, r *http.Request, key, versionID, disposition string) {
+	info, err := f.Stat(r.Context(), key, versionID)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "object not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	body, err := f.Open(r.Context(), key, info.VersionID, 0, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer func() { _ = body.Close() }()
+
+	h := w.Header()
+	if info.ContentType != "" {
+		h.Set("Content-Type", info.ContentType)
+	}
+	if disposition != "" {
+		h.Set("Content-Disposition", disposition)
+	}
+	h.Set("ETag", `"`+info.ETag+`"`)
+	h.Set("x-amz-version-id", info.VersionID)
+	h.Set("Last-Modified", info.LastModified.Format(http.TimeFormat))
+	h.Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, _ = io.Copy(w, body)
+}
+
+// put writes r as the newest version of key. A non-empty md5Hex must match the content.
+func (f *FS) put(key, contentType string, r io.Reader, size int64, md5Hex string) (fsVersion, error) {
+	tmp, etag, written, err := f.writeTemp(r)
+	if err != nil {
+		return fsVersion{}, fmt.Errorf("put object: %w", err)
+	}
+	if size >= 0 && written != size {
+		_ = os.Remove(tmp)
+		return fsVersion{}, fmt.Errorf("put object: got %d bytes, want %d", written, size)
+	}
+	if md5Hex != "" && !strings.EqualFold(md5Hex, etag) {
+		_ = os.Remove(tmp)
+		return fsVersion{}, errors.New("put object: content does not match Content-MD5")
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return f.commit(key, tmp, fsVersion{Size: written, ETag: etag, ContentType: contentType})
+}
+
+// copy adds the content of versionID of src as the newest version of dst.
+func (f *FS) copy(src, versionID, dst string) (fsVersion, error) {
+	f.mu.Lock()
+	v, err := f.version(src, versionID)
+	f.mu.Unlock()
+	if err != nil {
+		return fsVersion{}, err
+	}
+	file, err := os.Open(f.dataPath(src, v.ID))
+	if err != nil {
+		return fsVersion{}, fmt.Errorf("copy object: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	tmp, _, _, err := f.writeTemp(file)
+	if err != nil {
+		return fsVersion{}, fmt.Errorf("copy object: %w", err)
+	}
+	return f.commit(dst, tmp, fsVersion{Size: v.Size, ETag: v.ETag, ContentType: v.ContentType})
+}
+
+// commit moves a written temp file into place as a new version of key.
+func (f *FS) commit(key, tmp string, v fsVersion) (fsVersion, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	obj, err := f.load(key)
+	if err != nil {
+		_ = os.Remove(tmp)
+		return fsVersion{}, err
+	}
+	v.ID = newVersionID()
+	v.LastModified = time.Now().UTC()
+	if err := os.MkdirAll(f.objectDir(key), 0o750); err != nil {
+		_ = os.Remove(tmp)
+		return fsVersion{}, fmt.Errorf("put object: %w", err)
+	}
+	if err := os.Rename(tmp, f.dataPath(key, v.ID)); err != nil {
+		_ = os.Remove(tmp)
+		return fsVersion{}, fmt.Errorf("put object: %w", err)
+	}
+	obj.Versions = append(obj.Versions, v)
+	if err := f.save(obj); err != nil {
+		return fsVersion{}, err
+	}
+	return v, nil
+}
+
+// update changes the metadata of an existing version of key.
+func (f *FS) update(key, versionID string, change func(*fsVersion) error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	obj, err := f.load(key)
+	if err != nil {
+		return err
+	}
+	for i := range obj.Versions {
+		if v := &obj.Versions[i]; v.ID == versionID && !v.DeleteMarker {
+			if err := change(v); err != nil {
+				return err
+			}
+			return f.save(obj)
+		}
+	}
+	return ErrNotFound
+}
+
+// version returns a version of key, or the latest one when versionID is empty. Callers
+// hold f.mu.
+func (f *FS) version(key, versionID string) (fsVersion, error) {
+	obj, err := f.load(key)
+	if err != nil {
+		return fsVersion{}, err
+	}
+	if versionID == "" {
+		if n := len(obj.Versions); n > 0 && !obj.Versions[n-1].DeleteMarker {
+			return obj.Versions[n-1], nil
+		}
+		return fsVersion{}, ErrNotFound
+	}
+	for _, v := range obj.Versions {
+		if v.ID == versionID && !v.DeleteMarker {
+			return v, nil
+		}
+	}
+	return fsVersion{}, ErrNotFound
+}
+
+func (f *FS) load(key string) (fsObject, error) {
+	obj := fsObject{Key: key}
+	err := readJSON(filepath.Join(f.objectDir(key), "meta.json"), &obj)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fsObject{}, fmt.Errorf("read object metadata: %w", err)
+	}
+	return obj, nil
+}
+
+// save writes the metadata of obj, removing the directory of an object without versions.
+func (f *FS) save(obj fsObject) error {
+	dir := f.objectDir(obj.Key)
+	if len(obj.Versions) == 0 {
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("remove object: %w", err)
+		}
+		return nil
+	}
+	if err := writeJSON(filepath.Join(dir, "meta.json"), obj); err != nil {
+		return fmt.Errorf("write object metadata: %w", err)
+	}
+	return nil
+}
+
+func (f *FS) upload(key, uploadID string) (string, error) {
+	if _, err := uuid.Parse(uploadID); err != nil {
+		return "", errNoSuchUpload
+	}
+	dir := filepath.Join(f.root, "multipart", uploadID)
+	var info fsUpload
+	if err := readJSON(filepath.Join(dir, "upload.json"), &info); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", errNoSuchUpload
+		}
+		return "", err
+	}
+	if info.Key != key {
+		return "", errNoSuchUpload
+	}
+	return dir, nil
+}
+
+// writeTemp copies r into a temporary file and returns its name, MD5 (hex) and size.
+func (f *FS) writeTemp(r io.Reader) (string, string, int64, error) {
+	file, err := os.CreateTemp(filepath.Join(f.root, "tmp"), "object-")
+	if err != nil {
+		return "", "", 0, err
+	}
+	digest := md5.New()
+	written, err := io.Copy(io.MultiWriter(file, digest), r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return "", "", 0, err
+	}
+	return file.Name(), hex.EncodeToString(digest.Sum(nil)), written, nil
+}
+
+func (f *FS) objectDir(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(f.root, "objects", name[:2], name)
+}
+
+func (f *FS) dataPath(key, versionID string) string {
+	return filepath.Join(f.objectDir(key), versionID)
+}
+
+func (f *FS) objectURL(key string, params url.Values) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	u := f.public + "/" + strings.Join(segments, "/")
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	return u
+}
+
+func (f *FS) sign(method, key, versionID, disposition, contentType, contentMD5 string, expires int64) string {
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write([]byte(strings.Join([]string{method, key, versionID, disposition, contentType, contentMD5, strconv.FormatInt(expires, 10)}, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (f *FS) verify(signature, method, key, versionID, disposition, contentType, contentMD5 string, expires int64) bool {
+	want := f.sign(method, key, versionID, disposition, contentType, contentMD5, expires)
+	return hmac.Equal([]byte(signature), []byte(want))
+}
+
+func (v fsVersion) locked(now time.Time) bool {
+	return v.LegalHold || (v.RetainUntil != nil && now.Before(*v.RetainUntil))
+}
+
+func (v fsVersion) info(key string) ObjectInfo {
+	return ObjectInfo{
+		Key:          key,
+		Size:         v.Size,
+		ETag:         v.ETag,
+		ContentType:  v.ContentType,
+		VersionID:    v.ID,
+		LastModified: v.LastModified,
+	}
+}
+
+// newVersionID returns a time-ordered version ID.
+func newVersionID() string {
+	return uuid.Must(uuid.NewV7()).String()
+}
+
+func readJSON(name string, out any) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// writeJSON replaces name atomically.
+func writeJSON(name string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
@@ -0,0 +1,260 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestFS(t *testing.T) *FS {
+	t.Helper()
+	fs, err := NewFS(t.TempDir(), "secret")
+	if err != nil {
+		t.Fatalf("new fs: %v", err)
+	}
+	return fs
+}
+
+func readObject(t *testing.T, fs *FS, key, versionID string, offset, length int64) string {
+	t.Helper()
+	rc, err := fs.Open(context.Background(), key, versionID, offset, length)
+	if err != nil {
+		t.Fatalf("open %s@%s: %v", key, versionID, err)
+	}
+	defer func() { _ = rc.Close() }()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("read %s: %v", key, err)
+	}
+	return string(data)
+}
+
+func TestFSVersionsAndDeleteMarkers(t *testing.T) {
+	fs := newTestFS(t)
+	ctx := context.Background()
+
+	_, v1, err := fs.UploadBytes(ctx, "MAIN/designs", "a.txt", []byte("first"), "text/plain")
+	if err != nil {
+		t.Fatalf("upload v1: %v", err)
+	}
+	_, v2, err := fs.UploadBytes(ctx, "MAIN/designs", "a.txt", []byte("second"), "text/plain")
+	if err != nil {
+		t.Fatalf("upload v2: %v", err)
+	}
+
+	info, err := fs.Stat(ctx, "MAIN/designs/a.txt", "")
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	sum := md5.Sum([]byte("second"))
+	if info.VersionID != v2 || info.Size != 6 || info.ETag != hex.EncodeToString(sum[:]) || info.ContentType != "text/plain" {
+		t.Fatalf("latest = %+v", info)
+	}
+	if got := readObject(t, fs, "MAIN/designs/a.txt", v1, 0, 0); got != "first" {
+		t.Fatalf("v1 = %q", got)
+	}
+	if got := readObject(t, fs, "MAIN/designs/a.txt", "", 1, 3); got != "eco" {
+		t.Fatalf("range = %q", got)
+	}
+
+	if err := fs.Remove(ctx, "MAIN/designs/a.txt", ""); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if _, err := fs.Stat(ctx, "MAIN/designs/a.txt", ""); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("stat after delete marker: %v", err)
+	}
+	if _, err := fs.Stat(ctx, "MAIN/designs/a.txt", v1); err != nil {
+		t.Fatalf("old version must survive a delete marker: %v", err)
+	}
+
+	restored, err := fs.RestoreVersion(ctx, "MAIN/designs/a.txt", v1)
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if restored == v1 || readObject(t, fs, "MAIN/designs/a.txt", "", 0, 0) != "first" {
+		t.Fatalf("restore must add a new latest version, got %s", restored)
+	}
+
+	if err := fs.Remove(ctx, "MAIN/designs/a.txt", v1); err != nil {
+		t.Fatalf("remove version: %v", err)
+	}
+	if _, err := fs.Open(ctx, "MAIN/designs/a.txt", v1, 0, 0); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("removed version: %v", err)
+	}
+	if err := fs.Remove(ctx, "MAIN/missing", "v"); err != nil {
+		t.Fatalf("remove missing: %v", err)
+	}
+}
+
+func TestFSQuarantine(t *testing.T) {
+	fs := newTestFS(t)
+	ctx := context.Background()
+
+	_, v, err := fs.UploadBytes(ctx, "MAIN", "bad.exe", []byte("x"), "")
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	target, err := fs.Quarantine(ctx, "MAIN/bad.exe", v)
+	if err != nil {
+		t.Fatalf("quarantine: %v", err)
+	}
+	if target != QuarantinePrefix+"MAIN/bad.exe" || readObject(t, fs, target, "", 0, 0) != "x" {
+		t.Fatalf("quarantined to %s", target)
+	}
+	if _, err := fs.Stat(ctx, "MAIN/bad.exe", ""); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("original must be gone: %v", err)
+	}
+}
+
+func TestFSMultipart(t *testing.T) {
+	fs := newTestFS(t)
+	ctx := context.Background()
+
+	id, err := fs.CreateMultipart(ctx, "MAIN/big.bin", "application/zip")
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	p2, err := fs.UploadPart(ctx, "MAIN/big.bin", id, 2, strings.NewReader("world"), 5)
+	if err != nil {
+		t.Fatalf("part 2: %v", err)
+	}
+	p1, err := fs.UploadPart(ctx, "MAIN/big.bin", id, 1, strings.NewReader("hello "), 6)
+	if err != nil {
+		t.Fatalf("part 1: %v", err)
+	}
+	if _, err := fs.UploadPart(ctx, "MAIN/other.bin", id, 3, strings.NewReader("x"), 1); err == nil {
+		t.Fatal("expected error for a part of another key")
+	}
+	if _, err := fs.UploadPart(ctx, "MAIN/big.bin", id, 3, strings.NewReader("x"), 2); err == nil {
+		t.Fatal("expected error for a short part")
+	}
+
+	version, err := fs.CompleteMultipart(ctx, "MAIN/big.bin", id, []Part{p2, p1})
+	if err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	info, err := fs.Stat(ctx, "MAIN/big.bin", version)
+	if err != nil || info.ContentType != "application/zip" || readObject(t, fs, "MAIN/big.bin", "", 0, 0) != "hello world" {
+		t.Fatalf("assembled object %+v: %v", info, err)
+	}
+	if err := fs.AbortMultipart(ctx, "MAIN/big.bin", id); err != nil {
+		t.Fatalf("abort completed upload: %v", err)
+	}
+}
+
+func TestFSPresignedRoundTrip(t *testing.T) {
+	fs := newTestFS(t)
+	srv := httptest.NewServer(http.StripPrefix(FSRoute, fs))
+	defer srv.Close()
+	if err := fs.SetPublicEndpoint(srv.URL + FSRoute); err != nil {
+		t.Fatalf("public endpoint: %v", err)
+	}
+	ctx := context.Background()
+
+	body := []byte("%PDF-1.7 layout")
+	sum := md5.Sum(body)
+	put, err := fs.PresignPut(ctx, "MAIN/макеты/a.pdf", "application/pdf", hex.EncodeToString(sum[:]), time.Minute)
+	if err != nil {
+		t.Fatalf("presign put: %v", err)
+	}
+	send := func(data []byte, contentType string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPut, put.URL, bytes.NewReader(data))
+		for name, value := range put.Headers {
+			req.Header.Set(name, value)
+		}
+		req.Header.Set("Content-Type", contentType)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("put: %v", err)
+		}
+		_ = resp.Body.Close()
+		return resp
+	}
+	if resp := send(body, "text/plain"); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("changed content type: status %d", resp.StatusCode)
+	}
+	if resp := send([]byte("tampered"), "application/pdf"); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("changed body: status %d", resp.StatusCode)
+	}
+	resp := send(body, "application/pdf")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("x-amz-version-id") == "" {
+		t.Fatalf("put: status %d", resp.StatusCode)
+	}
+
+	get, _, err := fs.PresignGet(ctx, "MAIN/макеты/a.pdf", "", "макет.pdf", time.Minute)
+	if err != nil {
+		t.Fatalf("presign get: %v", err)
+	}
+	resp, err = http.Get(get)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !bytes.Equal(data, body) {
+		t.Fatalf("get: status %d body %q", resp.StatusCode, data)
+	}
+	if cd := resp.Header.Get("Content-Disposition"); !strings.HasPrefix(cd, "attachment") || resp.Header.Get("Content-Type") != "application/pdf" {
+		t.Fatalf("headers = %v", resp.Header)
+	}
+
+	resp, err = http.Get(strings.Replace(get, "X-Expires=", "X-Expires=9", 1))
+	if err != nil {
+		t.Fatalf("get tampered: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("tampered expiry: status %d", resp.StatusCode)
+	}
+}
+
+func TestFSObjectLock(t *testing.T) {
+	fs := newTestFS(t)
+	ctx := context.Background()
+
+	_, v, err := fs.UploadBytes(ctx, "MAIN/contracts", "c.pdf", []byte("signed"), "application/pdf")
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	until := time.Now().Add(time.Hour)
+	if err := fs.SetRetention(ctx, "MAIN/contracts/c.pdf", v, until); err != nil {
+		t.Fatalf("set retention: %v", err)
+	}
+	if err := fs.SetRetention(ctx, "MAIN/contracts/c.pdf", v, until.Add(-time.Minute)); !errors.Is(err, ErrObjectLocked) {
+		t.Fatalf("shortening retention: %v", err)
+	}
+	if err := fs.Remove(ctx, "MAIN/contracts/c.pdf", v); !errors.Is(err, ErrObjectLocked) {
+		t.Fatalf("remove retained version: %v", err)
+	}
+	if err := fs.Remove(ctx, "MAIN/contracts/c.pdf", ""); err != nil {
+		t.Fatalf("a delete marker is allowed under retention: %v", err)
+	}
+
+	_, held, err := fs.UploadBytes(ctx, "MAIN/contracts", "h.pdf", []byte("held"), "application/pdf")
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := fs.SetLegalHold(ctx, "MAIN/contracts/h.pdf", held, true); err != nil {
+		t.Fatalf("set legal hold: %v", err)
+	}
+	if err := fs.Remove(ctx, "MAIN/contracts/h.pdf", held); !errors.Is(err, ErrObjectLocked) {
+		t.Fatalf("remove held version: %v", err)
+	}
+	if err := fs.SetLegalHold(ctx, "MAIN/contracts/h.pdf", held, false); err != nil {
+		t.Fatalf("lift legal hold: %v", err)
+	}
+	if err := fs.Remove(ctx, "MAIN/contracts/h.pdf", held); err != nil {
+		t.Fatalf("remove released version: %v", err)
+	}
+	if err := fs.SetLegalHold(ctx, "MAIN/contracts/h.pdf", held, true); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("hold on a removed version: %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/minio/minio-go/v7"
+
+	"asfppro/pkg/tracing"
+)
+
+const (
+	// MinPartSize is the smallest part S3 accepts in a multipart upload; only the last part
+	// may be shorter.
+	MinPartSize = 5 << 20
+	// MaxParts is the number of parts a multipart upload can have.
+	MaxParts = 10000
+)
+
+// Part is an uploaded part of a multipart upload.
+type Part struct {
+	Number int    `json:"number"`
+	ETag   string `json:"etag"`
+	Size   int64  `json:"size"`
+}
+
+// CreateMultipart starts a multipart upload of key and returns its upload ID.
+func (c *Client) CreateMultipart(ctx context.Context, key, contentType string) (string, error) {
+	ctx, span := c.startSpan(ctx, "CreateMultipartUpload", key)
+	uploadID, err := c.core().NewMultipartUpload(ctx, c.bucket, key, minio.PutObjectOptions{ContentType: contentType})
+	tracing.End(span, err)
+	if err != nil {
+		return "", fmt.Errorf("create multipart upload: %w", err)
+	}
+	return uploadID, nil
+}
+
+// UploadPart stores size bytes from r as part number of the upload.
+func (c *Client) UploadPart(ctx context.Context, key, uploadID string, number int, r io.Reader, size int64) (Part, error) {
+	if number < 1 || number > MaxParts {
+		return Part{}, fmt.Errorf("part number %d out of range", number)
+	}
+	ctx, span := c.startSpan(ctx, "UploadPart", key)
+	part, err := c.core().PutObjectPart(ctx, c.bucket, key, uploadID, number, r, size, minio.PutObjectPartOptions{})
+	tracing.End(span, err)
+	if err != nil {
+		return Part{}, fmt.Errorf("upload part %d: %w", number, err)
+	}
+	return Part{Number: part.PartNumber, ETag: part.ETag, Size: part.Size}, nil
+}
+
+// CompleteMultipart assembles the parts into the object and returns its version ID.
+func (c *Client) CompleteMultipart(ctx context.Context, key, uploadID string, parts []Part) (string, error) {
+	if len(parts) == 0 {
+		return "", errors.New("complete multipart upload: no parts")
+	}
+	complete := make([]minio.CompletePart, 0, len(parts))
+	for _, p := range parts {
+		complete = append(complete, minio.CompletePart{PartNumber: p.Number, ETag: p.ETag})
+	}
+	sort.Slice(complete, func(i, j int) bool { return complete[i].PartNumber < complete[j].PartNumber })
+
+	ctx, span := c.startSpan(ctx, "CompleteMultipartUpload", key)
+	info, err := c.core().CompleteMultipartUpload(ctx, c.bucket, key, uploadID, complete, minio.PutObjectOptions{})
+	tracing.End(span, err)
+	if err != nil {
+		return "", fmt.Errorf("complete multipart upload: %w", err)
+	}
+	return info.VersionID, nil
+}
+
+// AbortMultipart discards an upload and its stored parts. Unknown uploads are not an error.
+func (c *Client) AbortMultipart(ctx context.Context, key, uploadID string) error {
+	ctx, span := c.startSpan(ctx, "AbortMultipartUpload", key)
+	err := c.core().AbortMultipartUpload(ctx, c.bucket, key, uploadID)
+	tracing.End(span, err)
+	if err != nil && minio.ToErrorResponse(err).Code != "NoSuchUpload" {
+		return fmt.Errorf("abort multipart upload: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) core() minio.Core {
+	return minio.Core{Client: c.client}
+}
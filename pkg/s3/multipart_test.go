@@ -0,0 +1,22 @@
+package s3
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestMultipartRejectsInvalidInputOffline(t *testing.T) {
+	c := offlineClient(t)
+	ctx := context.Background()
+
+	if _, err := c.UploadPart(ctx, "MAIN/a.tif", "upload", 0, strings.NewReader("x"), 1); err == nil {
+		t.Fatal("expected error for part number 0")
+	}
+	if _, err := c.UploadPart(ctx, "MAIN/a.tif", "upload", MaxParts+1, strings.NewReader("x"), 1); err == nil {
+		t.Fatal("expected error for part number above MaxParts")
+	}
+	if _, err := c.CompleteMultipart(ctx, "MAIN/a.tif", "upload", nil); err == nil {
+		t.Fatal("expected error when completing without parts")
+	}
+}
@@ -0,0 +1,226 @@
+package s3
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"asfppro/pkg/tracing"
+)
+
+// MaxPresignTTL is the longest lifetime SigV4 allows for a presigned URL.
+const MaxPresignTTL = 7 * 24 * time.Hour
+
+// ErrNotFound is returned when an object or object version does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// ObjectInfo describes a stored object version.
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ETag         string
+	ContentType  string
+	VersionID    string
+	LastModified time.Time
+}
+
+// PutRequest is a presigned upload: the client sends one PUT to URL with Headers exactly as given.
+type PutRequest struct {
+	URL     string
+	Headers map[string]string
+	Expires time.Time
+}
+
+// SetPublicEndpoint makes presigned URLs point at endpoint, the address clients reach the
+// storage on, instead of the internal one the gateway uses. Signing happens locally.
+func (c *Client) SetPublicEndpoint(endpoint string) error {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Host == "" {
+		return fmt.Errorf("parse public endpoint %q: invalid URL", endpoint)
+	}
+	presigner, err := minio.New(parsed.Host, &minio.Options{
+		Creds:  credentials.NewStaticV4(c.accessKey, c.secretKey, ""),
+		Secure: parsed.Scheme == "https",
+		Region: c.region,
+	})
+	if err != nil {
+		return fmt.Errorf("create presign client: %w", err)
+	}
+	c.presigner = presigner
+	return nil
+}
+
+// PresignPut returns a presigned PUT for key. The content type and MD5 digest (hex) are
+// signed, so the storage rejects a body that differs from what was announced.
+func (c *Client) PresignPut(ctx context.Context, key, contentType, md5Hex string, expires time.Duration) (PutRequest, error) {
+	digest, err := hex.DecodeString(md5Hex)
+	if err != nil || len(digest) != 16 {
+		return PutRequest{}, fmt.Errorf("md5 must be 32 hex characters")
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	signed := map[string]string{
+		"Content-Type": contentType,
+		"Content-MD5":  base64.StdEncoding.EncodeToString(digest),
+	}
+	headers := http.Header{}
+	for name, value := range signed {
+		headers.Set(name, value)
+	}
+
+	ctx, span := c.startSpan(ctx, "PresignPutObject", key)
+	u, err := c.signer().PresignHeader(ctx, http.MethodPut, c.bucket, key, expires, nil, headers)
+	tracing.End(span, err)
+	if err != nil {
+		return PutRequest{}, fmt.Errorf("presign put: %w", err)
+	}
+
+	return PutRequest{URL: u.String(), Headers: signed, Expires: time.Now().Add(expires).UTC()}, nil
+}
+
+// PresignGet returns a presigned download URL for key. An empty versionID selects the latest
+// version; filename, when set, is sent back as an attachment name.
+func (c *Client) PresignGet(ctx context.Context, key, versionID, filename string, expires time.Duration) (string, time.Time, error) {
+	params := url.Values{}
+	if versionID != "" {
+		params.Set("versionId", versionID)
+	}
+	if filename != "" {
+		params.Set("response-content-disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	}
+
+	ctx, span := c.startSpan(ctx, "PresignGetObject", key)
+	u, err := c.signer().PresignedGetObject(ctx, c.bucket, key, expires, params)
+	tracing.End(span, err)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("presign get: %w", err)
+	}
+	return u.String(), time.Now().Add(expires).UTC(), nil
+}
+
+// Stat returns metadata of key; an empty versionID selects the latest version.
+func (c *Client) Stat(ctx context.Context, key, versionID string) (ObjectInfo, error) {
+	ctx, span := c.startSpan(ctx, "HeadObject", key)
+	info, err := c.client.StatObject(ctx, c.bucket, key, minio.StatObjectOptions{VersionID: versionID})
+	tracing.End(span, err)
+	if err != nil {
+		if resp := minio.ToErrorResponse(err); resp.StatusCode == http.StatusNotFound {
+			return ObjectInfo{}, ErrNotFound
+		}
+		return ObjectInfo{}, fmt.Errorf("stat object: %w", err)
+	}
+	return ObjectInfo{
+		Key:          info.Key,
+		Size:         info.Size,
+		ETag:         strings.Trim(info.ETag, `"`),
+		ContentType:  info.ContentType,
+		VersionID:    info.VersionID,
+		LastModified: info.LastModified.UTC(),
+	}, nil
+}
+
+// signer returns the client presigned URLs are built with.
+func (c *Client) signer() *minio.Client {
+	if c.presigner != nil {
+		return c.presigner
+	}
+	return c.client
+}
+
+// Remove deletes one version of key; an empty versionID deletes the latest. Deleting a
+// version under retention or legal hold fails with ErrObjectLocked.
+func (c *Client) Remove(ctx context.Context, key, versionID string) error {
+	ctx, span := c.startSpan(ctx, "DeleteObject", key)
+	err := c.client.RemoveObject(ctx, c.bucket, key, minio.RemoveObjectOptions{VersionID: versionID})
+	tracing.End(span, err)
+	if err != nil {
+		if versionID != "" {
+			err = lockError(err)
+		}
+		return fmt.Errorf("remove object: %w", err)
+	}
+	return nil
+}
+
+// RestoreVersion copies versionID of key over key, making its content the latest version
+// again, and returns the version ID the copy received. Objects over 5 GiB are copied in parts.
+func (c *Client) RestoreVersion(ctx context.Context, key, versionID string) (string, error) {
+	ctx, span := c.startSpan(ctx, "CopyObject", key)
+	info, err := c.client.ComposeObject(ctx,
+		minio.CopyDestOptions{Bucket: c.bucket, Object: key},
+		minio.CopySrcOptions{Bucket: c.bucket, Object: key, VersionID: versionID},
+	)
+	tracing.End(span, err)
+	if err != nil {
+		if resp := minio.ToErrorResponse(err); resp.StatusCode == http.StatusNotFound {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("copy object: %w", err)
+	}
+	return info.VersionID, nil
+}
+
+// QuarantinePrefix holds objects moved out of reach of the company prefixes.
+const QuarantinePrefix = "quarantine/"
+
+// Open reads length bytes of a version of key starting at offset; length 0 reads to the end.
+func (c *Client) Open(ctx context.Context, key, versionID string, offset, length int64) (io.ReadCloser, error) {
+	opts := minio.GetObjectOptions{VersionID: versionID}
+	if offset > 0 || length > 0 {
+		end := int64(0)
+		if length > 0 {
+			end = offset + length - 1
+		}
+		if err := opts.SetRange(offset, end); err != nil {
+			return nil, fmt.Errorf("open object: %w", err)
+		}
+	}
+
+	ctx, span := c.startSpan(ctx, "GetObject", key)
+	obj, err := c.client.GetObject(ctx, c.bucket, key, opts)
+	if err == nil {
+		// GetObject is lazy; Stat surfaces a missing object before the first read.
+		_, err = obj.Stat()
+	}
+	tracing.End(span, err)
+	if err != nil {
+		if obj != nil {
+			_ = obj.Close()
+		}
+		if resp := minio.ToErrorResponse(err); resp.StatusCode == http.StatusNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("open object: %w", err)
+	}
+	return obj, nil
+}
+
+// Quarantine moves a version of key under QuarantinePrefix and returns the key it got there.
+func (c *Client) Quarantine(ctx context.Context, key, versionID string) (string, error) {
+	target := QuarantinePrefix + key
+	ctx, span := c.startSpan(ctx, "CopyObject", key)
+	_, err := c.client.ComposeObject(ctx,
+		minio.CopyDestOptions{Bucket: c.bucket, Object: target},
+		minio.CopySrcOptions{Bucket: c.bucket, Object: key, VersionID: versionID},
+	)
+	tracing.End(span, err)
+	if err != nil {
+		return "", fmt.Errorf("quarantine object: %w", err)
+	}
+	if err := c.Remove(ctx, key, versionID); err != nil {
+		return "", err
+	}
+	return target, nil
+}
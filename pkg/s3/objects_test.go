@@ -0,0 +1,73 @@
+package s3
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// offlineClient builds a client without the bucket check New performs; presigning never
+// touches the network when the region is known.
+func offlineClient(t *testing.T) *Client {
+	t.Helper()
+	mc, err := minio.New("ceph:7480", &minio.Options{Creds: credentials.NewStaticV4("key", "secret", ""), Region: "ru-central"})
+	if err != nil {
+		t.Fatalf("minio client: %v", err)
+	}
+	c := &Client{client: mc, bucket: "files", region: "ru-central", accessKey: "key", secretKey: "secret"}
+	if err := c.SetPublicEndpoint("https://files.example.com"); err != nil {
+		t.Fatalf("public endpoint: %v", err)
+	}
+	return c
+}
+
+func TestPresignPutSignsDigest(t *testing.T) {
+	c := offlineClient(t)
+
+	req, err := c.PresignPut(context.Background(), "MAIN/designs/a.pdf", "application/pdf", "9e107d9d372bb6826bd81d3542a419d6", 15*time.Minute)
+	if err != nil {
+		t.Fatalf("presign put: %v", err)
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		t.Fatalf("parse url: %v", err)
+	}
+	if u.Scheme != "https" || u.Host != "files.example.com" || u.Path != "/files/MAIN/designs/a.pdf" {
+		t.Fatalf("unexpected url %s", req.URL)
+	}
+	if signed := u.Query().Get("X-Amz-SignedHeaders"); signed != "content-md5;content-type;host" {
+		t.Fatalf("signed headers = %q", signed)
+	}
+	if req.Headers["Content-MD5"] != "nhB9nTcrtoJr2B01QqQZ1g==" || req.Headers["Content-Type"] != "application/pdf" {
+		t.Fatalf("headers = %v", req.Headers)
+	}
+
+	if _, err := c.PresignPut(context.Background(), "k", "", "not-hex", time.Minute); err == nil {
+		t.Fatal("expected error for malformed md5")
+	}
+}
+
+func TestPresignGetSelectsVersion(t *testing.T) {
+	c := offlineClient(t)
+
+	raw, expires, err := c.PresignGet(context.Background(), "MAIN/designs/a.pdf", "v2", "макет.pdf", time.Minute)
+	if err != nil {
+		t.Fatalf("presign get: %v", err)
+	}
+	if time.Until(expires) > time.Minute {
+		t.Fatalf("expiry too far: %v", expires)
+	}
+	u, _ := url.Parse(raw)
+	q := u.Query()
+	if q.Get("versionId") != "v2" {
+		t.Fatalf("versionId = %q", q.Get("versionId"))
+	}
+	if !strings.HasPrefix(q.Get("response-content-disposition"), "attachment; filename*=utf-8''") {
+		t.Fatalf("disposition = %q", q.Get("response-content-disposition"))
+	}
+}
@@ -0,0 +1,84 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Supported storage backends.
+const (
+	BackendS3 = "s3"
+	BackendFS = "fs"
+)
+
+// Storage is an object store with versioning. Client talks to S3-compatible services (Ceph
+// RGW, MinIO); FS keeps objects on a local disk for development and tests.
+type Storage interface {
+	Upload(ctx context.Context, folder, filename string, r io.Reader, size int64, contentType string) (string, string, error)
+	UploadBytes(ctx context.Context, folder, filename string, data []byte, contentType string) (string, string, error)
+	Ping(ctx context.Context) error
+	SetPublicEndpoint(endpoint string) error
+	PresignPut(ctx context.Context, key, contentType, md5Hex string, expires time.Duration) (PutRequest, error)
+	PresignGet(ctx context.Context, key, versionID, filename string, expires time.Duration) (string, time.Time, error)
+	Stat(ctx context.Context, key, versionID string) (ObjectInfo, error)
+	Open(ctx context.Context, key, versionID string, offset, length int64) (io.ReadCloser, error)
+	Remove(ctx context.Context, key, versionID string) error
+	RestoreVersion(ctx context.Context, key, versionID string) (string, error)
+	Quarantine(ctx context.Context, key, versionID string) (string, error)
+	SetRetention(ctx context.Context, key, versionID string, until time.Time) error
+	SetLegalHold(ctx context.Context, key, versionID string, on bool) error
+	CreateMultipart(ctx context.Context, key, contentType string) (string, error)
+	UploadPart(ctx context.Context, key, uploadID string, number int, r io.Reader, size int64) (Part, error)
+	CompleteMultipart(ctx context.Context, key, uploadID string, parts []Part) (string, error)
+	AbortMultipart(ctx context.Context, key, uploadID string) error
+}
+
+var (
+	_ Storage = (*Client)(nil)
+	_ Storage = (*FS)(nil)
+)
+
+// Config selects and configures a storage backend.
+type Config struct {
+	// Backend selects BackendS3 (default) or BackendFS.
+	Backend   string
+	Endpoint  string
+	Region    string
+	AccessKey string
+	SecretKey string
+	Bucket    string
+	UseSSL    bool
+	// PublicEndpoint is the address presigned URLs point at; optional.
+	PublicEndpoint string
+	// Root is the directory of the filesystem backend.
+	Root string
+}
+
+// NewStorage returns storage for the configured backend. The filesystem backend signs its
+// URLs with SecretKey.
+func NewStorage(cfg Config) (Storage, error) {
+	var (
+		storage Storage
+		err     error
+	)
+	switch strings.ToLower(strings.TrimSpace(cfg.Backend)) {
+	case BackendS3, "":
+		storage, err = New(cfg.Endpoint, cfg.Region, cfg.AccessKey, cfg.SecretKey, cfg.Bucket, cfg.UseSSL)
+	case BackendFS:
+		storage, err = NewFS(cfg.Root, cfg.SecretKey)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cfg.PublicEndpoint != "" {
+		if err := storage.SetPublicEndpoint(cfg.PublicEndpoint); err != nil {
+			return nil, err
+		}
+	}
+	return storage, nil
+}
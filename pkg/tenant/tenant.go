@@ -0,0 +1,96 @@
+// Package tenant carries the active company (legal entity) through request contexts.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Header names the company a request acts for. The gateway treats it as a request and
+// checks it against the user's grants (see Resolve). Module services trust it as is, so
+// they must only be reachable by internal callers, never through the public proxy.
+const Header = "X-Company"
+
+var (
+	// ErrNoCompany is returned when a company-scoped query runs without an active company.
+	ErrNoCompany = errors.New("no active company")
+	// ErrNotGranted is returned when a user asks for a company they have no grant for.
+	ErrNotGranted = errors.New("company not granted")
+)
+
+// Grant gives a user access to a company; at most one grant per user is the default.
+type Grant struct {
+	Code    string
+	Name    string
+	Default bool
+}
+
+// Resolve picks the company a request acts for: requested when it is granted, otherwise the
+// default grant, or the only grant when there is just one.
+func Resolve(requested string, grants []Grant) (string, error) {
+	if code := Normalize(requested); code != "" {
+		for _, g := range grants {
+			if Normalize(g.Code) == code {
+				return code, nil
+			}
+		}
+		return "", fmt.Errorf("%w: %s", ErrNotGranted, code)
+	}
+	for _, g := range grants {
+		if g.Default {
+			return Normalize(g.Code), nil
+		}
+	}
+	if len(grants) == 1 {
+		return Normalize(grants[0].Code), nil
+	}
+	if len(grants) == 0 {
+		return "", ErrNoCompany
+	}
+	return "", fmt.Errorf("%w: set the %s header", ErrNoCompany, Header)
+}
+
+type companyKey struct{}
+
+// Normalize trims and upper-cases a company code.
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// WithCompany returns ctx carrying code as the active company.
+func WithCompany(ctx context.Context, code string) context.Context {
+	return context.WithValue(ctx, companyKey{}, Normalize(code))
+}
+
+// Company returns the active company stored in ctx.
+func Company(ctx context.Context) (string, bool) {
+	code, ok := ctx.Value(companyKey{}).(string)
+	return code, ok && code != ""
+}
+
+// Require returns the active company or ErrNoCompany, so repositories never run an
+// unscoped query by accident.
+func Require(ctx context.Context) (string, error) {
+	code, ok := Company(ctx)
+	if !ok {
+		return "", ErrNoCompany
+	}
+	return code, nil
+}
+
+// Middleware reads Header into the request context and rejects requests without it.
+// Module services mount it on company-scoped routes; it does not authenticate the caller.
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		code := Normalize(c.Get(Header))
+		if code == "" {
+			return fiber.NewError(fiber.StatusBadRequest, Header+" header is required")
+		}
+		c.SetUserContext(WithCompany(c.UserContext(), code))
+		return c.Next()
+	}
+}
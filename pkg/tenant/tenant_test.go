@@ -0,0 +1,76 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRequire(t *testing.T) {
+	if _, err := Require(context.Background()); !errors.Is(err, ErrNoCompany) {
+		t.Fatalf("expected ErrNoCompany, got %v", err)
+	}
+	code, err := Require(WithCompany(context.Background(), " main "))
+	if err != nil || code != "MAIN" {
+		t.Fatalf("code = %q, err = %v", code, err)
+	}
+	if _, ok := Company(WithCompany(context.Background(), "  ")); ok {
+		t.Fatal("blank company must not count as set")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	grants := []Grant{{Code: "MAIN"}, {Code: "TRADE", Default: true}}
+
+	cases := []struct {
+		name      string
+		requested string
+		grants    []Grant
+		want      string
+		err       error
+	}{
+		{"requested grant", "main", grants, "MAIN", nil},
+		{"default grant", "", grants, "TRADE", nil},
+		{"single grant", "", []Grant{{Code: "main"}}, "MAIN", nil},
+		{"not granted", "OTHER", grants, "", ErrNotGranted},
+		{"no grants", "", nil, "", ErrNoCompany},
+		{"ambiguous", "", []Grant{{Code: "A"}, {Code: "B"}}, "", ErrNoCompany},
+	}
+	for _, tc := range cases {
+		got, err := Resolve(tc.requested, tc.grants)
+		if got != tc.want || !errors.Is(err, tc.err) {
+			t.Errorf("%s: got %q, %v; want %q, %v", tc.name, got, err, tc.want, tc.err)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", Middleware(), func(c *fiber.Ctx) error {
+		code, _ := Company(c.UserContext())
+		return c.SendString(code)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusBadRequest {
+		t.Fatalf("missing header: status = %d", resp.StatusCode)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(Header, "trade")
+	resp, err = app.Test(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusOK || string(body) != "TRADE" {
+		t.Fatalf("status = %d, body = %q", resp.StatusCode, body)
+	}
+}
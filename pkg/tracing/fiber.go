@@ -0,0 +1,84 @@
+package tracing
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentation = "asfppro/pkg/tracing"
+
+// Tracer returns the named tracer from the global provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentation)
+}
+
+// Middleware starts a server span per request from the incoming traceparent header and
+// stores it in the request user context, so handlers must derive contexts from c.UserContext().
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), headerCarrier{&c.Request().Header})
+		ctx, span := Tracer().Start(ctx, c.Method()+" "+c.Path(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Method()),
+				attribute.String("url.path", c.Path()),
+				attribute.String("client.address", c.IP()),
+			),
+		)
+		defer span.End()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			if fe, ok := err.(*fiber.Error); ok {
+				status = fe.Code
+			}
+		}
+
+		// Rename after routing so spans group by route template instead of raw path.
+		route := c.Route().Path
+		span.SetName(c.Method() + " " + route)
+		span.SetAttributes(
+			attribute.String("http.route", route),
+			attribute.Int("http.response.status_code", status),
+		)
+		if requestID := c.GetRespHeader(fiber.HeaderXRequestID); requestID != "" {
+			span.SetAttributes(attribute.String("http.request_id", requestID))
+		}
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, fasthttp.StatusMessage(status))
+			if err != nil {
+				span.RecordError(err)
+			}
+		}
+		return err
+	}
+}
+
+// headerCarrier adapts fasthttp request headers to propagation.TextMapCarrier.
+type headerCarrier struct {
+	h *fasthttp.RequestHeader
+}
+
+func (hc headerCarrier) Get(key string) string {
+	return string(hc.h.Peek(key))
+}
+
+func (hc headerCarrier) Set(key, value string) {
+	hc.h.Set(key, value)
+}
+
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, hc.h.Len())
+	hc.h.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
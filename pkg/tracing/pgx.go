@@ -0,0 +1,50 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PgxTracer emits a client span for every pgx query.
+type PgxTracer struct {
+	// Database is recorded as db.namespace.
+	Database string
+}
+
+type pgxSpanKey struct{}
+
+// TraceQueryStart implements pgx.QueryTracer.
+func (t PgxTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, span := Tracer().Start(ctx, "postgres "+SQLOperation(data.SQL),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.namespace", t.Database),
+			attribute.String("db.query.text", data.SQL),
+		),
+	)
+	return context.WithValue(ctx, pgxSpanKey{}, span)
+}
+
+// TraceQueryEnd implements pgx.QueryTracer.
+func (t PgxTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span, ok := ctx.Value(pgxSpanKey{}).(trace.Span)
+	if !ok {
+		return
+	}
+	span.SetAttributes(attribute.Int64("db.response.rows_affected", data.CommandTag.RowsAffected()))
+	End(span, data.Err)
+}
+
+// SQLOperation returns the lower-cased leading keyword of query, used to name spans.
+func SQLOperation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "query"
+	}
+	return strings.ToLower(fields[0])
+}
@@ -0,0 +1,102 @@
+// Package tracing configures OpenTelemetry tracing and instruments shared clients.
+// Without an OTLP endpoint the global no-op provider stays in place, so spans cost nothing,
+// while W3C trace context is still propagated.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Config describes the trace exporter.
+type Config struct {
+	ServiceName string
+	Environment string
+	// Endpoint is the OTLP/HTTP collector URL, e.g. http://otel-collector:4318. Empty disables export.
+	Endpoint string
+	// SampleRatio is the share of new traces recorded; parent decisions are always honoured.
+	SampleRatio float64
+}
+
+// Init installs the global tracer provider and W3C propagator. The returned function flushes
+// pending spans and must be called on shutdown.
+func Init(ctx context.Context, cfg Config, logger zerolog.Logger) (func(), error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if strings.TrimSpace(cfg.Endpoint) == "" {
+		return func() {}, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("create otlp exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", cfg.ServiceName),
+		attribute.String("deployment.environment", cfg.Environment),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("build trace resource: %w", err)
+	}
+
+	ratio := cfg.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+	logger.Info().Str("endpoint", cfg.Endpoint).Float64("sampleRatio", ratio).Msg("tracing enabled")
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			logger.Error().Err(err).Msg("flush traces")
+		}
+	}, nil
+}
+
+// Inject returns the W3C trace context of ctx as a string map, or nil when ctx has no span.
+func Inject(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// Extract returns ctx carrying the remote span context stored in carrier.
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	if len(carrier) == 0 {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
+}
+
+// End records err on span and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,88 @@
+package tracing
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func installRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return recorder
+}
+
+func TestInjectExtractRoundTrip(t *testing.T) {
+	installRecorder(t)
+
+	ctx, span := Tracer().Start(context.Background(), "parent")
+	defer span.End()
+
+	carrier := Inject(ctx)
+	if carrier["traceparent"] == "" {
+		t.Fatalf("expected traceparent, got %v", carrier)
+	}
+
+	remote := trace.SpanContextFromContext(Extract(context.Background(), carrier))
+	if remote.TraceID() != span.SpanContext().TraceID() || !remote.IsRemote() {
+		t.Fatalf("unexpected extracted span context %+v", remote)
+	}
+
+	if got := Inject(context.Background()); got != nil {
+		t.Fatalf("expected nil carrier without span, got %v", got)
+	}
+}
+
+func TestMiddlewareContinuesIncomingTrace(t *testing.T) {
+	recorder := installRecorder(t)
+
+	app := fiber.New()
+	app.Use(Middleware())
+	var handlerTrace trace.TraceID
+	app.Get("/deals/:id", func(c *fiber.Ctx) error {
+		handlerTrace = trace.SpanContextFromContext(c.UserContext()).TraceID()
+		return fiber.NewError(fiber.StatusBadGateway, "upstream")
+	})
+
+	req := httptest.NewRequest("GET", "/deals/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if _, err := app.Test(req); err != nil {
+		t.Fatalf("request: %v", err)
+	}
+
+	if handlerTrace.String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("handler saw trace %s", handlerTrace)
+	}
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Name() != "GET /deals/:id" {
+		t.Fatalf("unexpected span name %q", spans[0].Name())
+	}
+	if spans[0].Status().Code.String() != "Error" {
+		t.Fatalf("expected error status, got %v", spans[0].Status())
+	}
+}
+
+func TestSQLOperation(t *testing.T) {
+	cases := map[string]string{
+		"SELECT 1":                 "select",
+		"  insert into t values()": "insert",
+		"":                         "query",
+	}
+	for query, want := range cases {
+		if got := SQLOperation(query); got != want {
+			t.Fatalf("SQLOperation(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
@@ -47,7 +47,7 @@ func TestCRMDemoData(t *testing.T) {
 	client := &http.Client{Timeout: 10 * time.Second}
 	baseURL := getenv("SMOKE_CRM_URL", "http://localhost:8081")
 
-	resp, err := client.Get(baseURL + "/api/v1/deals?limit=50")
+	resp, err := moduleGet(client, baseURL+"/api/v1/deals?limit=50")
 	if err != nil {
 		recordArtifact(t, artifactsDir, "crm_seed.log", "request error: %v", err)
 		t.Fatalf("crm deals request failed: %v", err)
@@ -92,8 +92,13 @@ func TestSmokeEndpoints(t *testing.T) {
 			}
 
 			var payload struct {
-				Status string            `json:"status"`
-				Checks map[string]string `json:"checks"`
+				Status string `json:"status"`
+				Checks []struct {
+					Name     string `json:"name"`
+					Status   string `json:"status"`
+					Critical bool   `json:"critical"`
+					Error    string `json:"error"`
+				} `json:"checks"`
 			}
 			if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
 				t.Fatalf("decode ready payload: %v", err)
@@ -103,9 +108,9 @@ func TestSmokeEndpoints(t *testing.T) {
 				t.Fatalf("%s ready status payload: %s", svc.name, payload.Status)
 			}
 
-			for name, status := range payload.Checks {
-				if status != "ok" {
-					t.Fatalf("%s dependency %s status %s", svc.name, name, status)
+			for _, check := range payload.Checks {
+				if check.Status != "ok" {
+					t.Fatalf("%s dependency %s status %s: %s", svc.name, check.Name, check.Status, check.Error)
 				}
 			}
 		})
@@ -348,7 +353,7 @@ func TestSmokeEndpoints(t *testing.T) {
 	t.Run("crm_deals_list", func(t *testing.T) {
 		url := getenv("SMOKE_CRM_URL", "http://localhost:8081") + "/api/v1/deals?limit=50"
 		recordArtifact(t, artifactsDir, "crm_deals.log", "request url=%s", url)
-		resp, err := client.Get(url)
+		resp, err := moduleGet(client, url)
 		if err != nil {
 			recordArtifact(t, artifactsDir, "crm_deals.log", "request error: %v", err)
 			t.Fatalf("crm list request failed: %v", err)
@@ -392,7 +397,7 @@ func TestSmokeEndpoints(t *testing.T) {
 		base := getenv("SMOKE_CRM_URL", "http://localhost:8081")
 		url := base + "/api/v1/deals/31000000-0000-0000-0000-000000000001/history?limit=5"
 		recordArtifact(t, artifactsDir, "crm_deal_history.log", "request url=%s", url)
-		resp, err := client.Get(url)
+		resp, err := moduleGet(client, url)
 		if err != nil {
 			recordArtifact(t, artifactsDir, "crm_deal_history.log", "request error: %v", err)
 			t.Fatalf("crm history request failed: %v", err)
@@ -693,6 +698,7 @@ func TestSmokeEndpoints(t *testing.T) {
 			t.Fatalf("prepare wms upsert: %v", err)
 		}
 		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Company", smokeCompany())
 
 		recordArtifact(t, artifactsDir, "wms_stock.log", "upsert sku=%s warehouse=%s", sku, warehouse)
 		resp, err := client.Do(req)
@@ -722,7 +728,7 @@ func TestSmokeEndpoints(t *testing.T) {
 			t.Fatalf("unexpected wms response: %+v", created)
 		}
 
-		respList, err := client.Get(baseURL + "/api/v1/stock/?warehouse=" + warehouse)
+		respList, err := moduleGet(client, baseURL+"/api/v1/stock/?warehouse="+warehouse)
 		if err != nil {
 			recordArtifact(t, artifactsDir, "wms_stock.log", "list error: %v", err)
 			t.Fatalf("wms list request failed: %v", err)
@@ -1776,6 +1782,22 @@ func getenv(key, fallback string) string {
 	return fallback
 }
 
+// smokeCompany is the company module services act for when smoke tests call them directly,
+// as internal callers do; the gateway resolves it from the user's grants instead.
+func smokeCompany() string {
+	return getenv("SMOKE_COMPANY", "MAIN")
+}
+
+// moduleGet sends a company-scoped GET straight to a module service.
+func moduleGet(client *http.Client, url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Company", smokeCompany())
+	return client.Do(req)
+}
+
 func smokeAuthHeader() string {
 	if v := os.Getenv("SMOKE_GATEWAY_BASIC_AUTH"); v != "" {
 		v = strings.TrimSpace(v)